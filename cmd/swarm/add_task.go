@@ -5,11 +5,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/executor"
 	"github.com/yourusername/claude-swarm/pkg/state"
 )
 
@@ -27,7 +29,23 @@ var addTaskCmd = &cobra.Command{
     --priority 8 \
     --dependencies task-1,task-2 \
     --max-retries 5 \
-    --id custom-task-id`,
+    --id custom-task-id \
+    --timeout 30m --memory 4G
+
+  # 为任务注入环境变量（secret:名称 从密钥文件或系统钥匙串解析）
+  swarm add-task "发布 npm 包" --env NODE_ENV=production --env NPM_TOKEN=secret:npm
+
+  # 给出验收标准，评审时据此检查代码改动
+  swarm add-task "添加登出接口" --accept "POST /logout 清除会话" --accept "有单元测试"
+
+  # 完成条件：每次执行后在 agent 的 worktree 中自动校验，不满足时按 unmet_done 失败处理
+  swarm add-task "添加登出接口" \
+    --require-file internal/auth/logout.go \
+    --check "go test ./internal/auth/..." \
+    --forbid go.mod --forbid "vendor/"
+
+  # 声明文件范围：修改范围重叠的任务不会同时执行，范围外的修改会被标记
+  swarm add-task "添加登出接口" --writes "internal/auth/" --reads "internal/session/*.go"`,
 	Args: cobra.MinimumNArgs(1),
 	Run:  runAddTask,
 }
@@ -37,6 +55,15 @@ var (
 	taskDependencies []string
 	taskMaxRetries   int
 	taskID           string
+	taskEnv          []string
+	taskTimeoutFlag  time.Duration
+	taskMemory       string
+	taskAccept       []string
+	taskRequireFiles []string
+	taskChecks       []string
+	taskForbid       []string
+	taskWrites       []string
+	taskReads        []string
 )
 
 func init() {
@@ -46,6 +73,15 @@ func init() {
 	addTaskCmd.Flags().StringSliceVarP(&taskDependencies, "dependencies", "d", nil, "依赖的任务ID（逗号分隔）")
 	addTaskCmd.Flags().IntVar(&taskMaxRetries, "max-retries", 3, "最大重试次数")
 	addTaskCmd.Flags().StringVar(&taskID, "id", "", "自定义任务ID（留空自动生成）")
+	addTaskCmd.Flags().StringArrayVar(&taskEnv, "env", nil, "任务环境变量 KEY=VALUE（可重复，VALUE 可为 secret:名称）")
+	addTaskCmd.Flags().DurationVar(&taskTimeoutFlag, "timeout", 0, "任务超时时间，如 30m（默认使用 agent 的限制）")
+	addTaskCmd.Flags().StringVar(&taskMemory, "memory", "", "任务内存上限，如 4G（默认使用 agent 的限制）")
+	addTaskCmd.Flags().StringArrayVar(&taskAccept, "accept", nil, "验收标准（可重复），评审时据此检查任务")
+	addTaskCmd.Flags().StringArrayVar(&taskRequireFiles, "require-file", nil, "完成后必须存在的文件（可重复，支持 * 和 **）")
+	addTaskCmd.Flags().StringArrayVar(&taskChecks, "check", nil, "完成后必须通过的命令（可重复，在 worktree 根目录用 sh -c 运行）")
+	addTaskCmd.Flags().StringArrayVar(&taskForbid, "forbid", nil, "不允许修改的路径（可重复，支持 * 和 **）")
+	addTaskCmd.Flags().StringSliceVar(&taskWrites, "writes", nil, "任务会修改的文件（逗号分隔，支持目录、* 和 **）")
+	addTaskCmd.Flags().StringSliceVar(&taskReads, "reads", nil, "任务需要阅读的文件（逗号分隔，支持目录、* 和 **）")
 	addTaskCmd.Flags().StringVar(&taskQueuePath, "queue", "~/.claude-swarm/tasks.json", "任务队列文件路径")
 }
 
@@ -66,6 +102,18 @@ func runAddTask(cmd *cobra.Command, args []string) {
 		log.Fatalf("❌ 最大重试次数不能为负数，当前值: %d", taskMaxRetries)
 	}
 
+	// 验证环境变量
+	env, err := parseTaskEnv(taskEnv)
+	if err != nil {
+		log.Fatalf("❌ 无效的环境变量: %v", err)
+	}
+
+	// 验证资源限制
+	limits, err := parseTaskLimits(taskTimeoutFlag, taskMemory)
+	if err != nil {
+		log.Fatalf("❌ 无效的资源限制: %v", err)
+	}
+
 	// 2. 初始化任务队列
 	taskQueue, err := state.NewTaskQueue(expandPath(taskQueuePath))
 	if err != nil {
@@ -75,14 +123,22 @@ func runAddTask(cmd *cobra.Command, args []string) {
 
 	// 3. 创建任务
 	task := &models.Task{
-		ID:           generateTaskID(taskID),
-		Description:  description,
-		Status:       models.TaskStatusPending,
-		Priority:     taskPriority,
-		Dependencies: taskDependencies,
-		MaxRetries:   taskMaxRetries,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:                 generateTaskID(taskID),
+		Description:        description,
+		Status:             models.TaskStatusPending,
+		Priority:           taskPriority,
+		Dependencies:       taskDependencies,
+		MaxRetries:         taskMaxRetries,
+		AcceptanceCriteria: taskAccept,
+		RequiredFiles:      taskRequireFiles,
+		Checks:             taskChecks,
+		ForbiddenPaths:     taskForbid,
+		ReadPaths:          taskReads,
+		WritePaths:         taskWrites,
+		Env:                env,
+		Limits:             limits,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
 	}
 
 	// 4. 验证依赖是否存在
@@ -106,6 +162,67 @@ func runAddTask(cmd *cobra.Command, args []string) {
 		fmt.Printf("   依赖: %v\n", taskDependencies)
 	}
 	fmt.Printf("   最大重试: %d\n", taskMaxRetries)
+	for _, criterion := range taskAccept {
+		fmt.Printf("   验收标准: %s\n", criterion)
+	}
+	if len(taskRequireFiles) > 0 {
+		fmt.Printf("   必须存在: %s\n", strings.Join(taskRequireFiles, ", "))
+	}
+	for _, check := range taskChecks {
+		fmt.Printf("   必须通过: %s\n", check)
+	}
+	if len(taskForbid) > 0 {
+		fmt.Printf("   禁止修改: %s\n", strings.Join(taskForbid, ", "))
+	}
+	if len(taskWrites) > 0 {
+		fmt.Printf("   修改范围: %s\n", strings.Join(taskWrites, ", "))
+	}
+	if len(taskReads) > 0 {
+		fmt.Printf("   阅读范围: %s\n", strings.Join(taskReads, ", "))
+	}
+	if len(env) > 0 {
+		keys := make([]string, 0, len(env))
+		for key := range env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Printf("   环境变量: %s\n", strings.Join(keys, ", "))
+	}
+}
+
+// parseTaskEnv parses KEY=VALUE pairs from --env
+func parseTaskEnv(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	env := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q 不是 KEY=VALUE 格式", pair)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// parseTaskLimits builds per-task limit overrides; nil if none are set
+func parseTaskLimits(timeout time.Duration, memory string) (*models.ResourceLimits, error) {
+	memoryBytes, err := executor.ParseByteSize(memory)
+	if err != nil {
+		return nil, err
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("超时时间不能为负数: %s", timeout)
+	}
+	if timeout == 0 && memoryBytes == 0 {
+		return nil, nil
+	}
+	return &models.ResourceLimits{
+		TimeoutSeconds: int(timeout.Seconds()),
+		MemoryMB:       int(memoryBytes >> 20),
+	}, nil
 }
 
 // validateTaskDescription validates the task description
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+var approvalsCmd = &cobra.Command{
+	Use:   "approvals",
+	Short: "查看和处理等待人工审批的决策",
+	Long: `查看和处理等待人工审批的决策。
+
+高风险任务、被密钥扫描或风险评估（如修改 CI 配置）拦截的合并、AI主脑的询问
+（决定和备注会作为提示交给相关任务）以及重新规划任务图的提议
+（replan，附带对任务图的修改）都会进入审批队列，
+在等待期间 swarm 会继续执行其他任务。
+
+示例:
+  # 列出待审批项
+  swarm approvals list
+
+  # 列出所有审批记录
+  swarm approvals list --all
+
+  # 批准或拒绝
+  swarm approvals approve apr-1700000000 --note "已确认"
+  swarm approvals reject apr-1700000000 --note "包含生产密钥"`,
+}
+
+var approvalsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出审批项",
+	Args:  cobra.NoArgs,
+	Run:   runApprovalsList,
+}
+
+var approvalsApproveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "批准一个审批项",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		resolveApproval(args[0], true)
+	},
+}
+
+var approvalsRejectCmd = &cobra.Command{
+	Use:   "reject <id>",
+	Short: "拒绝一个审批项",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		resolveApproval(args[0], false)
+	},
+}
+
+var (
+	approvalsAll  bool
+	approvalsNote string
+)
+
+func init() {
+	rootCmd.AddCommand(approvalsCmd)
+	approvalsCmd.AddCommand(approvalsListCmd, approvalsApproveCmd, approvalsRejectCmd)
+
+	approvalsCmd.PersistentFlags().StringVar(&taskQueuePath, "queue", "~/.claude-swarm/tasks.json", "任务队列文件路径")
+	approvalsListCmd.Flags().BoolVar(&approvalsAll, "all", false, "显示所有审批记录（包括已处理）")
+	approvalsApproveCmd.Flags().StringVar(&approvalsNote, "note", "", "审批备注")
+	approvalsRejectCmd.Flags().StringVar(&approvalsNote, "note", "", "审批备注")
+}
+
+// openApprovalQueue opens the approval queue next to the task queue
+func openApprovalQueue() *state.ApprovalQueue {
+	approvals, err := state.NewApprovalQueue(state.ApprovalsPathFor(expandPath(taskQueuePath)))
+	if err != nil {
+		log.Fatalf("❌ 无法打开审批队列: %v", err)
+	}
+	return approvals
+}
+
+func runApprovalsList(cmd *cobra.Command, args []string) {
+	approvals := openApprovalQueue()
+	defer approvals.Close()
+
+	status := models.ApprovalStatusPending
+	if approvalsAll {
+		status = ""
+	}
+
+	list := approvals.List(status)
+	if len(list) == 0 {
+		fmt.Println("📭 没有待审批项")
+		return
+	}
+
+	fmt.Println("⏸️  审批队列")
+	fmt.Println(strings.Repeat("━", 60))
+	fmt.Println()
+
+	for _, approval := range list {
+		fmt.Printf("[%s] %s (%s, 来自 %s)\n", approval.Status, approval.ID, approval.Kind, approval.RequestedBy)
+		fmt.Printf("  %s\n", approval.Title)
+		if approval.TaskID != "" {
+			fmt.Printf("  任务: %s\n", approval.TaskID)
+		}
+		if approval.Branch != "" {
+			fmt.Printf("  分支: %s\n", approval.Branch)
+		}
+		if approval.Context != "" {
+			for _, line := range strings.Split(approval.Context, "\n") {
+				fmt.Printf("  │ %s\n", line)
+			}
+		}
+		if approval.ResolvedBy != "" {
+			fmt.Printf("  处理人: %s", approval.ResolvedBy)
+			if approval.Note != "" {
+				fmt.Printf(" (%s)", approval.Note)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("  创建时间: %s\n", approval.CreatedAt.Format("2006-01-02 15:04:05"))
+		fmt.Println()
+	}
+}
+
+// resolveApproval approves or rejects an approval by ID
+func resolveApproval(id string, approve bool) {
+	approvals := openApprovalQueue()
+	defer approvals.Close()
+
+	resolvedBy := os.Getenv("USER")
+	if resolvedBy == "" {
+		resolvedBy = "cli"
+	}
+
+	approval, err := approvals.Resolve(id, approve, approvalsNote, resolvedBy)
+	if err != nil {
+		log.Fatalf("❌ 审批失败: %v", err)
+	}
+
+	if approve {
+		fmt.Printf("👍 已批准 %s: %s\n", approval.ID, approval.Title)
+	} else {
+		fmt.Printf("👎 已拒绝 %s: %s\n", approval.ID, approval.Title)
+	}
+	fmt.Println("运行中的 swarm 将在下一个调度周期处理该决定")
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "查询和导出自动决策审计日志",
+	Long: `查询和导出自动决策审计日志。
+
+确认检测器、AI主脑、密钥扫描和人工审批的每一次决策都会追加到
+.swarm/audit/audit.jsonl。每条记录包含前一条记录的哈希，
+修改、删除或重排记录都会被 verify 发现。
+
+示例:
+  # 查看某个任务最近2小时的决策
+  swarm audit query --task task-1 --since 2h
+
+  # 只看人工决策
+  swarm audit query --actor human
+
+  # 导出为CSV供合规审查
+  swarm audit export --format csv --out audit.csv --since 7d
+
+  # 校验哈希链
+  swarm audit verify`,
+}
+
+var auditQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "按任务、Agent、决策者或时间查询审计记录",
+	Args:  cobra.NoArgs,
+	Run:   runAuditQuery,
+}
+
+var auditExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出审计记录 (jsonl 或 csv)",
+	Args:  cobra.NoArgs,
+	Run:   runAuditExport,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "校验审计日志的哈希链",
+	Args:  cobra.NoArgs,
+	Run:   runAuditVerify,
+}
+
+var (
+	auditDir      string
+	auditTask     string
+	auditAgent    string
+	auditActor    string
+	auditDecision string
+	auditSince    string
+	auditJSON     bool
+	auditFormat   string
+	auditOut      string
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditQueryCmd, auditExportCmd, auditVerifyCmd)
+
+	auditCmd.PersistentFlags().StringVar(&auditDir, "dir", audit.DirFor("."), "审计日志目录")
+
+	for _, cmd := range []*cobra.Command{auditQueryCmd, auditExportCmd} {
+		cmd.Flags().StringVar(&auditTask, "task", "", "按任务ID过滤")
+		cmd.Flags().StringVar(&auditAgent, "agent", "", "按Agent ID过滤")
+		cmd.Flags().StringVar(&auditActor, "actor", "", "按决策者过滤 (detector, brain, human...)")
+		cmd.Flags().StringVar(&auditDecision, "decision", "", "按决策类型过滤 (confirm, task_risk, next_action...)")
+		cmd.Flags().StringVar(&auditSince, "since", "", "起始时间 (如 2h, 7d, 2006-01-02)")
+	}
+	auditQueryCmd.Flags().BoolVar(&auditJSON, "json", false, "以JSONL格式输出")
+	auditExportCmd.Flags().StringVar(&auditFormat, "format", "jsonl", "导出格式 (jsonl, csv)")
+	auditExportCmd.Flags().StringVarP(&auditOut, "out", "o", "", "输出文件（默认输出到标准输出）")
+}
+
+// auditLogPath returns the audit log file selected by --dir
+func auditLogPath() string {
+	return filepath.Join(expandPath(auditDir), audit.FileName)
+}
+
+// queryAuditEntries runs the query described by the filter flags
+func queryAuditEntries() []audit.Entry {
+	since, err := audit.ParseSince(auditSince, time.Now())
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	entries, err := audit.Query(auditLogPath(), audit.Filter{
+		TaskID:   auditTask,
+		AgentID:  auditAgent,
+		Actor:    auditActor,
+		Decision: auditDecision,
+		Since:    since,
+	})
+	if err != nil {
+		log.Fatalf("❌ 读取审计日志失败: %v", err)
+	}
+	return entries
+}
+
+func runAuditQuery(cmd *cobra.Command, args []string) {
+	entries := queryAuditEntries()
+
+	if auditJSON {
+		if err := audit.Export(os.Stdout, entries, "jsonl"); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("📭 没有匹配的审计记录")
+		return
+	}
+
+	for _, e := range entries {
+		fmt.Printf("#%d %s  %-14s %-16s → %s\n",
+			e.Seq, e.Time.Local().Format("2006-01-02 15:04:05"), e.Actor, e.Decision, e.Action)
+
+		var refs []string
+		if e.TaskID != "" {
+			refs = append(refs, "任务: "+e.TaskID)
+		}
+		if e.AgentID != "" {
+			refs = append(refs, "Agent: "+e.AgentID)
+		}
+		if e.Rule != "" {
+			refs = append(refs, "规则: "+e.Rule)
+		}
+		if len(refs) > 0 {
+			fmt.Printf("   %s\n", strings.Join(refs, "  "))
+		}
+		if e.Outcome != "" {
+			fmt.Printf("   结果: %s\n", e.Outcome)
+		}
+		if len(e.Details) > 0 {
+			details, _ := json.Marshal(e.Details)
+			if len(details) > 200 {
+				details = append(details[:200], "..."...)
+			}
+			fmt.Printf("   详情: %s\n", details)
+		}
+	}
+	fmt.Printf("\n共 %d 条记录\n", len(entries))
+}
+
+func runAuditExport(cmd *cobra.Command, args []string) {
+	entries := queryAuditEntries()
+
+	out := os.Stdout
+	if auditOut != "" {
+		f, err := os.Create(auditOut)
+		if err != nil {
+			log.Fatalf("❌ 无法创建输出文件: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := audit.Export(out, entries, auditFormat); err != nil {
+		log.Fatalf("❌ 导出失败: %v", err)
+	}
+
+	if auditOut != "" {
+		fmt.Printf("✅ 已导出 %d 条记录到 %s\n", len(entries), auditOut)
+		if _, err := audit.Verify(auditLogPath()); err != nil {
+			fmt.Printf("⚠️  哈希链校验失败: %v\n", err)
+		}
+	}
+}
+
+func runAuditVerify(cmd *cobra.Command, args []string) {
+	n, err := audit.Verify(auditLogPath())
+	if err != nil {
+		fmt.Printf("❌ 审计日志被篡改: %v\n", err)
+		fmt.Printf("   前 %d 条记录完整\n", n)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 审计日志完整 (%d 条记录)\n", n)
+}
@@ -2,14 +2,19 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
 	"github.com/yourusername/claude-swarm/internal/models"
 	"github.com/yourusername/claude-swarm/pkg/state"
 )
@@ -22,10 +27,29 @@ var batchAddCmd = &cobra.Command{
 文件格式（每行一个任务）:
   描述文本 | priority:8 | depends:task-1,task-2 | max-retries:5
 
+可选的验收标准和完成条件（可重复；命令中不能含 |）:
+  accept:验收标准          评审时据此检查任务
+  require:a.go,docs/*.md   完成后必须存在的文件
+  check:go test ./...      完成后必须通过的命令
+  forbid:go.mod,vendor/    不允许修改的路径
+
+可选的文件范围（修改范围重叠的任务不会同时执行）:
+  writes:internal/auth/    任务会修改的文件
+  reads:internal/*.go      任务需要阅读的文件
+
+扩展名为 .json、.yaml 或 .yml 的文件按任务记录读取：任务列表，或任务队列文件
+（{"tasks": [...]}），字段同任务队列（description、priority、dependencies、
+acceptance_criteria、required_files、checks、forbidden_paths、write_paths、
+read_paths、env、limits 等）。状态、执行记录、重试次数和审批等运行状态不会导入，
+任务总是以 pending 状态加入。
+
 示例:
   # 从文件批量添加
   swarm batch-add --file tasks.txt
 
+  # 从任务记录批量添加
+  swarm batch-add --file tasks.yaml
+
   # 从 stdin
   cat tasks.txt | swarm batch-add --stdin
 
@@ -76,6 +100,22 @@ func runBatchAdd(cmd *cobra.Command, args []string) {
 	}
 	defer taskQueue.Close()
 
+	// 任务记录文件：整个文件有效才添加
+	if isTaskRecordFile(batchFile) {
+		tasks, err := readTaskRecords(batchFile)
+		if err != nil {
+			log.Fatalf("❌ 读取文件失败: %v", err)
+		}
+		added := 0
+		for i, task := range tasks {
+			if addBatchTask(taskQueue, task, fmt.Sprintf("第 %d 个任务", i+1)) {
+				added++
+			}
+		}
+		printBatchSummary(added, len(tasks)-added)
+		return
+	}
+
 	// 读取任务行
 	var lines []string
 	if batchFile != "" {
@@ -116,31 +156,131 @@ func runBatchAdd(cmd *cobra.Command, args []string) {
 			continue
 		}
 
-		// 验证依赖
-		if len(task.Dependencies) > 0 {
-			if err := validateDependencies(taskQueue, task.Dependencies); err != nil {
-				fmt.Printf("⚠️  第 %d 行依赖验证失败: %v\n", i+1, err)
-				fmt.Printf("   将继续添加，但任务可能被阻塞\n")
-			}
+		if addBatchTask(taskQueue, task, fmt.Sprintf("第 %d 行", i+1)) {
+			added++
+		} else {
+			failed++
 		}
+	}
 
-		// 添加到队列
-		if err := taskQueue.AddTask(task); err != nil {
-			fmt.Printf("❌ 第 %d 行添加失败: %v\n", i+1, err)
-			failed++
-			continue
+	printBatchSummary(added, failed)
+}
+
+// addBatchTask validates the dependencies of task and adds it to the queue,
+// reporting the result under label
+func addBatchTask(taskQueue *state.TaskQueue, task *models.Task, label string) bool {
+	// 验证依赖
+	if len(task.Dependencies) > 0 {
+		if err := validateDependencies(taskQueue, task.Dependencies); err != nil {
+			fmt.Printf("⚠️  %s依赖验证失败: %v\n", label, err)
+			fmt.Printf("   将继续添加，但任务可能被阻塞\n")
 		}
+	}
 
-		fmt.Printf("✅ 已添加: %s - %s (优先级: %d)\n", task.ID, task.Description, task.Priority)
-		added++
+	// 添加到队列
+	if err := taskQueue.AddTask(task); err != nil {
+		fmt.Printf("❌ %s添加失败: %v\n", label, err)
+		return false
 	}
 
-	// 总结
+	fmt.Printf("✅ 已添加: %s - %s (优先级: %d)\n", task.ID, task.Description, task.Priority)
+	return true
+}
+
+// printBatchSummary prints the totals of a batch
+func printBatchSummary(added, failed int) {
 	fmt.Println()
 	fmt.Println(strings.Repeat("━", 60))
 	fmt.Printf("📊 批量添加完成: 成功 %d 个，失败 %d 个\n", added, failed)
 }
 
+// isTaskRecordFile reports whether filename holds JSON or YAML task records
+// rather than task lines
+func isTaskRecordFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// readTaskRecords decodes a JSON or YAML list of tasks, or a task queue file
+// ({"tasks": [...]}). Unknown fields are errors, to catch typos. The run
+// state of a record (status, attempts, retries, approvals) is dropped.
+func readTaskRecords(filename string) ([]*models.Task, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is decoded through JSON so both use the task queue's field names
+	if ext := strings.ToLower(filepath.Ext(filename)); ext == ".yaml" || ext == ".yml" {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", filename, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", filename, err)
+		}
+	}
+
+	var records []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var queue struct {
+			Tasks []json.RawMessage `json:"tasks"`
+		}
+		if err := json.Unmarshal(trimmed, &queue); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", filename, err)
+		}
+		records = queue.Tasks
+	} else if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", filename, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s 中没有任务", filename)
+	}
+
+	tasks := make([]*models.Task, 0, len(records))
+	for i, record := range records {
+		task := &models.Task{Priority: 5, MaxRetries: 3}
+		decoder := json.NewDecoder(bytes.NewReader(record))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(task); err != nil {
+			return nil, fmt.Errorf("第 %d 个任务: %w", i+1, err)
+		}
+
+		switch {
+		case strings.TrimSpace(task.Description) == "":
+			return nil, fmt.Errorf("第 %d 个任务: 任务描述不能为空", i+1)
+		case task.Priority < 1 || task.Priority > 10:
+			return nil, fmt.Errorf("第 %d 个任务: 优先级必须在 1-10 之间: %d", i+1, task.Priority)
+		case task.MaxRetries < 0:
+			return nil, fmt.Errorf("第 %d 个任务: 重试次数不能为负数: %d", i+1, task.MaxRetries)
+		}
+
+		tasks = append(tasks, &models.Task{
+			ID:                 task.ID,
+			Description:        task.Description,
+			Status:             models.TaskStatusPending,
+			Dependencies:       task.Dependencies,
+			Priority:           task.Priority,
+			MaxRetries:         task.MaxRetries,
+			PreferAgent:        task.PreferAgent,
+			AcceptanceCriteria: task.AcceptanceCriteria,
+			RequiredFiles:      task.RequiredFiles,
+			Checks:             task.Checks,
+			ForbiddenPaths:     task.ForbiddenPaths,
+			ReadPaths:          task.ReadPaths,
+			WritePaths:         task.WritePaths,
+			Env:                task.Env,
+			Limits:             task.Limits,
+			CreatedAt:          time.Now(),
+			UpdatedAt:          time.Now(),
+		})
+	}
+	return tasks, nil
+}
+
 // parseTaskLine parses a task line in format: "description | key:value | key:value"
 func parseTaskLine(line string) (*models.Task, error) {
 	parts := strings.Split(line, "|")
@@ -193,11 +333,7 @@ func parseTaskLine(line string) (*models.Task, error) {
 			task.Priority = priority
 
 		case "depends", "dependencies", "d":
-			deps := strings.Split(value, ",")
-			for i, dep := range deps {
-				deps[i] = strings.TrimSpace(dep)
-			}
-			task.Dependencies = deps
+			task.Dependencies = splitList(value)
 
 		case "max-retries", "retries", "r":
 			retries, err := strconv.Atoi(value)
@@ -212,6 +348,24 @@ func parseTaskLine(line string) (*models.Task, error) {
 		case "id":
 			task.ID = value
 
+		case "accept":
+			task.AcceptanceCriteria = append(task.AcceptanceCriteria, value)
+
+		case "require":
+			task.RequiredFiles = append(task.RequiredFiles, splitList(value)...)
+
+		case "check":
+			task.Checks = append(task.Checks, value)
+
+		case "forbid":
+			task.ForbiddenPaths = append(task.ForbiddenPaths, splitList(value)...)
+
+		case "writes":
+			task.WritePaths = append(task.WritePaths, splitList(value)...)
+
+		case "reads":
+			task.ReadPaths = append(task.ReadPaths, splitList(value)...)
+
 		default:
 			return nil, fmt.Errorf("未知参数: %s", key)
 		}
@@ -220,6 +374,15 @@ func parseTaskLine(line string) (*models.Task, error) {
 	return task, nil
 }
 
+// splitList splits a comma-separated value, trimming each item
+func splitList(value string) []string {
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
+}
+
 // readLinesFromFile reads lines from a file
 func readLinesFromFile(filename string) ([]string, error) {
 	file, err := os.Open(filename)
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/claude-swarm/pkg/orchestrator"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+var brainCmd = &cobra.Command{
+	Use:   "brain",
+	Short: "查看AI主脑保存的项目运行和决策历史",
+	Long: `查看AI主脑保存的项目运行和决策历史。
+
+orchestrate 或 plan apply 创建任务时开始一次项目运行，需求、计划、对话和之后的
+决策保存在任务队列旁的 runs/ 目录中。swarm start --with-brain 启动的主脑会加载
+最近一次运行，因此了解原始需求和计划，并继续记录决策及其结果。
+
+示例:
+  # 列出项目运行
+  swarm brain runs
+
+  # 最近一次运行的决策历史
+  swarm brain history
+
+  # 指定运行，只看最近10条
+  swarm brain history run-20260101-120000 --limit 10`,
+}
+
+var brainRunsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "列出保存的项目运行",
+	Args:  cobra.NoArgs,
+	Run:   runBrainRuns,
+}
+
+var brainHistoryCmd = &cobra.Command{
+	Use:   "history [run-id]",
+	Short: "显示项目运行的需求、计划和决策历史（默认最近一次运行）",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runBrainHistory,
+}
+
+var (
+	brainHistoryLimit int
+	brainHistoryJSON  bool
+)
+
+func init() {
+	rootCmd.AddCommand(brainCmd)
+	brainCmd.AddCommand(brainRunsCmd, brainHistoryCmd)
+
+	brainCmd.PersistentFlags().StringVar(&taskQueuePath, "tasks", "~/.claude-swarm/tasks.json", "任务队列文件路径")
+	brainHistoryCmd.Flags().IntVar(&brainHistoryLimit, "limit", 0, "只显示最近N条决策（0 表示全部）")
+	brainHistoryCmd.Flags().BoolVar(&brainHistoryJSON, "json", false, "以JSON格式输出完整上下文")
+}
+
+// brainRunsDir returns the runs directory next to the task queue selected by --tasks
+func brainRunsDir() string {
+	return state.BrainRunsDirFor(expandPath(taskQueuePath))
+}
+
+func runBrainRuns(cmd *cobra.Command, args []string) {
+	dir := brainRunsDir()
+	ids, err := state.ListBrainRuns(dir)
+	if err != nil {
+		log.Fatalf("❌ 读取项目运行失败: %v", err)
+	}
+	if len(ids) == 0 {
+		fmt.Println("📭 没有保存的项目运行")
+		return
+	}
+
+	for i := len(ids) - 1; i >= 0; i-- {
+		run, err := orchestrator.LoadRun(dir, ids[i])
+		if err != nil {
+			fmt.Printf("%s  ⚠️  %v\n", ids[i], err)
+			continue
+		}
+		tasks := 0
+		if run.AnalysisResult != nil {
+			tasks = len(run.AnalysisResult.Tasks)
+		}
+		fmt.Printf("%s  %d个任务, %d条决策  %s\n", run.RunID, tasks, len(run.Decisions), truncateText(requirementOf(run), 60))
+	}
+}
+
+func runBrainHistory(cmd *cobra.Command, args []string) {
+	id := ""
+	if len(args) > 0 {
+		id = args[0]
+	}
+	run, err := orchestrator.LoadRun(brainRunsDir(), id)
+	if errors.Is(err, orchestrator.ErrNoRun) {
+		fmt.Println("📭 没有保存的项目运行（orchestrate 或 plan apply 创建任务时开始）")
+		return
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	if brainHistoryJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(run); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	fmt.Printf("🗂️  项目运行 %s\n", run.RunID)
+	if !run.StartedAt.IsZero() {
+		fmt.Printf("   开始: %s  更新: %s\n",
+			run.StartedAt.Local().Format("2006-01-02 15:04:05"), run.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("📝 需求: %s\n", requirementOf(run))
+	if run.AnalysisResult != nil {
+		fmt.Printf("📋 计划: %s (%d个任务)\n", run.AnalysisResult.Summary, len(run.AnalysisResult.Tasks))
+	}
+	fmt.Println(strings.Repeat("━", 60))
+
+	decisions := run.Decisions
+	if brainHistoryLimit > 0 && len(decisions) > brainHistoryLimit {
+		fmt.Printf("（省略较早的 %d 条决策）\n\n", len(decisions)-brainHistoryLimit)
+		decisions = decisions[len(decisions)-brainHistoryLimit:]
+	}
+	if len(decisions) == 0 {
+		fmt.Println("📭 还没有决策")
+	}
+	for _, d := range decisions {
+		printDecision(d)
+	}
+
+	// 等待审批或被拒绝的重新规划不在决策中
+	var pending []*orchestrator.ReplanRecord
+	for _, record := range run.Replans {
+		if record.Status != orchestrator.ReplanApplied {
+			pending = append(pending, record)
+		}
+	}
+	if len(pending) > 0 {
+		fmt.Println("🗺️  未应用的重新规划")
+		for _, record := range pending {
+			fmt.Printf("  %s  [%s] %s %s", record.Timestamp.Local().Format("2006-01-02 15:04:05"),
+				record.Status, record.Trigger.Kind, record.Trigger.TaskID)
+			if record.ApprovalID != "" {
+				fmt.Printf("  审批: %s", record.ApprovalID)
+			}
+			fmt.Println()
+			if record.Result != "" {
+				fmt.Printf("    %s\n", record.Result)
+			}
+		}
+	}
+}
+
+// printDecision prints one decision and its outcome
+func printDecision(d orchestrator.Decision) {
+	kind, refs := "-", []string(nil)
+	if a := d.Action; a != nil {
+		kind = string(a.Type)
+		if a.TaskID != "" {
+			refs = append(refs, "任务: "+a.TaskID)
+		}
+		if a.TargetAgent != "" {
+			refs = append(refs, "Agent: "+a.TargetAgent)
+		}
+		if a.Type == orchestrator.ActionMergeBranch && a.Command != "" {
+			refs = append(refs, "分支: "+a.Command)
+		}
+	}
+	fmt.Printf("%s  %-14s %s\n", d.Timestamp.Local().Format("2006-01-02 15:04:05"), kind, strings.Join(refs, ", "))
+	if d.Reasoning != "" {
+		fmt.Printf("  理由: %s\n", d.Reasoning)
+	}
+	result := d.Result
+	if result == "" {
+		result = "（未记录）"
+	}
+	lines := strings.Split(result, "\n")
+	fmt.Printf("  结果: %s\n", lines[0])
+	for _, line := range lines[1:] {
+		fmt.Printf("        %s\n", line)
+	}
+	fmt.Println()
+}
+
+// requirementOf returns the run's requirement, or a note if it was started without one
+func requirementOf(run *orchestrator.ConversationContext) string {
+	if run.Requirement == "" {
+		return "（未记录，任务不是由 orchestrate 创建的）"
+	}
+	return run.Requirement
+}
+
+// truncateText shortens s to n runes for one-line listings
+func truncateText(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "..."
+	}
+	return s
+}
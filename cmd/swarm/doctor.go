@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/claude-swarm/pkg/executor"
 )
 
 var doctorCmd = &cobra.Command{
@@ -19,6 +20,8 @@ Checks:
   - Go installation
   - Git installation and version
   - Claude CLI availability
+  - Agent sandbox support (bubblewrap or user namespaces)
+  - Resource limit enforcement (cgroups v2 or rlimits)
   - Project configuration
   - Environment variables`,
 	Run: runDoctor,
@@ -73,6 +76,23 @@ func runDoctor(cmd *cobra.Command, args []string) {
 		allOk = false
 	}
 
+	// Check sandbox support (optional, for --sandbox)
+	fmt.Print("Checking agent sandbox... ")
+	sandbox := executor.CheckSandbox()
+	if sandbox.Available {
+		fmt.Printf("OK (%s)\n", sandbox.Detail)
+		if sandbox.BwrapPath != "" {
+			fmt.Printf("  User namespaces (native backend)... %s\n", yesNo(sandbox.UserNamespaces))
+		}
+	} else {
+		fmt.Println("NOT AVAILABLE (optional, for --sandbox)")
+		fmt.Printf("  %s\n", sandbox.Detail)
+		fmt.Println("  Install bubblewrap or enable unprivileged user namespaces")
+	}
+
+	// Report how resource limits are enforced (--memory, --cpus, --max-procs)
+	fmt.Printf("Resource limits: %s\n", executor.ResourceEnforcement())
+
 	// Check current directory
 	fmt.Println()
 	cwd, _ := os.Getwd()
@@ -135,3 +155,11 @@ func runDoctor(cmd *cobra.Command, args []string) {
 		fmt.Println("Some checks failed. Please fix the issues above.")
 	}
 }
+
+// yesNo formats a boolean check result
+func yesNo(ok bool) string {
+	if ok {
+		return "YES"
+	}
+	return "NO"
+}
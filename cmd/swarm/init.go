@@ -17,6 +17,9 @@ var initCmd = &cobra.Command{
 This creates:
   - .swarm/config.yaml   - Project configuration
   - .swarm/tasks.json    - Task queue file
+  - .swarm/env.yaml      - Environment policy for agents
+  - .swarm/errors.yaml   - Extra matchers for classifying failed runs
+  - .swarm/retry.yaml    - Retry policy per error category
   - .gitignore update    - Ignore worktrees and logs
 
 Example:
@@ -44,6 +47,9 @@ func runInit(cmd *cobra.Command, args []string) {
 	swarmDir := filepath.Join(cwd, ".swarm")
 	configFile := filepath.Join(swarmDir, "config.yaml")
 	tasksFile := filepath.Join(swarmDir, "tasks.json")
+	envFile := filepath.Join(swarmDir, "env.yaml")
+	errorsFile := filepath.Join(swarmDir, "errors.yaml")
+	retryFile := filepath.Join(swarmDir, "retry.yaml")
 
 	// Check if already initialized
 	if _, err := os.Stat(swarmDir); err == nil && !initForce {
@@ -104,6 +110,69 @@ logging:
 	}
 	fmt.Println("  Created .swarm/tasks.json")
 
+	// Create env.yaml
+	envContent := `# Environment passed to agent processes
+# Known credentials (GEMINI_API_KEY, AWS_*, GITHUB_TOKEN, ...) are always removed.
+# "secret:<name>" values are read from ~/.claude-swarm/secrets.yaml
+# (or $SWARM_SECRETS_FILE, mode 600) or the OS keyring (service "claude-swarm").
+
+default:
+  # allow: [GOPATH, NODE_*]   # Only pass these through (PATH, HOME, ... are always kept)
+  deny: []                    # Extra variables to remove, e.g. [INTERNAL_*]
+  set: {}                     # Variables to inject, e.g. {NPM_TOKEN: "secret:npm"}
+
+# Per-agent overrides, merged onto default
+# agents:
+#   agent-0:
+#     set: {DATABASE_URL: "secret:staging-db"}
+`
+	if err := os.WriteFile(envFile, []byte(envContent), 0644); err != nil {
+		log.Fatalf("Failed to create env.yaml: %v", err)
+	}
+	fmt.Println("  Created .swarm/env.yaml")
+
+	// Create errors.yaml
+	errorsContent := `# Matchers for classifying failed agent runs, tried before the built-in ones.
+# Categories: rate_limit, auth, network, timeout, tool_failure, compile_error,
+# test_failure, policy_block, resource_limit, agent_gave_up, unmet_done
+# Patterns are Go regular expressions; the named groups file, line and
+# retry_after are recorded on the task.
+
+matchers: []
+#  - name: npm-registry
+#    category: network
+#    pattern: 'npm ERR! code E5\d\d'
+#  - name: eslint
+#    category: compile_error
+#    pattern: '(?m)^(?P<file>\S+\.js):(?P<line>\d+):\d+: .* \(eslint\)'
+`
+	if err := os.WriteFile(errorsFile, []byte(errorsContent), 0644); err != nil {
+		log.Fatalf("Failed to create errors.yaml: %v", err)
+	}
+	fmt.Println("  Created .swarm/errors.yaml")
+
+	// Create retry.yaml
+	retryContent := `# Retry policy per error category. Unlisted categories keep the defaults:
+#   rate_limit 5 attempts (exponential from 30s), network 3, timeout 1,
+#   tool_failure and agent_gave_up 1 on another agent, unmet_done 2, unknown 2,
+#   everything else is not retried.
+# Rate-limit and network retries don't count against a task's max_retries;
+# every other category stops there.
+
+categories: {}
+#  compile_error:
+#    max_attempts: 1
+#    backoff: constant      # constant, linear or exponential
+#    initial_delay: 10s
+#    max_delay: 5m
+#    jitter: 0.2            # Randomize the delay by ±20%
+#    switch_agent: true     # Prefer another agent for the retry
+`
+	if err := os.WriteFile(retryFile, []byte(retryContent), 0644); err != nil {
+		log.Fatalf("Failed to create retry.yaml: %v", err)
+	}
+	fmt.Println("  Created .swarm/retry.yaml")
+
 	// Update .gitignore
 	gitignorePath := filepath.Join(cwd, ".gitignore")
 	gitignoreEntries := `
@@ -111,6 +180,8 @@ logging:
 .worktrees/
 .swarm/tasks.json
 .swarm/swarm.log
+.swarm/audit/
+.swarm/transcripts/
 `
 
 	// Check if .gitignore exists and append, otherwise create
@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/claude-swarm/pkg/secrets"
 )
 
 // Version info (set by ldflags)
@@ -27,7 +29,8 @@ Quick Start:
 
 With AI:
   swarm orchestrate "Build API"   # AI generates tasks
-  swarm start --with-brain        # AI monitors execution`,
+  swarm start --with-brain        # AI monitors execution
+  swarm brain history             # Past AI decisions and outcomes`,
 	Version: Version,
 }
 
@@ -45,6 +48,9 @@ func init() {
 }
 
 func main() {
+	// Redact credentials from every log line (agent output is logged verbatim)
+	log.SetOutput(secrets.NewRedactingWriter(os.Stderr, secrets.Default()))
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
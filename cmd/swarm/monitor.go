@@ -21,12 +21,14 @@ var monitorCmd = &cobra.Command{
 
 var (
 	monitorTaskFile string
+	monitorOperator string
 )
 
 func init() {
 	rootCmd.AddCommand(monitorCmd)
 
 	monitorCmd.Flags().StringVar(&monitorTaskFile, "tasks", "~/.claude-swarm/tasks.json", "任务队列文件路径")
+	monitorCmd.Flags().StringVar(&monitorOperator, "operator", "", "记录为审批人的操作者名称（默认 $USER）")
 }
 
 func runMonitor(cmd *cobra.Command, args []string) {
@@ -46,13 +48,36 @@ func runMonitor(cmd *cobra.Command, args []string) {
 	}
 	defer taskQueue.Close()
 
+	// Initialize approval queue so tasks can be approved from the TUI
+	approvals, err := state.NewApprovalQueue(state.ApprovalsPathFor(monitorTaskFile))
+	if err != nil {
+		log.Fatalf("Failed to open approval queue: %v", err)
+	}
+	defer approvals.Close()
+
 	// Create agent status loader function
 	getAgentsFn := func() []*models.AgentStatus {
 		return loadAgentStatuses(taskQueue)
 	}
 
+	// Circuit breaker state published by a running coordinator
+	throttlePath := state.ThrottlePathFor(monitorTaskFile)
+	getThrottleFn := func() *models.ThrottleStatus {
+		status, _ := state.LoadThrottleStatus(throttlePath)
+		return status
+	}
+
+	// Approvals resolved in the TUI are recorded as tui:<operator>
+	operator := monitorOperator
+	if operator == "" {
+		operator = os.Getenv("USER")
+	}
+	if operator == "" {
+		operator = "operator"
+	}
+
 	// Start TUI using the Run helper
-	if err := tui.Run(taskQueue, getAgentsFn); err != nil {
+	if err := tui.Run(taskQueue, approvals, operator, getAgentsFn, getThrottleFn); err != nil {
 		fmt.Printf("Error running monitor: %v\n", err)
 		os.Exit(1)
 	}
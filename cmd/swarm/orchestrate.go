@@ -3,72 +3,137 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/exec"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yourusername/claude-swarm/pkg/config"
+	"github.com/yourusername/claude-swarm/pkg/llm"
 	"github.com/yourusername/claude-swarm/pkg/orchestrator"
+	"github.com/yourusername/claude-swarm/pkg/prompts"
+	"github.com/yourusername/claude-swarm/pkg/repocontext"
 	"github.com/yourusername/claude-swarm/pkg/state"
 )
 
 var orchestrateCmd = &cobra.Command{
 	Use:   "orchestrate [需求描述]",
 	Short: "🧠 AI主脑分析需求并自动拆分任务",
-	Long: `AI主脑（Gemini）分析用户需求，智能拆分成多个可并行开发的任务。
+	Long: `AI主脑分析用户需求，智能拆分成多个可并行开发的任务。
+
+主脑使用的 LLM 在 config.yaml 的 llm 段配置：gemini（默认）、anthropic、
+openai（任意 OpenAI 兼容端点，如 Ollama、llama.cpp server、vLLM）或 claude-cli。
+
+分析前会从 git.repo_path 的 HEAD 生成仓库摘要（文件树、包、关键符号、README、
+最近提交和 TODO），让拆分出的任务引用真实的路径和 API。摘要按提交缓存在
+.swarm/cache/repocontext/，大小由 config.yaml 的 repo_context.budget 控制。
+
+分析完成后进入审批环节：可以批准、拒绝，或在 $EDITOR 中以 YAML 编辑计划
+（模块、任务、依赖、优先级、文件），编辑后的计划会重新校验。计划也可以保存下来，
+之后用 swarm plan apply 创建任务。
 
 示例：
   swarm orchestrate "实现一个用户管理系统，包括注册、登录、权限管理"
-  swarm orchestrate "添加文件上传功能，支持图片预览和压缩"`,
+  swarm orchestrate "添加文件上传功能，支持图片预览和压缩"
+
+  # 只生成计划，审阅后再应用
+  swarm orchestrate "添加文件上传功能" --save-plan --plan upload.yaml
+  swarm plan apply upload.yaml
+
+  # 跳过审批，直接创建任务
+  swarm orchestrate "添加文件上传功能" --auto-approve`,
 	Args: cobra.MinimumNArgs(1),
 	Run:  runOrchestrate,
 }
 
 var (
-	geminiAPIKey   string
+	llmAPIKey      string
 	configFilePath string
 	autoStart      bool
 	autoApprove    bool
 	maxAgents      int
+	planFilePath   string
+	savePlanOnly   bool
+	noRepoContext  bool
 )
 
 func init() {
 	rootCmd.AddCommand(orchestrateCmd)
 
-	orchestrateCmd.Flags().StringVarP(&geminiAPIKey, "api-key", "k", "", "Gemini API Key（或使用配置文件/环境变量）")
+	orchestrateCmd.Flags().StringVarP(&llmAPIKey, "api-key", "k", "", "LLM API Key（或使用配置文件/环境变量）")
 	orchestrateCmd.Flags().StringVarP(&configFilePath, "config", "c", "", "配置文件路径（默认: ./config.yaml 或 ~/.claude-swarm/config.yaml）")
 	orchestrateCmd.Flags().BoolVar(&autoStart, "auto-start", false, "分析并审批通过后自动启动Agent集群")
-	orchestrateCmd.Flags().BoolVar(&autoApprove, "auto-approve", true, "跳过人工审批，自动创建任务（默认启用）")
+	orchestrateCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "跳过人工审批，自动创建任务")
+	orchestrateCmd.Flags().StringVar(&planFilePath, "plan", ".swarm/plan.yaml", "审批时编辑和保存计划的文件")
+	orchestrateCmd.Flags().BoolVar(&savePlanOnly, "save-plan", false, "只把计划写入 --plan 文件，不创建任务")
+	orchestrateCmd.Flags().BoolVar(&noRepoContext, "no-repo-context", false, "不提供仓库摘要，只根据需求文本分析")
 	orchestrateCmd.Flags().IntVarP(&maxAgents, "agents", "n", 5, "Agent数量（1-10）")
 	orchestrateCmd.Flags().StringVar(&taskQueuePath, "tasks", "~/.claude-swarm/tasks.json", "任务队列文件路径")
 }
 
-func runOrchestrate(cmd *cobra.Command, args []string) {
-	requirement := args[0]
-
-	// 加载配置（优先级：命令行参数 > 配置文件 > 环境变量）
-	cfg, err := config.Load(configFilePath)
+// newBrainClient 按配置文件创建AI主脑的 LLM 客户端，apiKey 非空时覆盖配置中的 API Key
+func newBrainClient(configPath, apiKey string) (llm.LLMClient, error) {
+	cfg, err := config.Read(configPath)
 	if err != nil {
-		// 如果配置文件加载失败，使用环境变量作为后备
-		log.Printf("⚠️  配置文件加载失败: %v", err)
-		log.Printf("📝 尝试使用环境变量 GEMINI_API_KEY")
+		return nil, err
 	}
+	if apiKey != "" {
+		cfg.LLM.APIKey = apiKey
+	}
+	return llm.New(cfg.LLM)
+}
 
-	// 获取API Key（命令行参数最高优先级）
-	apiKey := geminiAPIKey
-	if apiKey == "" && cfg != nil {
-		apiKey = cfg.Gemini.APIKey
+// newPromptStore 按配置文件的 prompts.language 创建主脑的提示词模板，当前目录 .swarm/prompts/ 中的模板优先
+func newPromptStore(configPath, language string) (*prompts.Store, error) {
+	if language == "" {
+		cfg, err := config.Read(configPath)
+		if err != nil {
+			return nil, err
+		}
+		language = cfg.Prompts.Language
+	}
+	return prompts.New(prompts.DefaultDir, language)
+}
+
+// loadRepoContext 按配置生成 git.repo_path 的仓库摘要；关闭或生成失败时返回空字符串，
+// 失败只打印警告，分析照常进行
+func loadRepoContext(configPath string) string {
+	if noRepoContext {
+		return ""
 	}
-	if apiKey == "" {
-		apiKey = os.Getenv("GEMINI_API_KEY")
+	cfg, err := config.Read(configPath)
+	if err != nil {
+		log.Printf("⚠️  读取配置失败，不使用仓库摘要: %v", err)
+		return ""
 	}
-	if apiKey == "" {
-		log.Fatal("❌ 请提供Gemini API Key:\n" +
-			"   1. 使用 --api-key 参数\n" +
-			"   2. 在 config.yaml 中配置\n" +
-			"   3. 设置环境变量 GEMINI_API_KEY\n" +
-			"   示例: cp config.yaml.example config.yaml && 编辑填入API Key")
+	if !cfg.RepoContext.Enabled {
+		return ""
+	}
+
+	rc, err := repocontext.Build(context.Background(), cfg.Git.RepoPath, repocontext.Options{CacheDir: repocontext.DefaultCacheDir})
+	if err != nil {
+		log.Printf("⚠️  生成仓库摘要失败，只根据需求文本分析: %v", err)
+		return ""
+	}
+	text := rc.Render(cfg.RepoContext.Budget)
+	fmt.Fprintf(os.Stderr, "📚 仓库摘要: %d 个文件, %d 个符号, 约 %d tokens (提交 %.12s)\n",
+		len(rc.Files), len(rc.Symbols), repocontext.EstimateTokens(text), rc.Commit)
+	return text
+}
+
+func runOrchestrate(cmd *cobra.Command, args []string) {
+	requirement := args[0]
+
+	// 加载配置（优先级：命令行参数 > 配置文件 > 环境变量）
+	client, err := newBrainClient(configFilePath, llmAPIKey)
+	if err != nil {
+		log.Fatalf("❌ 创建LLM客户端失败: %v\n"+
+			"   1. 使用 --api-key 参数\n"+
+			"   2. 在 config.yaml 的 llm 段中配置\n"+
+			"   3. 设置提供方的环境变量，如 GEMINI_API_KEY、ANTHROPIC_API_KEY\n"+
+			"   示例: cp config.yaml.example config.yaml && 编辑填入API Key", err)
 	}
 
 	fmt.Println("🧠 AI主脑启动中...")
@@ -89,11 +154,15 @@ func runOrchestrate(cmd *cobra.Command, args []string) {
 		log.Fatalf("❌ 初始化任务队列失败: %v", err)
 	}
 
-	// 创建AI主脑
-	brain, err := orchestrator.NewOrchestratorBrain(apiKey, taskQueue)
+	promptStore, err := newPromptStore(configFilePath, "")
 	if err != nil {
-		log.Fatalf("❌ 创建AI主脑失败: %v", err)
+		log.Fatalf("❌ 加载提示词模板失败: %v", err)
 	}
+
+	// 创建AI主脑
+	brain := orchestrator.NewOrchestratorBrain(client, taskQueue)
+	brain.SetPrompts(promptStore)
+	brain.SetRepoContext(loadRepoContext(configFilePath))
 	defer brain.Close()
 
 	ctx := context.Background()
@@ -108,25 +177,48 @@ func runOrchestrate(cmd *cobra.Command, args []string) {
 	// 显示分析结果
 	printAnalysisResult(result)
 
-	// 人工审批环节（除非使用--auto-approve）
-	approved := autoApprove
-	if !autoApprove {
-		approved = requestApproval(result)
+	plan := orchestrator.NewPlan(requirement, result)
+	if savePlanOnly {
+		savePlanAndExit(plan)
+		return
 	}
 
-	if !approved {
-		fmt.Println("\n❌ 已取消。未创建任务。")
-		fmt.Println("💡 提示：您可以修改需求描述后重新运行 orchestrate")
-		return
+	// 人工审批环节（除非使用--auto-approve）；没有终端可交互时保存计划。
+	// 检查命令会在 Agent 的工作区中执行，包含检查命令的计划总是需要人工审批
+	if checks := result.Checks(); autoApprove && len(checks) > 0 {
+		fmt.Printf("\n⚠️  计划包含 %d 条检查命令（checks），--auto-approve 不适用，需人工审批\n", len(checks))
+		autoApprove = false
+	}
+	if !autoApprove {
+		if !stdinIsTerminal() {
+			fmt.Println("\n⚠️  标准输入不是终端，无法审批")
+			savePlanAndExit(plan)
+			return
+		}
+
+		var approved bool
+		result, approved = reviewPlan(brain, plan)
+		if !approved {
+			fmt.Println("\n❌ 已取消。未创建任务。")
+			fmt.Println("💡 提示：您可以修改需求描述后重新运行 orchestrate")
+			return
+		}
 	}
 
-	// 验证依赖关系
+	createTasksFromPlan(ctx, brain, requirement, result)
+
+	// 提示下一步
+	printNextSteps()
+}
+
+// createTasksFromPlan 验证依赖关系后创建任务，并开始一次项目运行：
+// 需求、计划和之后的决策保存在任务队列旁的 runs/ 目录中，供 swarm start --with-brain 和 swarm brain history 使用
+func createTasksFromPlan(ctx context.Context, brain *orchestrator.OrchestratorBrain, requirement string, result *orchestrator.AnalysisResult) {
 	fmt.Println("\n🔍 验证依赖关系...")
 	if err := brain.ValidateDependencies(result); err != nil {
 		log.Fatalf("❌ 依赖关系验证失败: %v", err)
 	}
 
-	// 创建任务
 	fmt.Println("\n📋 创建任务队列...")
 	if err := brain.CreateTasksFromAnalysis(ctx, result); err != nil {
 		log.Fatalf("❌ 创建任务失败: %v", err)
@@ -134,7 +226,15 @@ func runOrchestrate(cmd *cobra.Command, args []string) {
 
 	fmt.Printf("\n✅ 任务队列创建完成！共 %d 个任务\n", len(result.Tasks))
 
-	// 提示下一步
+	if err := brain.StartRun(state.BrainRunsDirFor(expandPath(taskQueuePath)), requirement, result); err != nil {
+		log.Printf("⚠️  保存项目运行失败: %v", err)
+	} else {
+		fmt.Printf("🗂️  项目运行: %s\n", brain.Context().RunID)
+	}
+}
+
+// printNextSteps 提示创建任务后的下一步操作
+func printNextSteps() {
 	if autoStart {
 		fmt.Println("\n🚀 自动启动Agent集群...")
 		// TODO: 自动调用 start 命令
@@ -147,8 +247,10 @@ func runOrchestrate(cmd *cobra.Command, args []string) {
 	}
 }
 
-// requestApproval 请求用户审批AI分析结果
-func requestApproval(result *orchestrator.AnalysisResult) bool {
+// reviewPlan 请求用户审批计划，可在编辑器中修改。返回最终（已校验的）分析结果和是否批准
+func reviewPlan(brain *orchestrator.OrchestratorBrain, plan *orchestrator.Plan) (*orchestrator.AnalysisResult, bool) {
+	result := plan.Result()
+
 	fmt.Println("\n" + strings.Repeat("─", 60))
 	fmt.Println("🔍 审批环节")
 	fmt.Println(strings.Repeat("─", 60))
@@ -159,38 +261,123 @@ func requestApproval(result *orchestrator.AnalysisResult) bool {
 	fmt.Println("  • 依赖关系是否正确？")
 	fmt.Println("  • 预估时间是否合理？")
 
-	fmt.Printf("\n📊 统计: %d个模块, %d个任务, 预计%s\n",
-		len(result.Modules), len(result.Tasks), result.EstimatedTime)
+	for {
+		fmt.Printf("\n📊 统计: %d个模块, %d个任务, 预计%s\n",
+			len(result.Modules), len(result.Tasks), result.EstimatedTime)
 
-	fmt.Println("\n选项:")
-	fmt.Println("  1. ✅ 批准并创建任务队列")
-	fmt.Println("  2. ❌ 拒绝（取消创建）")
-	fmt.Println("  3. 📝 查看详细信息")
+		fmt.Println("\n选项:")
+		fmt.Println("  1. ✅ 批准并创建任务队列")
+		fmt.Println("  2. ❌ 拒绝（取消创建）")
+		fmt.Println("  3. 📝 查看详细信息")
+		fmt.Printf("  4. ✏️  在编辑器中修改计划（%s）\n", planFilePath)
+		fmt.Println("  5. 💾 保存计划，稍后用 swarm plan apply 创建")
 
-	for {
-		fmt.Print("\n请选择 [1/2/3]: ")
+		fmt.Print("\n请选择 [1-5]: ")
 		var choice string
-		fmt.Scanln(&choice)
+		if _, err := fmt.Scanln(&choice); err == io.EOF {
+			return nil, false
+		}
 
 		switch choice {
 		case "1", "y", "Y", "yes", "Yes", "YES":
 			fmt.Println("\n✅ 已批准！开始创建任务队列...")
-			return true
+			return result, true
 
 		case "2", "n", "N", "no", "No", "NO":
 			fmt.Println("\n❌ 已拒绝。")
-			return false
+			return nil, false
 
 		case "3", "d", "detail":
 			printDetailedAnalysis(result)
 			fmt.Println("\n返回审批选项...")
-			continue
+
+		case "4", "e", "edit":
+			edited, editedResult, err := editPlan(brain, plan)
+			if err != nil {
+				fmt.Printf("⚠️  %v，保留修改前的计划\n", err)
+				continue
+			}
+			plan, result = edited, editedResult
+			printAnalysisResult(result)
+
+		case "5", "s", "save":
+			savePlanAndExit(plan)
+			return nil, false
 
 		default:
-			fmt.Println("⚠️  无效选择，请输入 1、2 或 3")
-			continue
+			fmt.Println("⚠️  无效选择，请输入 1-5")
+		}
+	}
+}
+
+// editPlan 把计划写入 --plan 文件并打开编辑器，保存后重新读取和校验；
+// 校验失败时可以继续编辑同一个文件
+func editPlan(brain *orchestrator.OrchestratorBrain, plan *orchestrator.Plan) (*orchestrator.Plan, *orchestrator.AnalysisResult, error) {
+	if err := orchestrator.SavePlan(planFilePath, plan); err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		if err := openEditor(planFilePath); err != nil {
+			return nil, nil, err
 		}
+
+		edited, err := orchestrator.LoadPlan(planFilePath)
+		var result *orchestrator.AnalysisResult
+		if err == nil {
+			result, err = brain.ValidatePlan(edited)
+		}
+		if err == nil {
+			fmt.Println("✅ 计划已更新并通过校验")
+			return edited, result, nil
+		}
+
+		fmt.Printf("\n❌ 计划无效:\n%v\n", err)
+		fmt.Print("继续编辑? [Y/n]: ")
+		var answer string
+		fmt.Scanln(&answer)
+		if answer == "n" || answer == "N" {
+			return nil, nil, fmt.Errorf("计划未通过校验")
+		}
+	}
+}
+
+// openEditor 用 $VISUAL、$EDITOR 或 vi 编辑文件
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// 编辑器可以带参数，如 "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("编辑器 %s 退出失败: %w", editor, err)
 	}
+	return nil
+}
+
+// savePlanAndExit 把计划写入 --plan 文件并提示如何应用
+func savePlanAndExit(plan *orchestrator.Plan) {
+	if err := orchestrator.SavePlan(planFilePath, plan); err != nil {
+		log.Fatalf("❌ 保存计划失败: %v", err)
+	}
+	fmt.Printf("\n💾 计划已保存到 %s，未创建任务\n", planFilePath)
+	fmt.Println("💡 审阅或编辑后应用:")
+	fmt.Printf("   swarm plan apply %s\n", planFilePath)
+}
+
+// stdinIsTerminal 报告标准输入是否为终端（而非管道或文件）
+func stdinIsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
 }
 
 // printDetailedAnalysis 打印详细的分析信息
@@ -261,6 +448,15 @@ func printAnalysisResult(result *orchestrator.AnalysisResult) {
 		if len(task.Dependencies) > 0 {
 			fmt.Printf("     依赖: %v\n", task.Dependencies)
 		}
+		if len(task.Files) > 0 {
+			fmt.Printf("     修改范围: %s\n", strings.Join(task.Files, ", "))
+		}
+		if len(task.Checks) > 0 {
+			fmt.Printf("     必须通过: %s\n", strings.Join(task.Checks, "; "))
+		}
+		if len(task.ForbiddenPaths) > 0 {
+			fmt.Printf("     禁止修改: %s\n", strings.Join(task.ForbiddenPaths, ", "))
+		}
 	}
 
 	// 显示依赖关系
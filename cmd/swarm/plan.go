@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/claude-swarm/pkg/orchestrator"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "校验和应用保存的任务计划",
+	Long: `校验和应用由 swarm orchestrate 保存的 YAML 任务计划。
+
+计划包含需求、模块和任务（描述、依赖、优先级、文件），可以手工编辑；
+应用前会重新校验任务ID、依赖和优先级，不需要调用 LLM。
+
+示例:
+  # 生成计划但不创建任务
+  swarm orchestrate "添加文件上传功能" --save-plan --plan upload.yaml
+
+  # 只校验
+  swarm plan validate upload.yaml
+
+  # 校验并创建任务
+  swarm plan apply upload.yaml`,
+}
+
+var planValidateCmd = &cobra.Command{
+	Use:   "validate <plan.yaml>",
+	Short: "校验计划并显示内容",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		brain, taskQueue := openPlanBrain()
+		defer taskQueue.Close()
+
+		loadValidPlan(brain, args[0])
+		fmt.Println("\n✅ 计划有效")
+	},
+}
+
+var planApplyCmd = &cobra.Command{
+	Use:   "apply <plan.yaml>",
+	Short: "校验计划并创建任务",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		brain, taskQueue := openPlanBrain()
+		defer taskQueue.Close()
+
+		plan, result := loadValidPlan(brain, args[0])
+		createTasksFromPlan(context.Background(), brain, plan.Requirement, result)
+		printNextSteps()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	planCmd.AddCommand(planValidateCmd, planApplyCmd)
+
+	planCmd.PersistentFlags().StringVar(&taskQueuePath, "tasks", "~/.claude-swarm/tasks.json", "任务队列文件路径")
+}
+
+// openPlanBrain 创建不连接 LLM 的主脑，只用于校验计划和创建任务
+func openPlanBrain() (*orchestrator.OrchestratorBrain, *state.TaskQueue) {
+	taskQueue, err := state.NewTaskQueue(expandPath(taskQueuePath))
+	if err != nil {
+		log.Fatalf("❌ 初始化任务队列失败: %v", err)
+	}
+	return orchestrator.NewOrchestratorBrain(nil, taskQueue), taskQueue
+}
+
+// loadValidPlan 读取并校验计划，无效时退出
+func loadValidPlan(brain *orchestrator.OrchestratorBrain, path string) (*orchestrator.Plan, *orchestrator.AnalysisResult) {
+	plan, err := orchestrator.LoadPlan(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if plan.Requirement != "" {
+		fmt.Printf("📝 需求: %s\n", plan.Requirement)
+	}
+	result, err := brain.ValidatePlan(plan)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 计划无效:\n%v\n", err)
+		os.Exit(1)
+	}
+	printAnalysisResult(result)
+	return plan, result
+}
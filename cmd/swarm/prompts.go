@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/orchestrator"
+	"github.com/yourusername/claude-swarm/pkg/prompts"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "查看、渲染和导出AI主脑的提示词模板",
+	Long: `查看、渲染和导出AI主脑的提示词模板。
+
+模板是 text/template 文件，内置中文（zh）和英文（en）两套，语言由 config.yaml 的
+prompts.language 选择。项目可以在 .swarm/prompts/<name>.tmpl 或
+.swarm/prompts/<语言>/<name>.tmpl 中覆盖任意模板。每个模板有一个版本ID
+（名称/语言@内容摘要），记录在对话历史和审计日志中。
+
+示例:
+  # 列出模板、版本和来源
+  swarm prompts list
+
+  # 查看诊断某个任务时会发送的完整提示词
+  swarm prompts render diagnose --task task-1700000000000000000
+
+  # 查看需求分析的提示词（英文模板）
+  swarm prompts render analyze --requirement "添加用户登录" --lang en
+
+  # 把内置模板导出到 .swarm/prompts/ 以便修改
+  swarm prompts export`,
+}
+
+var promptsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出提示词模板",
+	Args:  cobra.NoArgs,
+	Run:   runPromptsList,
+}
+
+var promptsRenderCmd = &cobra.Command{
+	Use:   "render <name>",
+	Short: "渲染将要发送给 LLM 的提示词",
+	Args:  cobra.ExactArgs(1),
+	Run:   runPromptsRender,
+}
+
+var promptsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出内置模板作为覆盖的起点（不覆盖已有文件）",
+	Args:  cobra.NoArgs,
+	Run:   runPromptsExport,
+}
+
+var (
+	promptsLanguage    string
+	promptsTaskID      string
+	promptsRequirement string
+	promptsExportDir   string
+)
+
+func init() {
+	rootCmd.AddCommand(promptsCmd)
+	promptsCmd.AddCommand(promptsListCmd, promptsRenderCmd, promptsExportCmd)
+
+	promptsCmd.PersistentFlags().StringVar(&promptsLanguage, "lang", "", "模板语言（默认: 配置文件的 prompts.language）")
+	promptsCmd.PersistentFlags().StringVarP(&configFilePath, "config", "c", "", "配置文件路径（默认: ./config.yaml 或 ~/.claude-swarm/config.yaml）")
+	promptsRenderCmd.Flags().StringVar(&promptsTaskID, "task", "", "以该任务及其最近一次尝试作为输入")
+	promptsRenderCmd.Flags().StringVar(&promptsRequirement, "requirement", "", "analyze 模板的需求描述")
+	promptsRenderCmd.Flags().BoolVar(&noRepoContext, "no-repo-context", false, "analyze 模板不包含仓库摘要")
+	promptsRenderCmd.Flags().StringVar(&taskQueuePath, "queue", "~/.claude-swarm/tasks.json", "任务队列文件路径")
+	promptsExportCmd.Flags().StringVar(&promptsExportDir, "dir", prompts.DefaultDir, "导出目录")
+}
+
+func openPromptStore() *prompts.Store {
+	store, err := newPromptStore(configFilePath, promptsLanguage)
+	if err != nil {
+		log.Fatalf("❌ 加载提示词模板失败: %v", err)
+	}
+	return store
+}
+
+func runPromptsList(cmd *cobra.Command, args []string) {
+	store := openPromptStore()
+
+	fmt.Printf("语言: %s\n\n", store.Language())
+	fmt.Printf("%-18s %-32s %s\n", "模板", "版本", "来源")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, name := range prompts.Names() {
+		tmpl, err := store.Lookup(name)
+		if err != nil {
+			fmt.Printf("%-18s ❌ %v\n", name, err)
+			continue
+		}
+		fmt.Printf("%-18s %-32s %s\n", name, tmpl.Version, tmpl.Source)
+	}
+}
+
+func runPromptsRender(cmd *cobra.Command, args []string) {
+	store := openPromptStore()
+
+	var task *models.Task
+	if promptsTaskID != "" {
+		taskQueue, err := state.NewTaskQueue(expandPath(taskQueuePath))
+		if err != nil {
+			log.Fatalf("❌ 无法打开任务队列: %v", err)
+		}
+		task, err = taskQueue.GetTask(promptsTaskID)
+		taskQueue.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	repoContext := ""
+	if args[0] == orchestrator.TemplateAnalyze {
+		repoContext = loadRepoContext(configFilePath)
+	}
+
+	prompt, err := orchestrator.RenderPrompt(store, args[0], task, promptsRequirement, repoContext)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	// 版本信息写到 stderr，stdout 只有提示词本身，便于重定向或比较
+	fmt.Fprintf(os.Stderr, "# %s\n", prompt.Version)
+	fmt.Println(prompt.Text)
+}
+
+func runPromptsExport(cmd *cobra.Command, args []string) {
+	language := promptsLanguage
+	if language == "" {
+		language = openPromptStore().Language()
+	}
+
+	written, err := prompts.Export(promptsExportDir, language)
+	for _, file := range written {
+		fmt.Printf("✓ %s\n", file)
+	}
+	if err != nil {
+		log.Fatalf("❌ 导出失败: %v", err)
+	}
+	if len(written) == 0 {
+		fmt.Printf("%s 中的模板都已存在，未写入任何文件\n", promptsExportDir)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/config"
+	"github.com/yourusername/claude-swarm/pkg/orchestrator"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+// checkReplan 检查需要重新规划的事件，让主脑提出修改；按配置直接应用或加入审批队列
+func checkReplan(ctx context.Context, brain *orchestrator.OrchestratorBrain, cfg config.ReplanConfig, approvals *state.ApprovalQueue) {
+	triggers := brain.DetectReplanTriggers(orchestrator.ReplanOptions{
+		ConflictThreshold: cfg.ConflictThreshold,
+		FollowUpMarker:    cfg.FollowUpMarker,
+		MaxPerTask:        cfg.MaxPerTask,
+	})
+
+	for _, trigger := range triggers {
+		proposal, record, err := brain.Replan(ctx, trigger)
+		if err != nil {
+			log.Printf("⚠️  重新规划失败: %v", err)
+			continue
+		}
+
+		// 不需要修改时直接记录，不打扰人工；新增检查命令总是需要人工审批
+		checks := proposal.Checks()
+		if len(proposal.Changes) == 0 || cfg.AutoApply && len(checks) == 0 {
+			diff, err := brain.ApplyReplan(proposal, record, "brain")
+			if err != nil {
+				log.Printf("⚠️  应用重新规划失败: %v", err)
+				continue
+			}
+			log.Printf("🗺️  重新规划（%s）:\n%s", trigger.Kind, diff)
+			continue
+		}
+
+		payload, err := json.Marshal(proposal)
+		if err != nil {
+			log.Printf("⚠️  序列化重新规划提议失败: %v", err)
+			continue
+		}
+		approval, err := approvals.Request(&models.Approval{
+			Kind:        models.ApprovalKindReplan,
+			TaskID:      trigger.TaskID,
+			Title:       "重新规划: " + proposal.Summary,
+			Context:     record.Diff,
+			Payload:     payload,
+			RequestedBy: "brain",
+		})
+		if err != nil {
+			log.Printf("⚠️  创建审批请求失败: %v", err)
+			brain.ProposeReplan(record, "")
+			continue
+		}
+		brain.ProposeReplan(record, approval.ID)
+		if cfg.AutoApply {
+			log.Printf("🗺️  重新规划添加了 %d 条检查命令，需人工审批", len(checks))
+		}
+		log.Printf("🗺️  重新规划等待审批: %s (swarm approvals list)\n%s", approval.ID, record.Diff)
+	}
+}
+
+// applyReplanApprovals 应用人工处理过的重新规划提议
+func applyReplanApprovals(brain *orchestrator.OrchestratorBrain, approvals *state.ApprovalQueue) {
+	for _, approval := range approvals.ListUnapplied(models.ApprovalKindReplan) {
+		if approval.Status == models.ApprovalStatusPending {
+			continue
+		}
+
+		record := brain.ReplanRecordFor(approval.ID)
+		by := "human:" + approval.ResolvedBy
+		if approval.Status == models.ApprovalStatusApproved {
+			var proposal orchestrator.ReplanProposal
+			if err := json.Unmarshal(approval.Payload, &proposal); err != nil {
+				log.Printf("⚠️  审批项 %s 的重新规划提议无效: %v", approval.ID, err)
+			} else if diff, err := brain.ApplyReplan(&proposal, record, by); err != nil {
+				log.Printf("⚠️  应用重新规划 %s 失败: %v", approval.ID, err)
+			} else {
+				log.Printf("🗺️  已应用重新规划 %s（%s 批准）:\n%s", approval.ID, approval.ResolvedBy, diff)
+			}
+		} else {
+			brain.RejectReplan(record, by, approval.Note)
+			log.Printf("🗑️  重新规划 %s 被 %s 拒绝", approval.ID, approval.ResolvedBy)
+		}
+
+		if err := approvals.MarkApplied(approval.ID); err != nil {
+			log.Printf("⚠️  标记审批项 %s 失败: %v", approval.ID, err)
+		}
+	}
+}
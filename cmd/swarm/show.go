@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show <task-id>",
+	Short: "查看任务详情和每次尝试的时间线",
+	Long: `查看单个任务的详情，以及每次尝试的时间线：执行的Agent和分支、
+起止时间、退出码、错误类别、成本、校验结果、评审结果、提交和完整输出记录。
+
+示例:
+  swarm show task-1700000000000000000
+
+  # 同时显示每次尝试实际发送的提示词
+  swarm show task-1700000000000000000 --prompts
+
+  # 以 JSON 输出
+  swarm show task-1700000000000000000 --json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runShow,
+}
+
+var (
+	showPrompts bool
+	showJSON    bool
+)
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().BoolVar(&showPrompts, "prompts", false, "显示每次尝试的提示词")
+	showCmd.Flags().BoolVar(&showJSON, "json", false, "以 JSON 输出任务")
+	showCmd.Flags().StringVar(&taskQueuePath, "queue", "~/.claude-swarm/tasks.json", "任务队列文件路径")
+}
+
+func runShow(cmd *cobra.Command, args []string) {
+	taskQueue, err := state.NewTaskQueue(expandPath(taskQueuePath))
+	if err != nil {
+		log.Fatalf("❌ 无法打开任务队列: %v", err)
+	}
+	defer taskQueue.Close()
+
+	task, err := taskQueue.GetTask(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if showJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(task); err != nil {
+			log.Fatalf("❌ 输出失败: %v", err)
+		}
+		return
+	}
+
+	printTaskHeader(task)
+	printAttemptTimeline(task, showPrompts)
+}
+
+// printTaskHeader prints the task's current state
+func printTaskHeader(task *models.Task) {
+	fmt.Printf("%s 任务 %s\n", getStatusIcon(task.Status), task.ID)
+	fmt.Println(strings.Repeat("━", 60))
+	fmt.Printf("描述: %s\n", task.Description)
+	fmt.Printf("状态: %s   优先级: %d   重试: %d/%d\n", task.Status, task.Priority, task.RetryCount, task.MaxRetries)
+	if task.AssigneeID != "" {
+		fmt.Printf("执行者: %s\n", task.AssigneeID)
+	}
+	if len(task.Dependencies) > 0 {
+		fmt.Printf("依赖: %s\n", strings.Join(task.Dependencies, ", "))
+	}
+	if len(task.AcceptanceCriteria) > 0 {
+		fmt.Println("验收标准:")
+		for _, criterion := range task.AcceptanceCriteria {
+			fmt.Printf("  - %s\n", criterion)
+		}
+	}
+	if len(task.RequiredFiles) > 0 {
+		fmt.Printf("必须存在: %s\n", strings.Join(task.RequiredFiles, ", "))
+	}
+	if len(task.Checks) > 0 {
+		fmt.Println("必须通过:")
+		for _, check := range task.Checks {
+			fmt.Printf("  $ %s\n", check)
+		}
+	}
+	if len(task.ForbiddenPaths) > 0 {
+		fmt.Printf("禁止修改: %s\n", strings.Join(task.ForbiddenPaths, ", "))
+	}
+	if len(task.WritePaths) > 0 {
+		fmt.Printf("修改范围: %s\n", strings.Join(task.WritePaths, ", "))
+	}
+	if len(task.ReadPaths) > 0 {
+		fmt.Printf("阅读范围: %s\n", strings.Join(task.ReadPaths, ", "))
+	}
+	fmt.Printf("创建: %s   更新: %s\n",
+		task.CreatedAt.Format("2006-01-02 15:04:05"), task.UpdatedAt.Format("2006-01-02 15:04:05"))
+	if task.Status == models.TaskStatusPending && time.Now().Before(task.NotBefore) {
+		fmt.Printf("计划重试: %s", task.NotBefore.Format("2006-01-02 15:04:05"))
+		if task.AvoidAgent != "" {
+			fmt.Printf(" (避开 %s)", task.AvoidAgent)
+		}
+		fmt.Println()
+	}
+	if task.RetryHint != "" {
+		fmt.Printf("重试建议: %s\n", task.RetryHint)
+	}
+	if task.LastError != "" {
+		fmt.Printf("最近错误: %s\n", task.LastError)
+	}
+	fmt.Println()
+}
+
+// printAttemptTimeline prints one block per attempt, oldest first
+func printAttemptTimeline(task *models.Task, prompts bool) {
+	if len(task.Attempts) == 0 {
+		fmt.Println("尚无尝试记录")
+		return
+	}
+
+	var totalCost float64
+	fmt.Printf("尝试记录 (%d):\n", len(task.Attempts))
+	for i, attempt := range task.Attempts {
+		if i > 0 {
+			fmt.Println("  │")
+		}
+
+		result := "✅ 成功"
+		if attempt.FinishedAt.IsZero() {
+			result = "⏳ 进行中"
+		} else if attempt.Failure != nil {
+			result = fmt.Sprintf("❌ %s", attempt.Failure.Category)
+		}
+
+		fmt.Printf("  #%d  %s  %s", attempt.Number, attempt.AgentID, result)
+		if attempt.Branch != "" {
+			fmt.Printf("  (%s)", attempt.Branch)
+		}
+		fmt.Println()
+
+		fmt.Printf("      时间: %s", attempt.StartedAt.Format("2006-01-02 15:04:05"))
+		if d := attempt.Duration(); d > 0 {
+			fmt.Printf(" → %s (%s)", attempt.FinishedAt.Format("15:04:05"), d.Round(time.Second))
+		}
+		fmt.Println()
+
+		if attempt.Failure != nil {
+			if attempt.Failure.Message != "" {
+				fmt.Printf("      错误: %s\n", attempt.Failure.Message)
+			}
+			if loc := attempt.Failure.Location(); loc != "" {
+				fmt.Printf("      位置: %s\n", loc)
+			}
+		}
+		if attempt.ExitCode != 0 || attempt.CostUSD > 0 {
+			fmt.Printf("      退出码: %d   成本: $%.4f\n", attempt.ExitCode, attempt.CostUSD)
+		}
+		totalCost += attempt.CostUSD
+
+		if len(attempt.Verification) > 0 {
+			checks := make([]string, 0, len(attempt.Verification))
+			for _, check := range attempt.Verification {
+				mark := "✓"
+				if !check.Passed {
+					mark = "✗"
+				}
+				checks = append(checks, mark+" "+check.Name)
+			}
+			fmt.Printf("      校验: %s\n", strings.Join(checks, "  "))
+		}
+		if len(attempt.OutOfScope) > 0 {
+			fmt.Printf("      范围外修改: %s\n", strings.Join(attempt.OutOfScope, ", "))
+		}
+		if review := attempt.Review; review != nil {
+			verdict := "通过"
+			if review.SentBack {
+				verdict = "退回返工"
+			}
+			fmt.Printf("      评审: %d/100 %s", review.Score, verdict)
+			if review.Reviewer != "" {
+				fmt.Printf(" (%s)", review.Reviewer)
+			}
+			fmt.Println()
+			for _, issue := range review.Issues {
+				fmt.Printf("        - %s\n", issue)
+			}
+			if review.SentBack && review.Instructions != "" {
+				fmt.Printf("      返工指示: %s\n", review.Instructions)
+			}
+		}
+		if len(attempt.Commits) > 0 {
+			short := make([]string, 0, len(attempt.Commits))
+			for _, sha := range attempt.Commits {
+				if len(sha) > 8 {
+					sha = sha[:8]
+				}
+				short = append(short, sha)
+			}
+			fmt.Printf("      提交: %s\n", strings.Join(short, ", "))
+		}
+		if attempt.TranscriptPath != "" {
+			fmt.Printf("      输出记录: %s\n", attempt.TranscriptPath)
+		}
+		if prompts {
+			fmt.Println("      提示词:")
+			for _, line := range strings.Split(attempt.Prompt, "\n") {
+				fmt.Printf("        %s\n", line)
+			}
+		}
+	}
+
+	if totalCost > 0 {
+		fmt.Printf("\n总成本: $%.4f\n", totalCost)
+	}
+}
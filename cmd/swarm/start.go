@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -13,8 +15,12 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/config"
 	"github.com/yourusername/claude-swarm/pkg/controller"
+	"github.com/yourusername/claude-swarm/pkg/executor"
+	"github.com/yourusername/claude-swarm/pkg/git"
 	"github.com/yourusername/claude-swarm/pkg/orchestrator"
+	"github.com/yourusername/claude-swarm/pkg/retry"
 	"github.com/yourusername/claude-swarm/pkg/state"
 )
 
@@ -30,6 +36,24 @@ var (
 	taskFile    string
 	withBrain   bool
 	brainAPIKey string
+	controlAddr string
+	interactive bool
+
+	sandboxBackend string
+	sandboxNetwork string
+	sandboxAllow   []string
+
+	taskTimeout time.Duration
+	memoryLimit string
+	cpuLimit    float64
+	maxProcs    int
+	maxOutput   string
+
+	startRate        float64
+	startBurst       int
+	maxConcurrent    int
+	breakerThreshold int
+	breakerCooldown  time.Duration
 )
 
 func init() {
@@ -38,7 +62,22 @@ func init() {
 	startCmd.Flags().IntVar(&numAgents, "agents", 3, "Number of agents to start")
 	startCmd.Flags().StringVar(&taskFile, "tasks", "~/.claude-swarm/tasks.json", "Path to tasks file")
 	startCmd.Flags().BoolVar(&withBrain, "with-brain", false, "启用AI主脑监控和智能决策")
-	startCmd.Flags().StringVar(&brainAPIKey, "brain-api-key", "", "Gemini API Key for AI brain (or use GEMINI_API_KEY env var)")
+	startCmd.Flags().StringVar(&brainAPIKey, "brain-api-key", "", "API key of the AI brain's LLM provider (or set it in config.yaml / the provider's env var)")
+	startCmd.Flags().BoolVar(&interactive, "interactive", false, "Run agents under a PTY without --dangerously-skip-permissions; prompts are answered by the detector or escalated for approval")
+	startCmd.Flags().StringVar(&sandboxBackend, "sandbox", "", "Run agents in a filesystem sandbox: auto, bwrap or native (disabled if empty)")
+	startCmd.Flags().StringVar(&sandboxNetwork, "sandbox-network", "host", "Sandbox network: host, none or allowlist")
+	startCmd.Flags().StringSliceVar(&sandboxAllow, "sandbox-allow", nil, "Extra hosts reachable with --sandbox-network allowlist, e.g. github.com,*.npmjs.org")
+	startCmd.Flags().DurationVar(&taskTimeout, "task-timeout", 10*time.Minute, "Wall-clock limit per task (tasks may override)")
+	startCmd.Flags().StringVar(&memoryLimit, "memory", "", "Memory limit per agent process tree, e.g. 4G (unlimited if empty)")
+	startCmd.Flags().Float64Var(&cpuLimit, "cpus", 0, "CPU limit per agent in cores, e.g. 1.5 (needs cgroups v2)")
+	startCmd.Flags().IntVar(&maxProcs, "max-procs", 0, "Maximum number of processes per agent (unlimited if 0; without cgroups v2 it counts all of the user's processes and is not enforced for root)")
+	startCmd.Flags().StringVar(&maxOutput, "max-output", "16M", "Maximum captured output per task")
+	startCmd.Flags().Float64Var(&startRate, "start-rate", 0, "Task starts per minute across all agents (unlimited if 0)")
+	startCmd.Flags().IntVar(&startBurst, "start-burst", 1, "Task starts allowed at once by --start-rate")
+	startCmd.Flags().IntVar(&maxConcurrent, "max-concurrent", 0, "Maximum tasks running at once across all agents (number of agents if 0)")
+	startCmd.Flags().IntVar(&breakerThreshold, "breaker-threshold", 3, "Consecutive rate limit or network failures that pause task starts")
+	startCmd.Flags().DurationVar(&breakerCooldown, "breaker-cooldown", time.Minute, "Pause after the breaker trips, before a single probe task runs")
+	startCmd.Flags().StringVar(&controlAddr, "control-addr", "", "Listen address of the HTTP control API, e.g. 127.0.0.1:8787 (disabled if empty); requests need the bearer token written to control.token next to the tasks file")
 }
 
 func runStart(cmd *cobra.Command, args []string) {
@@ -68,6 +107,41 @@ func runStart(cmd *cobra.Command, args []string) {
 		log.Fatalf("Failed to create coordinator: %v", err)
 	}
 
+	if interactive {
+		if err := coord.SetExecutorMode(executor.ModeInteractive); err != nil {
+			coord.Cleanup()
+			log.Fatalf("Failed to enable interactive mode: %v", err)
+		}
+	}
+
+	limits, err := resourceLimitsFromFlags()
+	if err != nil {
+		coord.Cleanup()
+		log.Fatalf("Invalid resource limits: %v", err)
+	}
+	coord.SetResourceLimits(limits)
+
+	breaker := retry.DefaultBreakerConfig()
+	breaker.Threshold = breakerThreshold
+	breaker.Cooldown = breakerCooldown
+	coord.SetThrottle(retry.LimiterConfig{
+		StartsPerMinute: startRate,
+		Burst:           startBurst,
+		MaxConcurrent:   maxConcurrent,
+	}, breaker)
+
+	if sandboxBackend != "" {
+		err := coord.SetSandbox(executor.SandboxConfig{
+			Backend:    executor.SandboxBackend(sandboxBackend),
+			Network:    executor.NetworkMode(sandboxNetwork),
+			AllowHosts: sandboxAllow,
+		})
+		if err != nil {
+			coord.Cleanup()
+			log.Fatalf("Failed to enable sandbox: %v", err)
+		}
+	}
+
 	// Setup signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -80,8 +154,40 @@ func runStart(cmd *cobra.Command, args []string) {
 	fmt.Println()
 	fmt.Printf("✓ Swarm started with %d agents\n", numAgents)
 	fmt.Printf("✓ Task queue: %s\n", taskFile)
+	if interactive {
+		fmt.Println("✓ Interactive mode: confirmation prompts answered by the detector")
+	}
+	fmt.Printf("✓ Limits: %s (%s)\n", describeLimits(limits), executor.ResourceEnforcement())
+	if startRate > 0 || maxConcurrent > 0 {
+		fmt.Printf("✓ Throttle: %s\n", describeThrottle())
+	}
+	if sandboxBackend != "" {
+		fmt.Printf("✓ Sandbox: worktree read-write, toolchain read-only, $HOME hidden (network: %s)\n", sandboxNetwork)
+	}
 	fmt.Println()
 
+	// Start control API (optional)
+	if controlAddr != "" {
+		tokenPath := state.ControlTokenPathFor(taskFile)
+		token, err := state.NewControlToken(tokenPath)
+		if err != nil {
+			log.Fatalf("Failed to create control API token: %v", err)
+		}
+		operator := os.Getenv("USER")
+		if operator == "" {
+			operator = "operator"
+		}
+		server := &http.Server{Addr: controlAddr, Handler: coord.APIHandler(token, operator)}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("⚠️  Control API stopped: %v", err)
+			}
+		}()
+		defer server.Close()
+		fmt.Printf("✓ Control API listening on http://%s (bearer token in %s)\n", controlAddr, tokenPath)
+		fmt.Println()
+	}
+
 	// 启动AI主脑监控（可选）
 	var brainCancelFunc context.CancelFunc
 	if withBrain {
@@ -126,31 +232,72 @@ func runStart(cmd *cobra.Command, args []string) {
 
 // startBrainMonitor 启动AI主脑监控循环
 func startBrainMonitor(ctx context.Context, taskFilePath string, coord *controller.Coordinator) error {
-	// 获取API Key
-	apiKey := brainAPIKey
-	if apiKey == "" {
-		apiKey = os.Getenv("GEMINI_API_KEY")
-	}
-	if apiKey == "" {
-		return fmt.Errorf("需要Gemini API Key: 使用 --brain-api-key 或设置 GEMINI_API_KEY 环境变量")
+	// 按配置创建LLM客户端（--brain-api-key 覆盖配置中的 API Key）
+	client, err := newBrainClient("", brainAPIKey)
+	if err != nil {
+		return fmt.Errorf("创建LLM客户端失败: %w", err)
 	}
 
-	// 初始化任务队列
-	taskQueue, err := state.NewTaskQueue(taskFilePath)
+	promptStore, err := newPromptStore("", "")
 	if err != nil {
-		return fmt.Errorf("初始化任务队列失败: %w", err)
+		return fmt.Errorf("加载提示词模板失败: %w", err)
 	}
 
-	// 创建AI主脑
-	brain, err := orchestrator.NewOrchestratorBrain(apiKey, taskQueue)
+	cfg, err := config.Read("")
 	if err != nil {
-		return fmt.Errorf("创建AI主脑失败: %w", err)
+		return fmt.Errorf("读取配置失败: %w", err)
+	}
+
+	// 主脑行动直接作用于运行中的Agent，受配置的频率限制
+	coord.SetActionLimits(controller.ActionLimits{
+		PerMinute:   cfg.BrainActions.MaxPerMinute,
+		Cooldown:    time.Duration(cfg.BrainActions.Cooldown) * time.Second,
+		MaxRestarts: cfg.BrainActions.MaxRestarts,
+	})
+
+	// 创建AI主脑，与coordinator共用任务队列
+	taskQueue := coord.GetTaskQueue()
+	brain := orchestrator.NewOrchestratorBrain(client, taskQueue)
+	brain.SetPrompts(promptStore)
+	brain.SetAuditLog(coord.GetAuditLog())
+
+	// 执行成功的任务先由主脑评审，不合格时带着返工指示交回原Agent
+	if cfg.Review.Enabled {
+		coord.SetReviewer(brain, controller.ReviewConfig{
+			MinScore:   cfg.Review.MinScore,
+			MaxReworks: cfg.Review.MaxReworks,
+		})
+	}
+
+	// 合并冲突时应用主脑的解决方案，校验通过且符合策略时合并，否则进入审批队列
+	if cfg.Conflicts.Enabled {
+		checks := make([]controller.Check, len(cfg.Conflicts.Checks))
+		for i, command := range cfg.Conflicts.Checks {
+			checks[i] = controller.Check{Name: command, Command: command}
+		}
+		coord.SetConflictResolver(controller.ConflictResolverConfig{
+			AutoMerge: cfg.Conflicts.AutoMerge,
+			MaxFiles:  cfg.Conflicts.MaxFiles,
+			Checks:    checks,
+		})
+	}
+
+	// 接着 orchestrate 创建的项目运行，主脑了解原始需求和计划；没有时开始新的运行
+	runsDir := state.BrainRunsDirFor(taskFilePath)
+	if err := brain.ResumeRun(runsDir, ""); err == nil {
+		log.Printf("🗂️  恢复项目运行 %s: %s", brain.Context().RunID, brain.Context().Requirement)
+	} else {
+		if !errors.Is(err, orchestrator.ErrNoRun) {
+			log.Printf("⚠️  加载项目运行失败，开始新的运行: %v", err)
+		}
+		if err := brain.StartRun(runsDir, "", nil); err != nil {
+			log.Printf("⚠️  保存项目运行失败: %v", err)
+		}
 	}
 
 	// 启动监控协程
 	go func() {
 		defer brain.Close()
-		defer taskQueue.Close()
 
 		ticker := time.NewTicker(30 * time.Second) // 每30秒检查一次
 		defer ticker.Stop()
@@ -160,7 +307,7 @@ func startBrainMonitor(ctx context.Context, taskFilePath string, coord *controll
 		for {
 			select {
 			case <-ticker.C:
-				// 收集Agent状态（这里简化处理，实际应从coordinator获取）
+				// 收集Agent状态
 				agents := collectAgentStatus(taskQueue)
 
 				// AI监控进度
@@ -174,6 +321,11 @@ func startBrainMonitor(ctx context.Context, taskFilePath string, coord *controll
 				log.Printf("📊 进度: %d/%d 完成 (%.1f%%), %d进行中, %d失败",
 					progress.CompletedTasks, progress.TotalTasks, progress.OverallProgress,
 					progress.InProgressTasks, progress.FailedTasks)
+				if progress.Reviews.Reviewed > 0 {
+					log.Printf("🔍 评审: %d个任务, 平均 %.0f/100, 最低 %d, 返工 %d次",
+						progress.Reviews.Reviewed, progress.Reviews.AverageScore,
+						progress.Reviews.MinScore, progress.Reviews.Reworks)
+				}
 
 				// AI决策下一步行动
 				action, err := brain.DecideNextAction(ctx, progress)
@@ -184,12 +336,19 @@ func startBrainMonitor(ctx context.Context, taskFilePath string, coord *controll
 
 				// 执行行动
 				if action.Type != orchestrator.ActionWait {
-					executeAction(action, taskQueue)
+					result := executeAction(action, coord)
+					brain.RecordActionResult(action, result)
+				}
+
+				// 任务永久失败、反复冲突或报告后续工作时重新规划
+				if cfg.Replan.Enabled {
+					applyReplanApprovals(brain, coord.GetApprovalQueue())
+					checkReplan(ctx, brain, cfg.Replan, coord.GetApprovalQueue())
 				}
 
 				// 检查是否需要智能合并（每完成一批任务后）
 				if progress.CompletedTasks > 0 && progress.InProgressTasks == 0 {
-					checkAndMerge(ctx, brain, coord)
+					checkAndMerge(ctx, brain, coord, cfg.Conflicts.Enabled)
 				}
 
 			case <-ctx.Done():
@@ -235,50 +394,77 @@ func collectAgentStatus(taskQueue *state.TaskQueue) []*models.AgentStatus {
 	return result
 }
 
-// executeAction 执行主脑决策的行动
-func executeAction(action *orchestrator.Action, taskQueue *state.TaskQueue) {
+// executeAction 执行主脑决策的行动，返回执行结果。提示、重启、重新分配和指定分配由
+// coordinator 作用于运行中的Agent，超出频率限制或状态不符时不执行
+func executeAction(action *orchestrator.Action, coord *controller.Coordinator) string {
+	agentAction := controller.AgentAction{
+		AgentID: action.TargetAgent,
+		TaskID:  action.TaskID,
+		Hint:    action.Command,
+		Reason:  action.Reason,
+	}
+
 	switch action.Type {
 	case orchestrator.ActionHelpAgent:
 		log.Printf("🆘 主脑介入帮助Agent: %s", action.Reason)
-		if action.Command != "" {
-			log.Printf("   提示: %s", action.Command)
-		}
-		// TODO: 实际发送提示给Agent（需要IPC机制）
+		agentAction.Kind = controller.ActionHint
 
 	case orchestrator.ActionReassignTask:
 		log.Printf("🔄 主脑重新分配任务: %s", action.Reason)
-		if action.TaskID != "" {
-			// 重置任务为pending状态
-			if err := taskQueue.ResetOrphanedTask(action.TaskID); err != nil {
-				log.Printf("⚠️  重置任务失败: %v", err)
-			} else {
-				log.Printf("   ✓ 任务 %s 已重置为待执行", action.TaskID)
-			}
-		}
+		agentAction.Kind = controller.ActionReassign
 
 	case orchestrator.ActionRestartAgent:
-		log.Printf("♻️  主脑建议重启Agent: %s", action.Reason)
-		// TODO: 实现Agent重启逻辑
+		log.Printf("♻️  主脑重启Agent: %s", action.Reason)
+		agentAction.Kind = controller.ActionRestart
 
 	case orchestrator.ActionAssignTask:
-		log.Printf("📌 主脑建议分配任务: %s", action.Reason)
-		// 任务分配由coordinator自动处理
+		log.Printf("📌 主脑分配任务: %s", action.Reason)
+		agentAction.Kind = controller.ActionAssign
+		agentAction.Hint = ""
 
 	case orchestrator.ActionMergeBranch:
 		log.Printf("🔀 主脑决策合并分支: %s", action.Reason)
 		// 合并逻辑在 checkAndMerge 中处理
+		return "由合并检查处理"
+
+	case orchestrator.ActionAskUser:
+		log.Printf("🙋 主脑请求人工决策: %s", action.Reason)
+		approval, err := coord.GetApprovalQueue().Request(&models.Approval{
+			Kind:        models.ApprovalKindBrain,
+			TaskID:      action.TaskID,
+			AgentID:     action.TargetAgent,
+			Title:       action.Reason,
+			Context:     action.Command,
+			RequestedBy: "brain",
+		})
+		if err != nil {
+			log.Printf("⚠️  创建审批请求失败: %v", err)
+			return fmt.Sprintf("创建审批请求失败: %v", err)
+		}
+		log.Printf("   ✓ 已加入审批队列: %s (swarm approvals list)", approval.ID)
+		return "已加入审批队列: " + approval.ID
 
 	case orchestrator.ActionWait:
 		// 静默等待
-		return
+		return ""
 
 	default:
 		log.Printf("⚠️  未知的主脑行动类型: %s", action.Type)
+		return "未知的行动类型"
 	}
+
+	result, err := coord.ExecuteAction(agentAction)
+	if err != nil {
+		log.Printf("   ⚠️  未执行: %v", err)
+		return "未执行: " + err.Error()
+	}
+	log.Printf("   ✓ %s", result)
+	return result
 }
 
-// checkAndMerge 使用AI智能决策合并
-func checkAndMerge(ctx context.Context, brain *orchestrator.OrchestratorBrain, coord *controller.Coordinator) {
+// checkAndMerge 使用AI智能决策合并。applyResolutions 为 true 时，合并冲突的解决方案交给
+// coordinator 在临时分支上应用和校验
+func checkAndMerge(ctx context.Context, brain *orchestrator.OrchestratorBrain, coord *controller.Coordinator, applyResolutions bool) {
 	// 获取所有分支的合并状态
 	coordStatuses := coord.GetMergeStatuses()
 	if len(coordStatuses) == 0 {
@@ -330,18 +516,23 @@ func checkAndMerge(ctx context.Context, brain *orchestrator.OrchestratorBrain, c
 		log.Printf("⚠️  潜在问题: %v", decision.PotentialIssues)
 	}
 
-	// 按顺序执行合并
+	// 按顺序执行合并，结果记录到决策历史
 	for _, branch := range decision.MergeOrder {
 		log.Printf("🔀 合并分支: %s", branch)
+		action := &orchestrator.Action{Type: orchestrator.ActionMergeBranch, Command: branch, Reason: decision.Reason}
 
 		err := coord.MergeBranch(branch)
 		if err != nil {
+			result := fmt.Sprintf("合并失败: %v", err)
 			// 检查是否是冲突
-			if strings.Contains(err.Error(), "conflict") {
+			if errors.Is(err, git.ErrMergeConflict) {
 				log.Printf("⚠️  合并冲突: %s", branch)
 
 				// 获取冲突详情
-				conflictFiles, conflictContent, _ := coord.GetConflictDetails(branch)
+				conflictFiles, conflictContent, err := coord.GetConflictDetails(branch)
+				if err != nil {
+					log.Printf("⚠️  获取冲突详情失败: %v", err)
+				}
 				if len(conflictFiles) > 0 {
 					// 让AI分析冲突
 					resolution, err := brain.ResolveConflict(ctx, branch, conflictFiles, conflictContent)
@@ -349,7 +540,10 @@ func checkAndMerge(ctx context.Context, brain *orchestrator.OrchestratorBrain, c
 						log.Printf("⚠️  AI冲突分析失败: %v", err)
 					} else {
 						log.Printf("🧠 AI冲突分析: %s", resolution.Resolution)
-						if resolution.NeedsHumanReview {
+						result += "\nAI冲突分析: " + resolution.Resolution
+						if applyResolutions {
+							result += "\n" + applyResolution(coord, branch, resolution)
+						} else if resolution.NeedsHumanReview {
 							log.Printf("⚠️  需要人工审核冲突")
 						}
 					}
@@ -357,9 +551,77 @@ func checkAndMerge(ctx context.Context, brain *orchestrator.OrchestratorBrain, c
 			} else {
 				log.Printf("❌ 合并失败: %v", err)
 			}
+			brain.RecordDecision(action, decision.Reason, result)
 			continue
 		}
 
 		log.Printf("✅ 成功合并: %s", branch)
+		brain.RecordDecision(action, decision.Reason, "已合并")
+	}
+}
+
+// applyResolution 在临时分支上应用主脑的冲突解决方案并校验，返回处理结果
+func applyResolution(coord *controller.Coordinator, branch string, resolution *orchestrator.ConflictResolution) string {
+	outcome, err := coord.ApplyConflictResolution(controller.ConflictProposal{
+		Branch:      branch,
+		Files:       resolution.FileResolutions,
+		Summary:     resolution.Resolution,
+		NeedsReview: resolution.NeedsHumanReview || !resolution.CanAutoResolve,
+	})
+	if err != nil {
+		log.Printf("⚠️  应用冲突解决方案失败: %v", err)
+		return "应用冲突解决方案失败: " + err.Error()
+	}
+	log.Printf("🔧 冲突解决方案: %s", outcome)
+	return "冲突解决方案: " + outcome
+}
+
+// resourceLimitsFromFlags builds the per-agent limits from the start flags
+func resourceLimitsFromFlags() (models.ResourceLimits, error) {
+	memory, err := executor.ParseByteSize(memoryLimit)
+	if err != nil {
+		return models.ResourceLimits{}, err
+	}
+	output, err := executor.ParseByteSize(maxOutput)
+	if err != nil {
+		return models.ResourceLimits{}, err
+	}
+
+	return models.ResourceLimits{
+		TimeoutSeconds: int(taskTimeout.Seconds()),
+		MemoryMB:       int(memory >> 20),
+		CPUPercent:     int(cpuLimit * 100),
+		MaxProcs:       maxProcs,
+		MaxOutputBytes: output,
+	}, nil
+}
+
+// describeThrottle formats the start limiter flags for the startup banner
+func describeThrottle() string {
+	var parts []string
+	if startRate > 0 {
+		parts = append(parts, fmt.Sprintf("%g starts/min (burst %d)", startRate, startBurst))
+	}
+	if maxConcurrent > 0 {
+		parts = append(parts, fmt.Sprintf("%d concurrent", maxConcurrent))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeLimits formats the configured limits for the startup banner
+func describeLimits(limits models.ResourceLimits) string {
+	parts := []string{"timeout " + limits.Timeout().String()}
+	if limits.MemoryMB > 0 {
+		parts = append(parts, fmt.Sprintf("memory %d MB", limits.MemoryMB))
+	}
+	if limits.CPUPercent > 0 {
+		parts = append(parts, fmt.Sprintf("cpu %d%%", limits.CPUPercent))
+	}
+	if limits.MaxProcs > 0 {
+		parts = append(parts, fmt.Sprintf("%d processes", limits.MaxProcs))
+	}
+	if limits.MaxOutputBytes > 0 {
+		parts = append(parts, fmt.Sprintf("output %d KB", limits.MaxOutputBytes>>10))
 	}
+	return strings.Join(parts, ", ")
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yourusername/claude-swarm/internal/models"
@@ -38,7 +39,7 @@ func init() {
 	rootCmd.AddCommand(statusCmd)
 
 	statusCmd.Flags().BoolVarP(&statusVerbose, "verbose", "v", false, "显示详细信息")
-	statusCmd.Flags().StringVarP(&statusFilter, "filter", "f", "", "过滤任务状态 (pending/in_progress/completed/failed)")
+	statusCmd.Flags().StringVarP(&statusFilter, "filter", "f", "", "过滤任务状态 (pending/in_progress/completed/failed/awaiting_approval)")
 	statusCmd.Flags().StringVar(&taskQueuePath, "queue", "~/.claude-swarm/tasks.json", "任务队列文件路径")
 }
 
@@ -81,6 +82,8 @@ type TaskStats struct {
 	InProgress int
 	Pending    int
 	Failed     int
+	Awaiting   int // 等待人工审批
+	Reviews    models.ReviewSummary
 }
 
 // calculateStats calculates task statistics
@@ -97,15 +100,18 @@ func calculateStats(tasks []*models.Task) *TaskStats {
 			stats.Pending++
 		case models.TaskStatusFailed:
 			stats.Failed++
+		case models.TaskStatusAwaitingApproval:
+			stats.Awaiting++
 		}
 	}
 
+	stats.Reviews = models.SummarizeReviews(tasks)
 	return stats
 }
 
 // printStats prints task statistics
 func printStats(stats *TaskStats) {
-	total := stats.Completed + stats.InProgress + stats.Pending + stats.Failed
+	total := stats.Completed + stats.InProgress + stats.Pending + stats.Failed + stats.Awaiting
 	percentage := 0
 	if total > 0 {
 		percentage = (stats.Completed * 100) / total
@@ -116,6 +122,13 @@ func printStats(stats *TaskStats) {
 	fmt.Printf("  🔄 进行中: %d\n", stats.InProgress)
 	fmt.Printf("  ⏳ 待执行: %d\n", stats.Pending)
 	fmt.Printf("  ❌ 失败: %d\n", stats.Failed)
+	if stats.Awaiting > 0 {
+		fmt.Printf("  ⏸️  待审批: %d (使用 'swarm approvals list' 查看)\n", stats.Awaiting)
+	}
+	if stats.Reviews.Reviewed > 0 {
+		fmt.Printf("  🔍 评审: %d 个任务, 平均 %.0f/100, 最低 %d, 返工 %d 次\n",
+			stats.Reviews.Reviewed, stats.Reviews.AverageScore, stats.Reviews.MinScore, stats.Reviews.Reworks)
+	}
 	fmt.Println()
 
 	// 进度条
@@ -146,10 +159,11 @@ func printTaskList(tasks []*models.Task, filter string, verbose bool, taskQueue
 	sort.Slice(tasks, func(i, j int) bool {
 		// 首先按状态排序：in_progress > pending > failed > completed
 		statusPriority := map[models.TaskStatus]int{
-			models.TaskStatusInProgress: 4,
-			models.TaskStatusPending:    3,
-			models.TaskStatusFailed:     2,
-			models.TaskStatusCompleted:  1,
+			models.TaskStatusAwaitingApproval: 5,
+			models.TaskStatusInProgress:       4,
+			models.TaskStatusPending:          3,
+			models.TaskStatusFailed:           2,
+			models.TaskStatusCompleted:        1,
 		}
 		if statusPriority[tasks[i].Status] != statusPriority[tasks[j].Status] {
 			return statusPriority[tasks[i].Status] > statusPriority[tasks[j].Status]
@@ -185,11 +199,35 @@ func printTaskList(tasks []*models.Task, filter string, verbose bool, taskQueue
 			}
 		}
 
+		// 审批信息
+		if task.Status == models.TaskStatusAwaitingApproval && task.LastError != "" {
+			fmt.Printf("  等待审批: %s\n", task.LastError)
+		}
+
+		// 计划重试
+		if task.Status == models.TaskStatusPending && time.Now().Before(task.NotBefore) {
+			fmt.Printf("  计划重试: %s (第 %d 次", task.NotBefore.Format("15:04:05"), task.RetryCount)
+			if task.Failure != nil {
+				fmt.Printf(", %s", task.Failure.Category)
+			}
+			if task.AvoidAgent != "" {
+				fmt.Printf(", 避开 %s", task.AvoidAgent)
+			}
+			fmt.Println(")")
+		}
+
 		// 失败信息
 		if task.Status == models.TaskStatusFailed {
 			if task.LastError != "" {
 				fmt.Printf("  错误: %s\n", task.LastError)
 			}
+			if task.Failure != nil {
+				fmt.Printf("  错误类别: %s", task.Failure.Category)
+				if loc := task.Failure.Location(); loc != "" {
+					fmt.Printf(" (%s)", loc)
+				}
+				fmt.Println()
+			}
 			if task.RetryCount > 0 {
 				fmt.Printf("  重试: %d/%d\n", task.RetryCount, task.MaxRetries)
 			}
@@ -229,6 +267,8 @@ func getStatusIcon(status models.TaskStatus) string {
 		return "⏳"
 	case models.TaskStatusFailed:
 		return "❌"
+	case models.TaskStatusAwaitingApproval:
+		return "⏸️"
 	default:
 		return "❓"
 	}
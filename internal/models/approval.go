@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ApprovalKind identifies what a human is asked to approve
+type ApprovalKind string
+
+const (
+	ApprovalKindTask     ApprovalKind = "task"     // Task blocked by the risk assessment
+	ApprovalKindMerge    ApprovalKind = "merge"    // High-risk merge (secret scan findings or risky changes)
+	ApprovalKindBrain    ApprovalKind = "brain"    // AI brain asked the user (ActionAskUser); the answer reaches the task as a hint
+	ApprovalKindConfirm  ApprovalKind = "confirm"  // Confirmation prompt of a running interactive agent
+	ApprovalKindReplan   ApprovalKind = "replan"   // Change to the task graph proposed by the AI brain
+	ApprovalKindConflict ApprovalKind = "conflict" // Merge conflict resolution proposed by the AI brain
+)
+
+// ApprovalStatus represents the status of an approval request
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending  ApprovalStatus = "pending"
+	ApprovalStatusApproved ApprovalStatus = "approved"
+	ApprovalStatusRejected ApprovalStatus = "rejected"
+)
+
+// Approval is a decision escalated to a human
+type Approval struct {
+	ID          string          `json:"id"`
+	Kind        ApprovalKind    `json:"kind"`
+	Status      ApprovalStatus  `json:"status"`
+	TaskID      string          `json:"task_id,omitempty"`
+	AgentID     string          `json:"agent_id,omitempty"`
+	Branch      string          `json:"branch,omitempty"`
+	Title       string          `json:"title"`             // One-line summary shown in lists
+	Context     string          `json:"context,omitempty"` // Details needed to decide (risk context, scan report, ...)
+	Payload     json.RawMessage `json:"payload,omitempty"` // Data applied on approval (the proposal of a replan)
+	RequestedBy string          `json:"requested_by"`      // Component that escalated it
+	Note        string          `json:"note,omitempty"`    // Reviewer note
+	ResolvedBy  string          `json:"resolved_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ResolvedAt  *time.Time      `json:"resolved_at,omitempty"`
+	Applied     bool            `json:"applied"` // Decision has been acted on by the swarm
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Attempt records one run of a task by an agent
+type Attempt struct {
+	Number     int       `json:"number"` // 1-based
+	AgentID    string    `json:"agent_id"`
+	Worktree   string    `json:"worktree,omitempty"`
+	Branch     string    `json:"branch,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+
+	Prompt         string        `json:"prompt"`                    // Effective prompt sent to the agent
+	ExitCode       int           `json:"exit_code,omitempty"`       // Agent CLI exit code
+	CostUSD        float64       `json:"cost_usd,omitempty"`        // As reported by the agent CLI
+	Failure        *Failure      `json:"failure,omitempty"`         // Nil if the attempt succeeded
+	Verification   []CheckResult `json:"verification,omitempty"`    // Checks run on the attempt's work
+	OutOfScope     []string      `json:"out_of_scope,omitempty"`    // Changed files outside the task's write scope
+	Commits        []string      `json:"commits,omitempty"`         // Commits made during the attempt, oldest first
+	TranscriptPath string        `json:"transcript_path,omitempty"` // Full output of the run
+	Output         string        `json:"output,omitempty"`          // Last lines of output of a failed attempt
+	DiffStat       string        `json:"diff_stat,omitempty"`       // Worktree changes against main after a failed attempt
+	Interrupted    string        `json:"interrupted,omitempty"`     // Why the orchestrator stopped the attempt
+	Review         *Review       `json:"review,omitempty"`          // Reviewer's verdict on the work of a successful attempt
+}
+
+// CheckResult is the outcome of one verification check
+type CheckResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Output string `json:"output,omitempty"` // Trimmed output of a failed check
+}
+
+// Duration returns how long the attempt ran, 0 if it has not finished
+func (a *Attempt) Duration() time.Duration {
+	if a.FinishedAt.IsZero() {
+		return 0
+	}
+	return a.FinishedAt.Sub(a.StartedAt)
+}
+
+// LastAttempt returns the most recent attempt, or nil if the task never ran
+func (t *Task) LastAttempt() *Attempt {
+	if len(t.Attempts) == 0 {
+		return nil
+	}
+	return &t.Attempts[len(t.Attempts)-1]
+}
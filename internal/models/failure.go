@@ -0,0 +1,50 @@
+package models
+
+import "strconv"
+
+// ErrorCategory classifies why an agent run failed
+type ErrorCategory string
+
+const (
+	ErrorRateLimit     ErrorCategory = "rate_limit"     // API rate limit or overload
+	ErrorAuth          ErrorCategory = "auth"           // Missing or invalid credentials
+	ErrorNetwork       ErrorCategory = "network"        // Connection or DNS failure, 5xx from the API
+	ErrorTimeout       ErrorCategory = "timeout"        // A request or the run timed out
+	ErrorToolFailure   ErrorCategory = "tool_failure"   // A tool or the agent CLI itself failed
+	ErrorCompile       ErrorCategory = "compile_error"  // The code does not build
+	ErrorTestFailure   ErrorCategory = "test_failure"   // Tests fail
+	ErrorPolicyBlock   ErrorCategory = "policy_block"   // Blocked by risk assessment or permissions
+	ErrorResource      ErrorCategory = "resource_limit" // A resource limit was breached
+	ErrorAgentGaveUp   ErrorCategory = "agent_gave_up"  // The agent stopped without finishing
+	ErrorUnmetDone     ErrorCategory = "unmet_done"     // The work does not meet the task's definition of done
+	ErrorUncategorized ErrorCategory = "unknown"        // Nothing matched
+)
+
+// ErrorCategories lists every category in display order
+var ErrorCategories = []ErrorCategory{
+	ErrorRateLimit, ErrorAuth, ErrorNetwork, ErrorTimeout, ErrorToolFailure,
+	ErrorCompile, ErrorTestFailure, ErrorPolicyBlock, ErrorResource, ErrorAgentGaveUp,
+	ErrorUnmetDone, ErrorUncategorized,
+}
+
+// Failure describes the last failed attempt of a task
+type Failure struct {
+	Category          ErrorCategory `json:"category"`
+	Message           string        `json:"message,omitempty"`
+	Matcher           string        `json:"matcher,omitempty"`             // Rule that produced the category
+	ExitCode          int           `json:"exit_code,omitempty"`           // Agent process exit code, 0 if unknown
+	RetryAfterSeconds int           `json:"retry_after_seconds,omitempty"` // Server-requested backoff
+	File              string        `json:"file,omitempty"`                // First failing file, if any
+	Line              int           `json:"line,omitempty"`
+}
+
+// Location returns "file:line" of the failure, or "" if unknown
+func (f *Failure) Location() string {
+	if f == nil || f.File == "" {
+		return ""
+	}
+	if f.Line == 0 {
+		return f.File
+	}
+	return f.File + ":" + strconv.Itoa(f.Line)
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// LimitResource names a resource an agent process can exhaust
+type LimitResource string
+
+const (
+	LimitTimeout   LimitResource = "timeout"   // Wall-clock time
+	LimitMemory    LimitResource = "memory"    // Memory (OOM kill)
+	LimitCPU       LimitResource = "cpu"       // CPU time
+	LimitProcesses LimitResource = "processes" // Number of processes
+	LimitOutput    LimitResource = "output"    // Captured output bytes
+)
+
+// ResourceLimits bounds a single agent run. Zero fields are unlimited,
+// or inherit the agent's limit when used as a task override.
+type ResourceLimits struct {
+	TimeoutSeconds int   `json:"timeout_seconds,omitempty"`
+	MemoryMB       int   `json:"memory_mb,omitempty"`
+	CPUPercent     int   `json:"cpu_percent,omitempty"` // 100 = one full core
+	MaxProcs       int   `json:"max_procs,omitempty"`
+	MaxOutputBytes int64 `json:"max_output_bytes,omitempty"`
+}
+
+// Timeout returns the wall-clock limit, 0 if unlimited
+func (l ResourceLimits) Timeout() time.Duration {
+	return time.Duration(l.TimeoutSeconds) * time.Second
+}
+
+// WithOverrides returns l with the non-zero fields of o applied
+func (l ResourceLimits) WithOverrides(o *ResourceLimits) ResourceLimits {
+	if o == nil {
+		return l
+	}
+	if o.TimeoutSeconds > 0 {
+		l.TimeoutSeconds = o.TimeoutSeconds
+	}
+	if o.MemoryMB > 0 {
+		l.MemoryMB = o.MemoryMB
+	}
+	if o.CPUPercent > 0 {
+		l.CPUPercent = o.CPUPercent
+	}
+	if o.MaxProcs > 0 {
+		l.MaxProcs = o.MaxProcs
+	}
+	if o.MaxOutputBytes > 0 {
+		l.MaxOutputBytes = o.MaxOutputBytes
+	}
+	return l
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchPath reports whether the slash-separated, repository-relative path
+// name matches pattern. Each pattern segment uses path.Match syntax and
+// "**" matches any number of segments. A pattern naming a directory
+// matches everything below it, so "docs" and "docs/" cover "docs/a.md".
+func MatchPath(pattern, name string) bool {
+	pattern = strings.Trim(strings.TrimPrefix(pattern, "./"), "/")
+	name = strings.TrimPrefix(name, "./")
+	if pattern == "" {
+		return false
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// MatchAnyPath returns the first pattern name matches, or "" if none does
+func MatchAnyPath(patterns []string, name string) string {
+	for _, pattern := range patterns {
+		if MatchPath(pattern, name) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// PathsOverlap reports whether some file could match a pattern of a and a
+// pattern of b. It errs on the side of an overlap: two wildcard segments
+// are assumed to match a common name.
+func PathsOverlap(a, b []string) bool {
+	for _, p := range a {
+		for _, q := range b {
+			if patternsOverlap(p, q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func patternsOverlap(p, q string) bool {
+	p = strings.Trim(strings.TrimPrefix(p, "./"), "/")
+	q = strings.Trim(strings.TrimPrefix(q, "./"), "/")
+	if p == "" || q == "" {
+		return false
+	}
+	return overlapSegments(strings.Split(p, "/"), strings.Split(q, "/"))
+}
+
+func overlapSegments(p, q []string) bool {
+	// A pattern covers everything below the directories it matches
+	if len(p) == 0 || len(q) == 0 {
+		return true
+	}
+	if p[0] == "**" {
+		return overlapSegments(p[1:], q) || overlapSegments(p, q[1:])
+	}
+	if q[0] == "**" {
+		return overlapSegments(p, q[1:]) || overlapSegments(p[1:], q)
+	}
+	if !overlapSegment(p[0], q[0]) {
+		return false
+	}
+	return overlapSegments(p[1:], q[1:])
+}
+
+func overlapSegment(p, q string) bool {
+	const meta = "*?["
+	switch {
+	case !strings.ContainsAny(p, meta):
+		ok, _ := path.Match(q, p)
+		return ok
+	case !strings.ContainsAny(q, meta):
+		ok, _ := path.Match(p, q)
+		return ok
+	}
+	return true
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	// The rest of name is below a matching directory
+	return true
+}
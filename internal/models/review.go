@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Review is the reviewer model's verdict on the work of a successful attempt
+type Review struct {
+	Score        int       `json:"score"` // 0-100
+	Complete     bool      `json:"complete"`
+	Issues       []string  `json:"issues,omitempty"`
+	NeedsRework  bool      `json:"needs_rework"`           // The reviewer asked for rework
+	Instructions string    `json:"instructions,omitempty"` // What to change
+	SentBack     bool      `json:"sent_back"`              // The task went back to the agent for rework
+	Reviewer     string    `json:"reviewer,omitempty"`     // Model that reviewed the work
+	ReviewedAt   time.Time `json:"reviewed_at"`
+}
+
+// LastReview returns the most recent review of the task's work, or nil
+func (t *Task) LastReview() *Review {
+	for i := len(t.Attempts) - 1; i >= 0; i-- {
+		if t.Attempts[i].Review != nil {
+			return t.Attempts[i].Review
+		}
+	}
+	return nil
+}
+
+// Reworks returns how many times a review sent the task back to its agent
+func (t *Task) Reworks() int {
+	n := 0
+	for _, attempt := range t.Attempts {
+		if attempt.Review != nil && attempt.Review.SentBack {
+			n++
+		}
+	}
+	return n
+}
+
+// ReviewSummary aggregates the latest review scores of a set of tasks
+type ReviewSummary struct {
+	Reviewed     int     `json:"reviewed"`      // Tasks with a review
+	AverageScore float64 `json:"average_score"` // Of the latest reviews
+	MinScore     int     `json:"min_score"`
+	Reworks      int     `json:"reworks"` // Times work was sent back
+}
+
+// SummarizeReviews aggregates the reviews of tasks
+func SummarizeReviews(tasks []*Task) ReviewSummary {
+	var summary ReviewSummary
+	total := 0
+	for _, task := range tasks {
+		summary.Reworks += task.Reworks()
+		review := task.LastReview()
+		if review == nil {
+			continue
+		}
+		if summary.Reviewed == 0 || review.Score < summary.MinScore {
+			summary.MinScore = review.Score
+		}
+		summary.Reviewed++
+		total += review.Score
+	}
+	if summary.Reviewed > 0 {
+		summary.AverageScore = float64(total) / float64(summary.Reviewed)
+	}
+	return summary
+}
@@ -6,10 +6,11 @@ import "time"
 type TaskStatus string
 
 const (
-	TaskStatusPending     TaskStatus = "pending"
-	TaskStatusInProgress  TaskStatus = "in_progress"
-	TaskStatusCompleted   TaskStatus = "completed"
-	TaskStatusFailed      TaskStatus = "failed"
+	TaskStatusPending          TaskStatus = "pending"
+	TaskStatusInProgress       TaskStatus = "in_progress"
+	TaskStatusCompleted        TaskStatus = "completed"
+	TaskStatusFailed           TaskStatus = "failed"
+	TaskStatusAwaitingApproval TaskStatus = "awaiting_approval" // Parked until a human approves or rejects it
 )
 
 // Task represents a task to be executed by an agent
@@ -27,17 +28,61 @@ type Task struct {
 	RetryCount   int      `json:"retry_count"`            // Number of times this task has been retried
 	MaxRetries   int      `json:"max_retries"`            // Maximum number of retries allowed
 	LastError    string   `json:"last_error,omitempty"`   // Last error message if task failed
+
+	// Classification of the last failed attempt
+	Failure *Failure `json:"failure,omitempty"`
+
+	// Scheduling: not claimed before NotBefore, preferably not by AvoidAgent
+	// (a retry) and preferably by PreferAgent (assigned by the orchestrator)
+	NotBefore   time.Time `json:"not_before,omitzero"`
+	AvoidAgent  string    `json:"avoid_agent,omitempty"`
+	PreferAgent string    `json:"prefer_agent,omitempty"`
+
+	// Run history; retries get a prompt built from the previous attempt
+	Attempts  []Attempt `json:"attempts,omitempty"`
+	RetryHint string    `json:"retry_hint,omitempty"` // Orchestrator's suggestion for the next attempt
+
+	// Number of times merging the task's branch hit a conflict
+	MergeConflicts int `json:"merge_conflicts,omitempty"`
+
+	// What the reviewer checks the work against
+	AcceptanceCriteria []string `json:"acceptance_criteria,omitempty"`
+
+	// Definition of done, checked in the agent's worktree after each attempt
+	RequiredFiles  []string `json:"required_files,omitempty"`  // Paths or globs that must exist
+	Checks         []string `json:"checks,omitempty"`          // Shell commands that must exit 0
+	ForbiddenPaths []string `json:"forbidden_paths,omitempty"` // Globs the task must not change
+
+	// Declared file scope. Tasks whose write scopes overlap don't run at the
+	// same time, and changes outside WritePaths are flagged; empty means unknown.
+	ReadPaths  []string `json:"read_paths,omitempty"`  // Globs the task needs to read
+	WritePaths []string `json:"write_paths,omitempty"` // Globs the task may create or change
+
+	// Extra environment for the agent; "secret:name" values are resolved from the secret store
+	Env map[string]string `json:"env,omitempty"`
+
+	// Per-task resource limits, overriding the agent's limits
+	Limits *ResourceLimits `json:"limits,omitempty"`
+
+	// Human approval
+	RiskApproved bool `json:"risk_approved,omitempty"` // A human approved running this task despite a CRITICAL risk assessment
+}
+
+// HasDefinitionOfDone reports whether the task declares anything to verify
+// after an attempt besides the review
+func (t *Task) HasDefinitionOfDone() bool {
+	return len(t.RequiredFiles) > 0 || len(t.Checks) > 0 || len(t.ForbiddenPaths) > 0
 }
 
 // AgentState represents the state of an agent
 type AgentState string
 
 const (
-	AgentStateIdle            AgentState = "idle"
-	AgentStateWorking         AgentState = "working"
-	AgentStateWaitingConfirm  AgentState = "waiting_confirm"
-	AgentStateError           AgentState = "error"
-	AgentStateStuck           AgentState = "stuck"
+	AgentStateIdle           AgentState = "idle"
+	AgentStateWorking        AgentState = "working"
+	AgentStateWaitingConfirm AgentState = "waiting_confirm"
+	AgentStateError          AgentState = "error"
+	AgentStateStuck          AgentState = "stuck"
 )
 
 // AgentStatus represents the current status of an agent
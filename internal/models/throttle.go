@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// BreakerState is the state of the coordinator's circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Tasks start normally
+	BreakerOpen     BreakerState = "open"      // Claiming is paused until the cooldown ends
+	BreakerHalfOpen BreakerState = "half_open" // A single probe task may run
+)
+
+// ThrottleStatus is a snapshot of the shared rate limiter and circuit
+// breaker, written by the coordinator for the monitor and metrics
+type ThrottleStatus struct {
+	Breaker             BreakerState  `json:"breaker"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	LastCategory        ErrorCategory `json:"last_category,omitempty"` // Category that tripped the breaker
+	OpenUntil           time.Time     `json:"open_until,omitzero"`
+	Trips               int           `json:"trips"` // Times the breaker opened since start
+
+	Running       int     `json:"running"`                  // Tasks currently executing
+	MaxConcurrent int     `json:"max_concurrent,omitempty"` // 0 = no cap beyond the agent count
+	Tokens        float64 `json:"tokens"`                   // Task starts available right now
+	StartsPerMin  float64 `json:"starts_per_min,omitempty"` // 0 = unlimited
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -28,9 +28,29 @@ const (
 
 // ErrorDetails contains detailed information about an error
 type ErrorDetails struct {
-	Type    ErrorType
-	Message string
-	Context string
+	Type     ErrorType
+	Category models.ErrorCategory
+	Message  string
+	Context  string
+	Matcher  string // Rule that produced the category
+
+	ExitCode   int           // Agent process exit code, 0 if unknown
+	RetryAfter time.Duration // Server-requested backoff, if any
+	File       string        // First failing file, if any
+	Line       int
+}
+
+// Failure converts the details to the form persisted on a task
+func (e *ErrorDetails) Failure() *models.Failure {
+	return &models.Failure{
+		Category:          e.Category,
+		Message:           e.Message,
+		Matcher:           e.Matcher,
+		ExitCode:          e.ExitCode,
+		RetryAfterSeconds: int(e.RetryAfter / time.Second),
+		File:              e.File,
+		Line:              e.Line,
+	}
 }
 
 // ConfirmStats tracks confirmation statistics
@@ -44,12 +64,23 @@ type ConfirmStats struct {
 	LastConfirmTime time.Time
 }
 
+// ConfirmOutcome is how a confirmation prompt was handled
+type ConfirmOutcome int
+
+const (
+	ConfirmOutcomeAuto    ConfirmOutcome = iota // Answered by ShouldConfirm
+	ConfirmOutcomeManual                        // Escalated to a human
+	ConfirmOutcomeBlocked                       // Declined without asking anyone
+	ConfirmOutcomeTimeout                       // Prompt was not resolved in time
+)
+
 // Detector analyzes Claude output and detects state
 type Detector struct {
 	contextWindow       []string
 	lastOutput          time.Time
 	waitingConfirmSince time.Time     // 🔧 P1 FIX: 追踪进入确认等待状态的时间
 	confirmStats        ConfirmStats  // 🔧 P1 FIX: 确认统计信息
+	classifier          *ErrorClassifier
 }
 
 // NewDetector creates a new detector
@@ -57,6 +88,14 @@ func NewDetector() *Detector {
 	return &Detector{
 		contextWindow: make([]string, 0, ContextWindowSize),
 		lastOutput:    time.Now(),
+		classifier:    DefaultErrorClassifier(),
+	}
+}
+
+// SetErrorClassifier replaces the classifier used by AnalyzeError
+func (d *Detector) SetErrorClassifier(c *ErrorClassifier) {
+	if c != nil {
+		d.classifier = c
 	}
 }
 
@@ -229,100 +268,34 @@ func (d *Detector) GetConfirmStats() ConfirmStats {
 	return d.confirmStats
 }
 
+// RecordConfirm records how a confirmation prompt was handled
+func (d *Detector) RecordConfirm(outcome ConfirmOutcome) {
+	d.confirmStats.TotalRequests++
+	switch outcome {
+	case ConfirmOutcomeAuto:
+		d.confirmStats.AutoConfirmed++
+		d.confirmStats.LastConfirmTime = time.Now()
+	case ConfirmOutcomeManual:
+		d.confirmStats.ManualRequired++
+	case ConfirmOutcomeBlocked:
+		d.confirmStats.Blocked++
+	case ConfirmOutcomeTimeout:
+		d.confirmStats.TimeoutCount++
+	}
+}
+
 // ResetConfirmStats resets confirmation statistics
 // 🔧 P1 FIX: 重置统计信息（用于测试或周期性重置）
 func (d *Detector) ResetConfirmStats() {
 	d.confirmStats = ConfirmStats{}
 }
 
-// AnalyzeError analyzes the output to determine error type and details
+// AnalyzeError classifies the output of a failed run
 func (d *Detector) AnalyzeError(output string) *ErrorDetails {
-	outputLower := strings.ToLower(output)
-
-	details := &ErrorDetails{
-		Type:    ErrorTypeUnknown,
-		Context: output,
-	}
-
-	// Retryable errors (network, temporary failures)
-	retryablePatterns := []string{
-		"timeout",
-		"connection refused",
-		"connection reset",
-		"network unreachable",
-		"temporary failure",
-		"try again",
-		"rate limit",
-		"429",
-		"503 service unavailable",
-		"504 gateway timeout",
-		"econnrefused",
-		"econnreset",
-		"etimedout",
-	}
-
-	for _, pattern := range retryablePatterns {
-		if strings.Contains(outputLower, pattern) {
-			details.Type = ErrorTypeRetryable
-			details.Message = "Network or temporary failure detected"
-			return details
-		}
-	}
-
-	// Non-retryable errors (syntax, logic, validation)
-	nonRetryablePatterns := []string{
-		"syntax error",
-		"parse error",
-		"invalid syntax",
-		"unexpected token",
-		"undefined",
-		"not defined",
-		"cannot find",
-		"no such file",
-		"permission denied",
-		"access denied",
-		"401 unauthorized",
-		"403 forbidden",
-		"404 not found",
-		"validation error",
-		"invalid argument",
-		"type error",
-	}
-
-	for _, pattern := range nonRetryablePatterns {
-		if strings.Contains(outputLower, pattern) {
-			details.Type = ErrorTypeNonRetryable
-			details.Message = "Syntax or logic error detected"
-			return details
-		}
-	}
-
-	// Fatal errors (requires human intervention)
-	fatalPatterns := []string{
-		"panic",
-		"fatal error",
-		"segmentation fault",
-		"out of memory",
-		"disk full",
-		"no space left",
-		"database locked",
-		"corruption",
-		"critical error",
-	}
-
-	for _, pattern := range fatalPatterns {
-		if strings.Contains(outputLower, pattern) {
-			details.Type = ErrorTypeFatal
-			details.Message = "Fatal error requiring human intervention"
-			return details
-		}
-	}
-
-	// If we detected an error state but can't classify it, treat as retryable
-	if strings.Contains(outputLower, "error") || strings.Contains(outputLower, "failed") {
-		details.Type = ErrorTypeRetryable
-		details.Message = "Unclassified error - treating as retryable"
-	}
+	return d.classifier.Classify(output, 0)
+}
 
-	return details
+// AnalyzeFailure classifies a failed run using its exit code as well
+func (d *Detector) AnalyzeFailure(output string, exitCode int) *ErrorDetails {
+	return d.classifier.Classify(output, exitCode)
 }
@@ -399,6 +399,31 @@ func TestConfirmStatsIntegration(t *testing.T) {
 	}
 }
 
+// TestRecordConfirm tests that handled prompts are counted by outcome
+func TestRecordConfirm(t *testing.T) {
+	d := NewDetector()
+
+	d.RecordConfirm(ConfirmOutcomeAuto)
+	d.RecordConfirm(ConfirmOutcomeAuto)
+	d.RecordConfirm(ConfirmOutcomeManual)
+	d.RecordConfirm(ConfirmOutcomeBlocked)
+	d.RecordConfirm(ConfirmOutcomeTimeout)
+
+	stats := d.GetConfirmStats()
+	if stats.TotalRequests != 5 {
+		t.Errorf("TotalRequests = %d, want 5", stats.TotalRequests)
+	}
+	if stats.AutoConfirmed != 2 {
+		t.Errorf("AutoConfirmed = %d, want 2", stats.AutoConfirmed)
+	}
+	if stats.ManualRequired != 1 || stats.Blocked != 1 || stats.TimeoutCount != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.LastConfirmTime.IsZero() {
+		t.Error("LastConfirmTime should be set after an auto confirmation")
+	}
+}
+
 // TestReset tests detector reset
 func TestReset(t *testing.T) {
 	d := NewDetector()
@@ -500,20 +525,20 @@ func TestAnalyzeErrorTypes(t *testing.T) {
 			expectedType: ErrorTypeNonRetryable,
 		},
 		{
-			name:         "not found",
-			output:       "404 not found",
+			name:         "compile error",
+			output:       "./main.go:3:2: undefined: foo",
 			expectedType: ErrorTypeNonRetryable,
 		},
 		{
-			name:         "permission denied",
-			output:       "permission denied",
+			name:         "panic",
+			output:       "panic: runtime error",
 			expectedType: ErrorTypeNonRetryable,
 		},
 
 		// Fatal errors
 		{
-			name:         "panic",
-			output:       "panic: runtime error",
+			name:         "permission denied",
+			output:       "permission denied",
 			expectedType: ErrorTypeFatal,
 		},
 		{
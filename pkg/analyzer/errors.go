@@ -0,0 +1,385 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// ErrorMatcher assigns a category to output matching Pattern. Patterns may
+// capture details with the named groups file, line and retry_after.
+type ErrorMatcher struct {
+	Name     string               `yaml:"name"`
+	Category models.ErrorCategory `yaml:"category"`
+	Pattern  string               `yaml:"pattern"`
+
+	re *regexp.Regexp
+}
+
+// builtinErrorMatchers are tried after any configured matchers. The match
+// nearest the end of the output wins: the last error is the one the run
+// died of, while earlier text may only mention, say, a 429. On a tie the
+// earlier matcher wins, so provider and policy signals come first.
+var builtinErrorMatchers = []ErrorMatcher{
+	{Name: "rate-limit", Category: models.ErrorRateLimit,
+		Pattern: `(?i)\b(rate[ _-]?limit(ed|_error)?|too many requests|overloaded(_error)?|usage limit reached)\b|(?i)(status|http|code|error)[ :=]*429\b`},
+	{Name: "auth", Category: models.ErrorAuth,
+		Pattern: `(?i)(\b401\b.{0,20}unauthori[sz]ed|authentication_(error|failed)|invalid (x-)?api[ _-]?key|api key (is )?(missing|invalid|not set)|please run /login|not logged in|credit balance is too low|billing_error)`},
+	{Name: "permission-request", Category: models.ErrorPolicyBlock,
+		Pattern: `(?i)(requested permissions? to use \S+|haven't granted it yet|blocked by (policy|the sandbox|risk assessment)|operation not permitted|permission denied)`},
+	{Name: "resource-exhausted", Category: models.ErrorResource,
+		Pattern: `(?i)(out of memory|cannot allocate memory|no space left on device|disk quota exceeded|too many open files|fork: retry: resource temporarily unavailable)`},
+
+	{Name: "go-compile", Category: models.ErrorCompile,
+		Pattern: `(?m)^(?P<file>[\w./\\-]+\.(go|c|cc|cpp|h|hpp|java|kt|swift|cs)):(?P<line>\d+):\d+: `},
+	{Name: "tsc", Category: models.ErrorCompile,
+		Pattern: `(?m)^(?P<file>\S+\.tsx?)[:(](?P<line>\d+)[,:]\d+\)?:? -? ?error TS\d+`},
+	{Name: "rustc", Category: models.ErrorCompile,
+		Pattern: `(?m)^error(\[E\d+\])?: .*\n\s*--> (?P<file>[^:\s]+):(?P<line>\d+)`},
+	{Name: "python-syntax", Category: models.ErrorCompile,
+		Pattern: `(?m)^\s*File "(?P<file>[^"]+\.py)", line (?P<line>\d+)\n(?:.*\n){0,3}?\s*(SyntaxError|IndentationError|TabError):`},
+	{Name: "build-failed", Category: models.ErrorCompile,
+		Pattern: `(?i)(\[build failed\]|\bcompilation failed\b|\bbuild failed\b|\bsyntax error\b)`},
+
+	{Name: "go-test", Category: models.ErrorTestFailure,
+		Pattern: `(?m)^(--- FAIL: \S+|FAIL\s+\S+)`},
+	{Name: "go-test-location", Category: models.ErrorTestFailure,
+		Pattern: `(?m)^\s+(?P<file>[\w./-]+_test\.go):(?P<line>\d+): `},
+	{Name: "pytest", Category: models.ErrorTestFailure,
+		Pattern: `(?m)^(FAILED (?P<file>[^:\s]+\.py)::\S+|=+ .*\b\d+ failed\b)`},
+	{Name: "pytest-location", Category: models.ErrorTestFailure,
+		Pattern: `(?m)^(?P<file>[^:\s]+\.py):(?P<line>\d+): \w*(Error|Exception)\b`},
+	{Name: "js-test", Category: models.ErrorTestFailure,
+		Pattern: `(?m)^\s*(\d+ failing\b|Tests?:\s+\d+ failed)`},
+	{Name: "js-test-location", Category: models.ErrorTestFailure,
+		Pattern: `\((?P<file>[^()\s]+\.(test|spec)\.[jt]sx?):(?P<line>\d+):\d+\)`},
+	{Name: "cargo-test", Category: models.ErrorTestFailure,
+		Pattern: `test result: FAILED`},
+
+	{Name: "timeout", Category: models.ErrorTimeout,
+		Pattern: `(?i)\b(timed out|timeout exceeded|deadline exceeded|etimedout|504 gateway timeout|request timeout|connection timeout)\b`},
+	{Name: "network", Category: models.ErrorNetwork,
+		Pattern: `(?i)(\beconn(refused|reset|aborted)\b|\benotfound\b|\beai_again\b|connection (refused|reset|closed)|network (is )?unreachable|no route to host|temporary failure in name resolution|could not resolve host|tls handshake|\b50[0234]\b.{0,30}(bad gateway|service unavailable|internal server error|gateway timeout)|\bapi_error\b)`},
+
+	{Name: "gave-up", Category: models.ErrorAgentGaveUp,
+		Pattern: `(?i)(\bi('m| am) (unable|not able) to (complete|finish)|\bi (can't|cannot) (complete|finish) (this|the) task|\bgiving up\b|reached (the )?max(imum)? (number of )?turns|error_max_turns)`},
+	{Name: "tool-failure", Category: models.ErrorToolFailure,
+		Pattern: `(?im)(tool_use_error|tool (call|execution) failed|command not found|^panic: |segmentation fault|exited with (status|code) [1-9]\d*|exit status [1-9]\d*)`},
+}
+
+// retryAfterPattern extracts a server-requested backoff from anywhere in the output
+var retryAfterPattern = regexp.MustCompile(`(?i)(retry[-_ ]?after["']?\s*[:=]?\s*["']?|try again in )(?P<retry_after>\d+)`)
+
+// exitCodeCategories classifies exit codes that mean the same thing for
+// every agent CLI
+var exitCodeCategories = map[int]struct {
+	category models.ErrorCategory
+	message  string
+}{
+	124: {models.ErrorTimeout, "killed by timeout(1)"},
+	126: {models.ErrorToolFailure, "agent CLI is not executable"},
+	127: {models.ErrorToolFailure, "agent CLI not found"},
+	137: {models.ErrorResource, "killed by SIGKILL, likely out of memory"},
+}
+
+// streamErrorCategories maps error codes seen in stream-json events and API
+// error bodies to categories
+var streamErrorCategories = map[string]models.ErrorCategory{
+	"rate_limit":             models.ErrorRateLimit,
+	"rate_limit_error":       models.ErrorRateLimit,
+	"overloaded_error":       models.ErrorRateLimit,
+	"authentication_failed":  models.ErrorAuth,
+	"authentication_error":   models.ErrorAuth,
+	"permission_error":       models.ErrorAuth,
+	"billing_error":          models.ErrorAuth,
+	"server_error":           models.ErrorNetwork,
+	"api_error":              models.ErrorNetwork,
+	"timeout_error":          models.ErrorTimeout,
+	"error_max_turns":        models.ErrorAgentGaveUp,
+	"error_during_execution": models.ErrorToolFailure,
+}
+
+// failureTailLines is how much of the end of the output the matchers see
+const failureTailLines = 40
+
+// categoryDescriptions is the message used for each category
+var categoryDescriptions = map[models.ErrorCategory]string{
+	models.ErrorRateLimit:     "Rate limited by the model provider",
+	models.ErrorAuth:          "Authentication or billing failure",
+	models.ErrorNetwork:       "Network or provider failure",
+	models.ErrorTimeout:       "Operation timed out",
+	models.ErrorToolFailure:   "Tool or agent CLI failure",
+	models.ErrorCompile:       "Code does not compile",
+	models.ErrorTestFailure:   "Tests failed",
+	models.ErrorPolicyBlock:   "Blocked by policy or missing permission",
+	models.ErrorResource:      "Resource exhausted",
+	models.ErrorAgentGaveUp:   "Agent gave up before finishing",
+	models.ErrorUnmetDone:     "Definition of done not met",
+	models.ErrorUncategorized: "Unclassified failure",
+}
+
+// TypeForCategory returns the retry class of a category
+func TypeForCategory(category models.ErrorCategory) ErrorType {
+	switch category {
+	case models.ErrorRateLimit, models.ErrorNetwork, models.ErrorTimeout:
+		return ErrorTypeRetryable
+	case models.ErrorCompile, models.ErrorTestFailure, models.ErrorToolFailure, models.ErrorAgentGaveUp,
+		models.ErrorUnmetDone:
+		return ErrorTypeNonRetryable
+	case models.ErrorAuth, models.ErrorPolicyBlock, models.ErrorResource:
+		return ErrorTypeFatal
+	default:
+		return ErrorTypeUnknown
+	}
+}
+
+// IsKnownCategory reports whether category is one of models.ErrorCategories
+func IsKnownCategory(category models.ErrorCategory) bool {
+	for _, c := range models.ErrorCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorClassifier classifies failed agent runs
+type ErrorClassifier struct {
+	matchers []ErrorMatcher
+}
+
+// NewErrorClassifier compiles custom matchers, which are tried before the
+// built-in ones
+func NewErrorClassifier(custom []ErrorMatcher) (*ErrorClassifier, error) {
+	c := &ErrorClassifier{}
+	for i, m := range append(append([]ErrorMatcher{}, custom...), builtinErrorMatchers...) {
+		if !IsKnownCategory(m.Category) {
+			return nil, fmt.Errorf("error matcher %q: unknown category %q", m.Name, m.Category)
+		}
+		re, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error matcher %q: %w", m.Name, err)
+		}
+		if m.Name == "" {
+			m.Name = fmt.Sprintf("custom-%d", i+1)
+		}
+		m.re = re
+		c.matchers = append(c.matchers, m)
+	}
+	return c, nil
+}
+
+// DefaultErrorClassifier uses only the built-in matchers
+func DefaultErrorClassifier() *ErrorClassifier {
+	c, err := NewErrorClassifier(nil)
+	if err != nil {
+		panic(err) // Built-in patterns are static
+	}
+	return c
+}
+
+// errorMatcherFile is the layout of .swarm/errors.yaml
+type errorMatcherFile struct {
+	Matchers []ErrorMatcher `yaml:"matchers"`
+}
+
+// LoadErrorClassifier builds a classifier with the matchers in path. A
+// missing file yields the default classifier.
+func LoadErrorClassifier(path string) (*ErrorClassifier, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultErrorClassifier(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read error matchers: %w", err)
+	}
+
+	var file errorMatcherFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse error matchers %s: %w", path, err)
+	}
+	return NewErrorClassifier(file.Matchers)
+}
+
+// Classify determines why a run failed. Stream-json result and error events
+// are the most reliable signal, then well-known exit codes, then the
+// matchers, which only look at the end of the (rendered) output: the
+// transcript before it is the agent's work, not the failure. exitCode 0
+// means unknown.
+func (c *ErrorClassifier) Classify(output string, exitCode int) *ErrorDetails {
+	details := &ErrorDetails{
+		Type:     ErrorTypeUnknown,
+		Category: models.ErrorUncategorized,
+		Context:  output,
+		ExitCode: exitCode,
+	}
+
+	tail := lastLines(StreamText(output), failureTailLines)
+	if category, text, rule, ok := c.classifyStream(output); ok {
+		c.fill(details, category, rule, text)
+	} else if known, ok := exitCodeCategories[exitCode]; ok {
+		c.fill(details, known.category, fmt.Sprintf("exit-%d", exitCode), known.message)
+	} else if m, text := c.match(tail); m != nil {
+		c.fill(details, m.Category, m.Name, text)
+	} else {
+		details.Message = categoryDescriptions[models.ErrorUncategorized]
+		return details
+	}
+
+	c.extractDetails(details, tail)
+	return details
+}
+
+// lastLines returns the last n lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// fill sets the category and everything derived from it
+func (c *ErrorClassifier) fill(details *ErrorDetails, category models.ErrorCategory, rule, evidence string) {
+	details.Category = category
+	details.Type = TypeForCategory(category)
+	details.Matcher = rule
+	details.Message = categoryDescriptions[category]
+	if evidence = strings.TrimSpace(evidence); evidence != "" {
+		if len(evidence) > 200 {
+			evidence = evidence[:200] + "..."
+		}
+		details.Message += ": " + evidence
+	}
+}
+
+// match returns the matcher whose last match ends nearest the end of
+// output, the earlier matcher on a tie, and the matched text
+func (c *ErrorClassifier) match(output string) (*ErrorMatcher, string) {
+	var best *ErrorMatcher
+	var bestLoc []int
+	for i := range c.matchers {
+		m := &c.matchers[i]
+		all := m.re.FindAllStringIndex(output, -1)
+		if len(all) == 0 {
+			continue
+		}
+		if loc := all[len(all)-1]; best == nil || loc[1] > bestLoc[1] {
+			best, bestLoc = m, loc
+		}
+	}
+	if best == nil {
+		return nil, ""
+	}
+	return best, output[bestLoc[0]:bestLoc[1]]
+}
+
+// extractDetails fills in retry-after and the first file:line captured by
+// any matcher of the chosen category
+func (c *ErrorClassifier) extractDetails(details *ErrorDetails, output string) {
+	if groups := namedGroups(retryAfterPattern, output); groups["retry_after"] != "" {
+		if seconds, err := strconv.Atoi(groups["retry_after"]); err == nil {
+			details.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+
+	for i := range c.matchers {
+		m := &c.matchers[i]
+		if m.Category != details.Category {
+			continue
+		}
+		groups := namedGroups(m.re, output)
+		if details.RetryAfter == 0 && groups["retry_after"] != "" {
+			if seconds, err := strconv.Atoi(groups["retry_after"]); err == nil {
+				details.RetryAfter = time.Duration(seconds) * time.Second
+			}
+		}
+		if details.File == "" && groups["file"] != "" {
+			details.File = groups["file"]
+			details.Line, _ = strconv.Atoi(groups["line"])
+		}
+	}
+}
+
+// namedGroups returns the named groups of the first match of re. A name
+// used by several alternatives takes the first one that participated.
+func namedGroups(re *regexp.Regexp, s string) map[string]string {
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return nil
+	}
+	groups := make(map[string]string)
+	for i, name := range re.SubexpNames() {
+		if name != "" && groups[name] == "" {
+			groups[name] = match[i]
+		}
+	}
+	return groups
+}
+
+// classifyStream looks for stream-json result and error events. The final
+// result event decides; an earlier error event (e.g. a rate-limited API
+// call) explains a result whose text matches nothing.
+func (c *ErrorClassifier) classifyStream(output string) (models.ErrorCategory, string, string, bool) {
+	var result *streamEvent
+	var errCategory models.ErrorCategory
+	var errCode string
+
+	for _, event := range streamEvents(output) {
+		if event.Type == "result" {
+			result = &event
+		}
+		if code := streamErrorCode(event.Error); code != "" {
+			if category, ok := streamErrorCategories[code]; ok {
+				errCategory, errCode = category, code
+			}
+		}
+	}
+
+	if result != nil && (result.IsError || strings.HasPrefix(result.Subtype, "error")) {
+		if category, ok := streamErrorCategories[result.Subtype]; ok && category == models.ErrorAgentGaveUp {
+			return category, result.Result, "stream-json:" + result.Subtype, true
+		}
+		if m, text := c.match(result.Result); m != nil {
+			return m.Category, text, m.Name, true
+		}
+		if errCategory != "" {
+			return errCategory, result.Result, "stream-json:" + errCode, true
+		}
+		if category, ok := streamErrorCategories[result.Subtype]; ok {
+			return category, result.Result, "stream-json:" + result.Subtype, true
+		}
+		return models.ErrorToolFailure, result.Result, "stream-json:result", true
+	}
+	if errCategory != "" {
+		return errCategory, "", "stream-json:" + errCode, true
+	}
+	return "", "", "", false
+}
+
+// streamErrorCode reads an error that is either a code string or an API
+// error object {"type": "...", "message": "..."}
+func streamErrorCode(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var code string
+	if err := json.Unmarshal(raw, &code); err == nil {
+		return code
+	}
+	var obj struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil {
+		return obj.Type
+	}
+	return ""
+}
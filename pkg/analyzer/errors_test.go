@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// TestClassifyCategories tests the built-in matchers
+func TestClassifyCategories(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		category models.ErrorCategory
+	}{
+		{"rate limit", "API Error: 429 {\"type\":\"error\"} Too Many Requests", models.ErrorRateLimit},
+		{"overloaded", "API Error: overloaded_error", models.ErrorRateLimit},
+		{"auth", "Invalid API key · Please run /login", models.ErrorAuth},
+		{"network", "Error: connect ECONNREFUSED 127.0.0.1:443", models.ErrorNetwork},
+		{"timeout", "Request timed out after 60s", models.ErrorTimeout},
+		{"go compile", "# example.com/app\n./main.go:12:5: undefined: Foo", models.ErrorCompile},
+		{"go test", "--- FAIL: TestAdd (0.00s)\n    add_test.go:9: got 3 want 4\nFAIL", models.ErrorTestFailure},
+		{"pytest", "FAILED tests/test_add.py::test_add - assert 3 == 4", models.ErrorTestFailure},
+		{"policy", "Claude requested permissions to use Bash, but you haven't granted it yet.", models.ErrorPolicyBlock},
+		{"resource", "write /tmp/x: no space left on device", models.ErrorResource},
+		{"gave up", "I'm unable to complete this task without access to the database.", models.ErrorAgentGaveUp},
+		{"tool failure", "sh: 1: pnpm: command not found", models.ErrorToolFailure},
+		{"mentions error handling", "Added error handling to the parser and failed-request logging", models.ErrorUncategorized},
+		{"line number 429", "see main.go line 429 for the fix", models.ErrorUncategorized},
+		{"429 mentioned before a test failure", "Implemented retry for HTTP 429 responses.\n--- FAIL: TestRetry", models.ErrorTestFailure},
+		{"rate limit long before the end", "Hit a rate limit, waited\n" + strings.Repeat("edited handler.go\n", failureTailLines), models.ErrorUncategorized},
+	}
+
+	c := DefaultErrorClassifier()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := c.Classify(tt.output, 1)
+			if details.Category != tt.category {
+				t.Errorf("Classify() category = %s (matcher %q), want %s", details.Category, details.Matcher, tt.category)
+			}
+			if details.Type != TypeForCategory(tt.category) {
+				t.Errorf("Classify() type = %v, want %v", details.Type, TypeForCategory(tt.category))
+			}
+		})
+	}
+}
+
+// TestClassifyDetails tests extraction of retry-after and file:line
+func TestClassifyDetails(t *testing.T) {
+	c := DefaultErrorClassifier()
+
+	details := c.Classify("HTTP 429 rate_limit_error, retry-after: 30", 1)
+	if details.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", details.RetryAfter)
+	}
+
+	details = c.Classify("# app\n./pkg/util/strings.go:42:7: syntax error: unexpected }", 2)
+	if details.File != "./pkg/util/strings.go" || details.Line != 42 {
+		t.Errorf("location = %s:%d, want ./pkg/util/strings.go:42", details.File, details.Line)
+	}
+
+	details = c.Classify("--- FAIL: TestAdd (0.00s)\n    add_test.go:9: got 3 want 4\nFAIL\tapp\t0.01s", 1)
+	if got := details.Failure().Location(); got != "add_test.go:9" {
+		t.Errorf("Location() = %q, want add_test.go:9", got)
+	}
+}
+
+// TestClassifyStreamJSON tests that result events decide over the text
+func TestClassifyStreamJSON(t *testing.T) {
+	c := DefaultErrorClassifier()
+
+	output := `{"type":"system","subtype":"init"}
+{"type":"assistant","message":{"content":[]},"error":"rate_limit"}
+{"type":"result","subtype":"error_during_execution","is_error":true,"result":"request failed"}`
+	details := c.Classify(output, 1)
+	if details.Category != models.ErrorRateLimit || details.Matcher != "stream-json:rate_limit" {
+		t.Errorf("Classify() = %s via %q, want rate_limit via stream-json:rate_limit", details.Category, details.Matcher)
+	}
+
+	// The transcript mentions a compile error, but the run ended on max turns
+	output = "./main.go:1:1: expected 'package'\n" +
+		`{"type":"result","subtype":"error_max_turns","is_error":true,"result":""}`
+	if details := c.Classify(output, 1); details.Category != models.ErrorAgentGaveUp {
+		t.Errorf("Classify() = %s, want agent_gave_up", details.Category)
+	}
+
+	output = `{"type":"result","subtype":"success","is_error":false,"result":"Done, tests pass"}`
+	if details := c.Classify(output, 1); details.Category != models.ErrorUncategorized {
+		t.Errorf("successful result classified as %s", details.Category)
+	}
+}
+
+// TestClassifyExitCode tests well-known exit codes
+func TestClassifyExitCode(t *testing.T) {
+	c := DefaultErrorClassifier()
+	if details := c.Classify("", 127); details.Category != models.ErrorToolFailure {
+		t.Errorf("exit 127 = %s, want tool_failure", details.Category)
+	}
+	if details := c.Classify("", 124); details.Category != models.ErrorTimeout || details.ExitCode != 124 {
+		t.Errorf("exit 124 = %s (%d), want timeout", details.Category, details.ExitCode)
+	}
+}
+
+// TestLoadErrorClassifier tests configured matchers
+func TestLoadErrorClassifier(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "errors.yaml")
+
+	c, err := LoadErrorClassifier(path)
+	if err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+	if details := c.Classify("npm ERR! code E503", 1); details.Category != models.ErrorUncategorized {
+		t.Errorf("default classifier = %s, want unknown", details.Category)
+	}
+
+	config := `matchers:
+  - name: npm-registry
+    category: network
+    pattern: 'npm ERR! code E5\d\d'
+  - name: lint
+    category: compile_error
+    pattern: '(?m)^(?P<file>\S+\.js):(?P<line>\d+):\d+: lint'
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = LoadErrorClassifier(path)
+	if err != nil {
+		t.Fatalf("LoadErrorClassifier() error = %v", err)
+	}
+	if details := c.Classify("npm ERR! code E503", 1); details.Category != models.ErrorNetwork || details.Matcher != "npm-registry" {
+		t.Errorf("custom matcher = %s via %q, want network via npm-registry", details.Category, details.Matcher)
+	}
+	if details := c.Classify("src/app.js:7:3: lint no-unused-vars", 1); details.File != "src/app.js" || details.Line != 7 {
+		t.Errorf("custom location = %s:%d, want src/app.js:7", details.File, details.Line)
+	}
+
+	if err := os.WriteFile(path, []byte("matchers:\n  - category: flaky\n    pattern: x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadErrorClassifier(path); err == nil {
+		t.Error("unknown category should be rejected")
+	}
+}
+
+func TestResultCost(t *testing.T) {
+	output := `{"type":"system","subtype":"init"}
+plain text line
+{"type":"result","subtype":"success","is_error":false,"total_cost_usd":0.0421}`
+	if got := ResultCost(output); got != 0.0421 {
+		t.Errorf("ResultCost() = %v, want 0.0421", got)
+	}
+	if got := ResultCost("no stream output"); got != 0 {
+		t.Errorf("ResultCost() without result event = %v, want 0", got)
+	}
+}
+
+func TestStreamText(t *testing.T) {
+	output := `{"type":"system","subtype":"init"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Running the tests"},{"type":"tool_use","name":"Bash","input":{"command":"go test ./..."}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","is_error":true,"content":[{"type":"text","text":"FAIL\tapp\t0.01s"}]}]}}
+stderr line
+{"type":"result","subtype":"error_during_execution","is_error":true,"result":"request failed","error":"rate_limit"}`
+	want := "Running the tests\n→ Bash\n✗ FAIL\tapp\t0.01s\nstderr line\nrequest failed\nerror: rate_limit"
+	if got := StreamText(output); got != want {
+		t.Errorf("StreamText() = %q, want %q", got, want)
+	}
+
+	if got := StreamText("plain output\n{not json}"); got != "plain output\n{not json}" {
+		t.Errorf("StreamText() changed plain output: %q", got)
+	}
+}
@@ -75,6 +75,28 @@ func GetConfirmationInput(context string) string {
 	return "yes"
 }
 
+// GetRejectionInput 根据提示类型返回拒绝操作时应该发送的输入
+func GetRejectionInput(context string) string {
+	contextLower := strings.ToLower(context)
+
+	// 选项列表（❯ 1. Yes / 2. No）：Esc 取消
+	if strings.Contains(context, "1. Yes") {
+		return "\x1b"
+	}
+
+	if strings.Contains(contextLower, "(y/n)") ||
+		strings.Contains(contextLower, "[y/n]") {
+		return "n"
+	}
+
+	if strings.Contains(contextLower, "yes/no") {
+		return "no"
+	}
+
+	// 未知格式：Esc 通常会取消当前提示
+	return "\x1b"
+}
+
 // ShouldConfirm 综合判断是否应该自动确认
 // 返回: (shouldConfirm bool, input string, reason string)
 // 🤖 AI 自主决策：智能分析上下文风险，自动判断是否安全执行
@@ -333,6 +333,26 @@ func TestConfirmationStatistics(t *testing.T) {
 	}
 }
 
+// TestGetRejectionInput tests the input used to decline a prompt
+func TestGetRejectionInput(t *testing.T) {
+	tests := []struct {
+		context  string
+		expected string
+	}{
+		{"Do you want to edit main.go?\n❯ 1. Yes\n  2. No", "\x1b"},
+		{"Continue? (y/n)", "n"},
+		{"Overwrite? [Y/n]", "n"},
+		{"Proceed? (yes/no)", "no"},
+		{"Press Enter to continue", "\x1b"},
+	}
+
+	for _, tt := range tests {
+		if got := GetRejectionInput(tt.context); got != tt.expected {
+			t.Errorf("GetRejectionInput(%q) = %q, want %q", tt.context, got, tt.expected)
+		}
+	}
+}
+
 // BenchmarkGetConfirmationInput benchmarks input detection
 func BenchmarkGetConfirmationInput(b *testing.B) {
 	context := "Do you want to proceed? (yes/no)"
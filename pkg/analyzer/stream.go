@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// streamEvent is the part of a stream-json line used for classification,
+// cost and the readable transcript
+type streamEvent struct {
+	Type    string          `json:"type"`
+	Subtype string          `json:"subtype"`
+	IsError bool            `json:"is_error"`
+	Result  string          `json:"result"`
+	Error   json.RawMessage `json:"error"`
+	Message *struct {
+		Content []streamContent `json:"content"`
+	} `json:"message"`
+
+	TotalCostUSD float64 `json:"total_cost_usd"`
+}
+
+// streamContent is a content block of an assistant or user message
+type streamContent struct {
+	Type    string          `json:"type"` // text, tool_use or tool_result
+	Text    string          `json:"text"`
+	Name    string          `json:"name"`
+	Content json.RawMessage `json:"content"` // tool_result: a string or text blocks
+	IsError bool            `json:"is_error"`
+}
+
+// parseStreamLine decodes line if it is a stream-json event
+func parseStreamLine(line string) (streamEvent, bool) {
+	var event streamEvent
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") || !strings.Contains(line, `"type"`) {
+		return event, false
+	}
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return event, false
+	}
+	return event, true
+}
+
+// streamEvents returns the stream-json events in output; other lines are skipped
+func streamEvents(output string) []streamEvent {
+	var events []streamEvent
+	for _, line := range strings.Split(output, "\n") {
+		if event, ok := parseStreamLine(line); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// StreamText renders stream-json output as the text a person would have
+// seen: assistant text, tool calls, tool results and the final result.
+// Lines that are not events (e.g. the CLI's stderr) are kept as they are,
+// so plain output comes back unchanged.
+func StreamText(output string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(output, "\n") {
+		event, ok := parseStreamLine(line)
+		if !ok {
+			b.WriteString(line + "\n")
+			continue
+		}
+
+		switch event.Type {
+		case "assistant", "user":
+			if event.Message == nil {
+				continue
+			}
+			for _, block := range event.Message.Content {
+				switch block.Type {
+				case "text":
+					b.WriteString(block.Text + "\n")
+				case "tool_use":
+					b.WriteString("→ " + block.Name + "\n")
+				case "tool_result":
+					if block.IsError {
+						b.WriteString("✗ ")
+					}
+					b.WriteString(toolResultText(block.Content) + "\n")
+				}
+			}
+		case "result":
+			if event.Result != "" {
+				b.WriteString(event.Result + "\n")
+			}
+		}
+		if code := streamErrorCode(event.Error); code != "" {
+			b.WriteString("error: " + code + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// toolResultText reads tool_result content, a string or a list of text blocks
+func toolResultText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var blocks []streamContent
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
+	}
+	parts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ResultCost returns the total cost in USD reported by the last stream-json
+// result event, 0 if the output has none
+func ResultCost(output string) float64 {
+	var cost float64
+	for _, event := range streamEvents(output) {
+		if event.Type == "result" {
+			cost = event.TotalCostUSD
+		}
+	}
+	return cost
+}
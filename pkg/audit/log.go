@@ -0,0 +1,307 @@
+// Package audit keeps a tamper-evident record of every automated decision.
+//
+// Entries are appended to a JSONL file. Each entry stores the hash of the
+// previous one, so editing, reordering or deleting a line breaks the chain
+// and is reported by Verify.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/claude-swarm/pkg/secrets"
+)
+
+// Decision points recorded in the log
+const (
+	DecisionConfirm         = "confirm"          // Detector answered a confirmation prompt
+	DecisionTaskRisk        = "task_risk"        // Risk assessment before a task runs
+	DecisionNextAction      = "next_action"      // Brain DecideNextAction
+	DecisionMergeStrategy   = "merge_strategy"   // Brain DecideMergeStrategy
+	DecisionResolveConflict = "resolve_conflict" // Brain ResolveConflict
+	DecisionSecretScan      = "secret_scan"      // Merge blocked by the secret scanner
+	DecisionMergeRisk       = "merge_risk"       // Merge blocked by the risk assessment of its changes
+	DecisionApproval        = "approval"         // Human resolved an approval
+	DecisionRetry           = "retry"            // Retry policy applied to a failed task
+	DecisionThrottle        = "throttle"         // Circuit breaker changed state
+	DecisionReplan          = "replan"           // Brain proposed or applied a change to the task graph
+	DecisionAgentAction     = "agent_action"     // Brain action applied to a live agent, or refused by a guardrail
+	DecisionReview          = "review"           // Reviewer accepted the work of a task or sent it back
+	DecisionApplyResolution = "apply_resolution" // Conflict resolution merged, escalated or discarded
+	DecisionVerify          = "verify"           // Work of an attempt checked against the task's definition of done or file scope
+)
+
+// FileName is the audit log file inside the audit directory
+const FileName = "audit.jsonl"
+
+// Entry is a single audited decision
+type Entry struct {
+	Seq         int64             `json:"seq"`
+	Time        time.Time         `json:"time"`
+	Actor       string            `json:"actor"`    // Who or what decided (detector, brain, human:alice)
+	Decision    string            `json:"decision"` // Decision point, one of the Decision* constants
+	TaskID      string            `json:"task_id,omitempty"`
+	AgentID     string            `json:"agent_id,omitempty"`
+	InputDigest string            `json:"input_digest,omitempty"` // Digest of the context the decision was based on
+	Rule        string            `json:"rule,omitempty"`         // Rule or model used
+	Prompt      string            `json:"prompt,omitempty"`       // Version of the prompt template sent to the model
+	Action      string            `json:"action"`                 // Resulting action
+	Outcome     string            `json:"outcome,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	PrevHash    string            `json:"prev_hash"`
+	Hash        string            `json:"hash"`
+}
+
+// Log appends hash-chained entries to a JSONL file.
+// A nil *Log is valid and discards everything, so callers need no checks.
+type Log struct {
+	path     string
+	mu       sync.Mutex
+	lockFile *os.File
+}
+
+// DirFor returns the audit directory of a repository
+func DirFor(repoPath string) string {
+	return filepath.Join(repoPath, ".swarm", "audit")
+}
+
+// Open opens or creates the audit log in dir
+func Open(dir string) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	lockFile, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+
+	return &Log{path: path, lockFile: lockFile}, nil
+}
+
+// Path returns the log file path
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Close releases the lock file
+func (l *Log) Close() error {
+	if l == nil || l.lockFile == nil {
+		return nil
+	}
+	return l.lockFile.Close()
+}
+
+// Record appends an entry, filling in sequence, time and hashes.
+// Detail values are redacted before they are written.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Other processes (CLI, brain) append to the same file
+	if err := syscall.Flock(int(l.lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to acquire audit lock: %w", err)
+	}
+	defer syscall.Flock(int(l.lockFile.Fd()), syscall.LOCK_UN)
+
+	last, err := lastEntry(l.path)
+	if err != nil {
+		return err
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+	if last != nil {
+		e.Seq = last.Seq + 1
+		e.PrevHash = last.Hash
+	} else {
+		e.Seq = 1
+		e.PrevHash = ""
+	}
+	if e.Details != nil {
+		redacted := make(map[string]string, len(e.Details))
+		for k, v := range e.Details {
+			redacted[k] = secrets.Default().Redact(v)
+		}
+		e.Details = redacted
+	}
+
+	e.Hash, err = computeHash(e)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Digest returns a short, stable digest of decision input
+func Digest(input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// computeHash hashes the entry with an empty Hash field
+func computeHash(e Entry) (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastEntry returns the last entry of the file, or nil if it is empty
+func lastEntry(path string) (*Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := lastLine(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	var e Entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil, fmt.Errorf("audit log has a corrupt last entry: %w", err)
+	}
+	return &e, nil
+}
+
+// lastLine returns the last non-empty line of r, which is size bytes long.
+// It reads backwards in chunks until it reaches the start of the line, so
+// entries of any size are found.
+func lastLine(r io.ReaderAt, size int64) ([]byte, error) {
+	const chunkSize = 64 * 1024
+	var tail []byte
+	for end := size; end > 0; {
+		start := end - chunkSize
+		if start < 0 {
+			start = 0
+		}
+		chunk := make([]byte, end-start)
+		if _, err := r.ReadAt(chunk, start); err != nil {
+			return nil, err
+		}
+		tail = append(chunk, tail...)
+		end = start
+
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+	}
+	return bytes.TrimRight(tail, "\n"), nil
+}
+
+// ReadAll reads every entry of the log at path
+func ReadAll(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	// Entries have no size limit, so lines are read whole
+	var entries []Entry
+	reader := bufio.NewReader(f)
+	lineNum := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("line %d: %w", lineNum+1, err)
+		}
+		if len(line) > 0 {
+			lineNum++
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				var e Entry
+				if err := json.Unmarshal(line, &e); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				entries = append(entries, e)
+			}
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+	}
+}
+
+// Verify checks the hash chain and returns the number of valid entries.
+// The error names the first entry that breaks the chain.
+func Verify(path string) (int, error) {
+	entries, err := ReadAll(path)
+	if err != nil {
+		return 0, err
+	}
+
+	prevHash := ""
+	for i, e := range entries {
+		if e.Seq != int64(i+1) {
+			return i, fmt.Errorf("entry %d: expected seq %d, got %d (entry removed or reordered)", i+1, i+1, e.Seq)
+		}
+		if e.PrevHash != prevHash {
+			return i, fmt.Errorf("entry %d: prev_hash does not match the previous entry", e.Seq)
+		}
+		hash, err := computeHash(e)
+		if err != nil {
+			return i, err
+		}
+		if hash != e.Hash {
+			return i, fmt.Errorf("entry %d: content does not match its hash (entry modified)", e.Seq)
+		}
+		prevHash = e.Hash
+	}
+	return len(entries), nil
+}
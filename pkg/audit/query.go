@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects entries in Query. Empty fields match everything.
+type Filter struct {
+	TaskID   string
+	AgentID  string
+	Actor    string // Prefix match, so "human" matches "human:alice"
+	Decision string
+	Since    time.Time
+	Until    time.Time
+}
+
+// Match reports whether e passes the filter
+func (f Filter) Match(e Entry) bool {
+	if f.TaskID != "" && e.TaskID != f.TaskID {
+		return false
+	}
+	if f.AgentID != "" && e.AgentID != f.AgentID {
+		return false
+	}
+	if f.Actor != "" && !strings.HasPrefix(e.Actor, f.Actor) {
+		return false
+	}
+	if f.Decision != "" && e.Decision != f.Decision {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Query returns the entries of the log at path that match filter
+func Query(path string, filter Filter) ([]Entry, error) {
+	entries, err := ReadAll(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Entry
+	for _, e := range entries {
+		if filter.Match(e) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// ParseSince parses a relative duration ("2h", "30m", "7d") or an absolute
+// time (RFC3339 or YYYY-MM-DD) into the earliest time to include
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if strings.HasSuffix(s, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil {
+			return now.Add(-time.Duration(days) * 24 * time.Hour), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (use e.g. 2h, 7d, 2006-01-02 or RFC3339)", s)
+}
+
+// csvColumns is the column order of CSV exports
+var csvColumns = []string{
+	"seq", "time", "actor", "decision", "task_id", "agent_id",
+	"input_digest", "rule", "action", "outcome", "details", "prev_hash", "hash",
+}
+
+// Export writes entries as JSONL or CSV
+func Export(w io.Writer, entries []Entry, format string) error {
+	switch format {
+	case "", "jsonl":
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvColumns); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			record := []string{
+				strconv.FormatInt(e.Seq, 10),
+				e.Time.Format(time.RFC3339),
+				e.Actor,
+				e.Decision,
+				e.TaskID,
+				e.AgentID,
+				e.InputDigest,
+				e.Rule,
+				e.Action,
+				e.Outcome,
+				formatDetails(e.Details),
+				e.PrevHash,
+				e.Hash,
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		return fmt.Errorf("unknown export format: %s (use jsonl or csv)", format)
+	}
+}
+
+// formatDetails renders details as sorted key=value pairs
+func formatDetails(details map[string]string) string {
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+details[k])
+	}
+	return strings.Join(parts, "; ")
+}
@@ -10,18 +10,189 @@ import (
 
 // Config 主配置结构
 type Config struct {
-	Gemini GeminiConfig `yaml:"gemini"`
-	Swarm  SwarmConfig  `yaml:"swarm"`
-	Git    GitConfig    `yaml:"git"`
+	LLM          LLMConfig          `yaml:"llm"`
+	Prompts      PromptsConfig      `yaml:"prompts"`
+	RepoContext  RepoContextConfig  `yaml:"repo_context"`
+	Replan       ReplanConfig       `yaml:"replan"`
+	BrainActions BrainActionsConfig `yaml:"brain_actions"`
+	Review       ReviewConfig       `yaml:"review"`
+	Conflicts    ConflictsConfig    `yaml:"conflict_resolution"`
+	Gemini       GeminiConfig       `yaml:"gemini"`
+	Swarm        SwarmConfig        `yaml:"swarm"`
+	Git          GitConfig          `yaml:"git"`
 }
 
-// GeminiConfig Gemini API 配置
+// LLM 提供方
+const (
+	ProviderGemini    = "gemini"
+	ProviderAnthropic = "anthropic"
+	ProviderOpenAI    = "openai"     // 任意 OpenAI 兼容端点：OpenAI、Ollama、llama.cpp server、vLLM
+	ProviderClaudeCLI = "claude-cli" // 本机 claude CLI 的 print 模式
+	ProviderFake      = "fake"       // 按提示词模板 ID 返回脚本中的响应，用于离线测试
+)
+
+// Cassette 模式
+const (
+	CassetteReplay = "replay" // 从 cassette 文件回放，不调用提供方
+	CassetteRecord = "record" // 调用提供方并把请求/响应写入 cassette 文件
+)
+
+// LLMConfig AI主脑使用的 LLM 配置
+type LLMConfig struct {
+	Provider   string `yaml:"provider"`    // gemini | anthropic | openai | claude-cli | fake
+	Model      string `yaml:"model"`       // 为空时使用提供方的默认模型
+	APIKey     string `yaml:"api_key"`     // 为空时读取提供方的环境变量
+	BaseURL    string `yaml:"base_url"`    // 自定义端点，如 http://localhost:11434/v1
+	Timeout    int    `yaml:"timeout"`     // 单次调用超时（秒）
+	MaxRetries int    `yaml:"max_retries"` // 可重试错误的重试次数
+	MaxTokens  int    `yaml:"max_tokens"`  // 单次响应的最大 token 数
+
+	FakeScript   string `yaml:"fake_script"`   // fake 提供方的响应脚本（YAML）
+	Cassette     string `yaml:"cassette"`      // 录制/回放文件，为空时直接调用提供方
+	CassetteMode string `yaml:"cassette_mode"` // replay（默认）| record
+}
+
+// PromptsConfig AI主脑提示词模板配置，项目可在 .swarm/prompts/ 中覆盖单个模板
+type PromptsConfig struct {
+	Language string `yaml:"language"` // zh（默认）| en
+}
+
+// RepoContextConfig 需求分析时提供给AI主脑的仓库摘要（文件树、包、关键符号、README、最近提交、TODO）
+type RepoContextConfig struct {
+	Enabled bool `yaml:"enabled"` // 默认开启；git.repo_path 不是 git 仓库时自动跳过
+	Budget  int  `yaml:"budget"`  // 摘要的最大 token 数（估算），默认 4000
+}
+
+// ReplanConfig 任务永久失败、反复合并冲突或 Agent 报告后续工作时，AI主脑重新规划任务图
+type ReplanConfig struct {
+	Enabled           bool   `yaml:"enabled"`            // 默认开启
+	AutoApply         bool   `yaml:"auto_apply"`         // 直接应用提议；默认关闭，提议进入审批队列
+	ConflictThreshold int    `yaml:"conflict_threshold"` // 任务合并冲突达到该次数时触发，默认 2
+	FollowUpMarker    string `yaml:"follow_up_marker"`   // Agent 输出中报告后续工作的标记，默认 "TODO for follow-up"
+	MaxPerTask        int    `yaml:"max_per_task"`       // 每个任务最多重新规划的次数，默认 3，0 表示不限制
+}
+
+// BrainActionsConfig AI主脑对运行中 Agent 的行动（提示、重启、重新分配、指定分配）的限制，0 表示不限制
+type BrainActionsConfig struct {
+	MaxPerMinute int `yaml:"max_per_minute"` // 每分钟最多执行的行动数，默认 6
+	Cooldown     int `yaml:"cooldown"`       // 同一 Agent 或任务上同类行动的最小间隔（秒），默认 120
+	MaxRestarts  int `yaml:"max_restarts"`   // 每个 Agent 在一次 swarm start 中最多重启的次数，默认 3
+}
+
+// ReviewConfig 任务执行成功后，AI主脑按任务描述、验收标准和代码改动评审，不合格时返工
+type ReviewConfig struct {
+	Enabled    bool `yaml:"enabled"`     // 默认开启，仅在 swarm start --with-brain 时生效
+	MinScore   int  `yaml:"min_score"`   // 评分低于该值时返工，默认 70
+	MaxReworks int  `yaml:"max_reworks"` // 每个任务最多返工次数，之后按现状接受，默认 2
+}
+
+// ConflictsConfig 合并冲突时应用AI主脑给出的解决方案：在临时分支上写入并校验，通过且符合策略时合并，否则进入审批队列
+type ConflictsConfig struct {
+	Enabled   bool     `yaml:"enabled"`    // 默认关闭，仅在 swarm start --with-brain 时生效
+	AutoMerge bool     `yaml:"auto_merge"` // 校验通过时直接合并，默认开启；关闭时一律进入审批队列
+	MaxFiles  int      `yaml:"max_files"`  // 冲突文件超过该数量时进入审批队列，默认 5，0 表示不限制
+	Checks    []string `yaml:"checks"`     // 校验命令，在解决后的临时 worktree 中执行；为空时不自动合并
+}
+
+// GeminiConfig Gemini API 配置（旧格式，llm.provider 为 gemini 时作为 api_key 和 model 的后备）
 type GeminiConfig struct {
 	APIKey  string `yaml:"api_key"`
 	Model   string `yaml:"model"`
 	Timeout int    `yaml:"timeout"`
 }
 
+// DefaultLLMConfig 默认 LLM 配置：Gemini，2分钟超时，重试3次
+func DefaultLLMConfig() LLMConfig {
+	return LLMConfig{
+		Provider:   ProviderGemini,
+		Timeout:    120,
+		MaxRetries: 3,
+		MaxTokens:  8192,
+	}
+}
+
+// apiKeyEnv 各提供方读取 API Key 的环境变量
+var apiKeyEnv = map[string]string{
+	ProviderGemini:    "GEMINI_API_KEY",
+	ProviderAnthropic: "ANTHROPIC_API_KEY",
+	ProviderOpenAI:    "OPENAI_API_KEY",
+}
+
+// Resolve 补全 LLM 配置：提供方默认值、旧 gemini 配置和环境变量中的 API Key
+func (c *Config) Resolve() {
+	defaults := DefaultLLMConfig()
+	if c.LLM.Provider == "" {
+		c.LLM.Provider = defaults.Provider
+	}
+	if c.LLM.Timeout <= 0 {
+		c.LLM.Timeout = defaults.Timeout
+	}
+	if c.LLM.MaxRetries < 0 {
+		c.LLM.MaxRetries = 0
+	}
+	if c.LLM.MaxTokens <= 0 {
+		c.LLM.MaxTokens = defaults.MaxTokens
+	}
+
+	if c.LLM.Provider == ProviderGemini {
+		if c.LLM.APIKey == "" {
+			c.LLM.APIKey = c.Gemini.APIKey
+		}
+		if c.LLM.Model == "" {
+			c.LLM.Model = c.Gemini.Model
+		}
+	}
+	if c.LLM.APIKey == "" {
+		if env, ok := apiKeyEnv[c.LLM.Provider]; ok {
+			c.LLM.APIKey = os.Getenv(env)
+		}
+	}
+
+	// CI 中无需改配置文件即可切换到录制/回放
+	if cassette := os.Getenv("SWARM_LLM_CASSETTE"); cassette != "" {
+		c.LLM.Cassette = cassette
+	}
+	if mode := os.Getenv("SWARM_LLM_CASSETTE_MODE"); mode != "" {
+		c.LLM.CassetteMode = mode
+	}
+	if c.LLM.Cassette != "" && c.LLM.CassetteMode == "" {
+		c.LLM.CassetteMode = CassetteReplay
+	}
+}
+
+// Replaying 报告是否从 cassette 回放（此时不需要提供方的凭据）
+func (l LLMConfig) Replaying() bool {
+	return l.Cassette != "" && (l.CassetteMode == "" || l.CassetteMode == CassetteReplay)
+}
+
+// Validate 检查 LLM 配置是否可用
+func (l LLMConfig) Validate() error {
+	switch l.CassetteMode {
+	case "", CassetteReplay, CassetteRecord:
+	default:
+		return fmt.Errorf("unknown llm cassette_mode %q (replay or record)", l.CassetteMode)
+	}
+	if l.Replaying() {
+		return nil
+	}
+
+	switch l.Provider {
+	case ProviderGemini, ProviderAnthropic:
+		if l.APIKey == "" {
+			return fmt.Errorf("%s api_key is required (set llm.api_key in config.yaml or %s env var)", l.Provider, apiKeyEnv[l.Provider])
+		}
+	case ProviderOpenAI, ProviderClaudeCLI:
+		// 本地端点和 claude CLI 不需要 API Key
+	case ProviderFake:
+		if l.FakeScript == "" {
+			return fmt.Errorf("fake provider requires llm.fake_script")
+		}
+	default:
+		return fmt.Errorf("unknown llm provider %q (gemini, anthropic, openai, claude-cli or fake)", l.Provider)
+	}
+	return nil
+}
+
 // SwarmConfig Swarm 配置
 type SwarmConfig struct {
 	DefaultAgents   int    `yaml:"default_agents"`
@@ -37,11 +208,33 @@ type GitConfig struct {
 	MainBranch   string `yaml:"main_branch"`
 }
 
-// Load 加载配置文件
+// Load 加载并验证配置文件
 // 优先级：1. 指定路径 2. ./config.yaml 3. ~/.claude-swarm/config.yaml 4. 环境变量
 func Load(configPath string) (*Config, error) {
+	config, err := Read(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证必填项
+	if err := config.LLM.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Read 加载配置文件但不验证，供命令行参数补全 API Key 等必填项后再验证
+func Read(configPath string) (*Config, error) {
 	config := &Config{
 		// 默认值
+		LLM:          DefaultLLMConfig(),
+		Prompts:      PromptsConfig{Language: "zh"},
+		RepoContext:  RepoContextConfig{Enabled: true, Budget: 4000},
+		Replan:       ReplanConfig{Enabled: true, ConflictThreshold: 2, FollowUpMarker: "TODO for follow-up", MaxPerTask: 3},
+		BrainActions: BrainActionsConfig{MaxPerMinute: 6, Cooldown: 120, MaxRestarts: 3},
+		Review:       ReviewConfig{Enabled: true, MinScore: 70, MaxReworks: 2},
+		Conflicts:    ConflictsConfig{AutoMerge: true, MaxFiles: 5},
 		Gemini: GeminiConfig{
 			Model:   "gemini-3-flash-preview",
 			Timeout: 30,
@@ -96,11 +289,7 @@ func Load(configPath string) (*Config, error) {
 	if apiKey := os.Getenv("GEMINI_API_KEY"); apiKey != "" {
 		config.Gemini.APIKey = apiKey
 	}
-
-	// 验证必填项
-	if config.Gemini.APIKey == "" {
-		return nil, fmt.Errorf("gemini api_key is required (set in config.yaml or GEMINI_API_KEY env var)")
-	}
+	config.Resolve()
 
 	return config, nil
 }
@@ -111,6 +300,13 @@ func LoadOrDefault() *Config {
 	if err != nil {
 		// 如果加载失败，使用默认值
 		config = &Config{
+			LLM:          DefaultLLMConfig(),
+			Prompts:      PromptsConfig{Language: "zh"},
+			RepoContext:  RepoContextConfig{Enabled: true, Budget: 4000},
+			Replan:       ReplanConfig{Enabled: true, ConflictThreshold: 2, FollowUpMarker: "TODO for follow-up", MaxPerTask: 3},
+			BrainActions: BrainActionsConfig{MaxPerMinute: 6, Cooldown: 120, MaxRestarts: 3},
+			Review:       ReviewConfig{Enabled: true, MinScore: 70, MaxReworks: 2},
+			Conflicts:    ConflictsConfig{AutoMerge: true, MaxFiles: 5},
 			Gemini: GeminiConfig{
 				APIKey:  os.Getenv("GEMINI_API_KEY"),
 				Model:   "gemini-3-flash-preview",
@@ -128,6 +324,7 @@ func LoadOrDefault() *Config {
 				MainBranch:   "main",
 			},
 		}
+		config.Resolve()
 	}
 	return config
 }
@@ -0,0 +1,502 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+// ActionKind is an action the coordinator applies to its live agents
+type ActionKind string
+
+const (
+	ActionHint     ActionKind = "hint"     // Add a hint to the prompt of a task's next attempt
+	ActionRestart  ActionKind = "restart"  // Kill an agent's CLI and re-create its worktree
+	ActionReassign ActionKind = "reassign" // Stop a task's attempt and requeue it for another agent
+	ActionAssign   ActionKind = "assign"   // Reserve a pending task for an agent
+)
+
+// AgentAction is a request, usually from the brain, to act on an agent or task
+type AgentAction struct {
+	Kind    ActionKind
+	AgentID string
+	TaskID  string
+	Hint    string // Suggestion added to the task's next prompt
+	Reason  string
+	By      string // Audit actor, "brain" if empty
+}
+
+// ErrActionRefused is wrapped by the errors of invalid actions and of
+// actions a guardrail refused
+var ErrActionRefused = errors.New("action refused")
+
+// ActionLimits are the guardrails on agent actions. Zero disables a limit.
+type ActionLimits struct {
+	PerMinute   int           // Actions of any kind per minute
+	Cooldown    time.Duration // Between two actions of one kind on the same agent or task
+	MaxRestarts int           // Restarts per agent while the coordinator runs
+}
+
+// DefaultActionLimits allows six actions a minute, one action of a kind per
+// target every two minutes and three restarts per agent
+func DefaultActionLimits() ActionLimits {
+	return ActionLimits{PerMinute: 6, Cooldown: 2 * time.Minute, MaxRestarts: 3}
+}
+
+// maxHintLength caps a hint added to a prompt
+const maxHintLength = 2000
+
+// actionGuard rate limits agent actions and holds the hints for attempts
+// that are still running
+type actionGuard struct {
+	mu       sync.Mutex
+	limits   ActionLimits
+	recent   []time.Time          // Accepted actions in the last minute
+	last     map[string]time.Time // kind/target -> last accepted action
+	restarts map[string]int       // agentID -> accepted restarts
+	hints    map[string][]string  // taskID -> hints for the next attempt
+	now      func() time.Time
+}
+
+func newActionGuard(limits ActionLimits) *actionGuard {
+	return &actionGuard{
+		limits:   limits,
+		last:     make(map[string]time.Time),
+		restarts: make(map[string]int),
+		hints:    make(map[string][]string),
+		now:      time.Now,
+	}
+}
+
+// reserve checks an action of kind on target against the limits and counts
+// it in the same step, so two concurrent actions can't both slip under a
+// limit. The returned cancel undoes the count when the action then fails.
+func (g *actionGuard) reserve(kind ActionKind, target string) (cancel func(), err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.prune(now)
+	key := string(kind) + "/" + target
+	if g.limits.PerMinute > 0 && len(g.recent) >= g.limits.PerMinute {
+		return nil, fmt.Errorf("%w: rate limit of %d actions per minute reached", ErrActionRefused, g.limits.PerMinute)
+	}
+	last, hadLast := g.last[key]
+	if hadLast && g.limits.Cooldown > 0 && now.Sub(last) < g.limits.Cooldown {
+		return nil, fmt.Errorf("%w: %s of %s %s ago, cooldown is %s", ErrActionRefused,
+			kind, target, now.Sub(last).Round(time.Second), g.limits.Cooldown)
+	}
+	if kind == ActionRestart && g.limits.MaxRestarts > 0 && g.restarts[target] >= g.limits.MaxRestarts {
+		return nil, fmt.Errorf("%w: %s was already restarted %d times", ErrActionRefused, target, g.restarts[target])
+	}
+
+	g.recent = append(g.recent, now)
+	g.last[key] = now
+	if kind == ActionRestart {
+		g.restarts[target]++
+	}
+
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		for i := len(g.recent) - 1; i >= 0; i-- {
+			if g.recent[i].Equal(now) {
+				g.recent = append(g.recent[:i], g.recent[i+1:]...)
+				break
+			}
+		}
+		if g.last[key].Equal(now) {
+			if hadLast {
+				g.last[key] = last
+			} else {
+				delete(g.last, key)
+			}
+		}
+		if kind == ActionRestart && g.restarts[target] > 0 {
+			g.restarts[target]--
+		}
+	}, nil
+}
+
+// prune drops actions older than a minute; callers hold mu
+func (g *actionGuard) prune(now time.Time) {
+	i := 0
+	for i < len(g.recent) && now.Sub(g.recent[i]) >= time.Minute {
+		i++
+	}
+	g.recent = g.recent[i:]
+}
+
+// setLimits replaces the limits, keeping what was counted so far
+func (g *actionGuard) setLimits(limits ActionLimits) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.limits = limits
+}
+
+// addHint keeps hint for the next attempt of a running task
+func (g *actionGuard) addHint(taskID, hint string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.hints[taskID] = append(g.hints[taskID], hint)
+}
+
+// takeHints returns and clears the hints kept for taskID
+func (g *actionGuard) takeHints(taskID string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hints := g.hints[taskID]
+	delete(g.hints, taskID)
+	return strings.Join(hints, "\n")
+}
+
+// joinHints appends hint to the hints a task already has
+func joinHints(hints, hint string) string {
+	if hints == "" || hint == "" {
+		return hints + hint
+	}
+	return hints + "\n" + hint
+}
+
+// SetActionLimits replaces the guardrails on agent actions
+func (c *Coordinator) SetActionLimits(limits ActionLimits) {
+	c.actions.setLimits(limits)
+}
+
+// ExecuteAction applies action to the live agents and returns what was done.
+// Invalid actions and actions refused by a guardrail return an error
+// wrapping ErrActionRefused. Every action is audited either way.
+func (c *Coordinator) ExecuteAction(action AgentAction) (string, error) {
+	if action.By == "" {
+		action.By = "brain"
+	}
+
+	result, err := c.applyAction(&action)
+
+	entry := audit.Entry{
+		Actor:    action.By,
+		Decision: audit.DecisionAgentAction,
+		TaskID:   action.TaskID,
+		AgentID:  action.AgentID,
+		Action:   string(action.Kind),
+		Outcome:  result,
+		Details:  map[string]string{"reason": action.Reason},
+	}
+	if action.Hint != "" {
+		entry.Details["hint"] = action.Hint
+	}
+	if err != nil {
+		entry.Outcome = "refused: " + err.Error()
+		log.Printf("🚫 %s action %s refused: %v", action.By, action.Kind, err)
+	} else {
+		log.Printf("🎯 %s action %s: %s", action.By, action.Kind, result)
+	}
+	c.recordAudit(entry)
+	return result, err
+}
+
+// applyAction validates action against the agents and tasks, checks the
+// limits and carries it out. It fills in the agent or task it resolved.
+func (c *Coordinator) applyAction(action *AgentAction) (string, error) {
+	if len(action.Hint) > maxHintLength {
+		return "", fmt.Errorf("%w: hint is longer than %d bytes", ErrActionRefused, maxHintLength)
+	}
+
+	switch action.Kind {
+	case ActionHint:
+		return c.hintTask(action)
+	case ActionRestart:
+		return c.restartAgent(action)
+	case ActionReassign:
+		return c.reassignTask(action)
+	case ActionAssign:
+		return c.assignTask(action)
+	default:
+		return "", fmt.Errorf("%w: unknown action %q", ErrActionRefused, action.Kind)
+	}
+}
+
+// hintTask adds a hint to the next attempt of a task. A running attempt
+// keeps going; the hint is added when it ends, so it reaches the retry or
+// the attempt after a restart.
+func (c *Coordinator) hintTask(action *AgentAction) (string, error) {
+	if action.Hint == "" {
+		return "", fmt.Errorf("%w: no hint given", ErrActionRefused)
+	}
+	if action.TaskID == "" {
+		agent, err := c.lookupAgent(action.AgentID)
+		if err != nil {
+			return "", err
+		}
+		if action.TaskID = agent.currentTaskID(); action.TaskID == "" {
+			return "", fmt.Errorf("%w: %s is not running a task", ErrActionRefused, agent.ID)
+		}
+	}
+	task, err := c.lookupTask(action.TaskID)
+	if err != nil {
+		return "", err
+	}
+	if task.Status == models.TaskStatusCompleted {
+		return "", fmt.Errorf("%w: task %s is already completed", ErrActionRefused, task.ID)
+	}
+	cancel, err := c.actions.reserve(ActionHint, task.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if agent := c.agentRunning(task.ID); agent != nil {
+		action.AgentID = agent.ID
+		c.actions.addHint(task.ID, action.Hint)
+		return fmt.Sprintf("hint kept for the next attempt of task %s (running on %s)", task.ID, agent.ID), nil
+	}
+
+	task.RetryHint = joinHints(task.RetryHint, action.Hint)
+	if err := c.taskQueue.UpdateTask(task); err != nil {
+		cancel()
+		return "", fmt.Errorf("failed to save hint: %w", err)
+	}
+	return fmt.Sprintf("hint added to the next attempt of task %s", task.ID), nil
+}
+
+// restartAgent kills the agent's CLI and re-creates its worktree. A running
+// task is requeued, and gets the action's hint on its next attempt.
+func (c *Coordinator) restartAgent(action *AgentAction) (string, error) {
+	if action.AgentID == "" && action.TaskID != "" {
+		if agent := c.agentRunning(action.TaskID); agent != nil {
+			action.AgentID = agent.ID
+		}
+	}
+	agent, err := c.lookupAgent(action.AgentID)
+	if err != nil {
+		return "", err
+	}
+	if c.isHeld(agent.ID) {
+		return "", fmt.Errorf("%w: work of %s is waiting for a merge approval", ErrActionRefused, agent.ID)
+	}
+	cancel, err := c.actions.reserve(ActionRestart, agent.ID)
+	if err != nil {
+		return "", err
+	}
+
+	taskID := agent.currentTaskID()
+	intr := &agentInterrupt{taskID: taskID, reason: "agent restarted", hint: action.Hint, restart: true}
+	if action.Reason != "" {
+		intr.reason += ": " + action.Reason
+	}
+	if !agent.requestInterrupt(intr) {
+		cancel()
+		return "", fmt.Errorf("%w: %s is busy finishing its attempt, try again later", ErrActionRefused, agent.ID)
+	}
+
+	if taskID == "" {
+		return fmt.Sprintf("restarting idle %s", agent.ID), nil
+	}
+	action.TaskID = taskID
+	return fmt.Sprintf("restarting %s; task %s is requeued", agent.ID, taskID), nil
+}
+
+// reassignTask stops the task's attempt, if one is running, and requeues
+// the task for another agent than the one that last ran it
+func (c *Coordinator) reassignTask(action *AgentAction) (string, error) {
+	task, err := c.lookupTask(action.TaskID)
+	if err != nil {
+		return "", err
+	}
+	switch task.Status {
+	case models.TaskStatusCompleted:
+		return "", fmt.Errorf("%w: task %s is already completed", ErrActionRefused, task.ID)
+	case models.TaskStatusAwaitingApproval:
+		return "", fmt.Errorf("%w: task %s is waiting for a human approval", ErrActionRefused, task.ID)
+	}
+	cancel, err := c.actions.reserve(ActionReassign, task.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if agent := c.agentRunning(task.ID); agent != nil {
+		intr := &agentInterrupt{taskID: task.ID, reason: "task reassigned", hint: action.Hint, avoid: true}
+		if action.Reason != "" {
+			intr.reason += ": " + action.Reason
+		}
+		if !agent.requestInterrupt(intr) {
+			cancel()
+			return "", fmt.Errorf("%w: %s is busy finishing task %s, try again later", ErrActionRefused, agent.ID, task.ID)
+		}
+		action.AgentID = agent.ID
+		return fmt.Sprintf("stopped task %s on %s; it is requeued for another agent", task.ID, agent.ID), nil
+	}
+
+	// Not running here: failed, pending or orphaned by a stopped coordinator
+	previous := task.AssigneeID
+	if last := task.LastAttempt(); last != nil {
+		previous = last.AgentID
+	}
+	task.Status = models.TaskStatusPending
+	task.AssigneeID = ""
+	task.RetryHint = joinHints(task.RetryHint, action.Hint)
+	task.NotBefore = time.Now()
+	task.AvoidAgent = ""
+	if len(c.agents) > 1 {
+		task.AvoidAgent = previous
+	}
+	if err := c.taskQueue.UpdateTask(task); err != nil {
+		cancel()
+		return "", fmt.Errorf("failed to requeue task: %w", err)
+	}
+	return fmt.Sprintf("task %s is requeued", task.ID), nil
+}
+
+// assignTask reserves a pending task for an agent. The task waits for the
+// agent for state.AvoidAgentGrace, then any agent may claim it.
+func (c *Coordinator) assignTask(action *AgentAction) (string, error) {
+	agent, err := c.lookupAgent(action.AgentID)
+	if err != nil {
+		return "", err
+	}
+	task, err := c.lookupTask(action.TaskID)
+	if err != nil {
+		return "", err
+	}
+	if task.Status != models.TaskStatusPending {
+		return "", fmt.Errorf("%w: task %s is %s, only pending tasks can be assigned", ErrActionRefused, task.ID, task.Status)
+	}
+	if c.isHeld(agent.ID) {
+		return "", fmt.Errorf("%w: work of %s is waiting for a merge approval", ErrActionRefused, agent.ID)
+	}
+	cancel, err := c.actions.reserve(ActionAssign, task.ID)
+	if err != nil {
+		return "", err
+	}
+
+	task.PreferAgent = agent.ID
+	if task.AvoidAgent == agent.ID {
+		task.AvoidAgent = ""
+	}
+	// A scheduled retry keeps its time; the grace period starts from it
+	if now := time.Now(); task.NotBefore.Before(now) {
+		task.NotBefore = now
+	}
+	if err := c.taskQueue.UpdateTask(task); err != nil {
+		cancel()
+		return "", fmt.Errorf("failed to assign task: %w", err)
+	}
+	return fmt.Sprintf("task %s is reserved for %s for up to %s", task.ID, agent.ID, state.AvoidAgentGrace), nil
+}
+
+// lookupAgent returns the agent with id
+func (c *Coordinator) lookupAgent(id string) (*Agent, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: no agent given", ErrActionRefused)
+	}
+	for _, agent := range c.agents {
+		if agent.ID == id {
+			return agent, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown agent %s", ErrActionRefused, id)
+}
+
+// lookupTask returns the task with id
+func (c *Coordinator) lookupTask(id string) (*models.Task, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: no task given", ErrActionRefused)
+	}
+	task, err := c.taskQueue.GetTask(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown task %s", ErrActionRefused, id)
+	}
+	return task, nil
+}
+
+// agentRunning returns the agent running taskID, or nil
+func (c *Coordinator) agentRunning(taskID string) *Agent {
+	for _, agent := range c.agents {
+		if agent.currentTaskID() == taskID {
+			return agent
+		}
+	}
+	return nil
+}
+
+// requeueInterrupted records the attempt an action stopped and puts its
+// task back in the queue. The attempt counts neither as a failure nor as a
+// retry.
+func (c *Coordinator) requeueInterrupted(agent *Agent, task *models.Task, run *attemptRun, intr *agentInterrupt, err error) {
+	log.Printf("⏹️  Stopped task %s on %s (%s)", task.ID, agent.ID, intr.reason)
+
+	task.Failure = nil
+	run.Interrupted = intr.reason
+	c.finishAttempt(agent, task, run, err)
+	task.RetryHint = joinHints(task.RetryHint, intr.hint)
+
+	task.Status = models.TaskStatusPending
+	task.AssigneeID = ""
+	task.NotBefore = time.Now()
+	task.AvoidAgent = ""
+	if intr.avoid && len(c.agents) > 1 {
+		task.AvoidAgent = agent.ID
+	}
+	_ = c.taskQueue.UpdateTask(task)
+
+	if intr.restart {
+		c.resetWorktree(agent)
+	}
+}
+
+// resetWorktree gives a restarted agent a fresh worktree from main. A branch
+// with commits that are not merged yet is kept and only its uncommitted
+// changes are discarded, so a restart never loses committed work.
+func (c *Coordinator) resetWorktree(agent *Agent) {
+	if agent.Worktree == nil {
+		return
+	}
+	path, branch := agent.Worktree.Path, agent.Worktree.BranchName
+
+	if unmerged, err := c.hasNewCommits(branch); err != nil || unmerged {
+		err := c.gitCommand(path, "reset", "--hard")
+		if err == nil {
+			err = c.gitCommand(path, "clean", "-fd")
+		}
+		if err != nil {
+			log.Printf("⚠️  Failed to reset worktree of %s: %v", agent.ID, err)
+			return
+		}
+		log.Printf("♻️  Reset worktree of %s to its last commit (unmerged commits on %s kept)", agent.ID, branch)
+		return
+	}
+
+	agentNum := agent.ID[len("agent-"):]
+	if err := c.worktreeManager.RemoveWorktree(agentNum); err != nil {
+		log.Printf("⚠️  Failed to remove worktree of %s: %v", agent.ID, err)
+		return
+	}
+	worktree, err := c.worktreeManager.CreateWorktree(agentNum)
+	if err != nil {
+		log.Printf("⚠️  Failed to re-create worktree of %s: %v", agent.ID, err)
+		return
+	}
+
+	agent.mu.Lock()
+	agent.Worktree = worktree
+	agent.WorkingDir = worktree.Path
+	agent.mu.Unlock()
+	log.Printf("♻️  Re-created worktree of %s from main", agent.ID)
+}
+
+// restartIdle restarts an agent that had no task when the restart was requested
+func (c *Coordinator) restartIdle(agent *Agent, intr *agentInterrupt) {
+	log.Printf("♻️  Restarting %s (%s)", agent.ID, intr.reason)
+	c.resetWorktree(agent)
+	agent.markIdle()
+}
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+// actionCoordinator returns a coordinator with two idle agents and no
+// worktrees, enough to apply actions without running the agent CLI
+func actionCoordinator(t *testing.T, tasks ...*models.Task) *Coordinator {
+	t.Helper()
+	dir := t.TempDir()
+
+	taskQueue, err := state.NewTaskQueue(filepath.Join(dir, "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { taskQueue.Close() })
+	for _, task := range tasks {
+		if err := taskQueue.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auditLog, err := audit.Open(filepath.Join(dir, "audit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { auditLog.Close() })
+
+	return &Coordinator{
+		agents:     []*Agent{NewAgent("agent-0", nil, dir), NewAgent("agent-1", nil, dir)},
+		taskQueue:  taskQueue,
+		auditLog:   auditLog,
+		heldAgents: make(map[string]string),
+		actions:    newActionGuard(DefaultActionLimits()),
+	}
+}
+
+func TestExecuteAction(t *testing.T) {
+	c := actionCoordinator(t,
+		&models.Task{ID: "pending", Description: "Add login", Status: models.TaskStatusPending},
+		&models.Task{ID: "failed", Description: "Add logout", Status: models.TaskStatusFailed,
+			Attempts: []models.Attempt{{Number: 1, AgentID: "agent-0"}}},
+	)
+
+	// Targeted assignment reserves the task for the agent
+	if _, err := c.ExecuteAction(AgentAction{Kind: ActionAssign, TaskID: "pending", AgentID: "agent-1"}); err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	if task, _ := c.taskQueue.GetTask("pending"); task.PreferAgent != "agent-1" {
+		t.Errorf("assigned task prefers %q, want agent-1", task.PreferAgent)
+	}
+
+	// A hint for a task that is not running goes into its next prompt
+	if _, err := c.ExecuteAction(AgentAction{Kind: ActionHint, TaskID: "pending", Hint: "Reuse the session middleware"}); err != nil {
+		t.Fatalf("hint: %v", err)
+	}
+	if task, _ := c.taskQueue.GetTask("pending"); task.RetryHint != "Reuse the session middleware" {
+		t.Errorf("hinted task RetryHint = %q", task.RetryHint)
+	}
+
+	// Reassigning a failed task requeues it away from the agent that ran it
+	if _, err := c.ExecuteAction(AgentAction{Kind: ActionReassign, TaskID: "failed", Hint: "Clear the cookie too"}); err != nil {
+		t.Fatalf("reassign: %v", err)
+	}
+	task, _ := c.taskQueue.GetTask("failed")
+	if task.Status != models.TaskStatusPending || task.AvoidAgent != "agent-0" || task.RetryHint != "Clear the cookie too" {
+		t.Errorf("reassigned task = %s, avoid %q, hint %q", task.Status, task.AvoidAgent, task.RetryHint)
+	}
+
+	// Restarting an idle agent hands the restart to its worker
+	if _, err := c.ExecuteAction(AgentAction{Kind: ActionRestart, AgentID: "agent-0", Reason: "stuck"}); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if c.agents[0].IsIdle() {
+		t.Error("agent with a pending restart should not be scheduled")
+	}
+	if intr := c.agents[0].takeInterrupt(""); intr == nil || !intr.restart || intr.reason != "agent restarted: stuck" {
+		t.Errorf("pending restart = %+v", intr)
+	}
+
+	// Invalid actions are refused
+	for _, action := range []AgentAction{
+		{Kind: ActionRestart, AgentID: "agent-9"},
+		{Kind: ActionAssign, TaskID: "missing", AgentID: "agent-0"},
+		{Kind: ActionHint, AgentID: "agent-1", Hint: "agent-1 runs nothing"},
+		{Kind: "deploy"},
+	} {
+		if _, err := c.ExecuteAction(action); !errors.Is(err, ErrActionRefused) {
+			t.Errorf("ExecuteAction(%+v) error = %v, want ErrActionRefused", action, err)
+		}
+	}
+}
+
+func TestExecuteActionInterruptsRunningAttempt(t *testing.T) {
+	c := actionCoordinator(t, &models.Task{ID: "running", Description: "Add login", Status: models.TaskStatusInProgress})
+	agent := c.agents[1]
+	task, _ := c.taskQueue.GetTask("running")
+	agent.Status.State = models.AgentStateWorking
+	agent.Status.CurrentTask = task
+	agent.cliRunning = true
+
+	// The hint waits for the attempt to end, the reassignment stops it
+	if _, err := c.ExecuteAction(AgentAction{Kind: ActionHint, AgentID: "agent-1", Hint: "Check the router"}); err != nil {
+		t.Fatalf("hint: %v", err)
+	}
+	if _, err := c.ExecuteAction(AgentAction{Kind: ActionReassign, TaskID: "running", Reason: "no progress"}); err != nil {
+		t.Fatalf("reassign: %v", err)
+	}
+	if agent.ctx.Err() == nil {
+		t.Error("reassigning a running task should kill the agent CLI")
+	}
+
+	intr := agent.takeInterrupt("running")
+	if intr == nil || !intr.avoid {
+		t.Fatalf("pending interrupt = %+v", intr)
+	}
+	if agent.ctx.Err() != nil {
+		t.Error("the agent should get a fresh context for its next task")
+	}
+
+	c.requeueInterrupted(agent, task, &attemptRun{Attempt: models.Attempt{Number: 1, AgentID: agent.ID}}, intr, errors.New("killed"))
+	task, _ = c.taskQueue.GetTask("running")
+	last := task.LastAttempt()
+	if task.Status != models.TaskStatusPending || task.AvoidAgent != "agent-1" || task.RetryCount != 0 {
+		t.Errorf("requeued task = %s, avoid %q, retries %d", task.Status, task.AvoidAgent, task.RetryCount)
+	}
+	if last == nil || last.Failure != nil || last.Interrupted != "task reassigned: no progress" {
+		t.Errorf("interrupted attempt = %+v", last)
+	}
+	if task.RetryHint != "Check the router" {
+		t.Errorf("requeued task RetryHint = %q", task.RetryHint)
+	}
+}
+
+func TestActionGuard(t *testing.T) {
+	now := time.Now()
+	g := newActionGuard(ActionLimits{PerMinute: 3, Cooldown: 2 * time.Minute, MaxRestarts: 1})
+	g.now = func() time.Time { return now }
+
+	if _, err := g.reserve(ActionRestart, "agent-0"); err != nil {
+		t.Fatalf("first restart refused: %v", err)
+	}
+
+	// Cooldown applies per kind and target
+	if _, err := g.reserve(ActionHint, "task-1"); err != nil {
+		t.Errorf("hint refused: %v", err)
+	}
+	if _, err := g.reserve(ActionHint, "task-1"); !errors.Is(err, ErrActionRefused) {
+		t.Errorf("hint within cooldown error = %v", err)
+	}
+
+	// Restarts are capped per agent, even after the cooldown
+	now = now.Add(5 * time.Minute)
+	if _, err := g.reserve(ActionRestart, "agent-0"); !errors.Is(err, ErrActionRefused) {
+		t.Errorf("second restart error = %v", err)
+	}
+
+	// Rate limit across all actions
+	for _, task := range []string{"task-2", "task-3", "task-4"} {
+		if _, err := g.reserve(ActionReassign, task); err != nil {
+			t.Fatalf("reassign %s refused: %v", task, err)
+		}
+	}
+	if _, err := g.reserve(ActionReassign, "task-5"); !errors.Is(err, ErrActionRefused) {
+		t.Errorf("action over the rate limit error = %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := g.reserve(ActionReassign, "task-5"); err != nil {
+		t.Errorf("action after a minute refused: %v", err)
+	}
+}
+
+func TestActionGuardCancel(t *testing.T) {
+	g := newActionGuard(ActionLimits{PerMinute: 1, Cooldown: 2 * time.Minute, MaxRestarts: 1})
+
+	// An action that fails after its reservation uses up no limit
+	cancel, err := g.reserve(ActionRestart, "agent-0")
+	if err != nil {
+		t.Fatalf("restart refused: %v", err)
+	}
+	cancel()
+	if _, err := g.reserve(ActionRestart, "agent-0"); err != nil {
+		t.Errorf("restart after a cancelled one refused: %v", err)
+	}
+
+	// Concurrent reservations can't both pass the rate limit
+	g = newActionGuard(ActionLimits{PerMinute: 1})
+	var wg sync.WaitGroup
+	var accepted atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := g.reserve(ActionHint, fmt.Sprintf("task-%d", i)); err == nil {
+				accepted.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if accepted.Load() != 1 {
+		t.Errorf("%d concurrent actions accepted, want 1", accepted.Load())
+	}
+}
+
+func TestRefusedRestartKeepsBudget(t *testing.T) {
+	c := actionCoordinator(t, &models.Task{ID: "running", Description: "Add login", Status: models.TaskStatusInProgress})
+	agent := c.agents[0]
+	task, _ := c.taskQueue.GetTask("running")
+
+	// The CLI has exited and the worker is still merging: the restart is
+	// refused and must not start the cooldown
+	agent.Status.State = models.AgentStateWorking
+	agent.Status.CurrentTask = task
+	if _, err := c.ExecuteAction(AgentAction{Kind: ActionRestart, AgentID: agent.ID}); !errors.Is(err, ErrActionRefused) {
+		t.Fatalf("restart of a finishing agent error = %v", err)
+	}
+
+	agent.markIdle()
+	if _, err := c.ExecuteAction(AgentAction{Kind: ActionRestart, AgentID: agent.ID}); err != nil {
+		t.Errorf("restart once the agent is idle refused: %v", err)
+	}
+}
@@ -20,13 +20,28 @@ type Agent struct {
 	WorkingDir string
 	mu         sync.Mutex
 	version    uint64 // State version number for optimistic locking
+	cliRunning bool   // The agent CLI is running; only then can an attempt be stopped
 
 	// Task channel for receiving tasks
 	taskChan chan *models.Task
 
-	// Context and cancellation
+	// Context and cancellation; cancelling kills the running agent CLI
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// Orchestrator request to stop the current attempt or restart the idle
+	// agent, handled by the worker; wake signals a restart of an idle agent
+	interrupt *agentInterrupt
+	wake      chan struct{}
+}
+
+// agentInterrupt is an orchestrator request to stop an agent's attempt
+type agentInterrupt struct {
+	taskID  string // Task whose attempt is stopped; empty for an idle agent
+	reason  string
+	hint    string // Added to the prompt of the task's next attempt
+	restart bool   // Re-create the worktree before the next task
+	avoid   bool   // Requeue the task for another agent
 }
 
 // NewAgent creates a new agent
@@ -39,6 +54,7 @@ func NewAgent(id string, worktree *git.Worktree, workingDir string) *Agent {
 		Worktree:   worktree,
 		WorkingDir: workingDir,
 		taskChan:   make(chan *models.Task, 5), // Buffer for 5 tasks
+		wake:       make(chan struct{}, 1),
 		Status: &models.AgentStatus{
 			AgentID:    id,
 			State:      models.AgentStateIdle,
@@ -49,29 +65,29 @@ func NewAgent(id string, worktree *git.Worktree, workingDir string) *Agent {
 	}
 }
 
-// ExecuteTask executes a task using Claude Code CLI
-func (a *Agent) ExecuteTask(task *models.Task) error {
+// ExecuteTask runs task with the given prompt using Claude Code CLI. The
+// agent keeps the task when the CLI exits; the worker marks it idle once the
+// attempt has been verified and merged or handled as a failure.
+func (a *Agent) ExecuteTask(task *models.Task, prompt string) error {
 	a.mu.Lock()
 	a.Status.State = models.AgentStateWorking
 	a.Status.CurrentTask = task
+	a.cliRunning = true
 	a.version++
+	ctx := a.ctx
 	a.mu.Unlock()
 
 	log.Printf("🚀 Agent %s starting task: %s", a.ID, task.Description)
 
-	// Execute with timeout
-	taskCtx, cancel := context.WithTimeout(a.ctx, 10*time.Minute)
-	defer cancel()
-
-	err := a.Executor.ExecuteTask(taskCtx, task)
+	// The executor enforces the task's timeout and other resource limits
+	err := a.Executor.ExecutePrompt(ctx, task, prompt)
 
 	a.mu.Lock()
+	a.cliRunning = false
 	if err != nil {
 		a.Status.State = models.AgentStateError
 		log.Printf("❌ Agent %s task failed: %v", a.ID, err)
 	} else {
-		a.Status.State = models.AgentStateIdle
-		a.Status.CurrentTask = nil
 		log.Printf("✅ Agent %s task completed", a.ID)
 	}
 	a.version++
@@ -103,13 +119,96 @@ func (a *Agent) GetStatus() *models.AgentStatus {
 
 // Stop stops the agent
 func (a *Agent) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	a.cancel()
 }
 
-// IsIdle returns true if the agent is idle and has no task
+// IsIdle returns true if the agent is idle, has no task and no pending restart
 func (a *Agent) IsIdle() bool {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	return a.Status.State == models.AgentStateIdle && a.Status.CurrentTask == nil
+	return a.Status.State == models.AgentStateIdle && a.Status.CurrentTask == nil && a.interrupt == nil
+}
+
+// requestInterrupt asks the worker to stop the attempt of intr.taskID, or to
+// restart the agent if intr.taskID is empty and the agent is idle. The
+// running agent CLI is killed. It returns false if the agent is not in the
+// expected state or already has a pending interrupt.
+func (a *Agent) requestInterrupt(intr *agentInterrupt) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.interrupt != nil {
+		return false
+	}
+	current := a.Status.CurrentTask
+	if intr.taskID == "" {
+		if current != nil || a.Status.State != models.AgentStateIdle {
+			return false
+		}
+		a.interrupt = intr
+		select {
+		case a.wake <- struct{}{}:
+		default:
+		}
+		return true
+	}
+	if current == nil || current.ID != intr.taskID || !a.cliRunning {
+		return false
+	}
+	a.interrupt = intr
+	a.cancel()
+	return true
+}
+
+// takeInterrupt returns and clears the pending interrupt for taskID ("" for
+// an idle restart), giving the agent a fresh context if it was cancelled
+func (a *Agent) takeInterrupt(taskID string) *agentInterrupt {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	intr := a.interrupt
+	if intr == nil || intr.taskID != taskID {
+		return nil
+	}
+	a.interrupt = nil
+	if a.ctx.Err() != nil {
+		a.ctx, a.cancel = context.WithCancel(context.Background())
+	}
+	return intr
+}
+
+// currentTaskID returns the ID of the task the agent is running, or ""
+func (a *Agent) currentTaskID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.Status.CurrentTask == nil {
+		return ""
+	}
+	return a.Status.CurrentTask.ID
+}
+
+// markIdle clears the current task so the agent can be scheduled again
+func (a *Agent) markIdle() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.Status.State = models.AgentStateIdle
+	a.Status.CurrentTask = nil
+	a.Status.LastUpdate = time.Now()
+	a.version++
+}
+
+// setState updates the agent state while it keeps its current task
+func (a *Agent) setState(state models.AgentState) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.Status.State = state
+	a.Status.LastUpdate = time.Now()
+	a.version++
 }
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func TestAgentKeepsTaskAfterCLIExits(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "claude"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	agent := NewAgent("agent-0", nil, t.TempDir())
+	task := &models.Task{ID: "task-1", Description: "Add login"}
+	if err := agent.ExecuteTask(task, "Add login"); err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+
+	// Verification, review and merge still have to run, but the attempt
+	// can no longer be stopped
+	if agent.IsIdle() || agent.currentTaskID() != "task-1" {
+		t.Errorf("agent state = %s, task %q, want it busy with task-1", agent.GetStatus().State, agent.currentTaskID())
+	}
+	if agent.requestInterrupt(&agentInterrupt{taskID: "task-1", reason: "task reassigned"}) {
+		t.Error("interrupt accepted after the agent CLI exited")
+	}
+	agent.markIdle()
+	if !agent.IsIdle() {
+		t.Error("agent should be idle once the worker is done with the attempt")
+	}
+}
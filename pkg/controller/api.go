@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// resolveRequest is the body of an approve/reject call. The resolver is
+// not part of it: whoever holds the token acts as the API operator.
+type resolveRequest struct {
+	Note string `json:"note"`
+}
+
+// APIHandler returns the HTTP control API of the coordinator. Every route
+// requires "Authorization: Bearer <token>"; decisions made through the API
+// are recorded as resolved by "api:<operator>".
+//
+// Routes:
+//
+//	GET  /api/tasks
+//	GET  /api/agents
+//	GET  /api/approvals[?status=pending]
+//	POST /api/approvals/{id}/approve
+//	POST /api/approvals/{id}/reject
+//	GET  /api/throttle
+//	GET  /metrics (Prometheus text format)
+func (c *Coordinator) APIHandler(token, operator string) http.Handler {
+	resolvedBy := "api"
+	if operator != "" {
+		resolvedBy = "api:" + operator
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/tasks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.taskQueue.ListTasks())
+	})
+
+	mux.HandleFunc("GET /api/agents", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.GetAgentStatus())
+	})
+
+	mux.HandleFunc("GET /api/approvals", func(w http.ResponseWriter, r *http.Request) {
+		status := models.ApprovalStatus(r.URL.Query().Get("status"))
+		writeJSON(w, http.StatusOK, c.approvals.List(status))
+	})
+
+	mux.HandleFunc("POST /api/approvals/{id}/approve", func(w http.ResponseWriter, r *http.Request) {
+		c.handleResolve(w, r, true, resolvedBy)
+	})
+
+	mux.HandleFunc("POST /api/approvals/{id}/reject", func(w http.ResponseWriter, r *http.Request) {
+		c.handleResolve(w, r, false, resolvedBy)
+	})
+
+	mux.HandleFunc("GET /api/throttle", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.ThrottleStatus())
+	})
+
+	mux.HandleFunc("GET /metrics", c.handleMetrics)
+
+	return requireToken(token, mux)
+}
+
+// requireToken rejects requests without the bearer token. An empty token
+// rejects everything rather than leaving the API open.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid bearer token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleMetrics exposes task, agent and throttle gauges in the Prometheus
+// text exposition format
+func (c *Coordinator) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var sb strings.Builder
+	metric := func(name, help, kind string) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	queued := c.taskQueue.ListTasks()
+	tasks := make(map[models.TaskStatus]int)
+	for _, task := range queued {
+		tasks[task.Status]++
+	}
+	metric("swarm_tasks", "Tasks in the queue by status.", "gauge")
+	for _, status := range []models.TaskStatus{
+		models.TaskStatusPending, models.TaskStatusInProgress, models.TaskStatusAwaitingApproval,
+		models.TaskStatusCompleted, models.TaskStatusFailed,
+	} {
+		fmt.Fprintf(&sb, "swarm_tasks{status=%q} %d\n", status, tasks[status])
+	}
+
+	reviews := models.SummarizeReviews(queued)
+	metric("swarm_reviewed_tasks", "Tasks whose work was reviewed.", "gauge")
+	fmt.Fprintf(&sb, "swarm_reviewed_tasks %d\n", reviews.Reviewed)
+	metric("swarm_review_score_average", "Average of the latest review score per task.", "gauge")
+	fmt.Fprintf(&sb, "swarm_review_score_average %g\n", reviews.AverageScore)
+	metric("swarm_reworks_total", "Times a review sent work back to its agent.", "counter")
+	fmt.Fprintf(&sb, "swarm_reworks_total %d\n", reviews.Reworks)
+
+	agents := make(map[models.AgentState]int)
+	for _, agent := range c.GetAgentStatus() {
+		agents[agent.State]++
+	}
+	metric("swarm_agents", "Agents by state.", "gauge")
+	for _, state := range []models.AgentState{
+		models.AgentStateIdle, models.AgentStateWorking, models.AgentStateWaitingConfirm,
+		models.AgentStateError, models.AgentStateStuck,
+	} {
+		fmt.Fprintf(&sb, "swarm_agents{state=%q} %d\n", state, agents[state])
+	}
+
+	throttle := c.ThrottleStatus()
+	metric("swarm_breaker_state", "Circuit breaker state, 1 for the current state.", "gauge")
+	for _, state := range []models.BreakerState{models.BreakerClosed, models.BreakerOpen, models.BreakerHalfOpen} {
+		value := 0
+		if throttle.Breaker == state {
+			value = 1
+		}
+		fmt.Fprintf(&sb, "swarm_breaker_state{state=%q} %d\n", state, value)
+	}
+	metric("swarm_breaker_consecutive_failures", "Consecutive rate limit or network failures.", "gauge")
+	fmt.Fprintf(&sb, "swarm_breaker_consecutive_failures %d\n", throttle.ConsecutiveFailures)
+	metric("swarm_breaker_trips_total", "Times the circuit breaker opened.", "counter")
+	fmt.Fprintf(&sb, "swarm_breaker_trips_total %d\n", throttle.Trips)
+	metric("swarm_running_tasks", "Tasks currently executing.", "gauge")
+	fmt.Fprintf(&sb, "swarm_running_tasks %d\n", throttle.Running)
+	metric("swarm_limiter_tokens", "Task starts available in the token bucket.", "gauge")
+	fmt.Fprintf(&sb, "swarm_limiter_tokens %g\n", throttle.Tokens)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = w.Write([]byte(sb.String()))
+}
+
+// handleResolve approves or rejects the approval named in the URL
+func (c *Coordinator) handleResolve(w http.ResponseWriter, r *http.Request, approve bool, resolvedBy string) {
+	var req resolveRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+	}
+
+	id := r.PathValue("id")
+	if _, err := c.approvals.Get(id); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+
+	approval, err := c.approvals.Resolve(id, approve, req.Note, resolvedBy)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, approval)
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
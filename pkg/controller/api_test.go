@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func TestAPIHandlerRequiresToken(t *testing.T) {
+	c := conflictCoordinator(t)
+	approval, err := c.approvals.Request(&models.Approval{Kind: models.ApprovalKindTask, TaskID: "task-1", Title: "Deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := c.APIHandler("secret", "alice")
+
+	approve := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/approvals/"+approval.ID+"/approve",
+			strings.NewReader(`{"note": "ship it", "by": "human:bob"}`))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, authorization := range []string{"", "Bearer wrong", "secret", "Basic c2VjcmV0"} {
+		if rec := approve(authorization); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", authorization, rec.Code)
+		}
+	}
+	if got, _ := c.approvals.Get(approval.ID); got.Status != models.ApprovalStatusPending {
+		t.Fatalf("unauthenticated request resolved the approval: %+v", got)
+	}
+
+	if rec := approve("Bearer secret"); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	got, _ := c.approvals.Get(approval.ID)
+	if got.Status != models.ApprovalStatusApproved || got.Note != "ship it" {
+		t.Errorf("approval = %+v", got)
+	}
+	if got.ResolvedBy != "api:alice" {
+		t.Errorf("resolved by %q, want the server-side operator, not the request body", got.ResolvedBy)
+	}
+
+	// Read-only routes are protected too
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/approvals", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /api/approvals without token: status = %d", rec.Code)
+	}
+
+	// An empty token never opens the API
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/approvals", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	c.APIHandler("", "alice").ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("empty token: status = %d", rec.Code)
+	}
+}
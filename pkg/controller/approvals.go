@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/executor"
+	"github.com/yourusername/claude-swarm/pkg/secrets"
+)
+
+// escalateTask parks a task blocked by the risk assessment until a human decides
+func (c *Coordinator) escalateTask(agent *Agent, task *models.Task, reason *executor.ApprovalRequiredError) {
+	approval, err := c.approvals.Request(&models.Approval{
+		Kind:        models.ApprovalKindTask,
+		TaskID:      task.ID,
+		AgentID:     agent.ID,
+		Title:       fmt.Sprintf("%s risk: %s", reason.Risk, reason.Reason),
+		Context:     reason.Context,
+		RequestedBy: "risk-assessment",
+	})
+	if err != nil {
+		log.Printf("⚠️  Failed to request approval for task %s: %v", task.ID, err)
+		_ = c.taskQueue.UpdateTaskStatus(task.ID, models.TaskStatusFailed)
+		return
+	}
+
+	task.LastError = reason.Error()
+	task.Status = models.TaskStatusAwaitingApproval
+	_ = c.taskQueue.UpdateTask(task)
+
+	log.Printf("⏸️  Task %s awaiting approval (%s): %s", task.ID, approval.ID, reason.Reason)
+}
+
+// escalateMerge parks an agent's finished work until a human approves the
+// merge, for a *secrets.LeakError or a *MergeRiskError. The agent is held so
+// its worktree is not reused before the decision.
+func (c *Coordinator) escalateMerge(agent *Agent, task *models.Task, reason error) {
+	request := &models.Approval{
+		Kind:    models.ApprovalKindMerge,
+		TaskID:  task.ID,
+		AgentID: agent.ID,
+		Branch:  agent.Worktree.BranchName,
+	}
+	entry := audit.Entry{TaskID: task.ID, AgentID: agent.ID, Action: "block_merge"}
+
+	var leak *secrets.LeakError
+	var risk *MergeRiskError
+	switch {
+	case errors.As(reason, &leak):
+		request.Title = fmt.Sprintf("Merge %s despite %d secret scan finding(s)", agent.Worktree.BranchName, len(leak.Findings))
+		request.Context = secrets.FormatReport(leak.Findings)
+		request.RequestedBy = "secret-scan"
+		entry.Actor, entry.Decision, entry.Rule = "secret-scan", audit.DecisionSecretScan, "secrets.Scanner"
+	case errors.As(reason, &risk):
+		request.Title = fmt.Sprintf("Merge %s despite %s risk", agent.Worktree.BranchName, risk.Risk)
+		request.Context = strings.Join(risk.Reasons, "\n")
+		request.RequestedBy = "risk-assessment"
+		entry.Actor, entry.Decision, entry.Rule = "risk-assessment", audit.DecisionMergeRisk, "assessMergeRisk"
+	default:
+		log.Printf("⚠️  Cannot escalate merge of %s: %v", agent.ID, reason)
+		return
+	}
+
+	approval, err := c.approvals.Request(request)
+	if err != nil {
+		log.Printf("⚠️  Failed to request merge approval for %s: %v", agent.ID, err)
+		return
+	}
+
+	c.heldMu.Lock()
+	c.heldAgents[agent.ID] = approval.ID
+	c.heldMu.Unlock()
+
+	entry.Outcome = fmt.Sprintf("escalated as %s", approval.ID)
+	entry.Details = map[string]string{"report": approval.Context}
+	c.recordAudit(entry)
+
+	_ = c.taskQueue.UpdateTaskStatus(task.ID, models.TaskStatusAwaitingApproval)
+
+	log.Printf("⏸️  Merge of %s awaiting approval (%s)", agent.ID, approval.ID)
+}
+
+// isHeld reports whether an agent is waiting for a merge decision
+func (c *Coordinator) isHeld(agentID string) bool {
+	c.heldMu.Lock()
+	defer c.heldMu.Unlock()
+
+	_, held := c.heldAgents[agentID]
+	return held
+}
+
+// applyApprovals acts on task, merge, brain and conflict approvals resolved since the last call
+func (c *Coordinator) applyApprovals() {
+	for _, approval := range c.approvals.ListUnapplied(models.ApprovalKindTask) {
+		c.applyTaskApproval(approval)
+		c.recordApproval(approval)
+		_ = c.approvals.MarkApplied(approval.ID)
+	}
+
+	for _, approval := range c.approvals.ListUnapplied(models.ApprovalKindMerge) {
+		c.applyMergeApproval(approval)
+		c.recordApproval(approval)
+		_ = c.approvals.MarkApplied(approval.ID)
+	}
+
+	for _, approval := range c.approvals.ListUnapplied(models.ApprovalKindBrain) {
+		c.applyBrainApproval(approval)
+		c.recordApproval(approval)
+		_ = c.approvals.MarkApplied(approval.ID)
+	}
+
+	for _, approval := range c.approvals.ListUnapplied(models.ApprovalKindConflict) {
+		c.applyConflictApproval(approval)
+		c.recordApproval(approval)
+		_ = c.approvals.MarkApplied(approval.ID)
+	}
+}
+
+// recordApproval writes a resolved approval to the audit log
+func (c *Coordinator) recordApproval(approval *models.Approval) {
+	c.recordAudit(audit.Entry{
+		Actor:       "human:" + resolverName(approval),
+		Decision:    audit.DecisionApproval,
+		TaskID:      approval.TaskID,
+		AgentID:     approval.AgentID,
+		InputDigest: audit.Digest(approval.Context),
+		Rule:        string(approval.Kind),
+		Action:      string(approval.Status),
+		Outcome:     approval.Note,
+		Details:     map[string]string{"approval_id": approval.ID, "title": approval.Title},
+	})
+}
+
+// recordAudit appends an entry to the audit log, logging failures
+func (c *Coordinator) recordAudit(entry audit.Entry) {
+	if err := c.auditLog.Record(entry); err != nil {
+		log.Printf("⚠️  Failed to write audit entry: %v", err)
+	}
+}
+
+// applyTaskApproval requeues an approved task or fails a rejected one
+func (c *Coordinator) applyTaskApproval(approval *models.Approval) {
+	task, err := c.taskQueue.GetTask(approval.TaskID)
+	if err != nil {
+		log.Printf("⚠️  Approval %s refers to unknown task %s", approval.ID, approval.TaskID)
+		return
+	}
+
+	if approval.Status == models.ApprovalStatusApproved {
+		task.RiskApproved = true
+		task.Status = models.TaskStatusPending
+		task.AssigneeID = ""
+		log.Printf("👍 Task %s approved by %s, requeued", task.ID, resolverName(approval))
+	} else {
+		task.Status = models.TaskStatusFailed
+		log.Printf("👎 Task %s rejected by %s", task.ID, resolverName(approval))
+	}
+	task.LastError = rejectionMessage(approval)
+
+	if err := c.taskQueue.UpdateTask(task); err != nil {
+		log.Printf("⚠️  Failed to update task %s: %v", task.ID, err)
+	}
+}
+
+// applyMergeApproval merges or discards the held work of an agent
+func (c *Coordinator) applyMergeApproval(approval *models.Approval) {
+	defer func() {
+		c.heldMu.Lock()
+		delete(c.heldAgents, approval.AgentID)
+		c.heldMu.Unlock()
+	}()
+
+	var agent *Agent
+	for _, a := range c.agents {
+		if a.ID == approval.AgentID {
+			agent = a
+		}
+	}
+	if agent == nil || agent.Worktree == nil {
+		log.Printf("⚠️  Approval %s refers to unknown agent %s", approval.ID, approval.AgentID)
+		return
+	}
+
+	status := models.TaskStatusCompleted
+	if approval.Status == models.ApprovalStatusApproved {
+		if err := c.mergeAgentWork(agent, true); err != nil {
+			log.Printf("⚠️  Approved merge of %s failed: %v", agent.ID, err)
+			status = models.TaskStatusFailed
+		} else {
+			log.Printf("🔀 Merged work from %s to main (approved by %s)", agent.ID, resolverName(approval))
+		}
+	} else {
+		// Discard the work so the next task starts from a clean worktree
+		_ = c.gitCommand(agent.Worktree.Path, "reset", "--hard", "main")
+		_ = c.gitCommand(agent.Worktree.Path, "clean", "-fd")
+		status = models.TaskStatusFailed
+		log.Printf("🗑️  Discarded work from %s (merge rejected by %s)", agent.ID, resolverName(approval))
+	}
+
+	if approval.TaskID != "" {
+		_ = c.taskQueue.UpdateTaskStatus(approval.TaskID, status)
+	}
+}
+
+// applyBrainApproval passes the answer to a question of the AI brain on to
+// the task it was about, as a hint for the task's next attempt. Questions
+// about no task are only recorded in the audit log.
+func (c *Coordinator) applyBrainApproval(approval *models.Approval) {
+	if approval.TaskID == "" {
+		return
+	}
+	task, err := c.taskQueue.GetTask(approval.TaskID)
+	if err != nil {
+		log.Printf("⚠️  Approval %s refers to unknown task %s", approval.ID, approval.TaskID)
+		return
+	}
+	if task.Status == models.TaskStatusCompleted {
+		log.Printf("ℹ️  Task %s completed before %s answered the brain (%s)", task.ID, resolverName(approval), approval.ID)
+		return
+	}
+
+	proposal := approval.Context
+	if proposal == "" {
+		proposal = approval.Title
+	}
+	hint := "A human approved this proposal: " + proposal
+	if approval.Status == models.ApprovalStatusRejected {
+		hint = "A human rejected this proposal, do not do it: " + proposal
+	}
+	if approval.Note != "" {
+		hint += "\nTheir note: " + approval.Note
+	}
+
+	// A human decision is not subject to the action limits of the brain
+	if agent := c.agentRunning(task.ID); agent != nil {
+		c.actions.addHint(task.ID, hint)
+		log.Printf("💬 Answer to %s kept for the next attempt of task %s (running on %s)", approval.ID, task.ID, agent.ID)
+		return
+	}
+	task.RetryHint = joinHints(task.RetryHint, hint)
+	if err := c.taskQueue.UpdateTask(task); err != nil {
+		log.Printf("⚠️  Failed to update task %s: %v", task.ID, err)
+		return
+	}
+	log.Printf("💬 Answer to %s added to the next attempt of task %s", approval.ID, task.ID)
+}
+
+// resolverName returns who resolved an approval, for log messages
+func resolverName(approval *models.Approval) string {
+	if approval.ResolvedBy == "" {
+		return "unknown"
+	}
+	return approval.ResolvedBy
+}
+
+// rejectionMessage returns the LastError recorded for a rejected approval
+func rejectionMessage(approval *models.Approval) string {
+	if approval.Status != models.ApprovalStatusRejected {
+		return ""
+	}
+	msg := "rejected by " + resolverName(approval)
+	if approval.Note != "" {
+		msg += ": " + approval.Note
+	}
+	return msg
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func TestApplyBrainApproval(t *testing.T) {
+	c := actionCoordinator(t,
+		&models.Task{ID: "pending", Description: "Add login", Status: models.TaskStatusPending},
+		&models.Task{ID: "done", Description: "Add logout", Status: models.TaskStatusCompleted},
+	)
+
+	c.applyBrainApproval(&models.Approval{
+		ID: "apr-1", Kind: models.ApprovalKindBrain, TaskID: "pending",
+		Title: "Needs a session store", Context: "Use Redis for sessions",
+		Status: models.ApprovalStatusRejected, ResolvedBy: "alice", Note: "keep them in memory",
+	})
+	task, _ := c.taskQueue.GetTask("pending")
+	for _, want := range []string{"rejected this proposal, do not do it: Use Redis for sessions", "Their note: keep them in memory"} {
+		if !strings.Contains(task.RetryHint, want) {
+			t.Errorf("hint %q does not contain %q", task.RetryHint, want)
+		}
+	}
+
+	c.applyBrainApproval(&models.Approval{
+		ID: "apr-2", Kind: models.ApprovalKindBrain, TaskID: "done",
+		Context: "Use Redis for sessions", Status: models.ApprovalStatusApproved,
+	})
+	if task, _ := c.taskQueue.GetTask("done"); task.RetryHint != "" {
+		t.Errorf("completed task got a hint: %q", task.RetryHint)
+	}
+}
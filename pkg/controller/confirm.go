@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/executor"
+)
+
+// SetExecutorMode switches every agent to pipe or interactive execution.
+// Interactive agents escalate refused prompts to the approval queue.
+func (c *Coordinator) SetExecutorMode(mode executor.Mode) error {
+	for _, agent := range c.agents {
+		if err := agent.Executor.SetMode(mode); err != nil {
+			return err
+		}
+		if mode == executor.ModeInteractive {
+			agent.Executor.SetConfirmEscalator(c.confirmEscalator(agent))
+		}
+	}
+	return nil
+}
+
+// confirmEscalator returns an escalator that parks a prompt of agent in the
+// approval queue and waits for a human decision
+func (c *Coordinator) confirmEscalator(agent *Agent) executor.ConfirmEscalator {
+	return func(ctx context.Context, task *models.Task, prompt, reason string) (bool, error) {
+		approval, err := c.approvals.Request(&models.Approval{
+			Kind:        models.ApprovalKindConfirm,
+			TaskID:      task.ID,
+			AgentID:     agent.ID,
+			Branch:      agent.Worktree.BranchName,
+			Title:       reason,
+			Context:     prompt,
+			RequestedBy: "confirm-detector",
+		})
+		if err != nil {
+			return false, err
+		}
+
+		log.Printf("⏸️  %s waiting for approval %s", agent.ID, approval.ID)
+		agent.setState(models.AgentStateWaitingConfirm)
+		defer agent.setState(models.AgentStateWorking)
+
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-ticker.C:
+				current, err := c.approvals.Get(approval.ID)
+				if err != nil {
+					return false, err
+				}
+				if current.Status == models.ApprovalStatusPending {
+					continue
+				}
+				c.recordApproval(current)
+				_ = c.approvals.MarkApplied(approval.ID)
+				return current.Status == models.ApprovalStatusApproved, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/git"
+)
+
+const (
+	// maxConflictFileBytes is the largest conflicted file included in full
+	// in the conflict details sent to the brain
+	maxConflictFileBytes = 8000
+
+	// maxApprovalDiffBytes caps each diff shown in a conflict approval
+	maxApprovalDiffBytes = 20000
+)
+
+// ConflictResolverConfig decides whether a verified conflict resolution is
+// merged without asking a human
+type ConflictResolverConfig struct {
+	AutoMerge bool    // Merge resolutions that pass the checks; otherwise always ask
+	MaxFiles  int     // More conflicted files than this always need approval; 0 means no limit
+	Checks    []Check // Verification gate run on the resolved merge; auto-merge needs at least one
+}
+
+// ConflictProposal is a resolution of a merge conflict proposed by the brain
+type ConflictProposal struct {
+	Branch      string            // Branch whose merge into main conflicted
+	Files       map[string]string // Complete resolved content of each conflicted file
+	Summary     string
+	NeedsReview bool // The model asked for a human to review the resolution
+}
+
+// conflictPayload is stored on a conflict approval so the resolution can be
+// merged once it is approved
+type conflictPayload struct {
+	ResolveBranch string `json:"resolve_branch"`
+	Commit        string `json:"commit"`
+}
+
+// SetConflictResolver enables applying the brain's conflict resolutions
+func (c *Coordinator) SetConflictResolver(config ConflictResolverConfig) {
+	c.mergeMu.Lock()
+	defer c.mergeMu.Unlock()
+
+	c.conflictConfig = &config
+}
+
+// GetConflictDetails returns the files that conflict when branchName is
+// merged into main, and the conflict as git shows it: the diff with conflict
+// markers (diff3 style) followed by each conflicted file that is small enough
+// to be resolved as a whole. The merge runs in a scratch worktree.
+func (c *Coordinator) GetConflictDetails(branchName string) ([]string, string, error) {
+	c.mergeMu.Lock()
+	defer c.mergeMu.Unlock()
+
+	m, err := c.startScratchMerge(branchName)
+	if err != nil {
+		return nil, "", err
+	}
+	defer m.remove(false)
+
+	if len(m.conflicts) == 0 {
+		return nil, "", nil
+	}
+	return m.conflicts, m.conflictReport(), nil
+}
+
+// ApplyConflictResolution writes the brain's resolution of a conflict between
+// the branch and main on a scratch branch off main and runs the verification
+// gate on it. A green resolution is merged into main if the policy allows it;
+// any other outcome is escalated as a conflict approval showing the conflict
+// as a 3-way diff next to the proposed resolution.
+func (c *Coordinator) ApplyConflictResolution(proposal ConflictProposal) (string, error) {
+	c.mergeMu.Lock()
+	defer c.mergeMu.Unlock()
+
+	config := c.conflictConfig
+	if config == nil {
+		return "", errors.New("conflict resolver is disabled")
+	}
+
+	m, err := c.startScratchMerge(proposal.Branch)
+	if err != nil {
+		return "", err
+	}
+	if len(m.conflicts) == 0 {
+		m.remove(false)
+		return "branch no longer conflicts with main", nil
+	}
+	escalation := &conflictEscalation{proposal: proposal}
+	escalation.conflict, _ = gitOutput(m.dir, "diff", "--no-color")
+
+	unresolved, err := m.apply(proposal.Files)
+	if err != nil {
+		m.remove(false)
+		return "", err
+	}
+	if len(unresolved) > 0 {
+		m.remove(false)
+		escalation.reason = "no usable resolution for " + strings.Join(unresolved, ", ")
+		return c.escalateConflict(escalation)
+	}
+
+	msg := fmt.Sprintf("Merge branch '%s' (conflicts resolved by the orchestrator)", proposal.Branch)
+	if err := c.gitCommand(m.dir, "commit", "--no-edit", "-m", msg); err != nil {
+		m.remove(false)
+		return "", fmt.Errorf("failed to commit resolution: %w", err)
+	}
+	escalation.resolution, _ = gitOutput(m.dir, append([]string{"diff", "--no-color", "main", "HEAD", "--"}, m.conflicts...)...)
+	escalation.checks = runChecks(c.ctx, hostRunner(m.dir), config.Checks)
+	commit, _ := gitOutput(m.dir, "rev-parse", "HEAD")
+	m.remove(true)
+
+	if reason := config.refusal(proposal, m.conflicts, escalation.checks); reason != "" {
+		escalation.reason = reason
+		escalation.payload = &conflictPayload{ResolveBranch: m.resolve, Commit: strings.TrimSpace(commit)}
+		return c.escalateConflict(escalation)
+	}
+
+	defer func() { _ = c.gitCommand(c.repoPath, "branch", "-D", m.resolve) }()
+	if err := c.mergeResolution(m.resolve); err != nil {
+		c.recordResolution(proposal.Branch, "error", err.Error(), escalation.checks)
+		return "", err
+	}
+
+	log.Printf("✅ Merged %s with the orchestrator's conflict resolution (%d file(s))", proposal.Branch, len(m.conflicts))
+	c.recordResolution(proposal.Branch, "merge", "merged into main", escalation.checks)
+	return fmt.Sprintf("resolved %d conflicted file(s), checks passed, merged into main", len(m.conflicts)), nil
+}
+
+// refusal returns why a resolution may not be merged without approval, or ""
+func (config ConflictResolverConfig) refusal(proposal ConflictProposal, conflicts []string, checks []models.CheckResult) string {
+	switch {
+	case !checksPassed(checks):
+		return "verification failed"
+	case !config.AutoMerge:
+		return "auto-merge is disabled"
+	case len(config.Checks) == 0:
+		return "no verification checks configured"
+	case proposal.NeedsReview:
+		return "the model asked for human review"
+	case config.MaxFiles > 0 && len(conflicts) > config.MaxFiles:
+		return fmt.Sprintf("%d conflicted files exceed the limit of %d", len(conflicts), config.MaxFiles)
+	}
+	return ""
+}
+
+// mergeResolution merges a resolved scratch branch into main; the caller
+// holds mergeMu
+func (c *Coordinator) mergeResolution(branch string) error {
+	if _, err := c.mergeManager.MergeBranch(branch); err != nil {
+		if errors.Is(err, git.ErrMergeConflict) {
+			_ = c.mergeManager.AbortMerge()
+		}
+		return fmt.Errorf("failed to merge resolution: %w", err)
+	}
+	return nil
+}
+
+// conflictEscalation is what a conflict approval shows
+type conflictEscalation struct {
+	proposal   ConflictProposal
+	reason     string
+	conflict   string // diff with diff3 conflict markers
+	resolution string // Resolved files against main
+	checks     []models.CheckResult
+	payload    *conflictPayload // Nil if there is no resolution to merge
+}
+
+// escalateConflict opens a conflict approval; approving it merges the
+// resolution, if there is one
+func (c *Coordinator) escalateConflict(e *conflictEscalation) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reason: %s\n", e.reason)
+	if e.proposal.Summary != "" {
+		fmt.Fprintf(&b, "Resolution: %s\n", e.proposal.Summary)
+	}
+	if len(e.checks) > 0 {
+		b.WriteString("\nChecks:\n")
+		for _, check := range e.checks {
+			mark := "✓"
+			if !check.Passed {
+				mark = "✗"
+			}
+			fmt.Fprintf(&b, "  %s %s\n", mark, check.Name)
+			if check.Output != "" {
+				fmt.Fprintf(&b, "%s\n", check.Output)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "\nConflict (<<<<<<< main, ||||||| common ancestor, >>>>>>> %s):\n%s\n", e.proposal.Branch, headBytes(e.conflict, maxApprovalDiffBytes))
+	if e.resolution != "" {
+		fmt.Fprintf(&b, "\nProposed resolution (diff against main):\n%s\n", headBytes(e.resolution, maxApprovalDiffBytes))
+	}
+
+	approval := &models.Approval{
+		Kind:        models.ApprovalKindConflict,
+		AgentID:     c.branchAgent(e.proposal.Branch),
+		Branch:      e.proposal.Branch,
+		Title:       fmt.Sprintf("Resolve conflict merging %s (%s)", e.proposal.Branch, e.reason),
+		Context:     b.String(),
+		RequestedBy: "conflict-resolver",
+	}
+	if e.payload != nil {
+		payload, err := json.Marshal(e.payload)
+		if err != nil {
+			return "", err
+		}
+		approval.Payload = payload
+	}
+	approval, err := c.approvals.Request(approval)
+	if err != nil {
+		if e.payload != nil {
+			_ = c.gitCommand(c.repoPath, "branch", "-D", e.payload.ResolveBranch)
+		}
+		return "", fmt.Errorf("failed to request approval: %w", err)
+	}
+
+	log.Printf("⏸️  Conflict resolution for %s awaiting approval (%s): %s", e.proposal.Branch, approval.ID, e.reason)
+	c.recordResolution(e.proposal.Branch, "escalate", fmt.Sprintf("escalated as %s: %s", approval.ID, e.reason), e.checks)
+	return fmt.Sprintf("escalated as %s: %s", approval.ID, e.reason), nil
+}
+
+// applyConflictApproval merges an approved conflict resolution and drops
+// its scratch branch either way
+func (c *Coordinator) applyConflictApproval(approval *models.Approval) {
+	var payload conflictPayload
+	if len(approval.Payload) > 0 {
+		if err := json.Unmarshal(approval.Payload, &payload); err != nil {
+			log.Printf("⚠️  Approval %s has an invalid payload: %v", approval.ID, err)
+			return
+		}
+	}
+	if payload.ResolveBranch == "" {
+		if approval.Status == models.ApprovalStatusApproved {
+			log.Printf("ℹ️  Approval %s has no resolution to merge, resolve %s by hand", approval.ID, approval.Branch)
+		}
+		return
+	}
+	defer func() { _ = c.gitCommand(c.repoPath, "branch", "-D", payload.ResolveBranch) }()
+
+	if approval.Status != models.ApprovalStatusApproved {
+		log.Printf("🗑️  Discarded conflict resolution for %s (rejected by %s)", approval.Branch, resolverName(approval))
+		c.recordResolution(approval.Branch, "discard", "rejected by "+resolverName(approval), nil)
+		return
+	}
+
+	c.mergeMu.Lock()
+	defer c.mergeMu.Unlock()
+
+	if err := c.mergeResolution(payload.ResolveBranch); err != nil {
+		log.Printf("⚠️  Approved conflict resolution for %s failed: %v", approval.Branch, err)
+		c.recordResolution(approval.Branch, "error", err.Error(), nil)
+		return
+	}
+	log.Printf("🔀 Merged %s with the conflict resolution (approved by %s)", approval.Branch, resolverName(approval))
+	c.recordResolution(approval.Branch, "merge", "merged into main after approval", nil)
+}
+
+// recordResolution writes the outcome of a conflict resolution to the audit log
+func (c *Coordinator) recordResolution(branch, action, outcome string, checks []models.CheckResult) {
+	details := map[string]string{"branch": branch}
+	if len(checks) > 0 {
+		passed := 0
+		for _, check := range checks {
+			if check.Passed {
+				passed++
+			}
+		}
+		details["checks"] = fmt.Sprintf("%d/%d passed", passed, len(checks))
+	}
+	c.recordAudit(audit.Entry{
+		Actor:    "conflict-resolver",
+		Decision: audit.DecisionApplyResolution,
+		AgentID:  c.branchAgent(branch),
+		Action:   action,
+		Outcome:  outcome,
+		Details:  details,
+	})
+}
+
+// branchAgent returns the ID of the agent working on branch, or ""
+func (c *Coordinator) branchAgent(branch string) string {
+	for _, agent := range c.agents {
+		if agent.Worktree != nil && agent.Worktree.BranchName == branch {
+			return agent.ID
+		}
+	}
+	return ""
+}
+
+// scratchMerge is a merge of a branch into main in a temporary worktree,
+// stopped at its conflicts, so they can be inspected and resolved without
+// touching the main checkout
+type scratchMerge struct {
+	c         *Coordinator
+	resolve   string // Temporary branch off main holding the merge
+	dir       string
+	conflicts []string
+}
+
+// startScratchMerge starts merging branch into a new branch off main. The
+// caller holds mergeMu and must call remove.
+func (c *Coordinator) startScratchMerge(branch string) (*scratchMerge, error) {
+	name := strings.ReplaceAll(branch, "/", "-") + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	m := &scratchMerge{
+		c:       c,
+		resolve: "resolve/" + name,
+		dir:     filepath.Join(c.repoPath, ".worktrees", "resolve-"+name),
+	}
+	if err := c.gitCommand(c.repoPath, "worktree", "add", "-b", m.resolve, m.dir, "main"); err != nil {
+		return nil, fmt.Errorf("failed to create scratch worktree: %w", err)
+	}
+
+	// diff3 markers include the common ancestor, so the conflict reads as a 3-way diff
+	mergeErr := c.gitCommand(m.dir, "-c", "merge.conflictStyle=diff3", "merge", "--no-ff", "--no-commit", branch)
+	files, err := gitOutput(m.dir, "diff", "--name-only", "--diff-filter=U")
+	if err == nil {
+		for _, file := range strings.Split(files, "\n") {
+			if file != "" {
+				m.conflicts = append(m.conflicts, file)
+			}
+		}
+		if mergeErr != nil && len(m.conflicts) == 0 {
+			err = fmt.Errorf("merge failed: %w", mergeErr)
+		}
+	}
+	if err != nil {
+		m.remove(false)
+		return nil, err
+	}
+	return m, nil
+}
+
+// conflictReport returns the diff with conflict markers followed by the
+// conflicted files small enough to include in full
+func (m *scratchMerge) conflictReport() string {
+	diff, _ := gitOutput(m.dir, "diff", "--no-color")
+
+	var b strings.Builder
+	b.WriteString(diff)
+	for _, file := range m.conflicts {
+		content, err := os.ReadFile(filepath.Join(m.dir, file))
+		if err != nil || len(content) > maxConflictFileBytes {
+			continue
+		}
+		fmt.Fprintf(&b, "\n--- %s with conflict markers ---\n%s", file, content)
+	}
+	return b.String()
+}
+
+// apply writes and stages the resolved content of each conflicted file and
+// returns the files left without a usable resolution
+func (m *scratchMerge) apply(files map[string]string) ([]string, error) {
+	var unresolved []string
+	for _, file := range m.conflicts {
+		content, ok := files[file]
+		if !ok || hasConflictMarkers(content) {
+			unresolved = append(unresolved, file)
+			continue
+		}
+
+		path := filepath.Join(m.dir, file)
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			return nil, fmt.Errorf("failed to write resolution of %s: %w", file, err)
+		}
+		if err := m.c.gitCommand(m.dir, "add", "--", file); err != nil {
+			return nil, fmt.Errorf("failed to stage resolution of %s: %w", file, err)
+		}
+	}
+	return unresolved, nil
+}
+
+// remove deletes the scratch worktree and, unless keepBranch, its branch
+func (m *scratchMerge) remove(keepBranch bool) {
+	if err := m.c.gitCommand(m.c.repoPath, "worktree", "remove", "--force", m.dir); err != nil {
+		log.Printf("⚠️  Failed to remove scratch worktree %s: %v", m.dir, err)
+	}
+	if !keepBranch {
+		_ = m.c.gitCommand(m.c.repoPath, "branch", "-D", m.resolve)
+	}
+}
+
+// hasConflictMarkers reports whether content still has conflict marker lines
+func hasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		for _, marker := range []string{"<<<<<<< ", ">>>>>>> ", "||||||| "} {
+			if strings.HasPrefix(line, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gitOutput runs a git command in dir and returns its standard output
+func gitOutput(dir string, args ...string) (string, error) {
+	output, err := git.Command(dir, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(output), nil
+}
+
+// headBytes returns the first n bytes of s, noting the truncation
+func headBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "\n... (truncated)"
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/git"
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+// conflictCoordinator returns a coordinator for a repository whose feature
+// branch and main changed the same line of greet.txt
+func conflictCoordinator(t *testing.T) *Coordinator {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		if output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	commit := func(content, msg string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "greet.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("commit", "-qam", msg)
+	}
+
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test User")
+	if err := os.WriteFile(filepath.Join(dir, "greet.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "greet.txt")
+	run("commit", "-qm", "Initial commit")
+	run("checkout", "-qb", "feature")
+	commit("hello feature\n", "Feature greeting")
+	run("checkout", "-q", "main")
+	commit("hello main\n", "Main greeting")
+
+	repo, err := git.NewRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateDir := t.TempDir()
+	approvals, err := state.NewApprovalQueue(filepath.Join(stateDir, "approvals.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { approvals.Close() })
+	auditLog, err := audit.Open(filepath.Join(stateDir, "audit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { auditLog.Close() })
+
+	return &Coordinator{
+		repoPath:     dir,
+		mergeManager: git.NewMergeManager(repo),
+		approvals:    approvals,
+		auditLog:     auditLog,
+		ctx:          context.Background(),
+	}
+}
+
+// mainContent returns greet.txt on main and whether feature was merged
+func mainContent(t *testing.T, c *Coordinator) (string, bool) {
+	t.Helper()
+	content, err := gitOutput(c.repoPath, "show", "main:greet.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := exec.Command("git", "-C", c.repoPath, "merge-base", "--is-ancestor", "feature", "main").Run() == nil
+	return content, merged
+}
+
+// resolveBranches returns the scratch branches left in the repository
+func resolveBranches(t *testing.T, c *Coordinator) string {
+	t.Helper()
+	branches, err := gitOutput(c.repoPath, "branch", "--list", "resolve/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(branches)
+}
+
+func TestGetConflictDetails(t *testing.T) {
+	c := conflictCoordinator(t)
+
+	files, content, err := c.GetConflictDetails("feature")
+	if err != nil {
+		t.Fatalf("GetConflictDetails() error = %v", err)
+	}
+	if len(files) != 1 || files[0] != "greet.txt" {
+		t.Errorf("conflict files = %v", files)
+	}
+	for _, want := range []string{"<<<<<<< ", "||||||| ", "hello main", "hello feature"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("conflict details do not contain %q:\n%s", want, content)
+		}
+	}
+	if branches := resolveBranches(t, c); branches != "" {
+		t.Errorf("scratch branches left behind: %s", branches)
+	}
+}
+
+func TestApplyConflictResolution(t *testing.T) {
+	resolved := map[string]string{"greet.txt": "hello main and feature\n"}
+	passing := []Check{{Name: "greeting", Command: "grep -q feature greet.txt"}}
+
+	t.Run("merges a verified resolution", func(t *testing.T) {
+		c := conflictCoordinator(t)
+		c.SetConflictResolver(ConflictResolverConfig{AutoMerge: true, Checks: passing})
+
+		if _, err := c.ApplyConflictResolution(ConflictProposal{Branch: "feature", Files: resolved}); err != nil {
+			t.Fatalf("ApplyConflictResolution() error = %v", err)
+		}
+		if content, merged := mainContent(t, c); content != resolved["greet.txt"] || !merged {
+			t.Errorf("main greet.txt = %q, feature merged = %v", content, merged)
+		}
+		if branches := resolveBranches(t, c); branches != "" {
+			t.Errorf("scratch branches left behind: %s", branches)
+		}
+	})
+
+	t.Run("escalates a failed check and merges once approved", func(t *testing.T) {
+		c := conflictCoordinator(t)
+		c.SetConflictResolver(ConflictResolverConfig{AutoMerge: true, Checks: []Check{{Name: "tests", Command: "exit 1"}}})
+
+		if _, err := c.ApplyConflictResolution(ConflictProposal{Branch: "feature", Files: resolved}); err != nil {
+			t.Fatalf("ApplyConflictResolution() error = %v", err)
+		}
+		if _, merged := mainContent(t, c); merged {
+			t.Fatal("a resolution failing its checks should not be merged")
+		}
+
+		pending := c.approvals.List(models.ApprovalStatusPending)
+		if len(pending) != 1 || pending[0].Kind != models.ApprovalKindConflict {
+			t.Fatalf("pending approvals = %+v", pending)
+		}
+		for _, want := range []string{"verification failed", "✗ tests", "||||||| ", "+hello main and feature"} {
+			if !strings.Contains(pending[0].Context, want) {
+				t.Errorf("approval context does not contain %q:\n%s", want, pending[0].Context)
+			}
+		}
+
+		if _, err := c.approvals.Resolve(pending[0].ID, true, "", "alice"); err != nil {
+			t.Fatal(err)
+		}
+		c.applyApprovals()
+		if content, merged := mainContent(t, c); content != resolved["greet.txt"] || !merged {
+			t.Errorf("main greet.txt = %q, feature merged = %v after approval", content, merged)
+		}
+		if branches := resolveBranches(t, c); branches != "" {
+			t.Errorf("scratch branches left behind: %s", branches)
+		}
+	})
+
+	t.Run("escalates when the policy asks for a human", func(t *testing.T) {
+		c := conflictCoordinator(t)
+		c.SetConflictResolver(ConflictResolverConfig{AutoMerge: true, Checks: passing})
+
+		if _, err := c.ApplyConflictResolution(ConflictProposal{Branch: "feature", Files: resolved, NeedsReview: true}); err != nil {
+			t.Fatalf("ApplyConflictResolution() error = %v", err)
+		}
+		pending := c.approvals.List(models.ApprovalStatusPending)
+		if len(pending) != 1 || !strings.Contains(pending[0].Title, "asked for human review") {
+			t.Fatalf("pending approvals = %+v", pending)
+		}
+
+		// Rejecting drops the resolution
+		if _, err := c.approvals.Resolve(pending[0].ID, false, "", "alice"); err != nil {
+			t.Fatal(err)
+		}
+		c.applyApprovals()
+		if _, merged := mainContent(t, c); merged {
+			t.Error("a rejected resolution should not be merged")
+		}
+		if branches := resolveBranches(t, c); branches != "" {
+			t.Errorf("scratch branches left behind: %s", branches)
+		}
+	})
+
+	t.Run("escalates files without a usable resolution", func(t *testing.T) {
+		c := conflictCoordinator(t)
+		c.SetConflictResolver(ConflictResolverConfig{AutoMerge: true, Checks: passing})
+
+		markers := map[string]string{"greet.txt": "<<<<<<< HEAD\nhello main\n=======\nhello feature\n>>>>>>> feature\n"}
+		if _, err := c.ApplyConflictResolution(ConflictProposal{Branch: "feature", Files: markers}); err != nil {
+			t.Fatalf("ApplyConflictResolution() error = %v", err)
+		}
+		pending := c.approvals.List(models.ApprovalStatusPending)
+		if len(pending) != 1 || len(pending[0].Payload) != 0 || !strings.Contains(pending[0].Title, "no usable resolution for greet.txt") {
+			t.Fatalf("pending approvals = %+v", pending)
+		}
+		if branches := resolveBranches(t, c); branches != "" {
+			t.Errorf("scratch branches left behind: %s", branches)
+		}
+	})
+}
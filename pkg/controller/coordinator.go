@@ -2,17 +2,23 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os/exec"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/analyzer"
+	"github.com/yourusername/claude-swarm/pkg/audit"
 	"github.com/yourusername/claude-swarm/pkg/executor"
 	"github.com/yourusername/claude-swarm/pkg/git"
 	"github.com/yourusername/claude-swarm/pkg/retry"
+	"github.com/yourusername/claude-swarm/pkg/secrets"
 	"github.com/yourusername/claude-swarm/pkg/state"
 )
 
@@ -24,9 +30,34 @@ type Coordinator struct {
 	retryManager     *retry.RetryManager
 	mergeManager     *git.MergeManager
 	mainRepo         *git.Repository
+	secretScanner    *secrets.Scanner
+	approvals        *state.ApprovalQueue
+	auditLog         *audit.Log
+	sandbox          *executor.Sandbox
 	repoPath         string
 	mergeMu          sync.Mutex // Protect concurrent merge operations
 
+	// Agents whose work is parked behind a pending merge approval (agentID -> approvalID)
+	heldAgents map[string]string
+	heldMu     sync.Mutex
+
+	// Shared across agents so they back off together when the API pushes back
+	limiter      *retry.Limiter
+	breaker      *retry.CircuitBreaker
+	throttlePath string
+	throttleMu   sync.Mutex // Serializes writes of the throttle status file
+
+	// Guardrails and pending hints of the brain's actions on agents
+	actions *actionGuard
+
+	// Reviews the work of successful attempts before tasks are marked done
+	reviewer     Reviewer
+	reviewConfig ReviewConfig
+	reviewMu     sync.Mutex
+
+	// Applies the brain's conflict resolutions when set; guarded by mergeMu
+	conflictConfig *ConflictResolverConfig
+
 	ctx              context.Context
 	cancel           context.CancelFunc
 	wg               sync.WaitGroup
@@ -43,6 +74,44 @@ func NewCoordinator(repoPath string, taskQueuePath string, numAgents int) (*Coor
 		return nil, fmt.Errorf("failed to create task queue: %w", err)
 	}
 
+	// Initialize approval queue (stored next to the task queue)
+	approvals, err := state.NewApprovalQueue(state.ApprovalsPathFor(taskQueuePath))
+	if err != nil {
+		cancel()
+		taskQueue.Close()
+		return nil, fmt.Errorf("failed to create approval queue: %w", err)
+	}
+
+	// Initialize audit log
+	auditLog, err := audit.Open(audit.DirFor(repoPath))
+	if err != nil {
+		cancel()
+		taskQueue.Close()
+		approvals.Close()
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	// Per-agent environment policies and the secret store they reference
+	envPolicies, err := executor.LoadEnvPolicyFile(filepath.Join(repoPath, ".swarm", "env.yaml"))
+	if err != nil {
+		cancel()
+		taskQueue.Close()
+		approvals.Close()
+		auditLog.Close()
+		return nil, err
+	}
+	secretStore := secrets.NewStore(secrets.DefaultStorePath(), secrets.Default())
+
+	// Extra matchers for classifying failed runs
+	errorClassifier, err := analyzer.LoadErrorClassifier(filepath.Join(repoPath, ".swarm", "errors.yaml"))
+	if err != nil {
+		cancel()
+		taskQueue.Close()
+		approvals.Close()
+		auditLog.Close()
+		return nil, err
+	}
+
 	// Initialize worktree manager
 	worktreeManager, err := git.NewWorktreeManager(git.WorktreeConfig{
 		BaseRepoPath:    repoPath,
@@ -54,12 +123,18 @@ func NewCoordinator(repoPath string, taskQueuePath string, numAgents int) (*Coor
 		return nil, fmt.Errorf("failed to create worktree manager: %w", err)
 	}
 
-	// Initialize retry manager
+	// Initialize retry manager with the per-category policies
+	retryPolicies, err := retry.LoadPolicies(filepath.Join(repoPath, ".swarm", "retry.yaml"))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
 	retryManager := retry.NewRetryManager(retry.RetryConfig{
 		MaxRetries:    3,
 		InitialDelay:  5 * time.Second,
 		MaxDelay:      5 * time.Minute,
 		BackoffFactor: 2.0,
+		Policies:      retryPolicies,
 	})
 
 	// Initialize main repository and merge manager
@@ -77,10 +152,18 @@ func NewCoordinator(repoPath string, taskQueuePath string, numAgents int) (*Coor
 		retryManager:    retryManager,
 		mergeManager:    mergeManager,
 		mainRepo:        mainRepo,
+		secretScanner:   secrets.Default(),
+		approvals:       approvals,
+		auditLog:        auditLog,
 		repoPath:        repoPath,
+		heldAgents:      make(map[string]string),
+		throttlePath:    state.ThrottlePathFor(taskQueuePath),
+		actions:         newActionGuard(DefaultActionLimits()),
+		reviewConfig:    DefaultReviewConfig(),
 		ctx:             ctx,
 		cancel:          cancel,
 	}
+	c.SetThrottle(retry.LimiterConfig{}, retry.DefaultBreakerConfig())
 
 	// Create agents
 	for i := 0; i < numAgents; i++ {
@@ -95,6 +178,9 @@ func NewCoordinator(repoPath string, taskQueuePath string, numAgents int) (*Coor
 
 		// Create agent
 		agent := NewAgent(agentID, worktree, worktree.Path)
+		agent.Executor.SetAuditLog(auditLog)
+		agent.Executor.SetEnvPolicy(envPolicies.PolicyFor(agentID), secretStore)
+		agent.Executor.SetErrorClassifier(errorClassifier)
 		c.agents = append(c.agents, agent)
 
 		log.Printf("✓ Created agent: %s (worktree: %s)", agentID, worktree.Path)
@@ -103,6 +189,35 @@ func NewCoordinator(repoPath string, taskQueuePath string, numAgents int) (*Coor
 	return c, nil
 }
 
+// SetSandbox runs every agent inside a sandbox built from cfg
+func (c *Coordinator) SetSandbox(cfg executor.SandboxConfig) error {
+	sandbox, err := executor.NewSandbox(cfg)
+	if err != nil {
+		return err
+	}
+
+	c.sandbox.Close()
+	c.sandbox = sandbox
+	for _, agent := range c.agents {
+		agent.Executor.SetSandbox(sandbox)
+	}
+	return nil
+}
+
+// SetResourceLimits sets the resource limits of every agent
+func (c *Coordinator) SetResourceLimits(limits models.ResourceLimits) {
+	for _, agent := range c.agents {
+		agent.Executor.SetResourceLimits(limits)
+	}
+}
+
+// SetThrottle replaces the shared task start limiter and circuit breaker
+func (c *Coordinator) SetThrottle(limits retry.LimiterConfig, breaker retry.BreakerConfig) {
+	c.limiter = retry.NewLimiter(limits)
+	c.breaker = retry.NewCircuitBreaker(breaker)
+	c.breaker.OnStateChange(c.onBreakerChange)
+}
+
 // Start starts the coordinator
 func (c *Coordinator) Start() error {
 	log.Println("🚀 Starting Claude Swarm Coordinator")
@@ -137,28 +252,53 @@ func (c *Coordinator) runScheduler() {
 			return
 
 		case <-ticker.C:
+			// Act on approvals resolved since the last tick
+			c.applyApprovals()
+
 			// Check for idle agents and assign tasks
-			for _, agent := range c.agents {
-				if agent.IsIdle() {
-					// Try to claim a task from the queue
-					task, err := c.taskQueue.ClaimTask(agent.ID)
-					if err != nil {
-						log.Printf("⚠️  Failed to claim task for %s: %v", agent.ID, err)
-						continue
-					}
+			c.assignTasks()
+			c.saveThrottleStatus()
+		}
+	}
+}
 
-					if task != nil {
-						// Send task to agent's work channel
-						select {
-						case agent.taskChan <- task:
-							log.Printf("📋 Assigned task %s to %s", task.ID, agent.ID)
-						default:
-							// Channel full, task will be retried next cycle
-							_ = c.taskQueue.UpdateTaskStatus(task.ID, models.TaskStatusPending)
-						}
-					}
-				}
+// assignTasks claims a task for every idle agent, as far as the circuit
+// breaker and the start limiter allow
+func (c *Coordinator) assignTasks() {
+	for _, agent := range c.agents {
+		if !agent.IsIdle() || c.isHeld(agent.ID) {
+			continue
+		}
+
+		// Nothing more starts this tick while the breaker is open or the bucket is empty
+		if !c.breaker.Allow() {
+			return
+		}
+		if !c.limiter.Acquire() {
+			c.breaker.Release()
+			return
+		}
+
+		// Try to claim a task from the queue
+		task, err := c.taskQueue.ClaimTask(agent.ID)
+		if err != nil || task == nil {
+			if err != nil {
+				log.Printf("⚠️  Failed to claim task for %s: %v", agent.ID, err)
 			}
+			c.limiter.Refund()
+			c.breaker.Release()
+			continue
+		}
+
+		// Send task to agent's work channel
+		select {
+		case agent.taskChan <- task:
+			log.Printf("📋 Assigned task %s to %s", task.ID, agent.ID)
+		default:
+			// Channel full, task will be retried next cycle
+			c.limiter.Refund()
+			c.breaker.Release()
+			_ = c.taskQueue.UpdateTaskStatus(task.ID, models.TaskStatusPending)
 		}
 	}
 }
@@ -175,57 +315,327 @@ func (c *Coordinator) runAgentWorker(agent *Agent) {
 			log.Printf("👷 Worker stopped for %s", agent.ID)
 			return
 
+		case <-agent.wake:
+			// Restart requested while the agent had no task
+			if intr := agent.takeInterrupt(""); intr != nil {
+				c.restartIdle(agent, intr)
+			}
+
 		case task := <-agent.taskChan:
-			// Execute task
-			err := agent.ExecuteTask(task)
+			c.runTask(agent, task)
+		}
+	}
+}
 
-			if err != nil {
-				// Check if error is retryable
-				if _, ok := err.(*executor.RetryableError); ok {
-					// Update task for retry
-					task.RetryCount++
-					task.LastError = err.Error()
-
-					if c.retryManager.ShouldRetry(task, nil) {
-						delay := c.retryManager.CalculateDelay(task.RetryCount - 1)
-						log.Printf("🔄 Task %s will retry in %s (attempt %d/%d)",
-							task.ID, delay, task.RetryCount, task.MaxRetries)
-
-						// Schedule retry
-						go func() {
-							time.Sleep(delay)
-							_ = c.taskQueue.UpdateTaskStatus(task.ID, models.TaskStatusPending)
-						}()
-					} else {
-						// Max retries reached
-						log.Printf("❌ Task %s failed after %d retries", task.ID, task.RetryCount)
-						_ = c.taskQueue.UpdateTaskStatus(task.ID, models.TaskStatusFailed)
-					}
-				} else {
-					// Non-retryable error
-					log.Printf("❌ Task %s failed: %v", task.ID, err)
-					_ = c.taskQueue.UpdateTaskStatus(task.ID, models.TaskStatusFailed)
-				}
-			} else {
-				// Task completed successfully
-				log.Printf("✅ Task %s completed by %s", task.ID, agent.ID)
+// runTask runs one attempt of task on agent and everything that follows it:
+// verification, review, merge or failure handling. The agent stays busy
+// until all of it is done.
+func (c *Coordinator) runTask(agent *Agent, task *models.Task) {
+	defer agent.markIdle()
 
-				// Update task status
-				_ = c.taskQueue.UpdateTaskStatus(task.ID, models.TaskStatusCompleted)
+	// Execute task; retries get a prompt describing the last failure
+	attempt := c.startAttempt(agent, task)
+	err := agent.ExecuteTask(task, attempt.Prompt)
 
-				// Merge agent's work back to main
-				if err := c.mergeAgentWork(agent); err != nil {
-					log.Printf("⚠️  Failed to merge work from %s: %v", agent.ID, err)
-				} else {
-					log.Printf("🔀 Merged work from %s to main", agent.ID)
-				}
+	// Stopped by a brain action: requeue without counting a failure
+	if intr := agent.takeInterrupt(task.ID); intr != nil {
+		if err != nil {
+			c.limiter.Done()
+			c.breaker.Release()
+			c.requeueInterrupted(agent, task, attempt, intr, err)
+			return
+		}
+		log.Printf("ℹ️  Task %s finished on %s before it could be stopped", task.ID, agent.ID)
+	}
+
+	c.limiter.Done()
+
+	// Done according to the agent; flag changes outside the task's scope
+	// and hold the work to its definition of done
+	var unmet *VerificationError
+	if err == nil {
+		c.checkScope(agent, task, attempt)
+		if unmet = c.verifyWork(agent, task, attempt); unmet != nil {
+			log.Printf("✗ Task %s finished on %s: %v", task.ID, agent.ID, unmet)
+			err = unmet
+		}
+	}
+
+	var failure *models.Failure
+	if unmet != nil {
+		failure = unmet.Failure()
+		task.Failure = failure
+	} else if err != nil {
+		failure = executor.FailureOf(err)
+		task.Failure = failure
+	}
+
+	var approvalErr *executor.ApprovalRequiredError
+	if !errors.As(err, &approvalErr) {
+		// The agent ran; blocked tasks never started
+		c.finishAttempt(agent, task, attempt, err)
+		c.breaker.Record(failure)
+		if unmet != nil {
+			// Rejected work must not ride along with the agent's next merge
+			c.discardWork(agent, attempt)
+		}
+	} else {
+		// Never reached the API, so it proves nothing to the breaker
+		c.breaker.Release()
+	}
+
+	if approvalErr != nil {
+		// Blocked by the risk assessment: park the task for a human
+		c.escalateTask(agent, task, approvalErr)
+	} else if err != nil {
+		c.handleTaskFailure(agent, task, err)
+	} else if c.reviewWork(agent, task) {
+		// Sent back to the agent with the reviewer's instructions
+	} else {
+		// Task completed successfully
+		log.Printf("✅ Task %s completed by %s", task.ID, agent.ID)
+
+		// Update task status and history
+		task.Status = models.TaskStatusCompleted
+		_ = c.taskQueue.UpdateTask(task)
+
+		// Merge agent's work back to main
+		if err := c.mergeAgentWork(agent, false); err != nil {
+			var leak *secrets.LeakError
+			var risk *MergeRiskError
+			if errors.As(err, &leak) {
+				log.Printf("🔐 Merge of %s blocked by secret scan (task %s):\n%s",
+					agent.ID, task.ID, secrets.FormatReport(leak.Findings))
+				c.escalateMerge(agent, task, leak)
+			} else if errors.As(err, &risk) {
+				log.Printf("🔐 Merge of %s blocked by the risk assessment (task %s): %v", agent.ID, task.ID, risk)
+				c.escalateMerge(agent, task, risk)
+			} else if errors.Is(err, git.ErrMergeConflict) {
+				log.Printf("⚠️  Failed to merge work from %s: %v", agent.ID, err)
+				c.recordMergeConflict(agent.Worktree.BranchName)
+			} else {
+				log.Printf("⚠️  Failed to merge work from %s: %v", agent.ID, err)
 			}
+		} else {
+			log.Printf("🔀 Merged work from %s to main", agent.ID)
+			c.recordMergedCommits(task.ID, attempt)
 		}
 	}
 }
 
-// mergeAgentWork merges an agent's work back to the main branch
-func (c *Coordinator) mergeAgentWork(agent *Agent) error {
+// onBreakerChange logs and audits circuit breaker transitions
+func (c *Coordinator) onBreakerChange(from, to models.BreakerState) {
+	var status models.ThrottleStatus
+	c.breaker.Snapshot(&status)
+
+	switch to {
+	case models.BreakerOpen:
+		log.Printf("⛔ Circuit breaker open after %d consecutive %s failures, pausing task starts until %s",
+			status.ConsecutiveFailures, status.LastCategory, status.OpenUntil.Format("15:04:05"))
+	case models.BreakerHalfOpen:
+		log.Printf("◐ Circuit breaker half-open, sending a single probe task")
+	case models.BreakerClosed:
+		log.Printf("✅ Circuit breaker closed, resuming task starts")
+	}
+
+	c.recordAudit(audit.Entry{
+		Actor:    "circuit-breaker",
+		Decision: audit.DecisionThrottle,
+		Rule:     string(status.LastCategory),
+		Action:   string(to),
+		Outcome:  fmt.Sprintf("%s -> %s", from, to),
+		Details:  map[string]string{"consecutive_failures": strconv.Itoa(status.ConsecutiveFailures)},
+	})
+	c.saveThrottleStatus()
+}
+
+// ThrottleStatus returns a snapshot of the shared limiter and circuit breaker
+func (c *Coordinator) ThrottleStatus() *models.ThrottleStatus {
+	status := &models.ThrottleStatus{UpdatedAt: time.Now()}
+	c.breaker.Snapshot(status)
+	status.Running, status.Tokens = c.limiter.Snapshot()
+	config := c.limiter.Config()
+	status.MaxConcurrent = config.MaxConcurrent
+	status.StartsPerMin = config.StartsPerMinute
+	return status
+}
+
+// saveThrottleStatus publishes the throttle status for the monitor
+func (c *Coordinator) saveThrottleStatus() {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	if err := state.SaveThrottleStatus(c.throttlePath, c.ThrottleStatus()); err != nil {
+		log.Printf("⚠️  Failed to save throttle status: %v", err)
+	}
+}
+
+// handleTaskFailure records a failed attempt and either schedules a retry
+// according to the retry policy of the failure's category or fails the task.
+// The retry is persisted as the task's NotBefore time, so it survives a
+// coordinator restart.
+func (c *Coordinator) handleTaskFailure(agent *Agent, task *models.Task, err error) {
+	decision := c.retryManager.Decide(task, task.Failure)
+	task.RetryCount++
+	task.LastError = err.Error()
+
+	entry := audit.Entry{
+		Actor:    "retry-policy",
+		Decision: audit.DecisionRetry,
+		TaskID:   task.ID,
+		AgentID:  agent.ID,
+		Rule:     string(task.Failure.Category),
+		Action:   "fail",
+		Outcome:  decision.Reason,
+		Details:  map[string]string{"matcher": task.Failure.Matcher, "attempt": strconv.Itoa(task.RetryCount)},
+	}
+
+	if !decision.Retry {
+		log.Printf("❌ Task %s failed (%s, %s): %v", task.ID, task.Failure.Category, decision.Reason, err)
+		task.Status = models.TaskStatusFailed
+		_ = c.taskQueue.UpdateTask(task)
+		c.recordAudit(entry)
+		return
+	}
+
+	entry.Action = "retry"
+	entry.Details["delay"] = decision.Delay.Round(time.Second).String()
+	if decision.SwitchAgent {
+		entry.Details["switch_agent"] = "true"
+	}
+	c.recordAudit(entry)
+
+	task.Status = models.TaskStatusPending
+	task.AssigneeID = ""
+	task.NotBefore = time.Now().Add(decision.Delay)
+	task.AvoidAgent = ""
+	if decision.SwitchAgent && len(c.agents) > 1 {
+		task.AvoidAgent = agent.ID
+	}
+	_ = c.taskQueue.UpdateTask(task)
+
+	log.Printf("🔄 Task %s will retry in %s (%s)", task.ID, decision.Delay.Round(time.Second), decision.Reason)
+}
+
+// startAttempt opens a new attempt record for task on agent
+func (c *Coordinator) startAttempt(agent *Agent, task *models.Task) *attemptRun {
+	run := &attemptRun{Attempt: models.Attempt{
+		Number:    len(task.Attempts) + 1,
+		AgentID:   agent.ID,
+		StartedAt: time.Now(),
+		Prompt:    retry.BuildPrompt(task),
+	}}
+	if agent.Worktree != nil {
+		run.Worktree = agent.Worktree.Path
+		run.Branch = agent.Worktree.BranchName
+		if repo, err := git.NewRepository(agent.Worktree.Path); err == nil {
+			run.startCommit, _ = repo.GetCurrentCommit()
+		}
+	}
+	return run
+}
+
+// attemptRun is an attempt in progress
+type attemptRun struct {
+	models.Attempt
+	startCommit string // Worktree HEAD when the attempt started
+}
+
+// finishAttempt completes the attempt record from the executor's last run
+// and appends it to the task's history
+func (c *Coordinator) finishAttempt(agent *Agent, task *models.Task, run *attemptRun, err error) {
+	attempt := run.Attempt
+	attempt.FinishedAt = time.Now()
+
+	info := agent.Executor.LastRun()
+	attempt.ExitCode = info.ExitCode
+	attempt.CostUSD = info.CostUSD
+	attempt.TranscriptPath = c.writeTranscript(task.ID, attempt.Number, info.Output)
+
+	if err != nil {
+		attempt.Failure = task.Failure
+		attempt.Output = agent.Executor.GetRecentOutput(retry.PromptOutputLines)
+		attempt.DiffStat = c.worktreeDiffStat(agent)
+	}
+	attempt.Commits = c.commitsSince(attempt.Worktree, run.startCommit)
+
+	task.Attempts = append(task.Attempts, attempt)
+	task.RetryHint = c.actions.takeHints(task.ID)
+}
+
+// recordMergedCommits updates the last attempt with the commits made while
+// merging its work, e.g. the auto-commit of uncommitted changes
+func (c *Coordinator) recordMergedCommits(taskID string, run *attemptRun) {
+	// Reload: the merge may have changed the task's status meanwhile
+	task, err := c.taskQueue.GetTask(taskID)
+	if err != nil {
+		return
+	}
+	last := task.LastAttempt()
+	if last == nil || last.Number != run.Number {
+		return
+	}
+	last.Commits = c.commitsSince(last.Worktree, run.startCommit)
+	_ = c.taskQueue.UpdateTask(task)
+}
+
+// commitsSince lists the commits made in worktree after start
+func (c *Coordinator) commitsSince(worktree, start string) []string {
+	if worktree == "" || start == "" {
+		return nil
+	}
+	repo, err := git.NewRepository(worktree)
+	if err != nil {
+		return nil
+	}
+	commits, err := repo.CommitsSince(start)
+	if err != nil {
+		log.Printf("⚠️  Failed to list commits in %s: %v", worktree, err)
+		return nil
+	}
+	return commits
+}
+
+// writeTranscript saves the output of an attempt under .swarm/transcripts
+// and returns its path, or "" if there is nothing to save
+func (c *Coordinator) writeTranscript(taskID string, number int, output string) string {
+	if output == "" {
+		return ""
+	}
+	dir := filepath.Join(c.repoPath, ".swarm", "transcripts")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Printf("⚠️  Failed to create transcript directory: %v", err)
+		return ""
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-attempt-%d.log", taskID, number))
+	if err := os.WriteFile(path, []byte(output), 0600); err != nil {
+		log.Printf("⚠️  Failed to write transcript: %v", err)
+		return ""
+	}
+	return path
+}
+
+// worktreeDiffStat summarizes what an agent changed against main
+func (c *Coordinator) worktreeDiffStat(agent *Agent) string {
+	if agent.Worktree == nil {
+		return ""
+	}
+	repo, err := git.NewRepository(agent.Worktree.Path)
+	if err != nil {
+		return ""
+	}
+	stat, err := repo.DiffStat("main")
+	if err != nil {
+		log.Printf("⚠️  Failed to summarize changes of %s: %v", agent.ID, err)
+		return ""
+	}
+	return stat
+}
+
+// mergeAgentWork merges an agent's work back to the main branch.
+// approved is only set when a human approved the merge despite secret scan
+// findings or a high risk assessment, and skips both checks.
+func (c *Coordinator) mergeAgentWork(agent *Agent, approved bool) error {
 	if agent.Worktree == nil {
 		return fmt.Errorf("agent %s has no worktree", agent.ID)
 	}
@@ -241,14 +651,26 @@ func (c *Coordinator) mergeAgentWork(agent *Agent) error {
 		return fmt.Errorf("failed to check worktree status: %w", err)
 	}
 
-	// 2. If there are uncommitted changes, commit them first
+	// 2. Stage uncommitted changes so the secret scan and risk assessment see them
 	if !isClean {
-		log.Printf("📝 Agent %s has uncommitted changes, committing...", agent.ID)
-
-		// Stage all changes
 		if err := c.gitCommand(agent.Worktree.Path, "add", "-A"); err != nil {
 			return fmt.Errorf("failed to stage changes: %w", err)
 		}
+	}
+
+	// 3. Scan and assess everything that would reach main before committing or merging
+	if !approved {
+		if err := c.scanForSecrets(agent.Worktree.Path); err != nil {
+			return err
+		}
+		if err := c.assessMerge(agent.Worktree.Path); err != nil {
+			return err
+		}
+	}
+
+	// 4. If there are uncommitted changes, commit them first
+	if !isClean {
+		log.Printf("📝 Agent %s has uncommitted changes, committing...", agent.ID)
 
 		// Commit
 		commitMsg := fmt.Sprintf("Agent %s: Auto-commit task work", agent.ID)
@@ -257,7 +679,7 @@ func (c *Coordinator) mergeAgentWork(agent *Agent) error {
 		}
 	}
 
-	// 3. Check if there are any commits to merge (compare with main)
+	// 5. Check if there are any commits to merge (compare with main)
 	hasCommits, err := c.hasNewCommits(agent.Worktree.BranchName)
 	if err != nil {
 		return fmt.Errorf("failed to check for new commits: %w", err)
@@ -268,7 +690,7 @@ func (c *Coordinator) mergeAgentWork(agent *Agent) error {
 		return nil
 	}
 
-	// 4. Merge the agent's branch into main (with lock to prevent concurrent merges)
+	// 6. Merge the agent's branch into main (with lock to prevent concurrent merges)
 	c.mergeMu.Lock()
 	defer c.mergeMu.Unlock()
 
@@ -279,7 +701,7 @@ func (c *Coordinator) mergeAgentWork(agent *Agent) error {
 		if err == git.ErrMergeConflict {
 			log.Printf("⚠️  Merge conflict detected for %s, aborting merge", agent.ID)
 			_ = c.mergeManager.AbortMerge()
-			return fmt.Errorf("merge conflict: %v", result.Conflicts)
+			return fmt.Errorf("%w: %v", git.ErrMergeConflict, result.Conflicts)
 		}
 		return fmt.Errorf("merge failed: %w", err)
 	}
@@ -293,11 +715,62 @@ func (c *Coordinator) mergeAgentWork(agent *Agent) error {
 	return nil
 }
 
+// recordMergeConflict counts a merge conflict against the task that most
+// recently ran on branch, so repeated conflicts can trigger re-planning.
+// Agents reuse their branch, so earlier tasks on it are skipped.
+func (c *Coordinator) recordMergeConflict(branch string) {
+	latest := c.lastTaskOnBranch(branch)
+	if latest == nil {
+		return
+	}
+	latest.MergeConflicts++
+	if err := c.taskQueue.UpdateTask(latest); err != nil {
+		log.Printf("⚠️  Failed to record merge conflict for task %s: %v", latest.ID, err)
+	}
+}
+
+// lastTaskOnBranch returns the task that most recently ran on branch
+func (c *Coordinator) lastTaskOnBranch(branch string) *models.Task {
+	var latest *models.Task
+	for _, task := range c.taskQueue.ListTasks() {
+		last := task.LastAttempt()
+		if last == nil || last.Branch != branch {
+			continue
+		}
+		if latest == nil || last.StartedAt.After(latest.LastAttempt().StartedAt) {
+			latest = task
+		}
+	}
+	return latest
+}
+
+// agentForBranch returns the agent working on branch, if any
+func (c *Coordinator) agentForBranch(branch string) *Agent {
+	for _, agent := range c.agents {
+		if agent.Worktree != nil && agent.Worktree.BranchName == branch {
+			return agent
+		}
+	}
+	return nil
+}
+
+// scanForSecrets scans the staged and committed changes of a worktree against main
+func (c *Coordinator) scanForSecrets(worktreePath string) error {
+	diff, err := stagedDiff(worktreePath)
+	if err != nil {
+		return fmt.Errorf("failed to diff worktree for secret scan: %w", err)
+	}
+
+	findings := c.secretScanner.ScanDiff(diff)
+	if len(findings) > 0 {
+		return &secrets.LeakError{Findings: findings}
+	}
+	return nil
+}
+
 // gitCommand executes a git command in the specified directory
 func (c *Coordinator) gitCommand(dir string, args ...string) error {
-	cmdArgs := append([]string{"-C", dir}, args...)
-	cmd := exec.Command("git", cmdArgs...)
-	output, err := cmd.CombinedOutput()
+	output, err := git.Command(dir, args...).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, string(output))
 	}
@@ -306,7 +779,7 @@ func (c *Coordinator) gitCommand(dir string, args ...string) error {
 
 // hasNewCommits checks if a branch has commits that are not in main
 func (c *Coordinator) hasNewCommits(branchName string) (bool, error) {
-	cmd := exec.Command("git", "-C", c.repoPath, "rev-list", "--count", "main.."+branchName)
+	cmd := git.Command(c.repoPath, "rev-list", "--count", "main.."+branchName)
 	output, err := cmd.Output()
 	if err != nil {
 		return false, err
@@ -370,6 +843,11 @@ func (c *Coordinator) Cleanup() error {
 	if c.taskQueue != nil {
 		c.taskQueue.Close()
 	}
+	if c.approvals != nil {
+		c.approvals.Close()
+	}
+	c.auditLog.Close()
+	c.sandbox.Close()
 
 	log.Println("✓ Cleanup complete")
 	return nil
@@ -389,12 +867,23 @@ func (c *Coordinator) GetTaskQueue() *state.TaskQueue {
 	return c.taskQueue
 }
 
+// GetAuditLog returns the audit log shared by the agents and the brain
+func (c *Coordinator) GetAuditLog() *audit.Log {
+	return c.auditLog
+}
+
+// GetApprovalQueue returns the human approval queue
+func (c *Coordinator) GetApprovalQueue() *state.ApprovalQueue {
+	return c.approvals
+}
+
 // GetMergeStatuses 获取所有 agent 分支的合并状态
 func (c *Coordinator) GetMergeStatuses() []*MergeStatus {
 	var statuses []*MergeStatus
 
 	for _, agent := range c.agents {
-		if agent.Worktree == nil {
+		// Work waiting for a merge approval is not the brain's to merge
+		if agent.Worktree == nil || c.isHeld(agent.ID) {
 			continue
 		}
 
@@ -416,14 +905,14 @@ func (c *Coordinator) GetMergeStatuses() []*MergeStatus {
 		hasCommits, _ := c.hasNewCommits(agent.Worktree.BranchName)
 		if hasCommits {
 			// 获取提交数量
-			cmd := exec.Command("git", "-C", c.repoPath, "rev-list", "--count", "main.."+agent.Worktree.BranchName)
+			cmd := git.Command(c.repoPath, "rev-list", "--count", "main.."+agent.Worktree.BranchName)
 			output, err := cmd.Output()
 			if err == nil {
 				fmt.Sscanf(strings.TrimSpace(string(output)), "%d", &status.CommitCount)
 			}
 
 			// 获取修改的文件
-			cmd = exec.Command("git", "-C", c.repoPath, "diff", "--name-only", "main.."+agent.Worktree.BranchName)
+			cmd = git.Command(c.repoPath, "diff", "--name-only", "main.."+agent.Worktree.BranchName)
 			output, err = cmd.Output()
 			if err == nil {
 				files := strings.Split(strings.TrimSpace(string(output)), "\n")
@@ -444,11 +933,28 @@ func (c *Coordinator) GetMergeStatuses() []*MergeStatus {
 	return statuses
 }
 
-// MergeBranch 合并指定分支到 main（供外部调用）
+// MergeBranch 合并指定分支到 main（供外部调用）。等待合并审批的 Agent 的分支不会被合并；
+// 和 Agent 完成任务后的合并一样先做密钥扫描和风险评估，不通过时转人工审批
 func (c *Coordinator) MergeBranch(branchName string) error {
 	c.mergeMu.Lock()
 	defer c.mergeMu.Unlock()
 
+	agent := c.agentForBranch(branchName)
+	if agent != nil && c.isHeld(agent.ID) {
+		return fmt.Errorf("%w: %s is awaiting a merge approval", ErrMergeHeld, branchName)
+	}
+	if err := c.checkBranch(branchName); err != nil {
+		var leak *secrets.LeakError
+		var risk *MergeRiskError
+		if errors.As(err, &leak) || errors.As(err, &risk) {
+			log.Printf("🔐 Merge of %s blocked: %v", branchName, err)
+			if task := c.lastTaskOnBranch(branchName); agent != nil && task != nil {
+				c.escalateMerge(agent, task, err)
+			}
+		}
+		return err
+	}
+
 	log.Printf("🔀 Merging branch %s into main...", branchName)
 
 	result, err := c.mergeManager.MergeBranch(branchName)
@@ -456,7 +962,8 @@ func (c *Coordinator) MergeBranch(branchName string) error {
 		if err == git.ErrMergeConflict {
 			log.Printf("⚠️  Merge conflict detected for %s", branchName)
 			_ = c.mergeManager.AbortMerge()
-			return fmt.Errorf("merge conflict: %v", result.Conflicts)
+			c.recordMergeConflict(branchName)
+			return fmt.Errorf("%w: %v", git.ErrMergeConflict, result.Conflicts)
 		}
 		return fmt.Errorf("merge failed: %w", err)
 	}
@@ -470,34 +977,6 @@ func (c *Coordinator) MergeBranch(branchName string) error {
 	return nil
 }
 
-// GetConflictDetails 获取合并冲突详情
-func (c *Coordinator) GetConflictDetails(branchName string) ([]string, string, error) {
-	// 尝试合并（dry-run）
-	cmd := exec.Command("git", "-C", c.repoPath, "merge", "--no-commit", "--no-ff", branchName)
-	output, err := cmd.CombinedOutput()
-
-	// 获取冲突文件
-	conflictCmd := exec.Command("git", "-C", c.repoPath, "diff", "--name-only", "--diff-filter=U")
-	conflictOutput, _ := conflictCmd.Output()
-
-	var conflictFiles []string
-	for _, f := range strings.Split(strings.TrimSpace(string(conflictOutput)), "\n") {
-		if f != "" {
-			conflictFiles = append(conflictFiles, f)
-		}
-	}
-
-	// 中止合并
-	abortCmd := exec.Command("git", "-C", c.repoPath, "merge", "--abort")
-	_ = abortCmd.Run()
-
-	if err != nil && len(conflictFiles) > 0 {
-		return conflictFiles, string(output), nil
-	}
-
-	return nil, "", err
-}
-
 // MergeStatus 合并状态（本地定义，避免循环依赖）
 type MergeStatus struct {
 	Branch       string   `json:"branch"`
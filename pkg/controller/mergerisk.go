@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/analyzer"
+	"github.com/yourusername/claude-swarm/pkg/secrets"
+)
+
+// sensitiveMergePaths are files that run code outside the agents' sandbox
+// once they reach main: CI pipelines and shared git hooks
+var sensitiveMergePaths = []string{
+	".github/workflows/",
+	".gitlab-ci.yml",
+	".circleci/",
+	"Jenkinsfile",
+	".githooks/",
+	".husky/",
+	".gitmodules",
+}
+
+// ErrMergeHeld is returned when a branch is merged while its agent waits
+// for a human to approve the merge
+var ErrMergeHeld = errors.New("merge held for approval")
+
+// maxRiskReasons limits the reasons listed in a MergeRiskError
+const maxRiskReasons = 10
+
+// MergeRiskError blocks a merge whose changes the risk assessment rated
+// HIGH or CRITICAL, until a human approves it
+type MergeRiskError struct {
+	Risk    analyzer.RiskLevel
+	Reasons []string
+}
+
+func (e *MergeRiskError) Error() string {
+	return fmt.Sprintf("%s risk: %s", e.Risk, strings.Join(e.Reasons, "; "))
+}
+
+// assessMerge rates the staged and committed changes of a worktree
+func (c *Coordinator) assessMerge(worktreePath string) error {
+	diff, err := stagedDiff(worktreePath)
+	if err != nil {
+		return fmt.Errorf("failed to diff worktree for risk assessment: %w", err)
+	}
+	names, err := stagedDiff(worktreePath, "--name-only", "--no-renames")
+	if err != nil {
+		return fmt.Errorf("failed to diff worktree for risk assessment: %w", err)
+	}
+
+	if risk := assessMergeRisk(diff, strings.Fields(names)); risk != nil {
+		return risk
+	}
+	return nil
+}
+
+// checkBranch runs the secret scan and risk assessment over the commits of
+// branch since it forked from main
+func (c *Coordinator) checkBranch(branch string) error {
+	diff, err := branchDiff(c.repoPath, branch)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s: %w", branch, err)
+	}
+	names, err := branchDiff(c.repoPath, branch, "--name-only", "--no-renames")
+	if err != nil {
+		return fmt.Errorf("failed to diff %s: %w", branch, err)
+	}
+
+	if findings := c.secretScanner.ScanDiff(diff); len(findings) > 0 {
+		return &secrets.LeakError{Findings: findings}
+	}
+	if risk := assessMergeRisk(diff, strings.Fields(names)); risk != nil {
+		return risk
+	}
+	return nil
+}
+
+// assessMergeRisk runs the detector's risk assessment over each added line
+// of diff and flags changes to sensitive paths. It returns nil for merges
+// rated below HIGH.
+func assessMergeRisk(diff string, files []string) *MergeRiskError {
+	result := &MergeRiskError{Risk: analyzer.RiskLevelLow}
+	flag := func(risk analyzer.RiskLevel, reason string) {
+		if risk == analyzer.RiskLevelCritical {
+			result.Risk = risk
+		} else if result.Risk != analyzer.RiskLevelCritical {
+			result.Risk = analyzer.RiskLevelHigh
+		}
+		if len(result.Reasons) < maxRiskReasons {
+			result.Reasons = append(result.Reasons, reason)
+		}
+	}
+
+	for _, file := range files {
+		if pattern := models.MatchAnyPath(sensitiveMergePaths, file); pattern != "" {
+			flag(analyzer.RiskLevelHigh, fmt.Sprintf("changes %s (matches %s)", file, pattern))
+		}
+	}
+
+	detector := analyzer.NewDetector()
+	for _, line := range strings.Split(diff, "\n") {
+		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		added := strings.TrimSpace(line[1:])
+		switch risk := detector.AssessRisk(added); risk {
+		case analyzer.RiskLevelCritical, analyzer.RiskLevelHigh:
+			if len(added) > 120 {
+				added = added[:120] + "..."
+			}
+			flag(risk, fmt.Sprintf("adds %q (%s)", added, risk))
+		}
+	}
+
+	if result.Reasons == nil {
+		return nil
+	}
+	return result
+}
+
+// stagedDiff returns the staged and committed changes of a worktree since
+// it forked from main, with extra git diff arguments. Diffing against the
+// merge-base leaves out commits that landed on main in the meantime.
+func stagedDiff(worktreePath string, args ...string) (string, error) {
+	base, err := gitOutput(worktreePath, "merge-base", "main", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return gitOutput(worktreePath, append([]string{"diff", "--cached", "--no-color"}, append(args, strings.TrimSpace(base))...)...)
+}
+
+// branchDiff returns the committed changes of branch since it forked from
+// main, with extra git diff arguments
+func branchDiff(repoPath, branch string, args ...string) (string, error) {
+	base, err := gitOutput(repoPath, "merge-base", "main", branch)
+	if err != nil {
+		return "", err
+	}
+	return gitOutput(repoPath, append([]string{"diff", "--no-color"}, append(args, strings.TrimSpace(base), branch)...)...)
+}
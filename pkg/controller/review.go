@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/git"
+	"github.com/yourusername/claude-swarm/pkg/retry"
+)
+
+// Reviewer reviews the work of a successful attempt before its task is
+// marked done. It is called from the agent workers concurrently.
+type Reviewer interface {
+	ReviewTask(ctx context.Context, task *models.Task, diff, output string) (*models.Review, error)
+}
+
+// ReviewConfig decides when reviewed work goes back to its agent
+type ReviewConfig struct {
+	MinScore   int // Work scoring below this is sent back even if the reviewer accepts it
+	MaxReworks int // Rework rounds per task, after which the work is accepted as is
+}
+
+// DefaultReviewConfig sends work scoring below 70 back, at most twice
+func DefaultReviewConfig() ReviewConfig {
+	return ReviewConfig{MinScore: 70, MaxReworks: 2}
+}
+
+// SetReviewer enables the review stage; a nil reviewer disables it
+func (c *Coordinator) SetReviewer(reviewer Reviewer, config ReviewConfig) {
+	c.reviewMu.Lock()
+	defer c.reviewMu.Unlock()
+
+	c.reviewer = reviewer
+	c.reviewConfig = config
+}
+
+// reviewWork has the reviewer check the work of the task's last attempt,
+// which succeeded, and stores the review on the attempt. Work that needs
+// rework goes back to the agent, whose worktree keeps the changes; it
+// returns true in that case. A failed review accepts the work.
+func (c *Coordinator) reviewWork(agent *Agent, task *models.Task) bool {
+	c.reviewMu.Lock()
+	reviewer, config := c.reviewer, c.reviewConfig
+	c.reviewMu.Unlock()
+
+	last := task.LastAttempt()
+	if reviewer == nil || last == nil {
+		return false
+	}
+
+	diff, err := c.reviewDiff(agent)
+	if err != nil {
+		log.Printf("⚠️  Failed to diff the work of task %s for review: %v", task.ID, err)
+	}
+
+	review, err := reviewer.ReviewTask(c.ctx, task, diff, agent.Executor.GetRecentOutput(retry.PromptOutputLines))
+	if err != nil {
+		log.Printf("⚠️  Review of task %s failed, accepting the work: %v", task.ID, err)
+		c.recordAudit(audit.Entry{
+			Actor:    "reviewer",
+			Decision: audit.DecisionReview,
+			TaskID:   task.ID,
+			AgentID:  agent.ID,
+			Action:   "error",
+			Outcome:  err.Error(),
+		})
+		return false
+	}
+
+	rework := review.NeedsRework || review.Score < config.MinScore
+	reworks := task.Reworks()
+	if rework && reworks >= config.MaxReworks {
+		log.Printf("⚠️  Task %s still scores %d/100 after %d rework rounds, accepting it", task.ID, review.Score, reworks)
+		rework = false
+	}
+	review.SentBack = rework
+	last.Review = review
+
+	entry := audit.Entry{
+		Actor:    "reviewer",
+		Decision: audit.DecisionReview,
+		TaskID:   task.ID,
+		AgentID:  agent.ID,
+		Rule:     review.Reviewer,
+		Action:   "accept",
+		Outcome:  fmt.Sprintf("score %d/100", review.Score),
+		Details:  map[string]string{"reworks": strconv.Itoa(reworks), "min_score": strconv.Itoa(config.MinScore)},
+	}
+	if len(review.Issues) > 0 {
+		entry.Details["issues"] = strings.Join(review.Issues, "; ")
+	}
+	if !rework {
+		c.recordAudit(entry)
+		log.Printf("🔍 Review of task %s: %d/100, accepted", task.ID, review.Score)
+		return false
+	}
+
+	entry.Action = "rework"
+	c.recordAudit(entry)
+	log.Printf("🔁 Review of task %s: %d/100, sent back to %s for rework", task.ID, review.Score, agent.ID)
+
+	// The same agent continues in its worktree; the prompt carries the review
+	task.Status = models.TaskStatusPending
+	task.AssigneeID = ""
+	task.PreferAgent = agent.ID
+	task.AvoidAgent = ""
+	task.NotBefore = time.Now()
+	_ = c.taskQueue.UpdateTask(task)
+	return true
+}
+
+// reviewDiff returns the agent's changes since its branch left main,
+// including uncommitted and untracked files
+func (c *Coordinator) reviewDiff(agent *Agent) (string, error) {
+	if agent.Worktree == nil {
+		return "", nil
+	}
+	path := agent.Worktree.Path
+
+	base, err := git.Command(path, "merge-base", "main", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base: %w", err)
+	}
+	// Intent-to-add makes new files show up without staging their content
+	if err := c.gitCommand(path, "add", "--intent-to-add", "--all"); err != nil {
+		return "", err
+	}
+	diff, err := git.Command(path, "diff", "--no-color", strings.TrimSpace(string(base))).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to diff worktree: %w", err)
+	}
+	return string(diff), nil
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// fakeReviewer returns its reviews in order, repeating the last one
+type fakeReviewer struct {
+	reviews []models.Review
+	err     error
+	calls   int
+}
+
+func (f *fakeReviewer) ReviewTask(ctx context.Context, task *models.Task, diff, output string) (*models.Review, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	review := f.reviews[min(f.calls, len(f.reviews)-1)]
+	f.calls++
+	return &review, nil
+}
+
+// succeed appends a finished attempt by the agent, as the worker does
+// before the review
+func succeed(c *Coordinator, agent *Agent, id string) *models.Task {
+	task, _ := c.taskQueue.GetTask(id)
+	task.Status = models.TaskStatusInProgress
+	task.AssigneeID = agent.ID
+	task.Attempts = append(task.Attempts, models.Attempt{
+		Number: len(task.Attempts) + 1, AgentID: agent.ID, FinishedAt: time.Now(),
+	})
+	return task
+}
+
+func TestReviewWork(t *testing.T) {
+	c := actionCoordinator(t, &models.Task{ID: "task-1", Description: "Add logout", Status: models.TaskStatusPending})
+	agent := c.agents[1]
+	reviewer := &fakeReviewer{reviews: []models.Review{
+		{Score: 50, Issues: []string{"no tests"}, Instructions: "add tests"},
+		{Score: 90, Complete: true},
+	}}
+	c.SetReviewer(reviewer, ReviewConfig{MinScore: 70, MaxReworks: 2})
+
+	// Low scores go back to the same agent, even if the reviewer did not ask for rework
+	task := succeed(c, agent, "task-1")
+	if !c.reviewWork(agent, task) {
+		t.Fatal("work scoring 50/100 should be sent back")
+	}
+	task, _ = c.taskQueue.GetTask("task-1")
+	if task.Status != models.TaskStatusPending || task.PreferAgent != agent.ID {
+		t.Errorf("reworked task = %s, prefers %q", task.Status, task.PreferAgent)
+	}
+	if review := task.LastReview(); review == nil || !review.SentBack || task.Reworks() != 1 {
+		t.Errorf("stored review = %+v, reworks %d", review, task.Reworks())
+	}
+
+	task = succeed(c, agent, "task-1")
+	if c.reviewWork(agent, task) {
+		t.Error("work scoring 90/100 should be accepted")
+	}
+	if review := task.LastReview(); review == nil || review.Score != 90 || review.SentBack {
+		t.Errorf("accepted review = %+v", review)
+	}
+
+	summary := models.SummarizeReviews([]*models.Task{task})
+	if summary.Reviewed != 1 || summary.AverageScore != 90 || summary.Reworks != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestReviewWorkLimits(t *testing.T) {
+	c := actionCoordinator(t, &models.Task{ID: "task-1", Description: "Add logout", Status: models.TaskStatusPending})
+	agent := c.agents[0]
+
+	// Rework stops after MaxReworks rounds
+	c.SetReviewer(&fakeReviewer{reviews: []models.Review{{Score: 20, NeedsRework: true}}}, ReviewConfig{MinScore: 70, MaxReworks: 1})
+	if !c.reviewWork(agent, succeed(c, agent, "task-1")) {
+		t.Fatal("first review should send the work back")
+	}
+	if c.reviewWork(agent, succeed(c, agent, "task-1")) {
+		t.Error("work should be accepted once the rework rounds are used up")
+	}
+
+	// A failed review accepts the work
+	c.SetReviewer(&fakeReviewer{err: errors.New("rate limited")}, DefaultReviewConfig())
+	task := succeed(c, agent, "task-1")
+	if c.reviewWork(agent, task) {
+		t.Error("a failed review should not send the work back")
+	}
+	if task.LastAttempt().Review != nil {
+		t.Error("a failed review should not be stored")
+	}
+}
@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+)
+
+// Check is one command of a verification gate. It is run with sh -c in the
+// work tree being verified and passes if it exits 0.
+type Check struct {
+	Name    string
+	Command string
+}
+
+// checkRunner runs the command of a check and returns its combined output
+type checkRunner func(ctx context.Context, command string) (string, error)
+
+// hostRunner runs checks in dir on the host, for the checks of the
+// coordinator's own configuration
+func hostRunner(dir string) checkRunner {
+	return func(ctx context.Context, command string) (string, error) {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+}
+
+// agentRunner runs the checks of task through the agent's executor, in its
+// sandbox and under its environment policy and resource limits
+func agentRunner(agent *Agent, task *models.Task) checkRunner {
+	return func(ctx context.Context, command string) (string, error) {
+		return agent.Executor.RunCheck(ctx, task, command)
+	}
+}
+
+const (
+	// checkTimeout bounds each check command
+	checkTimeout = 10 * time.Minute
+
+	// checkOutputBytes caps the output kept for a failed check
+	checkOutputBytes = 2000
+)
+
+// runChecks runs checks in order with run and returns their results
+func runChecks(ctx context.Context, run checkRunner, checks []Check) []models.CheckResult {
+	results := make([]models.CheckResult, 0, len(checks))
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		output, err := run(checkCtx, check.Command)
+		cancel()
+
+		result := models.CheckResult{Name: check.Name, Passed: err == nil}
+		if err != nil {
+			result.Output = tailBytes(strings.TrimSpace(output), checkOutputBytes)
+			log.Printf("✗ Check %q failed: %v", check.Name, err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checksPassed reports whether every check passed
+func checksPassed(results []models.CheckResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// tailBytes returns the last n bytes of s, starting at a line if possible
+func tailBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	tail := s[len(s)-n:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return tail
+}
+
+// VerificationError is returned for a finished attempt whose work does not
+// meet the task's definition of done
+type VerificationError struct {
+	Failed []string // Names of the failed checks
+}
+
+func (e *VerificationError) Error() string {
+	return "definition of done not met: " + strings.Join(e.Failed, "; ")
+}
+
+// Failure classifies the attempt as unmet_done, so that category's retry
+// policy applies and a retry prompt lists the failed checks
+func (e *VerificationError) Failure() *models.Failure {
+	return &models.Failure{Category: models.ErrorUnmetDone, Message: e.Error(), Matcher: "definition-of-done"}
+}
+
+// discardWork resets the agent's worktree to where the attempt started and
+// removes untracked files, so work that failed its definition of done is not
+// merged with the agent's next task. The attempt record keeps its commits.
+func (c *Coordinator) discardWork(agent *Agent, run *attemptRun) {
+	if agent.Worktree == nil {
+		return
+	}
+	target := run.startCommit
+	if target == "" {
+		target = "main"
+	}
+
+	err := c.gitCommand(agent.Worktree.Path, "reset", "--hard", target)
+	if err == nil {
+		err = c.gitCommand(agent.Worktree.Path, "clean", "-fd")
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to discard rejected work of %s: %v", agent.ID, err)
+		return
+	}
+	log.Printf("🗑️  Discarded work of %s that did not meet the definition of done", agent.ID)
+}
+
+// verifyWork checks the work of an attempt the agent reported as done
+// against the task's required files, forbidden paths and check commands,
+// in that order, and stores the results on the attempt. It returns the
+// failed checks, or nil if all of them passed.
+func (c *Coordinator) verifyWork(agent *Agent, task *models.Task, run *attemptRun) *VerificationError {
+	if !task.HasDefinitionOfDone() {
+		return nil
+	}
+	dir, base := workBase(agent)
+
+	var results []models.CheckResult
+	for _, pattern := range task.RequiredFiles {
+		result := models.CheckResult{Name: pattern + " exists", Passed: fileExists(dir, pattern)}
+		if !result.Passed {
+			result.Output = "no file matches " + pattern
+		}
+		results = append(results, result)
+	}
+
+	if len(task.ForbiddenPaths) > 0 {
+		result := models.CheckResult{Name: "no changes to " + strings.Join(task.ForbiddenPaths, ", "), Passed: true}
+		changed, err := changedFiles(dir, base)
+		if err != nil {
+			result.Passed = false
+			result.Output = fmt.Sprintf("failed to list changed files: %v", err)
+		}
+		var touched []string
+		for _, file := range changed {
+			if pattern := models.MatchAnyPath(task.ForbiddenPaths, file); pattern != "" {
+				touched = append(touched, fmt.Sprintf("%s (matches %s)", file, pattern))
+			}
+		}
+		if len(touched) > 0 {
+			result.Passed = false
+			result.Output = "changed: " + strings.Join(touched, ", ")
+		}
+		results = append(results, result)
+	}
+
+	checks := make([]Check, 0, len(task.Checks))
+	for _, command := range task.Checks {
+		checks = append(checks, Check{Name: command, Command: command})
+	}
+	results = append(results, runChecks(c.ctx, agentRunner(agent, task), checks)...)
+	run.Verification = results
+
+	var failed []string
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result.Name)
+		}
+	}
+
+	entry := audit.Entry{
+		Actor:    "definition-of-done",
+		Decision: audit.DecisionVerify,
+		TaskID:   task.ID,
+		AgentID:  agent.ID,
+		Action:   "pass",
+		Outcome:  fmt.Sprintf("%d/%d checks passed", len(results)-len(failed), len(results)),
+	}
+	if len(failed) == 0 {
+		c.recordAudit(entry)
+		log.Printf("☑️  Task %s meets its definition of done (%d checks)", task.ID, len(results))
+		return nil
+	}
+	entry.Action = "fail"
+	entry.Details = map[string]string{"failed": strings.Join(failed, "; ")}
+	c.recordAudit(entry)
+	return &VerificationError{Failed: failed}
+}
+
+// checkScope flags the files an attempt changed outside the task's
+// declared write scope. They are stored on the attempt, where the reviewer
+// sees them, but don't fail it: the scope is a prediction.
+func (c *Coordinator) checkScope(agent *Agent, task *models.Task, run *attemptRun) {
+	if len(task.WritePaths) == 0 {
+		return
+	}
+	dir, base := workBase(agent)
+	changed, err := changedFiles(dir, base)
+	if err != nil {
+		log.Printf("⚠️  Failed to check the scope of task %s: %v", task.ID, err)
+		return
+	}
+
+	run.OutOfScope = nil
+	for _, file := range changed {
+		if models.MatchAnyPath(task.WritePaths, file) == "" {
+			run.OutOfScope = append(run.OutOfScope, file)
+		}
+	}
+	if len(run.OutOfScope) == 0 {
+		return
+	}
+
+	log.Printf("⚠️  Task %s changed %d file(s) outside its scope: %s",
+		task.ID, len(run.OutOfScope), strings.Join(run.OutOfScope, ", "))
+	c.recordAudit(audit.Entry{
+		Actor:    "scope-check",
+		Decision: audit.DecisionVerify,
+		TaskID:   task.ID,
+		AgentID:  agent.ID,
+		Rule:     strings.Join(task.WritePaths, ", "),
+		Action:   "out_of_scope",
+		Outcome:  fmt.Sprintf("%d file(s) changed outside the write scope", len(run.OutOfScope)),
+		Details:  map[string]string{"files": strings.Join(run.OutOfScope, ", ")},
+	})
+}
+
+// workBase returns the directory the agent works in and the commit its
+// changes are compared against: where its branch left main, or HEAD for
+// an agent without a worktree
+func workBase(agent *Agent) (dir, base string) {
+	dir, base = agent.WorkingDir, "HEAD"
+	if agent.Worktree != nil {
+		dir = agent.Worktree.Path
+		if mergeBase, err := gitOutput(dir, "merge-base", "main", "HEAD"); err == nil {
+			base = strings.TrimSpace(mergeBase)
+		}
+	}
+	return dir, base
+}
+
+// fileExists reports whether a file matching pattern exists in dir. A
+// pattern that isn't a plain path is matched against the files git sees,
+// tracked or not, so ignored build output doesn't count.
+func fileExists(dir, pattern string) bool {
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(pattern))); err == nil {
+		return true
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return false
+	}
+	files, err := gitOutput(dir, "ls-files", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return false
+	}
+	for _, file := range strings.Split(files, "\n") {
+		if file != "" && models.MatchPath(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedFiles lists the files changed in dir since base, committed or
+// not, plus untracked files. Renames count as a deletion and an addition.
+func changedFiles(dir, base string) ([]string, error) {
+	diff, err := gitOutput(dir, "diff", "--name-only", "--no-renames", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput(dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, file := range strings.Split(diff+untracked, "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/git"
+	"github.com/yourusername/claude-swarm/pkg/retry"
+)
+
+// featureAgent returns an agent working on the feature branch, which
+// changed greet.txt, with an untracked docs/guide.md in its worktree
+func featureAgent(t *testing.T, c *Coordinator) *Agent {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent-0")
+	if _, err := gitOutput(c.repoPath, "worktree", "add", "-q", path, "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(path, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "docs", "guide.md"), []byte("# Guide\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return NewAgent("agent-0", &git.Worktree{Path: path, BranchName: "feature"}, path)
+}
+
+func TestVerifyWork(t *testing.T) {
+	c := conflictCoordinator(t)
+
+	agent := featureAgent(t, c)
+
+	run := &attemptRun{}
+	if unmet := c.verifyWork(agent, &models.Task{ID: "task-0"}, run); unmet != nil || run.Verification != nil {
+		t.Fatalf("task without a definition of done: %v, %+v", unmet, run.Verification)
+	}
+
+	task := &models.Task{
+		ID:             "task-1",
+		RequiredFiles:  []string{"greet.txt", "docs/*.md"},
+		Checks:         []string{"grep -q feature greet.txt"},
+		ForbiddenPaths: []string{"go.mod", "vendor/"},
+	}
+	if unmet := c.verifyWork(agent, task, run); unmet != nil {
+		t.Fatalf("verifyWork() = %v", unmet)
+	}
+	if len(run.Verification) != 4 || !checksPassed(run.Verification) {
+		t.Errorf("verification = %+v", run.Verification)
+	}
+
+	task.RequiredFiles = append(task.RequiredFiles, "CHANGELOG.md")
+	task.ForbiddenPaths = []string{"greet.txt", "docs/"}
+	task.Checks = append(task.Checks, "echo tests broken; exit 1")
+	unmet := c.verifyWork(agent, task, run)
+	if unmet == nil {
+		t.Fatal("verifyWork() should fail")
+	}
+	want := []string{"CHANGELOG.md exists", "no changes to greet.txt, docs/", "echo tests broken; exit 1"}
+	if strings.Join(unmet.Failed, "|") != strings.Join(want, "|") {
+		t.Errorf("failed checks = %q, want %q", unmet.Failed, want)
+	}
+	if failure := unmet.Failure(); failure.Category != models.ErrorUnmetDone || failure.Matcher != "definition-of-done" {
+		t.Errorf("failure = %+v", failure)
+	}
+
+	var output strings.Builder
+	for _, result := range run.Verification {
+		output.WriteString(result.Output + "\n")
+	}
+	for _, want := range []string{"greet.txt (matches greet.txt)", "docs/guide.md (matches docs/)", "tests broken"} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("verification output does not contain %q:\n%s", want, output.String())
+		}
+	}
+}
+
+func TestCheckScope(t *testing.T) {
+	c := conflictCoordinator(t)
+
+	agent := featureAgent(t, c)
+
+	run := &attemptRun{}
+	c.checkScope(agent, &models.Task{ID: "task-1", WritePaths: []string{"greet.txt", "docs/"}}, run)
+	if run.OutOfScope != nil {
+		t.Errorf("changes within the scope flagged: %v", run.OutOfScope)
+	}
+
+	c.checkScope(agent, &models.Task{ID: "task-1", WritePaths: []string{"docs/**/*.md"}}, run)
+	if len(run.OutOfScope) != 1 || run.OutOfScope[0] != "greet.txt" {
+		t.Errorf("out of scope = %v, want greet.txt", run.OutOfScope)
+	}
+}
+
+func TestDiscardWork(t *testing.T) {
+	c := conflictCoordinator(t)
+	agent := featureAgent(t, c)
+
+	start, err := gitOutput(agent.Worktree.Path, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(agent.Worktree.Path, "go.mod"), []byte("module forbidden\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "go.mod"}, {"commit", "-qm", "Touch go.mod"}} {
+		if _, err := gitOutput(agent.Worktree.Path, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c.discardWork(agent, &attemptRun{startCommit: strings.TrimSpace(start)})
+
+	if head, _ := gitOutput(agent.Worktree.Path, "rev-parse", "HEAD"); head != start {
+		t.Errorf("HEAD = %s, want the attempt's start %s", head, start)
+	}
+	if status, _ := gitOutput(agent.Worktree.Path, "status", "--porcelain"); status != "" {
+		t.Errorf("worktree not clean after discarding work:\n%s", status)
+	}
+}
+
+func TestUnmetDefinitionOfDoneIsRetried(t *testing.T) {
+	task := &models.Task{ID: "task-1", Status: models.TaskStatusInProgress, MaxRetries: 3, Checks: []string{"go test ./..."}}
+	c := actionCoordinator(t, task)
+	c.retryManager = retry.NewRetryManager(retry.DefaultRetryConfig())
+
+	unmet := &VerificationError{Failed: []string{"go test ./..."}}
+	task.Failure = unmet.Failure()
+	task.Attempts = []models.Attempt{{Number: 1, Failure: task.Failure, Verification: []models.CheckResult{
+		{Name: "go test ./...", Output: "--- FAIL: TestLogout"},
+	}}}
+	c.handleTaskFailure(c.agents[0], task, unmet)
+
+	got, err := c.taskQueue.GetTask("task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.TaskStatusPending {
+		t.Fatalf("status = %s, want a retry", got.Status)
+	}
+	prompt := retry.BuildPrompt(got)
+	for _, want := range []string{"Failure category: unmet_done", "Failed check: go test ./...", "--- FAIL: TestLogout"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("retry prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+}
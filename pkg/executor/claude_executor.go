@@ -2,76 +2,230 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/yourusername/claude-swarm/internal/models"
 	"github.com/yourusername/claude-swarm/pkg/analyzer"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/secrets"
 )
 
-// ClaudeExecutor executes tasks using Claude Code CLI with echo pipe
+// Mode selects how the agent CLI is run
+type Mode string
+
+const (
+	ModePipe        Mode = "pipe"        // echo 'task' | claude -p --output-format stream-json --verbose --dangerously-skip-permissions
+	ModeInteractive Mode = "interactive" // claude under a PTY, prompts answered by the Detector
+)
+
+// ClaudeExecutor executes tasks using Claude Code CLI
 type ClaudeExecutor struct {
 	workDir  string
+	cliPath  string
+	mode     Mode
 	detector *analyzer.Detector
+	sandbox  *Sandbox
+	env      *EnvPolicy
+	secrets  *secrets.Store
+	limits   models.ResourceLimits
+	lastRun  RunInfo
 	mu       sync.Mutex
+
+	// Interactive mode
+	escalate   ConfirmEscalator
+	audit      *audit.Log
+	idleSettle time.Duration // Quiet time at the idle prompt before the session is ended
+}
+
+// RunInfo describes the last run of the agent CLI
+type RunInfo struct {
+	ExitCode int     // 0 on success or if the process didn't exit normally
+	CostUSD  float64 // As reported by a stream-json result event
+	Duration time.Duration
+	Output   string // Full output as text (stream-json rendered), with secrets redacted
 }
 
 // NewClaudeExecutor creates a new Claude executor
 func NewClaudeExecutor(workDir string) *ClaudeExecutor {
 	return &ClaudeExecutor{
-		workDir:  workDir,
-		detector: analyzer.NewDetector(),
+		workDir:    workDir,
+		cliPath:    "claude",
+		mode:       ModePipe,
+		detector:   analyzer.NewDetector(),
+		env:        DefaultEnvPolicy(),
+		limits:     DefaultResourceLimits(),
+		idleSettle: 5 * time.Second,
+	}
+}
+
+// SetMode selects pipe or interactive execution
+func (ce *ClaudeExecutor) SetMode(mode Mode) error {
+	if mode == ModeInteractive && !ptySupported {
+		return fmt.Errorf("executor mode %q is not supported on this platform", mode)
+	}
+	if mode != ModePipe && mode != ModeInteractive {
+		return fmt.Errorf("unknown executor mode: %q", mode)
+	}
+
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.mode = mode
+	return nil
+}
+
+// SetConfirmEscalator sets where prompts that ShouldConfirm refuses are sent.
+// Without an escalator such prompts are declined.
+func (ce *ClaudeExecutor) SetConfirmEscalator(escalate ConfirmEscalator) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.escalate = escalate
+}
+
+// SetAuditLog sets where risk and confirmation decisions are recorded
+func (ce *ClaudeExecutor) SetAuditLog(log *audit.Log) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.audit = log
+}
+
+// SetSandbox runs the agent CLI inside sandbox. Nil disables sandboxing.
+func (ce *ClaudeExecutor) SetSandbox(sandbox *Sandbox) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.sandbox = sandbox
+}
+
+// SetEnvPolicy sets which environment variables the agent CLI gets.
+// Secret references in the policy and in task env are resolved via store.
+func (ce *ClaudeExecutor) SetEnvPolicy(policy *EnvPolicy, store *secrets.Store) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	if policy == nil {
+		policy = DefaultEnvPolicy()
 	}
+	ce.env = policy
+	ce.secrets = store
+}
+
+// SetErrorClassifier sets how failed runs are classified
+func (ce *ClaudeExecutor) SetErrorClassifier(classifier *analyzer.ErrorClassifier) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.detector.SetErrorClassifier(classifier)
+}
+
+// SetResourceLimits sets the agent's limits; tasks may override single fields
+func (ce *ClaudeExecutor) SetResourceLimits(limits models.ResourceLimits) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.limits = limits
+}
+
+// buildEnv returns the scoped environment for task
+func (ce *ClaudeExecutor) buildEnv(task *models.Task) ([]string, error) {
+	return ce.env.Build(os.Environ(), task.Env, ce.secrets)
 }
 
 // ExecuteTask executes a task using Claude Code CLI
-// Uses: echo "task" | claude --dangerously-skip-permissions
 func (ce *ClaudeExecutor) ExecuteTask(ctx context.Context, task *models.Task) error {
+	return ce.ExecutePrompt(ctx, task, task.Description)
+}
+
+// ExecutePrompt executes task with prompt sent to the CLI instead of the
+// description, e.g. a retry prompt. The risk assessment still looks at the
+// description.
+func (ce *ClaudeExecutor) ExecutePrompt(ctx context.Context, task *models.Task, prompt string) error {
 	ce.mu.Lock()
 	defer ce.mu.Unlock()
 
 	log.Printf("🤖 [%s] Executing task: %s", ce.workDir, task.ID)
+	ce.lastRun = RunInfo{}
 
 	// 1. AI pre-assessment: check task risk before execution
-	risk := ce.assessTaskRisk(task.Description)
+	risk := ce.assessTaskRisk(task)
 	if risk == analyzer.RiskLevelCritical {
-		log.Printf("🚫 [%s] AI blocked task: CRITICAL risk detected", ce.workDir)
-		return fmt.Errorf("AI blocked: critical risk operation detected")
+		if !task.RiskApproved {
+			log.Printf("🚫 [%s] AI blocked task: CRITICAL risk detected, escalating for human approval", ce.workDir)
+			return &ApprovalRequiredError{
+				Reason:  "critical risk operation detected",
+				Risk:    risk,
+				Context: task.Description,
+			}
+		}
+		log.Printf("⚠️  [%s] CRITICAL risk approved by a human - proceeding", ce.workDir)
+	} else {
+		log.Printf("🧠 [%s] AI risk assessment: %s - proceeding", ce.workDir, risk)
 	}
 
-	log.Printf("🧠 [%s] AI risk assessment: %s - proceeding", ce.workDir, risk)
-
-	// 2. Prepare command
-	// Escape single quotes in task description
-	escapedTask := strings.ReplaceAll(task.Description, "'", "'\\''")
-
-	// Build command: echo 'task' | claude --dangerously-skip-permissions
-	cmdStr := fmt.Sprintf("echo '%s' | claude --dangerously-skip-permissions", escapedTask)
-
-	cmd := exec.CommandContext(ctx, "sh", "-c", cmdStr)
-	cmd.Dir = ce.workDir
+	// 2. Run the agent CLI under its resource limits and capture output
+	limits := ce.limits.WithOverrides(task.Limits)
+	runCtx := ctx
+	if timeout := limits.Timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	guard := newResourceGuard(limits, filepath.Base(ce.workDir))
 
-	// 3. Execute and capture output
 	startTime := time.Now()
-	output, err := cmd.CombinedOutput()
+	var outputStr string
+	var err error
+	if ce.mode == ModeInteractive {
+		outputStr, err = ce.runInteractive(runCtx, task, prompt, limits, guard)
+	} else {
+		outputStr, err = ce.runPipe(runCtx, task, prompt, limits, guard)
+	}
+	breach := guard.finish()
 	duration := time.Since(startTime)
 
-	outputStr := string(output)
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+	// Cost and the failure classification read the stream-json events;
+	// people, prompts and the detector get the rendered text
+	text := analyzer.StreamText(outputStr)
+	ce.lastRun = RunInfo{
+		ExitCode: exitCode,
+		CostUSD:  analyzer.ResultCost(outputStr),
+		Duration: duration,
+		Output:   secrets.Default().Redact(text),
+	}
 
-	// 4. Log execution details
+	// 3. Log execution details
 	log.Printf("⏱️  [%s] Task completed in %s", ce.workDir, duration)
-	log.Printf("📤 [%s] Claude output:\n%s", ce.workDir, outputStr)
+	log.Printf("📤 [%s] Claude output:\n%s", ce.workDir, ce.lastRun.Output)
 
-	// 5. Analyze output for errors
-	ce.detector.Analyze(outputStr)
+	// 4. Analyze output for errors
+	ce.detector.Analyze(text)
 
 	if err != nil {
-		errorDetails := ce.detector.AnalyzeError(outputStr)
-		log.Printf("❌ [%s] Task failed: %v (Error type: %v)", ce.workDir, err, errorDetails.Type)
+		var limitErr *ResourceLimitError
+		switch {
+		case errors.As(err, &limitErr):
+		case breach != "":
+			limitErr = breachError(breach, limits, guard.name())
+		case errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+			limitErr = breachError(models.LimitTimeout, limits, "executor")
+		}
+		if limitErr != nil {
+			log.Printf("🛑 [%s] Task %s stopped: %v", ce.workDir, task.ID, limitErr)
+			return limitErr
+		}
+
+		errorDetails := ce.detector.AnalyzeFailure(outputStr, exitCode)
+		log.Printf("❌ [%s] Task failed: %v (category: %s, matcher: %s)",
+			ce.workDir, err, errorDetails.Category, errorDetails.Matcher)
 
 		// Return error with type information for retry logic
 		if errorDetails.Type == analyzer.ErrorTypeRetryable {
@@ -81,27 +235,165 @@ func (ce *ClaudeExecutor) ExecuteTask(ctx context.Context, task *models.Task) er
 			}
 		}
 
-		return fmt.Errorf("task execution failed: %w", err)
+		return &TaskFailedError{Original: err, Details: errorDetails}
 	}
 
 	log.Printf("✅ [%s] Task %s completed successfully", ce.workDir, task.ID)
 	return nil
 }
 
+// runPipe runs the task non-interactively with permissions bypassed. The
+// CLI reports in stream-json, whose result and error events tell why a run
+// failed and what it cost.
+// Uses: echo "task" | claude -p --output-format stream-json --verbose --dangerously-skip-permissions
+func (ce *ClaudeExecutor) runPipe(ctx context.Context, task *models.Task, prompt string, limits models.ResourceLimits, guard resourceGuard) (string, error) {
+	// Escape single quotes in the prompt
+	escapedTask := strings.ReplaceAll(prompt, "'", "'\\''")
+
+	// Build command; stream-json output needs --verbose in print mode
+	cmdStr := fmt.Sprintf("echo '%s' | %s -p --output-format stream-json --verbose --dangerously-skip-permissions", escapedTask, ce.cliPath)
+
+	env, err := ce.buildEnv(task)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", cmdStr)
+	cmd.Dir = ce.workDir
+	cmd.Env = env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := ce.sandbox.Wrap(cmd); err != nil {
+		return "", err
+	}
+
+	output := newLimitedBuffer(limits.MaxOutputBytes, func() { killProcessGroup(cmd) })
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	if err := startGuarded(cmd, guard); err != nil {
+		return "", err
+	}
+	err = cmd.Wait()
+	if output.Exceeded() {
+		return output.String(), breachError(models.LimitOutput, limits, "executor")
+	}
+	return output.String(), err
+}
+
+// RunCheck runs a verification command of task with sh -c in the agent's
+// work directory. The command comes from the task, which the orchestrator
+// may have written, so it gets the same sandbox, scoped environment and
+// resource limits as the agent CLI. It returns the combined output.
+func (ce *ClaudeExecutor) RunCheck(ctx context.Context, task *models.Task, command string) (string, error) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	env, err := ce.buildEnv(task)
+	if err != nil {
+		return "", err
+	}
+
+	limits := ce.limits.WithOverrides(task.Limits)
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = ce.workDir
+	cmd.Env = env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := ce.sandbox.Wrap(cmd); err != nil {
+		return "", err
+	}
+
+	output := newLimitedBuffer(limits.MaxOutputBytes, func() { killProcessGroup(cmd) })
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	guard := newResourceGuard(limits, filepath.Base(ce.workDir))
+	if err := startGuarded(cmd, guard); err != nil {
+		guard.finish()
+		return "", err
+	}
+	err = cmd.Wait()
+	breach := guard.finish()
+	switch {
+	case output.Exceeded():
+		return output.String(), breachError(models.LimitOutput, limits, "executor")
+	case err != nil && breach != "":
+		return output.String(), breachError(breach, limits, guard.name())
+	}
+	return output.String(), err
+}
+
+// startGuarded starts cmd inside guard. The whole process group is killed
+// when ctx ends, and Wait gives up on pipes held open by orphans.
+func startGuarded(cmd *exec.Cmd, guard resourceGuard) error {
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	cmd.WaitDelay = 5 * time.Second
+
+	if err := guard.prepare(cmd); err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+	if err := guard.started(cmd.Process.Pid); err != nil {
+		killProcessGroup(cmd)
+		_ = cmd.Wait()
+		return err
+	}
+	return nil
+}
+
+// killProcessGroup kills cmd and everything in its process group
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
+
 // assessTaskRisk performs AI risk assessment on task description
-func (ce *ClaudeExecutor) assessTaskRisk(description string) analyzer.RiskLevel {
+func (ce *ClaudeExecutor) assessTaskRisk(task *models.Task) analyzer.RiskLevel {
 	// Simulate analyzing the task description
-	ce.detector.Analyze(description)
+	ce.detector.Analyze(task.Description)
 
 	// Use the same risk assessment logic
-	risk := ce.detector.AssessRisk(description)
+	risk := ce.detector.AssessRisk(task.Description)
+
+	action := "allow"
+	if risk == analyzer.RiskLevelCritical {
+		action = "escalate"
+		if task.RiskApproved {
+			action = "allow_approved"
+		}
+	}
+	if err := ce.audit.Record(audit.Entry{
+		Actor:       "detector",
+		Decision:    audit.DecisionTaskRisk,
+		TaskID:      task.ID,
+		InputDigest: audit.Digest(task.Description),
+		Rule:        "AssessRisk",
+		Action:      action,
+		Outcome:     string(risk),
+		Details:     map[string]string{"work_dir": ce.workDir},
+	}); err != nil {
+		log.Printf("⚠️  [%s] Failed to write audit entry: %v", ce.workDir, err)
+	}
 
 	return risk
 }
 
+// LastRun describes the most recent run of the agent CLI
+func (ce *ClaudeExecutor) LastRun() RunInfo {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return ce.lastRun
+}
+
 // GetRecentOutput returns recent output for debugging
 func (ce *ClaudeExecutor) GetRecentOutput(lines int) string {
-	return ce.detector.GetRecentOutput(lines)
+	return secrets.Default().Redact(ce.detector.GetRecentOutput(lines))
 }
 
 // RetryableError represents an error that can be retried
@@ -117,3 +409,57 @@ func (e *RetryableError) Error() string {
 func (e *RetryableError) Unwrap() error {
 	return e.Original
 }
+
+// TaskFailedError is a classified failure that is not retryable as is
+type TaskFailedError struct {
+	Original error
+	Details  *analyzer.ErrorDetails
+}
+
+func (e *TaskFailedError) Error() string {
+	return fmt.Sprintf("task execution failed: %v", e.Original)
+}
+
+func (e *TaskFailedError) Unwrap() error {
+	return e.Original
+}
+
+// FailureOf classifies an error returned by ExecuteTask for persisting on
+// the task. It returns nil for a nil error.
+func FailureOf(err error) *models.Failure {
+	if err == nil {
+		return nil
+	}
+
+	var retryable *RetryableError
+	var failed *TaskFailedError
+	var limit *ResourceLimitError
+	var approval *ApprovalRequiredError
+	switch {
+	case errors.As(err, &retryable) && retryable.Details != nil:
+		return retryable.Details.Failure()
+	case errors.As(err, &failed) && failed.Details != nil:
+		return failed.Details.Failure()
+	case errors.As(err, &limit):
+		category := models.ErrorResource
+		if limit.Resource == models.LimitTimeout {
+			category = models.ErrorTimeout
+		}
+		return &models.Failure{Category: category, Message: limit.Error(), Matcher: "limit-" + string(limit.Resource)}
+	case errors.As(err, &approval):
+		return &models.Failure{Category: models.ErrorPolicyBlock, Message: approval.Error(), Matcher: "risk-assessment"}
+	default:
+		return &models.Failure{Category: models.ErrorUncategorized, Message: err.Error()}
+	}
+}
+
+// ApprovalRequiredError is returned when a task must not run without human approval
+type ApprovalRequiredError struct {
+	Reason  string
+	Risk    analyzer.RiskLevel
+	Context string
+}
+
+func (e *ApprovalRequiredError) Error() string {
+	return fmt.Sprintf("AI blocked: %s (risk: %s), human approval required", e.Reason, e.Risk)
+}
@@ -0,0 +1,51 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func TestExecuteTaskStreamJSON(t *testing.T) {
+	// The fake CLI records its arguments and fails like a run whose tests broke
+	ce := newPipeExecutor(t, `echo "$@" > "$(dirname "$0")/args"
+cat <<'EOF'
+{"type":"system","subtype":"init"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Implemented retry for HTTP 429 responses."},{"type":"tool_use","name":"Bash"}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","is_error":true,"content":"--- FAIL: TestRetry (0.00s)\nFAIL"}]}}
+{"type":"result","subtype":"success","is_error":false,"result":"Tests still fail","total_cost_usd":0.12}
+EOF
+exit 1`)
+
+	err := ce.ExecuteTask(context.Background(), &models.Task{ID: "task-1", Description: "add retries"})
+
+	args, readErr := os.ReadFile(filepath.Join(filepath.Dir(ce.cliPath), "args"))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if !strings.Contains(string(args), "-p --output-format stream-json --verbose") {
+		t.Errorf("CLI args = %q, want stream-json print mode", args)
+	}
+
+	if failure := FailureOf(err); failure == nil || failure.Category != models.ErrorTestFailure {
+		t.Errorf("failure = %+v, want test_failure", failure)
+	}
+	var retryable *RetryableError
+	if errors.As(err, &retryable) {
+		t.Errorf("test failure classified as retryable: %v", err)
+	}
+
+	run := ce.LastRun()
+	if run.CostUSD != 0.12 {
+		t.Errorf("CostUSD = %v, want 0.12", run.CostUSD)
+	}
+	want := "Implemented retry for HTTP 429 responses.\n→ Bash\n✗ --- FAIL: TestRetry (0.00s)\nFAIL\nTests still fail"
+	if run.Output != want {
+		t.Errorf("Output = %q, want %q", run.Output, want)
+	}
+}
@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/claude-swarm/pkg/secrets"
+)
+
+// essentialEnv is always passed through, even with an allowlist
+var essentialEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "LANG", "LC_*", "TZ", "TMPDIR"}
+
+// defaultDenyEnv keeps the coordinator's and brain's credentials away from
+// the agents. ANTHROPIC_API_KEY is not listed: the agent CLI needs it.
+var defaultDenyEnv = []string{
+	"GEMINI_API_KEY", "GOOGLE_API_KEY", "GOOGLE_APPLICATION_CREDENTIALS", "OPENAI_API_KEY",
+	"AWS_*", "AZURE_*", "GCP_*", "CLOUDSDK_*", "DIGITALOCEAN_*",
+	"GH_TOKEN", "GITHUB_TOKEN", "GITLAB_TOKEN", "NPM_TOKEN", "DOCKER_*",
+	"SSH_AUTH_SOCK", "VAULT_*", "SWARM_SECRETS_FILE",
+}
+
+// EnvPolicy decides which environment variables an agent process gets.
+// Patterns use path.Match syntax, e.g. "AWS_*".
+type EnvPolicy struct {
+	Allow []string          `yaml:"allow,omitempty"` // If set, only these (plus essentials) pass through
+	Deny  []string          `yaml:"deny,omitempty"`  // Removed after Allow
+	Set   map[string]string `yaml:"set,omitempty"`   // Injected; "secret:name" values are resolved
+}
+
+// DefaultEnvPolicy passes the environment through minus known credentials
+func DefaultEnvPolicy() *EnvPolicy {
+	return &EnvPolicy{Deny: append([]string{}, defaultDenyEnv...)}
+}
+
+// EnvPolicyFile is the per-agent policy file (.swarm/env.yaml)
+type EnvPolicyFile struct {
+	Default EnvPolicy            `yaml:"default"`
+	Agents  map[string]EnvPolicy `yaml:"agents,omitempty"` // Merged onto Default for one agent
+}
+
+// LoadEnvPolicyFile reads a policy file. A missing file yields the default policy.
+func LoadEnvPolicyFile(path string) (*EnvPolicyFile, error) {
+	file := &EnvPolicyFile{Default: *DefaultEnvPolicy()}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env policy: %w", err)
+	}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse env policy %s: %w", path, err)
+	}
+
+	// The built-in denylist always applies; the file can only extend it
+	file.Default.Deny = append(append([]string{}, defaultDenyEnv...), file.Default.Deny...)
+	return file, nil
+}
+
+// PolicyFor returns the default policy with the agent's overrides merged in
+func (f *EnvPolicyFile) PolicyFor(agentID string) *EnvPolicy {
+	policy := &EnvPolicy{
+		Allow: append([]string{}, f.Default.Allow...),
+		Deny:  append([]string{}, f.Default.Deny...),
+		Set:   make(map[string]string),
+	}
+	for k, v := range f.Default.Set {
+		policy.Set[k] = v
+	}
+
+	if override, ok := f.Agents[agentID]; ok {
+		if len(override.Allow) > 0 {
+			policy.Allow = append([]string{}, override.Allow...)
+		}
+		policy.Deny = append(policy.Deny, override.Deny...)
+		for k, v := range override.Set {
+			policy.Set[k] = v
+		}
+	}
+	return policy
+}
+
+// Build filters base (KEY=VALUE pairs) and adds the policy's and the task's
+// variables. Task variables win over policy variables; both may reference
+// secrets, which are resolved through store.
+func (p *EnvPolicy) Build(base []string, taskEnv map[string]string, store *secrets.Store) ([]string, error) {
+	env := make(map[string]string)
+	for _, kv := range base {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !p.passes(key) {
+			continue
+		}
+		env[key] = value
+	}
+
+	for _, vars := range []map[string]string{p.Set, taskEnv} {
+		for key, value := range vars {
+			if store != nil {
+				resolved, err := store.ResolveValue(value)
+				if err != nil {
+					return nil, fmt.Errorf("env %s: %w", key, err)
+				}
+				value = resolved
+			} else if strings.HasPrefix(value, secrets.RefPrefix) {
+				return nil, fmt.Errorf("env %s references a secret but no secret store is configured", key)
+			}
+			env[key] = value
+		}
+	}
+
+	result := make([]string, 0, len(env))
+	for key, value := range env {
+		result = append(result, key+"="+value)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// passes reports whether an inherited variable survives the policy
+func (p *EnvPolicy) passes(key string) bool {
+	if len(p.Allow) > 0 && !matchAny(p.Allow, key) && !matchAny(essentialEnv, key) {
+		return false
+	}
+	return !matchAny(p.Deny, key)
+}
+
+// matchAny reports whether key matches one of the patterns
+func matchAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
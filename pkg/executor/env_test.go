@@ -0,0 +1,119 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/pkg/secrets"
+)
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
+
+func TestDefaultEnvPolicyStripsCredentials(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "GEMINI_API_KEY=g", "AWS_SECRET_ACCESS_KEY=a", "ANTHROPIC_API_KEY=k", "GOPATH=/go"}
+
+	env, err := DefaultEnvPolicy().Build(base, nil, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	got := envMap(env)
+
+	for _, key := range []string{"GEMINI_API_KEY", "AWS_SECRET_ACCESS_KEY"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("%s should not reach the agent", key)
+		}
+	}
+	for _, key := range []string{"PATH", "ANTHROPIC_API_KEY", "GOPATH"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("%s should be passed through", key)
+		}
+	}
+}
+
+func TestEnvPolicyAllowlistAndInjection(t *testing.T) {
+	dir := t.TempDir()
+	secretsFile := filepath.Join(dir, "secrets.yaml")
+	os.WriteFile(secretsFile, []byte("npm: npm_0123456789abcdef\n"), 0600)
+	store := secrets.NewStore(secretsFile, secrets.NewScanner())
+
+	policy := &EnvPolicy{
+		Allow: []string{"NODE_*"},
+		Set:   map[string]string{"NODE_ENV": "development", "CI": "1"},
+	}
+	base := []string{"PATH=/usr/bin", "NODE_OPTIONS=--max-old-space-size=4096", "EDITOR=vim"}
+	taskEnv := map[string]string{"NODE_ENV": "production", "NPM_TOKEN": "secret:npm"}
+
+	env, err := policy.Build(base, taskEnv, store)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	got := envMap(env)
+
+	if _, ok := got["EDITOR"]; ok {
+		t.Error("EDITOR is not on the allowlist")
+	}
+	if got["PATH"] != "/usr/bin" || got["NODE_OPTIONS"] == "" {
+		t.Errorf("essential and allowed variables missing: %v", got)
+	}
+	if got["NODE_ENV"] != "production" || got["CI"] != "1" {
+		t.Errorf("task env should override policy env: %v", got)
+	}
+	if got["NPM_TOKEN"] != "npm_0123456789abcdef" {
+		t.Errorf("secret reference not resolved: %q", got["NPM_TOKEN"])
+	}
+
+	if _, err := policy.Build(nil, map[string]string{"X": "secret:missing"}, nil); err == nil {
+		t.Error("secret reference without a store should fail")
+	}
+}
+
+func TestEnvPolicyFilePerAgent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env.yaml")
+	os.WriteFile(path, []byte(`
+default:
+  deny: [INTERNAL_*]
+  set: {LOG_LEVEL: info}
+agents:
+  agent-1:
+    set: {LOG_LEVEL: debug}
+`), 0644)
+
+	file, err := LoadEnvPolicyFile(path)
+	if err != nil {
+		t.Fatalf("LoadEnvPolicyFile() error = %v", err)
+	}
+
+	env, _ := file.PolicyFor("agent-1").Build([]string{"INTERNAL_URL=x", "GEMINI_API_KEY=g"}, nil, nil)
+	got := envMap(env)
+	if got["LOG_LEVEL"] != "debug" {
+		t.Errorf("agent override not applied: %v", got)
+	}
+	if _, ok := got["INTERNAL_URL"]; ok {
+		t.Error("file denylist not applied")
+	}
+	if _, ok := got["GEMINI_API_KEY"]; ok {
+		t.Error("built-in denylist must survive a policy file")
+	}
+
+	if got := envMap(mustBuild(t, file.PolicyFor("agent-0"))); got["LOG_LEVEL"] != "info" {
+		t.Errorf("agent-0 should use the default policy: %v", got)
+	}
+}
+
+func mustBuild(t *testing.T, policy *EnvPolicy) []string {
+	t.Helper()
+	env, err := policy.Build(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	return env
+}
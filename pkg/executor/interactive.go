@@ -0,0 +1,299 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/analyzer"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/secrets"
+)
+
+// ConfirmAction is what the executor did with a confirmation prompt
+type ConfirmAction string
+
+const (
+	ConfirmActionAuto     ConfirmAction = "auto_confirmed" // ShouldConfirm accepted the prompt
+	ConfirmActionApproved ConfirmAction = "human_approved" // Escalated and approved
+	ConfirmActionRejected ConfirmAction = "human_rejected" // Escalated and rejected
+	ConfirmActionBlocked  ConfirmAction = "blocked"        // Declined, nobody to escalate to
+	ConfirmActionTimeout  ConfirmAction = "timeout"        // Prompt not resolved in time
+)
+
+// ConfirmEscalator asks a human about a prompt ShouldConfirm refused.
+// It blocks until the prompt is approved, rejected or ctx is done.
+type ConfirmEscalator func(ctx context.Context, task *models.Task, prompt, reason string) (bool, error)
+
+const (
+	// promptGrace ignores redraws of a prompt that was just answered
+	promptGrace = 2 * time.Second
+	// exitGrace is how long the CLI may take to exit after /exit
+	exitGrace = 10 * time.Second
+)
+
+// ansiPattern matches terminal escape sequences (CSI, OSC and two-byte escapes)
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// cleanTerminalOutput strips escape sequences and normalizes line endings
+func cleanTerminalOutput(s string) string {
+	s = ansiPattern.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
+
+// runInteractive runs the task under a pseudo-terminal without bypassing
+// permissions. The screen is streamed into the Detector; confirmation
+// prompts are answered with ShouldConfirm or escalated. Once the CLI is back
+// at its idle prompt the session is ended with /exit.
+func (ce *ClaudeExecutor) runInteractive(ctx context.Context, task *models.Task, prompt string, limits models.ResourceLimits, guard resourceGuard) (string, error) {
+	env, err := ce.buildEnv(task)
+	if err != nil {
+		return "", err
+	}
+
+	master, slave, err := openPTY(50, 220)
+	if err != nil {
+		return "", err
+	}
+	defer master.Close()
+
+	cmd := exec.CommandContext(ctx, ce.cliPath, prompt)
+	cmd.Dir = ce.workDir
+	cmd.Env = append(env, "TERM=xterm-256color")
+	attachPTY(cmd, slave)
+	if err := ce.sandbox.Wrap(cmd); err != nil {
+		slave.Close()
+		return "", err
+	}
+
+	if err := startGuarded(cmd, guard); err != nil {
+		slave.Close()
+		return "", err
+	}
+	slave.Close()
+
+	// Read the terminal until the child closes it (EIO)
+	chunks := make(chan string, 64)
+	go func() {
+		defer close(chunks)
+		buf := make([]byte, 4096)
+		for {
+			n, err := master.Read(buf)
+			if n > 0 {
+				chunks <- string(buf[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+
+	ce.detector.Reset()
+
+	var transcript strings.Builder
+	var (
+		lastOutput = time.Now()
+		sawWork    bool
+		idle       bool
+		answeredAt time.Time
+		exitSentAt time.Time
+	)
+
+	stop := func() {
+		_ = killProcessGroup(cmd)
+		<-waitErr
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				chunks = nil
+				continue
+			}
+			text := cleanTerminalOutput(chunk)
+			transcript.WriteString(text)
+			lastOutput = time.Now()
+
+			if limits.MaxOutputBytes > 0 && int64(transcript.Len()) > limits.MaxOutputBytes {
+				stop()
+				return transcript.String(), breachError(models.LimitOutput, limits, "executor")
+			}
+
+			state := ce.detector.Analyze(text)
+			idle = state == models.AgentStateIdle
+			if !idle {
+				sawWork = true
+			}
+
+			if state == models.AgentStateWaitingConfirm && time.Since(answeredAt) > promptGrace {
+				if err := ce.handlePrompt(ctx, task, master); err != nil {
+					stop()
+					return transcript.String(), err
+				}
+				answeredAt = time.Now()
+			}
+
+		case err := <-waitErr:
+			drainChunks(chunks, &transcript)
+			if !exitSentAt.IsZero() {
+				// We ended the session ourselves
+				err = nil
+			}
+			return transcript.String(), err
+
+		case <-ticker.C:
+			if ce.detector.IsConfirmTimeout() {
+				wait := ce.detector.GetConfirmWaitDuration()
+				ce.detector.RecordConfirm(analyzer.ConfirmOutcomeTimeout)
+				prompt := ce.detector.GetRecentOutput(20)
+				ce.recordConfirm(task, confirmRecord{
+					prompt: prompt,
+					risk:   ce.detector.AssessRisk(prompt),
+					action: ConfirmActionTimeout,
+					reason: fmt.Sprintf("prompt still open after %s", wait.Round(time.Second)),
+				})
+				stop()
+				return transcript.String(), fmt.Errorf("confirmation prompt not resolved after %s", wait.Round(time.Second))
+			}
+
+			switch {
+			case exitSentAt.IsZero() && sawWork && idle && time.Since(lastOutput) > ce.idleSettle:
+				log.Printf("🏁 [%s] Agent is idle, ending interactive session", ce.workDir)
+				_, _ = io.WriteString(master, "/exit\r")
+				exitSentAt = time.Now()
+			case !exitSentAt.IsZero() && time.Since(exitSentAt) > exitGrace:
+				stop()
+				drainChunks(chunks, &transcript)
+				return transcript.String(), nil
+			}
+		}
+	}
+}
+
+// handlePrompt answers the confirmation prompt currently on screen
+func (ce *ClaudeExecutor) handlePrompt(ctx context.Context, task *models.Task, terminal io.Writer) error {
+	prompt := ce.detector.GetRecentOutput(20)
+	rec := confirmRecord{
+		prompt: prompt,
+		risk:   ce.detector.AssessRisk(prompt),
+	}
+
+	ok, input, reason := ce.detector.ShouldConfirm()
+	rec.reason = reason
+
+	switch {
+	case ok:
+		ce.detector.RecordConfirm(analyzer.ConfirmOutcomeAuto)
+		rec.action = ConfirmActionAuto
+
+	case ce.escalate != nil:
+		ce.detector.RecordConfirm(analyzer.ConfirmOutcomeManual)
+		log.Printf("🙋 [%s] Prompt needs a human decision: %s", ce.workDir, reason)
+
+		approved, err := ce.escalate(ctx, task, secrets.Default().Redact(prompt), reason)
+		if err != nil {
+			rec.action = ConfirmActionTimeout
+			rec.reason = fmt.Sprintf("%s; escalation failed: %v", reason, err)
+			ce.recordConfirm(task, rec)
+			return fmt.Errorf("confirmation escalation failed: %w", err)
+		}
+		if approved {
+			rec.action = ConfirmActionApproved
+			input = analyzer.GetConfirmationInput(prompt)
+		} else {
+			rec.action = ConfirmActionRejected
+			input = analyzer.GetRejectionInput(prompt)
+		}
+
+	default:
+		ce.detector.RecordConfirm(analyzer.ConfirmOutcomeBlocked)
+		rec.action = ConfirmActionBlocked
+		input = analyzer.GetRejectionInput(prompt)
+	}
+
+	rec.input = input
+	ce.recordConfirm(task, rec)
+
+	// Forget the answered prompt so redraws don't trigger it again
+	ce.detector.Reset()
+
+	// Esc acts immediately, everything else is submitted with Enter
+	if input != "\x1b" {
+		input += "\r"
+	}
+	_, err := io.WriteString(terminal, input)
+	return err
+}
+
+// confirmRecord describes how a single confirmation prompt was handled
+type confirmRecord struct {
+	prompt string
+	risk   analyzer.RiskLevel
+	action ConfirmAction
+	input  string
+	reason string
+}
+
+// recordConfirm logs a confirmation decision and appends it to the audit log
+func (ce *ClaudeExecutor) recordConfirm(task *models.Task, rec confirmRecord) {
+	log.Printf("🤖 [%s] Confirmation %s (risk: %s, input: %q)",
+		ce.workDir, rec.action, rec.risk, rec.input)
+
+	actor := "detector"
+	if rec.action == ConfirmActionApproved || rec.action == ConfirmActionRejected {
+		actor = "human"
+	}
+
+	if err := ce.audit.Record(audit.Entry{
+		Actor:       actor,
+		Decision:    audit.DecisionConfirm,
+		TaskID:      task.ID,
+		InputDigest: audit.Digest(rec.prompt),
+		Rule:        fmt.Sprintf("ShouldConfirm (risk %s)", rec.risk),
+		Action:      string(rec.action),
+		Outcome:     fmt.Sprintf("sent %q", rec.input),
+		Details: map[string]string{
+			"work_dir": ce.workDir,
+			"reason":   rec.reason,
+			"prompt":   rec.prompt,
+		},
+	}); err != nil {
+		log.Printf("⚠️  [%s] Failed to write audit entry: %v", ce.workDir, err)
+	}
+}
+
+// drainChunks appends what is left in the terminal buffer, without waiting
+// on grandchildren that may still hold the terminal open
+func drainChunks(chunks <-chan string, transcript *strings.Builder) {
+	if chunks == nil {
+		return
+	}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				return
+			}
+			transcript.WriteString(cleanTerminalOutput(chunk))
+		case <-timeout:
+			return
+		}
+	}
+}
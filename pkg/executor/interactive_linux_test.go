@@ -0,0 +1,131 @@
+//go:build linux
+
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+)
+
+// fakeCLI asks one confirmation question, records the answer and waits at an idle prompt
+const fakeCLI = `#!/bin/sh
+printf 'Working on: %s\n' "$1"
+printf 'Do you want to create notes.txt? (y/n) '
+read answer
+echo "$answer" > answer.txt
+printf 'Done.\n> '
+read cmd
+`
+
+func newFakeExecutor(t *testing.T, script string) *ClaudeExecutor {
+	t.Helper()
+
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "fake-claude")
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+
+	ce := NewClaudeExecutor(dir)
+	ce.cliPath = cli
+	ce.idleSettle = 200 * time.Millisecond
+	if err := ce.SetMode(ModeInteractive); err != nil {
+		t.Fatalf("SetMode() error = %v", err)
+	}
+	return ce
+}
+
+func readAnswer(t *testing.T, ce *ClaudeExecutor) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(ce.workDir, "answer.txt"))
+	if err != nil {
+		t.Fatalf("Fake CLI did not record an answer: %v", err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestInteractiveAutoConfirm(t *testing.T) {
+	ce := newFakeExecutor(t, fakeCLI)
+
+	auditLog, err := audit.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("audit.Open() error = %v", err)
+	}
+	defer auditLog.Close()
+	ce.SetAuditLog(auditLog)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	task := &models.Task{ID: "task-1", Description: "write some notes"}
+	if err := ce.ExecuteTask(ctx, task); err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+
+	if got := readAnswer(t, ce); got != "y" {
+		t.Errorf("answer = %q, want y", got)
+	}
+	decisions, err := audit.Query(auditLog.Path(), audit.Filter{Decision: audit.DecisionConfirm})
+	if err != nil {
+		t.Fatalf("audit.Query() error = %v", err)
+	}
+	if len(decisions) != 1 || decisions[0].Action != string(ConfirmActionAuto) || decisions[0].TaskID != "task-1" {
+		t.Errorf("Unexpected decisions: %+v", decisions)
+	}
+	if stats := ce.detector.GetConfirmStats(); stats.AutoConfirmed != 1 {
+		t.Errorf("AutoConfirmed = %d, want 1", stats.AutoConfirmed)
+	}
+}
+
+func TestInteractiveEscalatesRiskyPrompt(t *testing.T) {
+	script := strings.Replace(fakeCLI, "create notes.txt", "run rm -rf / on the host", 1)
+	ce := newFakeExecutor(t, script)
+
+	var asked string
+	ce.SetConfirmEscalator(func(ctx context.Context, task *models.Task, prompt, reason string) (bool, error) {
+		asked = prompt
+		return false, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	if err := ce.ExecuteTask(ctx, &models.Task{ID: "task-2", Description: "clean up"}); err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+
+	if !strings.Contains(asked, "rm -rf /") {
+		t.Errorf("Escalated prompt = %q, want the risky question", asked)
+	}
+	if got := readAnswer(t, ce); got != "n" {
+		t.Errorf("answer = %q, want n", got)
+	}
+	stats := ce.detector.GetConfirmStats()
+	if stats.ManualRequired != 1 || stats.AutoConfirmed != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestCleanTerminalOutput(t *testing.T) {
+	in := "\x1b[2K\x1b[1;32mDone\x1b[0m\r\n\x1b]0;title\x07> "
+	if got := cleanTerminalOutput(in); got != "Done\n> " {
+		t.Errorf("cleanTerminalOutput() = %q", got)
+	}
+}
+
+func TestSetModeRejectsUnknown(t *testing.T) {
+	ce := NewClaudeExecutor(t.TempDir())
+	if err := ce.SetMode(Mode("tmux")); err == nil {
+		t.Error("SetMode() should reject unknown modes")
+	}
+	if ce.mode != ModePipe {
+		t.Errorf("mode = %s, want %s", ce.mode, ModePipe)
+	}
+}
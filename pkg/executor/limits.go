@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// DefaultResourceLimits are the per-agent limits used unless configured
+func DefaultResourceLimits() models.ResourceLimits {
+	return models.ResourceLimits{
+		TimeoutSeconds: 600,
+		MaxOutputBytes: 16 << 20,
+	}
+}
+
+// ResourceLimitError is returned when an agent run breaches a resource limit
+type ResourceLimitError struct {
+	Resource models.LimitResource
+	Limit    string // Human-readable limit, e.g. "2048 MB"
+	Enforcer string // cgroup, rlimit or executor
+}
+
+func (e *ResourceLimitError) Error() string {
+	return fmt.Sprintf("resource limit exceeded: %s (limit %s, enforced by %s)", e.Resource, e.Limit, e.Enforcer)
+}
+
+// breachError describes a breach of resource under limits
+func breachError(resource models.LimitResource, limits models.ResourceLimits, enforcer string) *ResourceLimitError {
+	var limit string
+	switch resource {
+	case models.LimitTimeout:
+		limit = limits.Timeout().String()
+	case models.LimitMemory:
+		limit = fmt.Sprintf("%d MB", limits.MemoryMB)
+	case models.LimitCPU:
+		limit = fmt.Sprintf("%d%% CPU", limits.CPUPercent)
+	case models.LimitProcesses:
+		limit = fmt.Sprintf("%d processes", limits.MaxProcs)
+	case models.LimitOutput:
+		limit = fmt.Sprintf("%d bytes", limits.MaxOutputBytes)
+	}
+	return &ResourceLimitError{Resource: resource, Limit: limit, Enforcer: enforcer}
+}
+
+// resourceGuard enforces memory, CPU and process limits on one process tree
+type resourceGuard interface {
+	// prepare is called before Start and may adjust cmd.SysProcAttr
+	prepare(cmd *exec.Cmd) error
+	// started is called with the pid right after Start
+	started(pid int) error
+	// finish is called after Wait; it returns the breached resource, if any,
+	// and releases the guard
+	finish() models.LimitResource
+	// name identifies the enforcement mechanism
+	name() string
+}
+
+// noopGuard is used when only timeout and output are limited
+type noopGuard struct{}
+
+func (noopGuard) prepare(cmd *exec.Cmd) error  { return nil }
+func (noopGuard) started(pid int) error        { return nil }
+func (noopGuard) finish() models.LimitResource { return "" }
+func (noopGuard) name() string                 { return "executor" }
+
+// limitedBuffer captures output up to max bytes and calls onExceed once
+// when more is written. Writes beyond the limit are dropped.
+type limitedBuffer struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	max      int64
+	exceeded bool
+	onExceed func()
+}
+
+func newLimitedBuffer(max int64, onExceed func()) *limitedBuffer {
+	return &limitedBuffer{max: max, onExceed: onExceed}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.max > 0 && int64(b.buf.Len()+len(p)) > b.max {
+		if room := b.max - int64(b.buf.Len()); room > 0 {
+			b.buf.Write(p[:room])
+		}
+		if !b.exceeded {
+			b.exceeded = true
+			if b.onExceed != nil {
+				go b.onExceed()
+			}
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// Exceeded reports whether output was truncated
+func (b *limitedBuffer) Exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// ParseByteSize parses sizes like "512M", "2G", "16MiB" or plain bytes
+func ParseByteSize(size string) (int64, error) {
+	s := strings.TrimSpace(strings.ToUpper(size))
+	if s == "" {
+		return 0, nil
+	}
+
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512M, 2G)", size)
+	}
+	return int64(n * float64(multiplier)), nil
+}
@@ -0,0 +1,352 @@
+//go:build linux
+
+package executor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// cgroupControllers must be delegated to use cgroups v2
+var cgroupControllers = []string{"memory", "cpu", "pids"}
+
+var (
+	cgroupOnce sync.Once
+	cgroupBase string // cgroup v2 directory agent cgroups are created in
+	cgroupErr  error
+	cgroupSeq  atomic.Int64
+
+	cpuWarnOnce  sync.Once
+	rootWarnOnce sync.Once
+)
+
+// newResourceGuard returns a cgroup v2 guard when cgroups are delegated to
+// us and an rlimit guard otherwise
+func newResourceGuard(limits models.ResourceLimits, label string) resourceGuard {
+	if limits.MemoryMB == 0 && limits.CPUPercent == 0 && limits.MaxProcs == 0 {
+		return noopGuard{}
+	}
+	if base, err := cgroupV2Base(); err == nil {
+		return &cgroupGuard{limits: limits, base: base, label: label}
+	}
+	return &rlimitGuard{limits: limits}
+}
+
+// ResourceEnforcement describes how memory, CPU and process limits are
+// enforced on this host
+func ResourceEnforcement() string {
+	base, err := cgroupV2Base()
+	if err != nil {
+		return fmt.Sprintf("rlimits, process limit counted per user (cgroups v2 unavailable: %v)", err)
+	}
+	return "cgroups v2 (" + base + ")"
+}
+
+// cgroupV2Base finds our own cgroup v2 directory and enables the memory, cpu
+// and pids controllers for its children. If our cgroup holds processes the
+// controllers can't be enabled, so the coordinator first moves itself into a
+// leaf cgroup.
+func cgroupV2Base() (string, error) {
+	cgroupOnce.Do(func() {
+		cgroupBase, cgroupErr = setupCgroupV2()
+	})
+	return cgroupBase, cgroupErr
+}
+
+func setupCgroupV2() (string, error) {
+	mount, err := cgroupV2Mount()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	var own string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			own = strings.TrimPrefix(line, "0::")
+		}
+	}
+	if own == "" {
+		return "", fmt.Errorf("process is not in a cgroup v2 hierarchy")
+	}
+	base := filepath.Join(mount, own)
+
+	available, err := os.ReadFile(filepath.Join(base, "cgroup.controllers"))
+	if err != nil {
+		return "", err
+	}
+	for _, c := range cgroupControllers {
+		if !containsField(string(available), c) {
+			return "", fmt.Errorf("%s controller not delegated to %s", c, base)
+		}
+	}
+
+	enable := "+" + strings.Join(cgroupControllers, " +")
+	subtree := filepath.Join(base, "cgroup.subtree_control")
+	if err := os.WriteFile(subtree, []byte(enable), 0644); err != nil {
+		// "No internal processes": move ourselves into a leaf, then retry
+		leaf := filepath.Join(base, "swarm-coordinator")
+		if mkErr := os.MkdirAll(leaf, 0755); mkErr != nil {
+			return "", fmt.Errorf("cannot enable controllers in %s: %w", base, err)
+		}
+		pid := []byte(strconv.Itoa(os.Getpid()))
+		if mvErr := os.WriteFile(filepath.Join(leaf, "cgroup.procs"), pid, 0644); mvErr != nil {
+			return "", fmt.Errorf("cannot enable controllers in %s: %w", base, err)
+		}
+		if err := os.WriteFile(subtree, []byte(enable), 0644); err != nil {
+			return "", fmt.Errorf("cannot enable controllers in %s: %w", base, err)
+		}
+	}
+	return base, nil
+}
+
+// cgroupV2Mount returns where the cgroup2 filesystem is mounted
+func cgroupV2Mount() (string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 0:30 / /sys/fs/cgroup rw,nosuid - cgroup2 cgroup2 rw
+		fields := strings.Fields(scanner.Text())
+		for i, field := range fields {
+			if field == "-" && i+1 < len(fields) && fields[i+1] == "cgroup2" && len(fields) > 4 {
+				return fields[4], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cgroup2 filesystem not mounted")
+}
+
+// cgroupGuard runs the process tree in its own cgroup
+type cgroupGuard struct {
+	limits models.ResourceLimits
+	base   string
+	label  string
+	dir    string
+	fd     int
+}
+
+func (g *cgroupGuard) name() string { return "cgroup" }
+
+func (g *cgroupGuard) prepare(cmd *exec.Cmd) error {
+	g.dir = filepath.Join(g.base, fmt.Sprintf("swarm-%s-%d-%d", g.label, os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(g.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cgroup: %w", err)
+	}
+
+	settings := map[string]string{}
+	if g.limits.MemoryMB > 0 {
+		settings["memory.max"] = strconv.FormatInt(int64(g.limits.MemoryMB)<<20, 10)
+		settings["memory.swap.max"] = "0"
+	}
+	if g.limits.CPUPercent > 0 {
+		settings["cpu.max"] = fmt.Sprintf("%d 100000", g.limits.CPUPercent*1000)
+	}
+	if g.limits.MaxProcs > 0 {
+		settings["pids.max"] = strconv.Itoa(g.limits.MaxProcs)
+	}
+	for file, value := range settings {
+		err := os.WriteFile(filepath.Join(g.dir, file), []byte(value), 0644)
+		if err != nil && file != "memory.swap.max" { // No swap accounting is fine
+			g.remove()
+			return fmt.Errorf("failed to set %s: %w", file, err)
+		}
+	}
+
+	fd, err := syscall.Open(g.dir, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		g.remove()
+		return fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	g.fd = fd
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = fd
+	return nil
+}
+
+func (g *cgroupGuard) started(pid int) error {
+	return syscall.Close(g.fd)
+}
+
+func (g *cgroupGuard) finish() models.LimitResource {
+	if g.dir == "" {
+		return ""
+	}
+	var breach models.LimitResource
+	switch {
+	case readEventCount(filepath.Join(g.dir, "memory.events"), "oom_kill") > 0:
+		breach = models.LimitMemory
+	case readEventCount(filepath.Join(g.dir, "pids.events"), "max") > 0:
+		breach = models.LimitProcesses
+	}
+	g.remove()
+	return breach
+}
+
+// remove kills anything left in the cgroup and deletes it
+func (g *cgroupGuard) remove() {
+	if err := os.WriteFile(filepath.Join(g.dir, "cgroup.kill"), []byte("1"), 0644); err != nil {
+		// cgroup.kill needs Linux 5.14; kill the members one by one
+		if procs, err := os.ReadFile(filepath.Join(g.dir, "cgroup.procs")); err == nil {
+			for _, field := range strings.Fields(string(procs)) {
+				if pid, err := strconv.Atoi(field); err == nil {
+					_ = syscall.Kill(pid, syscall.SIGKILL)
+				}
+			}
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := os.Remove(g.dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	log.Printf("⚠️  Failed to remove cgroup %s", g.dir)
+}
+
+// readEventCount reads "key N" from a cgroup events file
+func readEventCount(path, key string) int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			n, _ := strconv.ParseInt(fields[1], 10, 64)
+			return n
+		}
+	}
+	return 0
+}
+
+// rlimitNproc is RLIMIT_NPROC, missing from package syscall
+const rlimitNproc = 6
+
+// rlimitInitEnv carries the limits to the re-executed rlimit shim
+const rlimitInitEnv = "_SWARM_RLIMIT_INIT"
+
+// rlimitGuard starts the command through a shim: this binary re-executed
+// with rlimitInitEnv set, which sets the rlimits on itself and then execs
+// the command, so they are in place before it runs and are inherited by
+// its children. Memory is bounded with RLIMIT_DATA, processes with
+// RLIMIT_NPROC. RLIMIT_NPROC counts every process of the user, not just the
+// agent's, and is not enforced for root. CPU share can't be expressed as an
+// rlimit.
+type rlimitGuard struct {
+	limits models.ResourceLimits
+}
+
+func (g *rlimitGuard) name() string { return "rlimit" }
+
+func (g *rlimitGuard) prepare(cmd *exec.Cmd) error {
+	if g.limits.CPUPercent > 0 {
+		cpuWarnOnce.Do(func() {
+			log.Printf("⚠️  CPU share limits need cgroups v2 and are not enforced on this host")
+		})
+	}
+	if g.limits.MaxProcs > 0 && os.Geteuid() == 0 {
+		rootWarnOnce.Do(func() {
+			log.Printf("⚠️  Process limits are not enforced for root without cgroups v2")
+		})
+	}
+	if g.limits.MemoryMB == 0 && g.limits.MaxProcs == 0 {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate rlimit shim: %w", err)
+	}
+	data, err := json.Marshal(g.limits)
+	if err != nil {
+		return err
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(withoutEnv(env, rlimitInitEnv), rlimitInitEnv+"="+string(data))
+	cmd.Args = append([]string{"swarm-rlimit", cmd.Path}, cmd.Args...)
+	cmd.Path = exe
+	return nil
+}
+
+func (g *rlimitGuard) started(pid int) error { return nil }
+
+func (g *rlimitGuard) finish() models.LimitResource { return "" }
+
+// runRlimitInit is the shim's main. os.Args holds the command's path
+// followed by its argv. It only returns if the limits or the exec fail.
+func runRlimitInit(data string) int {
+	var limits models.ResourceLimits
+	if err := json.Unmarshal([]byte(data), &limits); err != nil || len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "swarm-rlimit: invalid invocation\n")
+		return 125
+	}
+
+	if limits.MemoryMB > 0 {
+		if err := setRlimit(syscall.RLIMIT_DATA, uint64(limits.MemoryMB)<<20); err != nil {
+			fmt.Fprintf(os.Stderr, "swarm-rlimit: failed to set memory rlimit: %v\n", err)
+			return 125
+		}
+	}
+	if limits.MaxProcs > 0 {
+		if err := setRlimit(rlimitNproc, uint64(limits.MaxProcs)); err != nil {
+			fmt.Fprintf(os.Stderr, "swarm-rlimit: failed to set process rlimit: %v\n", err)
+			return 125
+		}
+	}
+
+	err := syscall.Exec(os.Args[1], os.Args[2:], withoutEnv(os.Environ(), rlimitInitEnv))
+	fmt.Fprintf(os.Stderr, "swarm-rlimit: failed to exec %s: %v\n", os.Args[1], err)
+	return 127
+}
+
+// setRlimit sets both the soft and hard limit of resource for this process
+func setRlimit(resource int, value uint64) error {
+	limit := syscall.Rlimit{Cur: value, Max: value}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, 0, uintptr(resource),
+		uintptr(unsafe.Pointer(&limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// containsField reports whether the whitespace-separated list s contains f
+func containsField(s, f string) bool {
+	for _, field := range strings.Fields(s) {
+		if field == f {
+			return true
+		}
+	}
+	return false
+}
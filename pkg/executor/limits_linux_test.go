@@ -0,0 +1,34 @@
+//go:build linux
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func TestRlimitGuardAppliesLimitsBeforeExec(t *testing.T) {
+	guard := &rlimitGuard{limits: models.ResourceLimits{MemoryMB: 512, MaxProcs: 64}}
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(context.Background(), "cat", "/proc/self/limits")
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := startGuarded(cmd, guard); err != nil {
+		t.Fatalf("startGuarded() error = %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("guarded command failed: %v\n%s", err, out.String())
+	}
+
+	for _, want := range []string{"Max data size             536870912", "Max processes             64"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the command's limits, got:\n%s", want, out.String())
+		}
+	}
+}
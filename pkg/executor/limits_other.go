@@ -0,0 +1,28 @@
+//go:build !linux
+
+package executor
+
+import (
+	"log"
+	"sync"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+var limitWarnOnce sync.Once
+
+// newResourceGuard only warns: memory, CPU and process limits need Linux
+func newResourceGuard(limits models.ResourceLimits, label string) resourceGuard {
+	if limits.MemoryMB > 0 || limits.CPUPercent > 0 || limits.MaxProcs > 0 {
+		limitWarnOnce.Do(func() {
+			log.Printf("⚠️  Memory, CPU and process limits are only enforced on Linux")
+		})
+	}
+	return noopGuard{}
+}
+
+// ResourceEnforcement describes how memory, CPU and process limits are
+// enforced on this host
+func ResourceEnforcement() string {
+	return "none (timeout and output only)"
+}
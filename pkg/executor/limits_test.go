@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func newPipeExecutor(t *testing.T, script string) *ClaudeExecutor {
+	t.Helper()
+
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "fake-claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+
+	ce := NewClaudeExecutor(dir)
+	ce.cliPath = cli
+	return ce
+}
+
+func TestExecuteTaskTimeoutIsLimitError(t *testing.T) {
+	// The grandchild keeps the output pipe open; the whole group must die
+	ce := newPipeExecutor(t, "sleep 30 & sleep 30")
+	ce.SetResourceLimits(models.ResourceLimits{TimeoutSeconds: 1})
+
+	start := time.Now()
+	err := ce.ExecuteTask(context.Background(), &models.Task{ID: "task-1", Description: "run the tests"})
+
+	var limitErr *ResourceLimitError
+	if !errors.As(err, &limitErr) || limitErr.Resource != models.LimitTimeout {
+		t.Fatalf("ExecuteTask() error = %v, want a timeout ResourceLimitError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("ExecuteTask() took %s after the timeout", elapsed)
+	}
+}
+
+func TestExecuteTaskOutputLimit(t *testing.T) {
+	ce := newPipeExecutor(t, "while true; do echo 'lots of output from a runaway test suite'; done")
+	ce.SetResourceLimits(models.ResourceLimits{TimeoutSeconds: 30, MaxOutputBytes: 4096})
+
+	err := ce.ExecuteTask(context.Background(), &models.Task{ID: "task-1", Description: "run the tests"})
+
+	var limitErr *ResourceLimitError
+	if !errors.As(err, &limitErr) || limitErr.Resource != models.LimitOutput {
+		t.Fatalf("ExecuteTask() error = %v, want an output ResourceLimitError", err)
+	}
+}
+
+func TestTaskLimitsOverrideAgentLimits(t *testing.T) {
+	agent := models.ResourceLimits{TimeoutSeconds: 600, MemoryMB: 2048, MaxOutputBytes: 1 << 20}
+	got := agent.WithOverrides(&models.ResourceLimits{TimeoutSeconds: 60})
+
+	want := models.ResourceLimits{TimeoutSeconds: 60, MemoryMB: 2048, MaxOutputBytes: 1 << 20}
+	if got != want {
+		t.Errorf("WithOverrides() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"":      0,
+		"4096":  4096,
+		"512K":  512 << 10,
+		"16M":   16 << 20,
+		"16MiB": 16 << 20,
+		"1.5G":  3 << 29,
+		"2gb":   2 << 30,
+	}
+	for in, want := range tests {
+		if got, err := ParseByteSize(in); err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := ParseByteSize("lots"); err == nil {
+		t.Error("ParseByteSize() should reject invalid sizes")
+	}
+}
+
+func TestRunCheckUsesAgentPolicy(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_coordinator")
+	ce := newPipeExecutor(t, "")
+	ce.SetResourceLimits(models.ResourceLimits{MaxOutputBytes: 4096})
+	task := &models.Task{ID: "task-1", Env: map[string]string{"CHECK_MODE": "strict"}}
+
+	output, err := ce.RunCheck(context.Background(), task, `echo "token=$GITHUB_TOKEN mode=$CHECK_MODE"; pwd`)
+	if err != nil {
+		t.Fatalf("RunCheck() error = %v", err)
+	}
+	want := "token= mode=strict\n" + ce.workDir + "\n"
+	if output != want {
+		t.Errorf("RunCheck() output = %q, want %q", output, want)
+	}
+
+	_, err = ce.RunCheck(context.Background(), task, "yes 'runaway check output'")
+	var limitErr *ResourceLimitError
+	if !errors.As(err, &limitErr) || limitErr.Resource != models.LimitOutput {
+		t.Errorf("RunCheck() error = %v, want an output ResourceLimitError", err)
+	}
+}
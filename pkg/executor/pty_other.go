@@ -0,0 +1,18 @@
+//go:build !linux
+
+package executor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// ptySupported reports whether interactive mode can run on this platform
+const ptySupported = false
+
+func openPTY(rows, cols uint16) (master, slave *os.File, err error) {
+	return nil, nil, errors.New("interactive mode requires Linux")
+}
+
+func attachPTY(cmd *exec.Cmd, slave *os.File) {}
@@ -0,0 +1,464 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SandboxBackend selects how agent processes are isolated
+type SandboxBackend string
+
+const (
+	SandboxAuto   SandboxBackend = "auto"   // bubblewrap if installed, otherwise native namespaces
+	SandboxBwrap  SandboxBackend = "bwrap"  // bubblewrap (bwrap)
+	SandboxNative SandboxBackend = "native" // clone(2) with user/mount namespaces and a re-exec helper
+)
+
+// NetworkMode selects the network access of sandboxed agents
+type NetworkMode string
+
+const (
+	NetworkHost      NetworkMode = "host"      // Share the host network
+	NetworkNone      NetworkMode = "none"      // Private network namespace with loopback only
+	NetworkAllowlist NetworkMode = "allowlist" // Private namespace, HTTP(S) through an allowlist proxy
+)
+
+// defaultAllowHosts are always reachable in allowlist mode so the agent can
+// talk to its API
+var defaultAllowHosts = []string{"api.anthropic.com", "*.anthropic.com"}
+
+// systemReadOnlyPaths hold the toolchain and are mounted read-only
+var systemReadOnlyPaths = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc", "/opt"}
+
+// homeToolchainPaths are visible read-only inside the otherwise hidden $HOME
+var homeToolchainPaths = []string{
+	"go", "sdk", ".cargo", ".rustup", ".nvm", ".volta", ".bun", ".npm-global",
+	".local/bin", ".gitconfig",
+}
+
+// homeStatePaths are visible read-write inside $HOME; the CLI keeps its
+// login and session state there
+var homeStatePaths = []string{".claude", ".claude.json"}
+
+// SandboxConfig configures the sandbox around agent processes
+type SandboxConfig struct {
+	Backend       SandboxBackend
+	Network       NetworkMode
+	AllowHosts    []string // Extra hosts for NetworkAllowlist; "*.example.com" matches subdomains
+	ReadOnlyPaths []string // Extra paths visible read-only
+	WritablePaths []string // Extra paths visible read-write
+	HiddenPaths   []string // Extra paths replaced by an empty directory or file
+}
+
+// Sandbox wraps agent commands so they only see their worktree read-write,
+// the toolchain read-only and nothing of $HOME except CLI state.
+// A nil *Sandbox leaves commands untouched.
+type Sandbox struct {
+	cfg     SandboxConfig
+	backend SandboxBackend
+	bwrap   string // Path of bwrap for SandboxBwrap
+	exe     string // Path of this binary, re-executed as the sandbox helper
+	proxy   *allowlistProxy
+}
+
+// SandboxStatus describes sandbox support on this host
+type SandboxStatus struct {
+	Available      bool
+	Backend        SandboxBackend // Backend SandboxAuto would pick
+	BwrapPath      string
+	UserNamespaces bool
+	Detail         string
+}
+
+// NewSandbox checks that the requested backend works on this host and
+// starts the allowlist proxy if needed. Close releases the proxy.
+func NewSandbox(cfg SandboxConfig) (*Sandbox, error) {
+	if cfg.Backend == "" {
+		cfg.Backend = SandboxAuto
+	}
+	if cfg.Network == "" {
+		cfg.Network = NetworkHost
+	}
+	switch cfg.Network {
+	case NetworkHost, NetworkNone, NetworkAllowlist:
+	default:
+		return nil, fmt.Errorf("unknown sandbox network mode: %q", cfg.Network)
+	}
+
+	status := CheckSandbox()
+	if !status.Available {
+		return nil, fmt.Errorf("sandbox is not available: %s", status.Detail)
+	}
+
+	s := &Sandbox{cfg: cfg}
+	switch cfg.Backend {
+	case SandboxAuto:
+		s.backend = status.Backend
+	case SandboxBwrap:
+		if status.BwrapPath == "" {
+			return nil, fmt.Errorf("bwrap not found in PATH")
+		}
+		s.backend = SandboxBwrap
+	case SandboxNative:
+		if !status.UserNamespaces {
+			return nil, fmt.Errorf("unprivileged user namespaces are disabled: %s", status.Detail)
+		}
+		s.backend = SandboxNative
+	default:
+		return nil, fmt.Errorf("unknown sandbox backend: %q", cfg.Backend)
+	}
+	s.bwrap = status.BwrapPath
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable for the sandbox helper: %w", err)
+	}
+	s.exe = exe
+
+	if cfg.Network == NetworkAllowlist {
+		proxy, err := startAllowlistProxy(append(append([]string{}, defaultAllowHosts...), cfg.AllowHosts...))
+		if err != nil {
+			return nil, err
+		}
+		s.proxy = proxy
+	}
+
+	return s, nil
+}
+
+// Backend returns the backend in use
+func (s *Sandbox) Backend() SandboxBackend {
+	if s == nil {
+		return ""
+	}
+	return s.backend
+}
+
+// Network returns the network mode in use
+func (s *Sandbox) Network() NetworkMode {
+	if s == nil {
+		return NetworkHost
+	}
+	return s.cfg.Network
+}
+
+// Close stops the allowlist proxy
+func (s *Sandbox) Close() error {
+	if s == nil || s.proxy == nil {
+		return nil
+	}
+	return s.proxy.Close()
+}
+
+// sandboxSpec is what the sandbox must set up for one command. For the
+// native backend and the proxy forwarder it is passed to the re-executed
+// helper as JSON.
+type sandboxSpec struct {
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+	Env  []string `json:"env"`
+	Dir  string   `json:"dir"`
+
+	Masks    []string `json:"masks,omitempty"`    // Replaced by an empty tmpfs ($HOME, /tmp)
+	Hidden   []string `json:"hidden,omitempty"`   // Replaced by an empty dir or /dev/null
+	System   []string `json:"system,omitempty"`   // Remounted read-only in place
+	ReadOnly []string `json:"readonly,omitempty"` // Kept visible read-only under a mask
+	Writable []string `json:"writable,omitempty"` // Kept visible read-write
+
+	Protected []string `json:"protected,omitempty"` // Read-only inside a writable path
+
+	Mount     bool   `json:"mount,omitempty"`      // Helper performs the mounts (native)
+	Loopback  bool   `json:"loopback,omitempty"`   // Helper brings up lo (native, private network)
+	ProxySock string `json:"proxy_sock,omitempty"` // Unix socket of the allowlist proxy
+	UID       int    `json:"uid"`
+	GID       int    `json:"gid"`
+}
+
+// planFor builds the mount plan for cmd. Paths that don't exist are left out.
+func (s *Sandbox) planFor(cmd *exec.Cmd) (*sandboxSpec, error) {
+	workDir, err := filepath.Abs(cmd.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+
+	spec := &sandboxSpec{
+		Path: cmd.Path,
+		Args: cmd.Args,
+		Env:  env,
+		Dir:  workDir,
+		UID:  os.Getuid(),
+		GID:  os.Getgid(),
+	}
+
+	home, _ := os.UserHomeDir()
+	if home != "" && home != "/" {
+		spec.Masks = append(spec.Masks, home)
+		for _, p := range homeToolchainPaths {
+			spec.ReadOnly = appendExisting(spec.ReadOnly, filepath.Join(home, p))
+		}
+		for _, p := range homeStatePaths {
+			spec.Writable = appendExisting(spec.Writable, filepath.Join(home, p))
+		}
+		// The helper binary itself may live under $HOME (e.g. ~/go/bin)
+		if isWithin(home, s.exe) {
+			spec.ReadOnly = appendExisting(spec.ReadOnly, s.exe)
+		}
+	}
+	spec.Masks = append(spec.Masks, os.TempDir())
+
+	for _, p := range systemReadOnlyPaths {
+		if info, err := os.Lstat(p); err == nil && info.IsDir() {
+			spec.System = append(spec.System, p)
+		}
+	}
+
+	// Worktrees commit into the main repository's .git directory. Hooks,
+	// config and the worktree's links back to it stay read-only: git runs
+	// what they point at outside the sandbox.
+	spec.Writable = append(spec.Writable, workDir)
+	if gitDir := gitCommonDir(workDir); gitDir != "" {
+		if !isWithin(workDir, gitDir) {
+			spec.Writable = appendExisting(spec.Writable, gitDir)
+		}
+		spec.Protected = gitProtectedPaths(workDir, gitDir)
+	}
+
+	for _, p := range s.cfg.ReadOnlyPaths {
+		spec.ReadOnly = appendExisting(spec.ReadOnly, expandHome(p, home))
+	}
+	for _, p := range s.cfg.WritablePaths {
+		spec.Writable = appendExisting(spec.Writable, expandHome(p, home))
+	}
+	for _, p := range s.cfg.HiddenPaths {
+		spec.Hidden = appendExisting(spec.Hidden, expandHome(p, home))
+	}
+
+	if s.proxy != nil {
+		spec.ProxySock = s.proxy.socket
+		spec.Writable = append(spec.Writable, filepath.Dir(s.proxy.socket))
+	}
+
+	return spec, nil
+}
+
+// gitCommonDir returns the absolute .git directory shared by a worktree
+func gitCommonDir(workDir string) string {
+	out, err := exec.Command("git", "-C", workDir, "rev-parse", "--git-common-dir").Output()
+	if err != nil {
+		return ""
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// gitProtectedPaths lists the files in a repository that make git run
+// commands or read its configuration from somewhere else
+func gitProtectedPaths(workDir, commonDir string) []string {
+	var paths []string
+	for _, p := range []string{"hooks", "config", "info"} {
+		paths = appendExisting(paths, filepath.Join(commonDir, p))
+	}
+	if info, err := os.Lstat(filepath.Join(workDir, ".git")); err == nil && !info.IsDir() {
+		paths = append(paths, filepath.Join(workDir, ".git"))
+	}
+	out, err := exec.Command("git", "-C", workDir, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return paths
+	}
+	if gitDir := strings.TrimSpace(string(out)); gitDir != commonDir {
+		for _, p := range []string{"commondir", "gitdir", "config.worktree"} {
+			paths = appendExisting(paths, filepath.Join(gitDir, p))
+		}
+	}
+	return paths
+}
+
+// appendExisting appends path if it exists
+func appendExisting(paths []string, path string) []string {
+	if path == "" {
+		return paths
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return paths
+	}
+	return append(paths, filepath.Clean(path))
+}
+
+// isWithin reports whether path is dir or below it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// expandHome expands a leading ~/ to home
+func expandHome(path, home string) string {
+	if strings.HasPrefix(path, "~/") && home != "" {
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
+
+// sandboxProxyPort is where the helper exposes the allowlist proxy inside
+// the sandbox's private network namespace
+const sandboxProxyPort = 3128
+
+// proxyEnv points HTTP clients inside the sandbox at the proxy forwarder
+func proxyEnv() []string {
+	url := fmt.Sprintf("http://127.0.0.1:%d", sandboxProxyPort)
+	return []string{
+		"HTTPS_PROXY=" + url, "HTTP_PROXY=" + url,
+		"https_proxy=" + url, "http_proxy=" + url,
+	}
+}
+
+// allowlistProxy is an HTTP proxy on a unix socket that only lets
+// sandboxed agents reach allowed hosts
+type allowlistProxy struct {
+	socket string
+	allow  []string
+	server *http.Server
+}
+
+// startAllowlistProxy listens on a unix socket in a fresh temp directory
+func startAllowlistProxy(allow []string) (*allowlistProxy, error) {
+	dir, err := os.MkdirTemp("", "swarm-proxy-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proxy directory: %w", err)
+	}
+
+	p := &allowlistProxy{
+		socket: filepath.Join(dir, "proxy.sock"),
+		allow:  allow,
+	}
+	ln, err := net.Listen("unix", p.socket)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to start sandbox proxy: %w", err)
+	}
+
+	p.server = &http.Server{Handler: p}
+	go p.server.Serve(ln)
+	return p, nil
+}
+
+// Close stops the proxy and removes its socket
+func (p *allowlistProxy) Close() error {
+	err := p.server.Close()
+	os.RemoveAll(filepath.Dir(p.socket))
+	return err
+}
+
+// allowed reports whether host (with or without port) is on the allowlist
+func (p *allowlistProxy) allowed(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	for _, pattern := range p.allow {
+		pattern = strings.ToLower(pattern)
+		if strings.HasPrefix(pattern, "*.") {
+			if strings.HasSuffix(host, pattern[1:]) || host == pattern[2:] {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *allowlistProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if r.Method != http.MethodConnect && r.URL.Host != "" {
+		host = r.URL.Host
+	}
+	if !p.allowed(host) {
+		log.Printf("🚫 Sandbox proxy blocked %s %s", r.Method, host)
+		http.Error(w, "host not on the sandbox allowlist", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, host)
+		return
+	}
+
+	// Plain HTTP request in proxy form
+	r.RequestURI = ""
+	r.Header.Del("Proxy-Connection")
+	r.Header.Del("Proxy-Authorization")
+	resp, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// tunnel handles CONNECT by splicing the client to the target
+func (p *allowlistProxy) tunnel(w http.ResponseWriter, host string) {
+	target, err := net.DialTimeout("tcp", host, 30*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		target.Close()
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, _, err := hijacker.Hijack()
+	if err != nil {
+		target.Close()
+		return
+	}
+
+	_, _ = client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+	splice(client, target)
+}
+
+// splice copies between a and b until either side closes
+func splice(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		done <- struct{}{}
+	}()
+	<-done
+	a.Close()
+	b.Close()
+}
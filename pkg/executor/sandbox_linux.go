@@ -0,0 +1,481 @@
+//go:build linux
+
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// sandboxInitEnv carries the sandboxSpec to the re-executed helper
+const sandboxInitEnv = "_SWARM_SANDBOX_INIT"
+
+// The helper is this binary started with sandboxInitEnv set. It takes over
+// before main runs, sets up the sandbox and runs the real command.
+func init() {
+	// The rlimit shim may start the helper; it runs first and execs it
+	if limits := os.Getenv(rlimitInitEnv); limits != "" {
+		os.Exit(runRlimitInit(limits))
+	}
+	if spec := os.Getenv(sandboxInitEnv); spec != "" {
+		os.Exit(runSandboxInit(spec))
+	}
+}
+
+// CheckSandbox reports whether agents can be sandboxed on this host
+func CheckSandbox() SandboxStatus {
+	status := SandboxStatus{}
+	if path, err := exec.LookPath("bwrap"); err == nil {
+		status.BwrapPath = path
+	}
+
+	// Only a real clone tells whether user namespaces are permitted
+	probe := exec.Command("/bin/true")
+	probe.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+	}
+	probeErr := probe.Run()
+	status.UserNamespaces = probeErr == nil
+
+	switch {
+	case status.BwrapPath != "":
+		status.Available = true
+		status.Backend = SandboxBwrap
+		status.Detail = "bubblewrap at " + status.BwrapPath
+	case status.UserNamespaces:
+		status.Available = true
+		status.Backend = SandboxNative
+		status.Detail = "native user and mount namespaces"
+	default:
+		status.Detail = fmt.Sprintf("bwrap not installed and user namespaces unavailable (%v)", probeErr)
+	}
+	return status
+}
+
+// Wrap rewrites cmd to run inside the sandbox. It must be called after
+// Path, Args, Dir, Env and SysProcAttr are set and before Start.
+func (s *Sandbox) Wrap(cmd *exec.Cmd) error {
+	if s == nil {
+		return nil
+	}
+
+	spec, err := s.planFor(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to plan sandbox: %w", err)
+	}
+
+	if s.backend == SandboxBwrap {
+		return s.wrapBwrap(cmd, spec)
+	}
+	return s.wrapNative(cmd, spec)
+}
+
+// wrapBwrap runs cmd under bubblewrap
+func (s *Sandbox) wrapBwrap(cmd *exec.Cmd, spec *sandboxSpec) error {
+	args := []string{
+		"bwrap", "--die-with-parent", "--unshare-user-try", "--unshare-ipc", "--unshare-uts",
+		"--ro-bind", "/", "/",
+		"--dev-bind", "/dev", "/dev",
+		"--proc", "/proc",
+	}
+	if s.cfg.Network != NetworkHost {
+		args = append(args, "--unshare-net")
+	}
+	for _, p := range spec.Masks {
+		args = append(args, "--tmpfs", p)
+	}
+	for _, p := range spec.Hidden {
+		if isDir(p) {
+			args = append(args, "--tmpfs", p)
+		} else {
+			args = append(args, "--ro-bind", "/dev/null", p)
+		}
+	}
+	for _, p := range spec.ReadOnly {
+		args = append(args, "--ro-bind", p, p)
+	}
+	for _, p := range spec.Writable {
+		args = append(args, "--bind", p, p)
+	}
+	for _, p := range spec.Protected {
+		args = append(args, "--ro-bind", p, p)
+	}
+	args = append(args, "--chdir", spec.Dir, "--")
+
+	if spec.ProxySock != "" {
+		// bwrap has done the mounts; the helper only runs the proxy forwarder
+		data, err := json.Marshal(spec)
+		if err != nil {
+			return err
+		}
+		args = append(args, s.exe)
+		cmd.Env = append(spec.Env, sandboxInitEnv+"="+string(data))
+	} else {
+		args = append(args, spec.Path)
+		args = append(args, spec.Args[1:]...)
+	}
+
+	cmd.Path = s.bwrap
+	cmd.Args = args
+	return nil
+}
+
+// wrapNative runs cmd through the helper in fresh user and mount namespaces
+func (s *Sandbox) wrapNative(cmd *exec.Cmd, spec *sandboxSpec) error {
+	spec.Mount = true
+	spec.Loopback = s.cfg.Network != NetworkHost
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	attr := cmd.SysProcAttr
+	if attr == nil {
+		attr = &syscall.SysProcAttr{}
+	}
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS
+	if s.cfg.Network != NetworkHost {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: spec.UID, Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: spec.GID, Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+
+	cmd.Path = s.exe
+	cmd.Args = []string{"swarm-sandbox"}
+	cmd.Env = append(spec.Env, sandboxInitEnv+"="+string(data))
+	cmd.SysProcAttr = attr
+	return nil
+}
+
+// runSandboxInit is the helper's main. It returns the exit code of the
+// sandboxed command.
+func runSandboxInit(data string) int {
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(data), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "swarm-sandbox: invalid spec: %v\n", err)
+		return 125
+	}
+
+	if spec.Mount {
+		if err := applySandboxMounts(&spec); err != nil {
+			fmt.Fprintf(os.Stderr, "swarm-sandbox: %v\n", err)
+			return 125
+		}
+	}
+	if spec.Loopback {
+		if err := bringUpLoopback(); err != nil {
+			fmt.Fprintf(os.Stderr, "swarm-sandbox: failed to bring up loopback: %v\n", err)
+			return 125
+		}
+	}
+
+	env := withoutEnv(spec.Env, sandboxInitEnv)
+	if spec.ProxySock != "" {
+		if err := forwardProxy(spec.ProxySock); err != nil {
+			fmt.Fprintf(os.Stderr, "swarm-sandbox: %v\n", err)
+			return 125
+		}
+		env = append(env, proxyEnv()...)
+	}
+
+	cmd := &exec.Cmd{
+		Path:   spec.Path,
+		Args:   spec.Args,
+		Env:    env,
+		Dir:    spec.Dir,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		SysProcAttr: &syscall.SysProcAttr{
+			Pdeathsig: syscall.SIGKILL,
+		},
+	}
+	if spec.Mount {
+		// Drop back to the caller's uid; the command gets no capabilities
+		cmd.SysProcAttr.Cloneflags = syscall.CLONE_NEWUSER
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: spec.UID, HostID: 0, Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: spec.GID, HostID: 0, Size: 1}}
+	}
+
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "swarm-sandbox: %v\n", err)
+		return 127
+	}
+
+	signals := make(chan os.Signal, 4)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return 128 + int(ws.Signal())
+		}
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// Statfs flags that are locked inside a user namespace and must be kept
+// when remounting. They share their values with the MS_* flags.
+const lockedMountFlags = syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC |
+	syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME
+
+// oPath is O_PATH, missing from package syscall
+const oPath = 0x200000
+
+// applySandboxMounts makes the whole tree read-only, hides masked and
+// hidden paths and binds kept paths back in
+func applySandboxMounts(spec *sandboxSpec) error {
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+
+	// Hold on to kept paths before the masks hide them
+	type kept struct {
+		path     string
+		fd       int
+		dir      bool
+		readOnly bool
+	}
+	var keeps []kept
+	defer func() {
+		for _, k := range keeps {
+			syscall.Close(k.fd)
+		}
+	}()
+	for _, group := range []struct {
+		paths    []string
+		readOnly bool
+	}{{spec.ReadOnly, true}, {spec.Writable, false}, {spec.Protected, true}} {
+		for _, p := range group.paths {
+			fd, err := syscall.Open(p, oPath|syscall.O_CLOEXEC, 0)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", p, err)
+			}
+			keeps = append(keeps, kept{path: p, fd: fd, dir: isDir(p), readOnly: group.readOnly})
+		}
+	}
+
+	// Like bwrap's --ro-bind / /: nothing outside the kept paths is writable
+	if err := remountTreeReadOnly(); err != nil {
+		return err
+	}
+
+	for _, p := range spec.Masks {
+		if err := syscall.Mount("tmpfs", p, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0755"); err != nil {
+			return fmt.Errorf("failed to mask %s: %w", p, err)
+		}
+	}
+	for _, p := range spec.Hidden {
+		var err error
+		if isDir(p) {
+			err = syscall.Mount("tmpfs", p, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0755")
+		} else {
+			err = syscall.Mount("/dev/null", p, "", syscall.MS_BIND, "")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to hide %s: %w", p, err)
+		}
+	}
+
+	for _, p := range spec.System {
+		if err := syscall.Mount(p, p, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", p, err)
+		}
+		if err := remount(p, true); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range keeps {
+		if err := ensureMountpoint(k.path, k.dir); err != nil {
+			return err
+		}
+		source := fmt.Sprintf("/proc/self/fd/%d", k.fd)
+		if err := syscall.Mount(source, k.path, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", k.path, err)
+		}
+		// The bind inherits the read-only flag of the tree it came from
+		if err := remount(k.path, k.readOnly); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remountTreeReadOnly makes / and every mount below it read-only, except
+// /dev and /proc which bwrap also leaves writable. A bind remount doesn't
+// recurse even with MS_REC, so each mount is remounted on its own.
+func remountTreeReadOnly() error {
+	data, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return fmt.Errorf("failed to list mounts: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		path := unescapeMountPath(fields[4])
+		if isWithin("/dev", path) || isWithin("/proc", path) {
+			continue
+		}
+		if err := remount(path, true); err != nil {
+			// Unreachable or shadowed mount points can't be written through either
+			if errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.ENOENT) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for a space, ...) in a
+// mountinfo path
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, "\\") {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if n, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// remount makes the bind mount at path read-only or writable, keeping the
+// flags that are locked inside a user namespace
+func remount(path string, readOnly bool) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	flags := uintptr(st.Flags)&lockedMountFlags | syscall.MS_BIND | syscall.MS_REMOUNT
+	mode := "writable"
+	if readOnly {
+		flags |= syscall.MS_RDONLY
+		mode = "read-only"
+	}
+	if err := syscall.Mount("", path, "", flags, ""); err != nil {
+		return fmt.Errorf("failed to make %s %s: %w", path, mode, err)
+	}
+	return nil
+}
+
+// ensureMountpoint creates path (a directory or an empty file) if a mask
+// removed it
+func ensureMountpoint(path string, dir bool) error {
+	if _, err := os.Lstat(path); err == nil {
+		return nil
+	}
+	if dir {
+		return os.MkdirAll(path, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// bringUpLoopback sets lo up in a fresh network namespace
+func bringUpLoopback() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	var ifr struct {
+		name  [syscall.IFNAMSIZ]byte
+		flags uint16
+		_     [22]byte
+	}
+	copy(ifr.name[:], "lo")
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCGIFFLAGS, uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return errno
+	}
+	ifr.flags |= syscall.IFF_UP
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// forwardProxy exposes the host's allowlist proxy socket on loopback
+// inside the sandbox
+func forwardProxy(socket string) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", sandboxProxyPort))
+	if err != nil {
+		return fmt.Errorf("failed to start proxy forwarder: %w", err)
+	}
+
+	go func() {
+		for {
+			client, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				upstream, err := net.Dial("unix", socket)
+				if err != nil {
+					client.Close()
+					return
+				}
+				splice(client, upstream)
+			}()
+		}
+	}()
+	return nil
+}
+
+// withoutEnv drops key from env
+func withoutEnv(env []string, key string) []string {
+	result := make([]string, 0, len(env))
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, key+"=") {
+			result = append(result, kv)
+		}
+	}
+	return result
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,158 @@
+//go:build linux
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func newTestSandbox(t *testing.T, network NetworkMode) *Sandbox {
+	t.Helper()
+	if status := CheckSandbox(); !status.UserNamespaces {
+		t.Skipf("user namespaces unavailable: %s", status.Detail)
+	}
+	sb, err := NewSandbox(SandboxConfig{Backend: SandboxNative, Network: network})
+	if err != nil {
+		t.Fatalf("NewSandbox() error = %v", err)
+	}
+	t.Cleanup(func() { sb.Close() })
+	return sb
+}
+
+func runSandboxed(t *testing.T, sb *Sandbox, dir, script string) (string, error) {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Dir = dir
+	if err := sb.Wrap(cmd); err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestSandboxHidesHomeAndProtectsToolchain(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".ssh"), 0700)
+	os.WriteFile(filepath.Join(home, ".ssh", "id_ed25519"), []byte("secret"), 0600)
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+
+	sb := newTestSandbox(t, NetworkHost)
+	workDir := t.TempDir()
+
+	out, err := runSandboxed(t, sb, workDir, `
+		test ! -e "$HOME/.ssh/id_ed25519" || { echo "ssh key visible"; exit 1; }
+		echo ok > result.txt || { echo "worktree not writable"; exit 1; }
+		echo state > "$HOME/.claude/session" || { echo "cli state not writable"; exit 1; }
+		if touch /usr/.swarm-sandbox-probe 2>/dev/null; then echo "toolchain writable"; exit 1; fi
+		test "$(id -u)" = "`+strconv.Itoa(os.Getuid())+`" || { echo "uid changed"; exit 1; }
+	`)
+	if err != nil {
+		t.Fatalf("sandboxed command failed: %v\n%s", err, out)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(workDir, "result.txt")); string(data) != "ok\n" {
+		t.Errorf("worktree write not visible outside the sandbox: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".claude", "session")); err != nil {
+		t.Errorf("CLI state write not visible outside the sandbox: %v", err)
+	}
+}
+
+func TestSandboxNetworkNone(t *testing.T) {
+	sb := newTestSandbox(t, NetworkNone)
+
+	out, err := runSandboxed(t, sb, t.TempDir(), `test "$(tail -n +3 /proc/net/dev | cut -d: -f1 | tr -d ' ')" = "lo"`)
+	if err != nil {
+		t.Errorf("sandbox should only have loopback: %v\n%s", err, out)
+	}
+}
+
+func TestSandboxExitCode(t *testing.T) {
+	sb := newTestSandbox(t, NetworkHost)
+
+	_, err := runSandboxed(t, sb, t.TempDir(), "exit 3")
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit code 3, got %v", err)
+	}
+}
+
+func TestSandboxProtectsGitHooksAndConfig(t *testing.T) {
+	sb := newTestSandbox(t, NetworkHost)
+
+	repo := t.TempDir()
+	worktree := filepath.Join(t.TempDir(), "wt")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main", repo},
+		{"-C", repo, "-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "init"},
+		{"-C", repo, "worktree", "add", "-q", "-b", "agent", worktree},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	os.MkdirAll(filepath.Join(repo, ".git", "hooks"), 0755)
+
+	out, err := runSandboxed(t, sb, worktree, `
+		echo change > file.txt
+		git add file.txt && git -c user.name=t -c user.email=t@t commit -q -m work || { echo "commit failed"; exit 1; }
+		if echo x > "$(git rev-parse --git-common-dir)/hooks/post-merge" 2>/dev/null; then echo "hooks writable"; exit 1; fi
+		if git config core.fsmonitor true 2>/dev/null; then echo "config writable"; exit 1; fi
+		if echo "gitdir: /tmp" > .git 2>/dev/null; then echo "gitdir link writable"; exit 1; fi
+		exit 0
+	`)
+	if err != nil {
+		t.Fatalf("sandboxed command failed: %v\n%s", err, out)
+	}
+	if count, _ := exec.Command("git", "-C", repo, "rev-list", "--count", "main..agent").Output(); string(count) != "1\n" {
+		t.Errorf("commit from the sandbox not visible on the branch: %q", count)
+	}
+}
+
+func TestSandboxRootIsReadOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Outside $HOME and the temp dir, so neither mask covers it
+	outside, err := os.MkdirTemp(".", "sandbox-probe-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(outside) })
+	outside, _ = filepath.Abs(outside)
+
+	for _, backend := range []SandboxBackend{SandboxNative, SandboxBwrap} {
+		t.Run(string(backend), func(t *testing.T) {
+			status := CheckSandbox()
+			if backend == SandboxBwrap && status.BwrapPath == "" {
+				t.Skip("bwrap not installed")
+			}
+			if backend == SandboxNative && !status.UserNamespaces {
+				t.Skipf("user namespaces unavailable: %s", status.Detail)
+			}
+			sb, err := NewSandbox(SandboxConfig{Backend: backend, Network: NetworkHost})
+			if err != nil {
+				t.Fatalf("NewSandbox() error = %v", err)
+			}
+			t.Cleanup(func() { sb.Close() })
+
+			workDir := t.TempDir()
+			out, err := runSandboxed(t, sb, workDir, `
+				echo ok > result.txt || { echo "worktree not writable"; exit 1; }
+				if echo x > "`+outside+`/leak" 2>/dev/null; then echo "outside path writable"; exit 1; fi
+			`)
+			if err != nil {
+				t.Fatalf("sandboxed command failed: %v\n%s", err, out)
+			}
+			if _, err := os.Stat(filepath.Join(outside, "leak")); err == nil {
+				t.Error("write outside the allowed paths reached the host")
+			}
+			if _, err := os.Stat(filepath.Join(workDir, "result.txt")); err != nil {
+				t.Errorf("worktree write not visible outside the sandbox: %v", err)
+			}
+		})
+	}
+}
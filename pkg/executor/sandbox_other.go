@@ -0,0 +1,22 @@
+//go:build !linux
+
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// CheckSandbox reports whether agents can be sandboxed on this host
+func CheckSandbox() SandboxStatus {
+	return SandboxStatus{Detail: fmt.Sprintf("namespaces are not available on %s", runtime.GOOS)}
+}
+
+// Wrap rewrites cmd to run inside the sandbox
+func (s *Sandbox) Wrap(cmd *exec.Cmd) error {
+	if s == nil {
+		return nil
+	}
+	return fmt.Errorf("sandbox is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,33 @@
+package executor
+
+import "testing"
+
+func TestAllowlistProxyAllowed(t *testing.T) {
+	p := &allowlistProxy{allow: []string{"api.anthropic.com", "*.github.com"}}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.anthropic.com:443", true},
+		{"API.Anthropic.com", true},
+		{"github.com", true},
+		{"codeload.github.com:443", true},
+		{"evilgithub.com", false},
+		{"example.com:80", false},
+	}
+	for _, tt := range tests {
+		if got := p.allowed(tt.host); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	if !isWithin("/home/u", "/home/u/repo") || !isWithin("/home/u", "/home/u") {
+		t.Error("isWithin() should accept the directory and its children")
+	}
+	if isWithin("/home/u", "/home/user") || isWithin("/home/u", "/home") {
+		t.Error("isWithin() should reject siblings and parents")
+	}
+}
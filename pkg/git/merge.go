@@ -2,7 +2,6 @@ package git
 
 import (
 	"fmt"
-	"os/exec"
 	"strings"
 	"sync"
 )
@@ -35,7 +34,7 @@ func (mm *MergeManager) MergeBranch(branchName string) (*MergeResult, error) {
 	result := &MergeResult{}
 
 	// Try fast-forward merge first
-	cmd := exec.Command("git", "-C", mm.repo.Path, "merge", "--ff-only", branchName)
+	cmd := Command(mm.repo.Path, "merge", "--ff-only", branchName)
 	output, err := cmd.CombinedOutput()
 
 	if err == nil {
@@ -46,7 +45,7 @@ func (mm *MergeManager) MergeBranch(branchName string) (*MergeResult, error) {
 	}
 
 	// Fast-forward failed, try three-way merge
-	cmd = exec.Command("git", "-C", mm.repo.Path, "merge", "--no-ff",
+	cmd = Command(mm.repo.Path, "merge", "--no-ff",
 		"-m", fmt.Sprintf("Merge branch '%s'", branchName), branchName)
 	output, err = cmd.CombinedOutput()
 
@@ -68,7 +67,7 @@ func (mm *MergeManager) MergeBranch(branchName string) (*MergeResult, error) {
 
 // AbortMerge aborts an in-progress merge
 func (mm *MergeManager) AbortMerge() error {
-	cmd := exec.Command("git", "-C", mm.repo.Path, "merge", "--abort")
+	cmd := Command(mm.repo.Path, "merge", "--abort")
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to abort merge: %w, output: %s", err, string(output))
 	}
@@ -78,7 +77,7 @@ func (mm *MergeManager) AbortMerge() error {
 // CanFastForward checks if a branch can be fast-forward merged
 func (mm *MergeManager) CanFastForward(branchName string) (bool, error) {
 	// Check if current branch is ancestor of target branch
-	cmd := exec.Command("git", "-C", mm.repo.Path, "merge-base", "--is-ancestor", "HEAD", branchName)
+	cmd := Command(mm.repo.Path, "merge-base", "--is-ancestor", "HEAD", branchName)
 	err := cmd.Run()
 	return err == nil, nil
 }
@@ -90,7 +89,7 @@ func (mm *MergeManager) getCurrentCommit() (string, error) {
 
 // getConflicts returns the list of conflicted files
 func (mm *MergeManager) getConflicts() ([]string, error) {
-	cmd := exec.Command("git", "-C", mm.repo.Path, "diff", "--name-only", "--diff-filter=U")
+	cmd := Command(mm.repo.Path, "diff", "--name-only", "--diff-filter=U")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get conflicts: %w", err)
@@ -7,6 +7,14 @@ import (
 	"strings"
 )
 
+// Command builds a git command that runs in dir. Hooks and fsmonitor are
+// switched off because agents can write to worktrees and the shared .git
+// directory, and anything they plant there must not run outside the sandbox.
+func Command(dir string, args ...string) *exec.Cmd {
+	cmdArgs := append([]string{"-C", dir, "-c", "core.hooksPath=/dev/null", "-c", "core.fsmonitor=false"}, args...)
+	return exec.Command("git", cmdArgs...)
+}
+
 // NewRepository creates a new Repository instance and verifies it's a valid git repo
 func NewRepository(path string) (*Repository, error) {
 	absPath, err := filepath.Abs(path)
@@ -15,7 +23,7 @@ func NewRepository(path string) (*Repository, error) {
 	}
 
 	// Verify it's a git repository
-	cmd := exec.Command("git", "-C", absPath, "rev-parse", "--git-dir")
+	cmd := Command(absPath, "rev-parse", "--git-dir")
 	if err := cmd.Run(); err != nil {
 		return nil, ErrNotGitRepo
 	}
@@ -25,7 +33,7 @@ func NewRepository(path string) (*Repository, error) {
 
 // GetCurrentBranch returns the current branch name
 func (r *Repository) GetCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "-C", r.Path, "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := Command(r.Path, "rev-parse", "--abbrev-ref", "HEAD")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
@@ -36,7 +44,7 @@ func (r *Repository) GetCurrentBranch() (string, error) {
 
 // IsClean checks if the repository has uncommitted changes
 func (r *Repository) IsClean() (bool, error) {
-	cmd := exec.Command("git", "-C", r.Path, "status", "--porcelain")
+	cmd := Command(r.Path, "status", "--porcelain")
 	output, err := cmd.Output()
 	if err != nil {
 		return false, fmt.Errorf("failed to check status: %w", err)
@@ -47,7 +55,7 @@ func (r *Repository) IsClean() (bool, error) {
 
 // GetCurrentCommit returns the current commit hash
 func (r *Repository) GetCurrentCommit() (string, error) {
-	cmd := exec.Command("git", "-C", r.Path, "rev-parse", "HEAD")
+	cmd := Command(r.Path, "rev-parse", "HEAD")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current commit: %w", err)
@@ -55,3 +63,26 @@ func (r *Repository) GetCurrentCommit() (string, error) {
 
 	return strings.TrimSpace(string(output)), nil
 }
+
+// DiffStat summarizes changes to tracked files against base, committed or not
+func (r *Repository) DiffStat(base string) (string, error) {
+	cmd := Command(r.Path, "diff", "--stat", base)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff stat: %w", err)
+	}
+
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// CommitsSince lists the commits reachable from HEAD but not from base,
+// oldest first
+func (r *Repository) CommitsSince(base string) ([]string, error) {
+	cmd := Command(r.Path, "rev-list", "--reverse", base+"..HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	return strings.Fields(string(output)), nil
+}
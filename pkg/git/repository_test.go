@@ -155,3 +155,62 @@ func TestGetCurrentCommit(t *testing.T) {
 		t.Errorf("Expected 40 character commit hash, got %d: %s", len(commit), commit)
 	}
 }
+
+func TestCommitsSince(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepository(repoPath)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	base, err := repo.GetCurrentCommit()
+	if err != nil {
+		t.Fatalf("Failed to get current commit: %v", err)
+	}
+
+	commits, err := repo.CommitsSince(base)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(commits) != 0 {
+		t.Errorf("Expected no commits since HEAD, got %v", commits)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		os.WriteFile(filepath.Join(repoPath, name), []byte(name), 0644)
+		exec.Command("git", "-C", repoPath, "add", name).Run()
+		if err := exec.Command("git", "-C", repoPath, "commit", "-m", "Add "+name).Run(); err != nil {
+			t.Fatalf("Failed to commit %s: %v", name, err)
+		}
+	}
+
+	commits, err = repo.CommitsSince(base)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	head, _ := repo.GetCurrentCommit()
+	if len(commits) != 2 || commits[1] != head {
+		t.Errorf("Expected 2 commits ending at HEAD %s, got %v", head, commits)
+	}
+}
+
+func TestCommandSkipsHooks(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	marker := filepath.Join(repoPath, "hook-ran")
+	hook := filepath.Join(repoPath, ".git", "hooks", "pre-commit")
+	os.MkdirAll(filepath.Dir(hook), 0755)
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+
+	if out, err := Command(repoPath, "commit", "--allow-empty", "-m", "empty").CombinedOutput(); err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected the pre-commit hook not to run")
+	}
+}
@@ -3,7 +3,6 @@ package git
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -66,7 +65,7 @@ func (wm *WorktreeManager) CreateWorktree(agentID string) (*Worktree, error) {
 	}
 
 	// git worktree add -b agent-X-branch .worktrees/agent-X main
-	cmd := exec.Command("git", "-C", wm.repo.Path, "worktree", "add",
+	cmd := Command(wm.repo.Path, "worktree", "add",
 		"-b", branchName, worktreePath, wm.config.BaseBranch)
 
 	if output, err := cmd.CombinedOutput(); err != nil {
@@ -101,7 +100,7 @@ func (wm *WorktreeManager) removeWorktreeUnlocked(agentID string) error {
 		fmt.Sprintf("agent-%s", agentID))
 
 	// Remove the worktree
-	cmd := exec.Command("git", "-C", wm.repo.Path, "worktree", "remove", worktreePath, "--force")
+	cmd := Command(wm.repo.Path, "worktree", "remove", worktreePath, "--force")
 	if output, err := cmd.CombinedOutput(); err != nil {
 		// If worktree doesn't exist, that's fine
 		if !strings.Contains(string(output), "not a working tree") {
@@ -110,7 +109,7 @@ func (wm *WorktreeManager) removeWorktreeUnlocked(agentID string) error {
 	}
 
 	// Delete the branch
-	cmd = exec.Command("git", "-C", wm.repo.Path, "branch", "-D", branchName)
+	cmd = Command(wm.repo.Path, "branch", "-D", branchName)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		// If branch doesn't exist, that's fine
 		if !strings.Contains(string(output), "not found") {
@@ -126,7 +125,7 @@ func (wm *WorktreeManager) removeWorktreeUnlocked(agentID string) error {
 
 // ListWorktrees lists all worktrees
 func (wm *WorktreeManager) ListWorktrees() ([]*Worktree, error) {
-	cmd := exec.Command("git", "-C", wm.repo.Path, "worktree", "list", "--porcelain")
+	cmd := Command(wm.repo.Path, "worktree", "list", "--porcelain")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/claude-swarm/pkg/config"
+)
+
+const (
+	// DefaultAnthropicModel is used when no model is configured
+	DefaultAnthropicModel = "claude-sonnet-4-5"
+	// DefaultAnthropicURL is the Messages API base URL
+	DefaultAnthropicURL = "https://api.anthropic.com"
+
+	anthropicVersion = "2023-06-01"
+)
+
+// AnthropicClient calls the Anthropic Messages API
+type AnthropicClient struct {
+	http      *http.Client
+	baseURL   string
+	apiKey    string
+	model     string
+	maxTokens int
+}
+
+// NewAnthropicClient creates an Anthropic client from cfg
+func NewAnthropicClient(cfg config.LLMConfig) *AnthropicClient {
+	c := &AnthropicClient{
+		http:      &http.Client{},
+		baseURL:   strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:    cfg.APIKey,
+		model:     cfg.Model,
+		maxTokens: cfg.MaxTokens,
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultAnthropicURL
+	}
+	if c.model == "" {
+		c.model = DefaultAnthropicModel
+	}
+	if c.maxTokens <= 0 {
+		c.maxTokens = config.DefaultLLMConfig().MaxTokens // Required by the API
+	}
+	return c
+}
+
+func (c *AnthropicClient) Provider() string { return config.ProviderAnthropic }
+func (c *AnthropicClient) Model() string    { return c.model }
+
+// anthropicRequest is the body of POST /v1/messages
+type anthropicRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+}
+
+// anthropicResponse is the part of a Messages response we use
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (c *AnthropicClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	body := anthropicRequest{
+		Model:     c.model,
+		MaxTokens: req.maxTokens(c.maxTokens),
+		System:    req.systemWithSchema(),
+		Messages:  req.Messages,
+	}
+	headers := map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+
+	var out anthropicResponse
+	if err := postJSON(ctx, c.http, c.Provider(), c.baseURL+"/v1/messages", headers, body, &out); err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return &Response{
+		Text:  text.String(),
+		Model: out.Model,
+		Usage: Usage{InputTokens: out.Usage.InputTokens, OutputTokens: out.Usage.OutputTokens},
+	}, nil
+}
@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Template string    `json:"template,omitempty"`
+	Digest   string    `json:"digest"`
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+}
+
+// Cassette is a recording of a client's successful calls
+type Cassette struct {
+	Provider     string         `json:"provider"`
+	Model        string         `json:"model"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// requestDigest identifies a request's content, ignoring its template ID
+func requestDigest(req *Request) string {
+	data, _ := json.Marshal(struct {
+		System   string    `json:"system"`
+		Messages []Message `json:"messages"`
+	}{req.System, req.Messages})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoadCassette reads a cassette file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save atomically writes the cassette to path
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette dir: %w", err)
+	}
+
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
+
+// Recorder calls the wrapped client and appends every successful call to a
+// cassette, saved after each call so an interrupted run keeps what it got.
+// Recording starts a new cassette, replacing any existing file.
+type Recorder struct {
+	LLMClient
+	path string
+
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// NewRecorder wraps client to record into the cassette at path
+func NewRecorder(client LLMClient, path string) *Recorder {
+	return &Recorder{
+		LLMClient: client,
+		path:      path,
+		cassette:  &Cassette{Provider: client.Provider(), Model: client.Model()},
+	}
+}
+
+func (r *Recorder) Generate(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := r.LLMClient.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Template: req.Template,
+		Digest:   requestDigest(req),
+		Request:  req,
+		Response: resp,
+	})
+	if err := r.cassette.Save(r.path); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Replayer answers requests from a cassette without network access. A
+// request gets the unused interaction with identical content; failing
+// that, the next unused interaction of the same template, so prompts that
+// embed paths or timestamps still replay in order.
+type Replayer struct {
+	path     string
+	cassette *Cassette
+
+	mu   sync.Mutex
+	used []bool
+}
+
+// NewReplayer loads the cassette at path for replay
+func NewReplayer(path string) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{path: path, cassette: cassette, used: make([]bool, len(cassette.Interactions))}, nil
+}
+
+func (r *Replayer) Provider() string { return r.cassette.Provider }
+func (r *Replayer) Model() string    { return r.cassette.Model }
+
+func (r *Replayer) Generate(ctx context.Context, req *Request) (*Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	digest := requestDigest(req)
+	match := -1
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || in.Template != req.Template {
+			continue
+		}
+		if in.Digest == digest {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("cassette %s: no recorded response left for template %q", r.path, req.Template)
+	}
+
+	r.used[match] = true
+	resp := *r.cassette.Interactions[match].Response
+	return &resp, nil
+}
+
+// Remaining returns how many recorded interactions have not been replayed
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/pkg/config"
+)
+
+func TestFakeClient(t *testing.T) {
+	fake := NewFakeClient().
+		On("analyze", "first", "second").
+		On(AnyTemplate, "fallback")
+	ctx := context.Background()
+
+	var got []string
+	for _, template := range []string{"analyze", "analyze", "analyze", "diagnose"} {
+		resp, err := fake.Generate(ctx, NewTemplateRequest(template, "prompt"))
+		if err != nil {
+			t.Fatalf("Generate(%s) error = %v", template, err)
+		}
+		got = append(got, resp.Text)
+	}
+	want := []string{"first", "second", "second", "fallback"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("response %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if fake.CallCount("analyze") != 3 || len(fake.Calls()) != 4 {
+		t.Errorf("calls = %d analyze, %d total", fake.CallCount("analyze"), len(fake.Calls()))
+	}
+
+	if _, err := NewFakeClient().Generate(ctx, NewTemplateRequest("analyze", "prompt")); err == nil {
+		t.Error("unscripted template should fail")
+	}
+}
+
+func TestLoadFakeScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.yaml")
+	script := "analyze:\n  - error: overloaded\n  - text: '{\"tasks\": []}'\n"
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(config.LLMConfig{Provider: config.ProviderFake, FakeScript: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// The scripted error is not retryable, so the retry wrapper passes it on
+	if _, err := client.Generate(context.Background(), NewTemplateRequest("analyze", "x")); err == nil || IsRetryable(err) {
+		t.Errorf("first call error = %v, want a non-retryable error", err)
+	}
+	resp, err := client.Generate(context.Background(), NewTemplateRequest("analyze", "x"))
+	if err != nil || resp.Text != `{"tasks": []}` {
+		t.Errorf("second call = %v, %v", resp, err)
+	}
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "brain.json")
+	ctx := context.Background()
+
+	recorder := NewRecorder(NewFakeClient().On("diagnose", "a", "b").On("merge_strategy", "m"), path)
+	for _, req := range []*Request{
+		NewTemplateRequest("diagnose", "task-1 failed"),
+		NewTemplateRequest("diagnose", "task-2 failed"),
+		NewTemplateRequest("merge_strategy", "branches"),
+	} {
+		if _, err := recorder.Generate(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Replay needs no credentials: the anthropic provider would otherwise
+	// require an api key
+	replayer, err := New(config.LLMConfig{Provider: config.ProviderAnthropic, Cassette: path, CassetteMode: config.CassetteReplay})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if replayer.Provider() != config.ProviderFake {
+		t.Errorf("Provider() = %s, want the recorded provider", replayer.Provider())
+	}
+
+	tests := []struct {
+		template, prompt, want string
+	}{
+		{"diagnose", "task-2 failed", "b"}, // exact match, out of order
+		{"diagnose", "task-9 failed", "a"}, // next unused of the template
+		{"merge_strategy", "other", "m"},
+	}
+	for _, tt := range tests {
+		resp, err := replayer.Generate(ctx, NewTemplateRequest(tt.template, tt.prompt))
+		if err != nil || resp.Text != tt.want {
+			t.Errorf("replay %s %q = %v, %v, want %q", tt.template, tt.prompt, resp, err, tt.want)
+		}
+	}
+	if _, err := replayer.Generate(ctx, NewTemplateRequest("diagnose", "again")); err == nil {
+		t.Error("replay past the end of the cassette should fail")
+	}
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/yourusername/claude-swarm/pkg/config"
+)
+
+// ClaudeCLIClient runs the local claude CLI in print mode, so the brain can
+// use the same subscription or credentials as the agents
+type ClaudeCLIClient struct {
+	cliPath string
+	model   string
+}
+
+// NewClaudeCLIClient creates a Claude CLI client from cfg. An empty model
+// leaves the choice to the CLI.
+func NewClaudeCLIClient(cfg config.LLMConfig) *ClaudeCLIClient {
+	return &ClaudeCLIClient{cliPath: "claude", model: cfg.Model}
+}
+
+func (c *ClaudeCLIClient) Provider() string { return config.ProviderClaudeCLI }
+
+func (c *ClaudeCLIClient) Model() string {
+	if c.model == "" {
+		return "claude-cli"
+	}
+	return c.model
+}
+
+// claudeCLIResult is the JSON printed by claude -p --output-format json
+type claudeCLIResult struct {
+	IsError      bool    `json:"is_error"`
+	Result       string  `json:"result"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	Usage        struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (c *ClaudeCLIClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	args := []string{"-p", "--output-format", "json"}
+	if c.model != "" {
+		args = append(args, "--model", c.model)
+	}
+	if system := req.systemWithSchema(); system != "" {
+		args = append(args, "--append-system-prompt", system)
+	}
+
+	cmd := exec.CommandContext(ctx, c.cliPath, args...)
+	cmd.Stdin = strings.NewReader(req.Transcript())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(string(output))
+		}
+		return nil, &APIError{Provider: c.Provider(), Message: fmt.Sprintf("%v: %s", err, msg)}
+	}
+
+	var result claudeCLIResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("%s: invalid output: %w", c.Provider(), err)
+	}
+	if result.IsError {
+		return nil, &APIError{Provider: c.Provider(), Message: result.Result}
+	}
+
+	return &Response{
+		Text:  result.Result,
+		Model: c.Model(),
+		Usage: Usage{
+			InputTokens:  result.Usage.InputTokens,
+			OutputTokens: result.Usage.OutputTokens,
+			CostUSD:      result.TotalCostUSD,
+		},
+	}, nil
+}
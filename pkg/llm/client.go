@@ -0,0 +1,179 @@
+// Package llm provides a provider-agnostic client for the orchestrator
+// brain: Gemini, Anthropic Messages, any OpenAI-compatible endpoint
+// (OpenAI, Ollama, llama.cpp server, vLLM) and the Claude CLI in print mode.
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/claude-swarm/pkg/config"
+)
+
+// LLMClient generates a completion for a conversation
+type LLMClient interface {
+	// Generate returns the model's reply to req
+	Generate(ctx context.Context, req *Request) (*Response, error)
+	// Provider returns the provider name, e.g. "anthropic"
+	Provider() string
+	// Model returns the model used for requests
+	Model() string
+}
+
+// Role is the author of a message
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is one turn of a conversation
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a provider-independent completion request
+type Request struct {
+	// Template identifies the prompt the request was built from, e.g.
+	// "analyze". Providers ignore it; the fake client and cassettes key on it.
+	Template  string    `json:"template,omitempty"`
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+	MaxTokens int       `json:"max_tokens,omitempty"` // 0 uses the client's default
+	// Schema, if set, constrains the reply to JSON conforming to it, using
+	// the provider's structured output mode where it has one
+	Schema Schema `json:"schema,omitempty"`
+}
+
+// NewRequest returns a single-turn request for prompt
+func NewRequest(prompt string) *Request {
+	return &Request{Messages: []Message{{Role: RoleUser, Content: prompt}}}
+}
+
+// NewTemplateRequest returns a single-turn request for a prompt rendered
+// from the given template
+func NewTemplateRequest(template, prompt string) *Request {
+	req := NewRequest(prompt)
+	req.Template = template
+	return req
+}
+
+// Usage counts the tokens of a call, where the provider reports them
+type Usage struct {
+	InputTokens  int     `json:"input_tokens,omitempty"`
+	OutputTokens int     `json:"output_tokens,omitempty"`
+	CostUSD      float64 `json:"cost_usd,omitempty"`
+}
+
+// Response is a completion
+type Response struct {
+	Text  string `json:"text"`
+	Model string `json:"model,omitempty"`
+	Usage Usage  `json:"usage,omitzero"`
+}
+
+// APIError is a failed call to a provider
+type APIError struct {
+	Provider   string
+	StatusCode int // HTTP status, 0 if the request never got a response
+	Message    string
+	RetryAfter time.Duration // Server-requested backoff, if any
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s: %s", e.Provider, e.Message)
+	}
+	return fmt.Sprintf("%s: HTTP %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Retryable reports whether the call may succeed if repeated: rate limits,
+// overload and server errors, and connection failures
+func (e *APIError) Retryable() bool {
+	switch {
+	case e.StatusCode == 0:
+		return true
+	case e.StatusCode == http.StatusTooManyRequests, e.StatusCode == http.StatusRequestTimeout:
+		return true
+	case e.StatusCode >= 500:
+		return true
+	}
+	return false
+}
+
+// IsRetryable reports whether err is worth retrying. Errors that are not
+// an APIError, such as malformed responses, are not.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable()
+}
+
+// New creates the client for cfg, wrapped with its timeout and retries.
+// cfg should have been completed with config.Config.Resolve.
+func New(cfg config.LLMConfig) (LLMClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Replaying() {
+		return NewReplayer(cfg.Cassette)
+	}
+
+	var client LLMClient
+	var err error
+	switch cfg.Provider {
+	case config.ProviderGemini:
+		client, err = NewGeminiClient(cfg)
+	case config.ProviderAnthropic:
+		client = NewAnthropicClient(cfg)
+	case config.ProviderOpenAI:
+		client = NewOpenAIClient(cfg)
+	case config.ProviderClaudeCLI:
+		client = NewClaudeCLIClient(cfg)
+	case config.ProviderFake:
+		client, err = LoadFakeScript(cfg.FakeScript)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client = WithRetry(client, RetryConfig{
+		Timeout:    time.Duration(cfg.Timeout) * time.Second,
+		MaxRetries: cfg.MaxRetries,
+	})
+	if cfg.Cassette != "" && cfg.CassetteMode == config.CassetteRecord {
+		client = NewRecorder(client, cfg.Cassette)
+	}
+	return client, nil
+}
+
+// maxTokens returns the request's token limit or the fallback
+func (r *Request) maxTokens(fallback int) int {
+	if r.MaxTokens > 0 {
+		return r.MaxTokens
+	}
+	return fallback
+}
+
+// Transcript flattens the conversation into a single prompt for providers
+// without multi-turn input
+func (r *Request) Transcript() string {
+	if len(r.Messages) == 1 {
+		return r.Messages[0].Content
+	}
+
+	var sb strings.Builder
+	for i, msg := range r.Messages {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "[%s]\n%s", msg.Role, msg.Content)
+	}
+	return sb.String()
+}
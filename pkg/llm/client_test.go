@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/pkg/config"
+)
+
+func TestAnthropicClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("missing auth headers: %v", r.Header)
+		}
+
+		var body anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Model != "claude-test" || body.System != "be brief" || body.MaxTokens != 100 || len(body.Messages) != 1 {
+			t.Errorf("request = %+v", body)
+		}
+
+		w.Write([]byte(`{"model":"claude-test","content":[{"type":"text","text":"{\"ok\":"},{"type":"text","text":"true}"}],
+			"usage":{"input_tokens":12,"output_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	client := NewAnthropicClient(config.LLMConfig{APIKey: "key", Model: "claude-test", BaseURL: server.URL, MaxTokens: 100})
+	req := NewRequest("hello")
+	req.System = "be brief"
+	resp, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if resp.Text != `{"ok":true}` || resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 3 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestOpenAIClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization = %q, want none for a keyless local endpoint", auth)
+		}
+
+		var body openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Messages) != 2 || body.Messages[0].Role != "system" || body.Model != "llama3" {
+			t.Errorf("request = %+v, want system message first", body)
+		}
+		if body.ResponseFormat == nil || body.ResponseFormat.JSONSchema.Name != "analyze" {
+			t.Errorf("response_format = %+v, want the request schema", body.ResponseFormat)
+		}
+
+		w.Write([]byte(`{"model":"llama3","choices":[{"message":{"role":"assistant","content":"hi"}}],
+			"usage":{"prompt_tokens":5,"completion_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(config.LLMConfig{Model: "llama3", BaseURL: server.URL + "/v1/"})
+	req := NewTemplateRequest("analyze", "hello")
+	req.System = "be brief"
+	req.Schema = Schema{"type": "object"}
+	resp, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if resp.Text != "hi" || resp.Usage.InputTokens != 5 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	type task struct {
+		ID       string   `json:"id"`
+		Priority int      `json:"priority" jsonschema:"minimum=1,maximum=10"`
+		Deps     []string `json:"dependencies,omitempty"`
+		internal string
+	}
+	type plan struct {
+		Complexity string            `json:"complexity" jsonschema:"enum=low|high"`
+		Tasks      []*task           `json:"tasks"`
+		Notes      map[string]string `json:"notes,omitempty"`
+		Skipped    bool              `json:"-"`
+	}
+
+	got, err := json.Marshal(SchemaFor(&plan{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"properties":{"complexity":{"enum":["low","high"],"type":"string"},` +
+		`"notes":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"tasks":{"items":{"properties":{"dependencies":{"items":{"type":"string"},"type":"array"},` +
+		`"id":{"type":"string"},"priority":{"maximum":10,"minimum":1,"type":"integer"}},` +
+		`"required":["id","priority"],"type":"object"},"type":"array"}},` +
+		`"required":["complexity","tasks"],"type":"object"}`
+	if string(got) != want {
+		t.Errorf("SchemaFor() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// scriptedClient fails with the queued errors, then answers "ok"
+type scriptedClient struct {
+	errs  []error
+	calls int
+}
+
+func (c *scriptedClient) Provider() string { return "scripted" }
+func (c *scriptedClient) Model() string    { return "scripted" }
+
+func (c *scriptedClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	c.calls++
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return nil, err
+	}
+	return &Response{Text: "ok"}, nil
+}
+
+func TestWithRetry(t *testing.T) {
+	fast := RetryConfig{MaxRetries: 2, Delays: []time.Duration{time.Millisecond}}
+	rateLimited := &APIError{Provider: "scripted", StatusCode: http.StatusTooManyRequests}
+
+	t.Run("retries retryable errors", func(t *testing.T) {
+		inner := &scriptedClient{errs: []error{rateLimited, &APIError{Provider: "scripted", Message: "connection reset"}}}
+		resp, err := WithRetry(inner, fast).Generate(context.Background(), NewRequest("x"))
+		if err != nil || resp.Text != "ok" || inner.calls != 3 {
+			t.Errorf("got %v, %v after %d calls, want ok after 3", resp, err, inner.calls)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		inner := &scriptedClient{errs: []error{rateLimited, rateLimited, rateLimited}}
+		_, err := WithRetry(inner, fast).Generate(context.Background(), NewRequest("x"))
+		if !errors.Is(err, rateLimited) || inner.calls != 3 {
+			t.Errorf("got %v after %d calls, want the rate limit error after 3", err, inner.calls)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		inner := &scriptedClient{errs: []error{&APIError{Provider: "scripted", StatusCode: http.StatusBadRequest}}}
+		_, err := WithRetry(inner, fast).Generate(context.Background(), NewRequest("x"))
+		if err == nil || inner.calls != 1 {
+			t.Errorf("got %v after %d calls, want an error after 1", err, inner.calls)
+		}
+	})
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	if _, err := New(config.LLMConfig{Provider: config.ProviderAnthropic}); err == nil {
+		t.Error("anthropic without api key should be rejected")
+	}
+	if _, err := New(config.LLMConfig{Provider: "bard"}); err == nil {
+		t.Error("unknown provider should be rejected")
+	}
+	client, err := New(config.LLMConfig{Provider: config.ProviderOpenAI, BaseURL: "http://localhost:11434/v1", Model: "llama3"})
+	if err != nil {
+		t.Fatalf("keyless openai-compatible endpoint: %v", err)
+	}
+	if client.Provider() != config.ProviderOpenAI || client.Model() != "llama3" {
+		t.Errorf("client = %s/%s, want openai/llama3", client.Provider(), client.Model())
+	}
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/claude-swarm/pkg/config"
+)
+
+// AnyTemplate scripts the responses for templates without their own script
+const AnyTemplate = "*"
+
+// FakeResponse is one scripted reply: the text, or the error to fail with
+type FakeResponse struct {
+	Text string
+	Err  error
+}
+
+// FakeClient serves scripted responses keyed by the request's prompt
+// template ID. Each template's responses are served in order and the last
+// one repeats, so a prompt that is sent again gets the final answer.
+type FakeClient struct {
+	mu        sync.Mutex
+	model     string
+	responses map[string][]FakeResponse
+	served    map[string]int
+	calls     []*Request
+}
+
+// NewFakeClient creates a fake client with no responses scripted
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		model:     "fake",
+		responses: make(map[string][]FakeResponse),
+		served:    make(map[string]int),
+	}
+}
+
+// On appends text responses for template
+func (f *FakeClient) On(template string, texts ...string) *FakeClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, text := range texts {
+		f.responses[template] = append(f.responses[template], FakeResponse{Text: text})
+	}
+	return f
+}
+
+// OnError appends a failing response for template
+func (f *FakeClient) OnError(template string, err error) *FakeClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[template] = append(f.responses[template], FakeResponse{Err: err})
+	return f
+}
+
+// fakeScript is the YAML form of a script: template ID to responses
+//
+//	analyze:
+//	  - text: '{"modules": [], "tasks": []}'
+//	merge_strategy:
+//	  - error: "overloaded"
+//	  - text: '{"merge_order": []}'
+type fakeScript map[string][]struct {
+	Text  string `yaml:"text"`
+	Error string `yaml:"error"`
+}
+
+// LoadFakeScript creates a fake client from a YAML script. Scripted errors
+// are returned as non-retryable API errors.
+func LoadFakeScript(path string) (*FakeClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake script: %w", err)
+	}
+	var script fakeScript
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse fake script %s: %w", path, err)
+	}
+
+	f := NewFakeClient()
+	for template, responses := range script {
+		for _, r := range responses {
+			if r.Error != "" {
+				f.OnError(template, &APIError{Provider: config.ProviderFake, StatusCode: 400, Message: r.Error})
+			} else {
+				f.On(template, r.Text)
+			}
+		}
+	}
+	return f, nil
+}
+
+func (f *FakeClient) Provider() string { return config.ProviderFake }
+func (f *FakeClient) Model() string    { return f.model }
+
+func (f *FakeClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, req)
+
+	template := req.Template
+	responses := f.responses[template]
+	if len(responses) == 0 {
+		template = AnyTemplate
+		responses = f.responses[template]
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("fake: no response scripted for template %q", req.Template)
+	}
+
+	i := min(f.served[template], len(responses)-1)
+	f.served[template]++
+	if responses[i].Err != nil {
+		return nil, responses[i].Err
+	}
+	return &Response{Text: responses[i].Text, Model: f.model}, nil
+}
+
+// Calls returns the requests received so far
+func (f *FakeClient) Calls() []*Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Request(nil), f.calls...)
+}
+
+// CallCount returns how many requests used template
+func (f *FakeClient) CallCount(template string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, req := range f.calls {
+		if req.Template == template {
+			n++
+		}
+	}
+	return n
+}
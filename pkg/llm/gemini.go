@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genai"
+
+	"github.com/yourusername/claude-swarm/pkg/config"
+)
+
+// DefaultGeminiModel is used when no model is configured
+const DefaultGeminiModel = "gemini-3-flash-preview"
+
+// GeminiClient calls the Gemini API
+type GeminiClient struct {
+	client    *genai.Client
+	model     string
+	maxTokens int
+}
+
+// NewGeminiClient creates a Gemini client from cfg
+func NewGeminiClient(cfg config.LLMConfig) (*GeminiClient, error) {
+	clientConfig := &genai.ClientConfig{APIKey: cfg.APIKey}
+	if cfg.BaseURL != "" {
+		clientConfig.HTTPOptions.BaseURL = cfg.BaseURL
+	}
+	client, err := genai.NewClient(context.Background(), clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = DefaultGeminiModel
+	}
+	return &GeminiClient{client: client, model: model, maxTokens: cfg.MaxTokens}, nil
+}
+
+func (c *GeminiClient) Provider() string { return config.ProviderGemini }
+func (c *GeminiClient) Model() string    { return c.model }
+
+func (c *GeminiClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	contents := make([]*genai.Content, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		role := genai.Role(genai.RoleUser)
+		if msg.Role == RoleAssistant {
+			role = genai.RoleModel
+		}
+		contents = append(contents, genai.NewContentFromText(msg.Content, role))
+	}
+
+	genConfig := &genai.GenerateContentConfig{}
+	if maxTokens := req.maxTokens(c.maxTokens); maxTokens > 0 {
+		genConfig.MaxOutputTokens = int32(maxTokens)
+	}
+	if req.System != "" {
+		genConfig.SystemInstruction = genai.NewContentFromText(req.System, genai.RoleUser)
+	}
+	if req.Schema != nil {
+		genConfig.ResponseMIMEType = "application/json"
+		genConfig.ResponseJsonSchema = req.Schema
+	}
+
+	result, err := c.client.Models.GenerateContent(ctx, c.model, contents, genConfig)
+	if err != nil {
+		var apiErr genai.APIError
+		if errors.As(err, &apiErr) {
+			return nil, &APIError{Provider: c.Provider(), StatusCode: apiErr.Code, Message: apiErr.Message}
+		}
+		return nil, &APIError{Provider: c.Provider(), Message: err.Error()}
+	}
+
+	resp := &Response{Text: result.Text(), Model: c.model}
+	if usage := result.UsageMetadata; usage != nil {
+		resp.Usage.InputTokens = int(usage.PromptTokenCount)
+		resp.Usage.OutputTokens = int(usage.CandidatesTokenCount)
+	}
+	return resp, nil
+}
@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/claude-swarm/pkg/config"
+)
+
+const (
+	// DefaultOpenAIModel is used when no model is configured
+	DefaultOpenAIModel = "gpt-4o-mini"
+	// DefaultOpenAIURL is the OpenAI API base URL. Ollama serves the same API
+	// at http://localhost:11434/v1, llama.cpp server and vLLM at
+	// http://localhost:8080/v1 and http://localhost:8000/v1 by default.
+	DefaultOpenAIURL = "https://api.openai.com/v1"
+)
+
+// OpenAIClient calls an OpenAI-compatible chat completions endpoint
+type OpenAIClient struct {
+	http      *http.Client
+	baseURL   string
+	apiKey    string
+	model     string
+	maxTokens int
+}
+
+// NewOpenAIClient creates a client for the OpenAI-compatible endpoint in cfg
+func NewOpenAIClient(cfg config.LLMConfig) *OpenAIClient {
+	c := &OpenAIClient{
+		http:      &http.Client{},
+		baseURL:   strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:    cfg.APIKey,
+		model:     cfg.Model,
+		maxTokens: cfg.MaxTokens,
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultOpenAIURL
+	}
+	if c.model == "" {
+		c.model = DefaultOpenAIModel
+	}
+	return c
+}
+
+func (c *OpenAIClient) Provider() string { return config.ProviderOpenAI }
+func (c *OpenAIClient) Model() string    { return c.model }
+
+// openAIRequest is the body of POST /chat/completions
+type openAIRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// responseFormat requests structured output conforming to a JSON Schema
+type responseFormat struct {
+	Type       string `json:"type"` // "json_schema"
+	JSONSchema struct {
+		Name   string `json:"name"`
+		Schema Schema `json:"schema"`
+	} `json:"json_schema"`
+}
+
+// openAIResponse is the part of a chat completion we use
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (c *OpenAIClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	messages := make([]Message, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	messages = append(messages, req.Messages...)
+
+	body := openAIRequest{
+		Model:     c.model,
+		Messages:  messages,
+		MaxTokens: req.maxTokens(c.maxTokens),
+	}
+	if req.Schema != nil {
+		body.ResponseFormat = &responseFormat{Type: "json_schema"}
+		body.ResponseFormat.JSONSchema.Name = "response"
+		if req.Template != "" {
+			body.ResponseFormat.JSONSchema.Name = req.Template
+		}
+		body.ResponseFormat.JSONSchema.Schema = req.Schema
+	}
+	headers := map[string]string{}
+	if c.apiKey != "" {
+		headers["Authorization"] = "Bearer " + c.apiKey
+	}
+
+	var out openAIResponse
+	if err := postJSON(ctx, c.http, c.Provider(), c.baseURL+"/chat/completions", headers, body, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("%s: response has no choices", c.Provider())
+	}
+
+	return &Response{
+		Text:  out.Choices[0].Message.Content,
+		Model: out.Model,
+		Usage: Usage{InputTokens: out.Usage.PromptTokens, OutputTokens: out.Usage.CompletionTokens},
+	}, nil
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultRetryDelays are the waits before each retry: 1s, 3s, 10s, then 10s
+var DefaultRetryDelays = []time.Duration{1 * time.Second, 3 * time.Second, 10 * time.Second}
+
+// RetryConfig bounds a client's calls
+type RetryConfig struct {
+	Timeout    time.Duration   // Per call, including retries; 0 = none
+	MaxRetries int             // Retries of retryable errors
+	Delays     []time.Duration // Default DefaultRetryDelays; the last one repeats
+}
+
+// retryClient retries retryable errors of the wrapped client
+type retryClient struct {
+	LLMClient
+	config RetryConfig
+}
+
+// WithRetry wraps client with a timeout and retries of retryable errors.
+// A server-requested retry-after replaces the delay when it is longer.
+func WithRetry(client LLMClient, config RetryConfig) LLMClient {
+	if len(config.Delays) == 0 {
+		config.Delays = DefaultRetryDelays
+	}
+	return &retryClient{LLMClient: client, config: config}
+}
+
+func (c *retryClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := c.config.Delays[min(attempt-1, len(c.config.Delays)-1)]
+			var apiErr *APIError
+			if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > delay {
+				delay = apiErr.RetryAfter
+			}
+			log.Printf("⚠️  %s 调用失败，%s 后第 %d/%d 次重试: %v", c.Provider(), delay, attempt, c.config.MaxRetries, lastErr)
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%s 调用取消: %w", c.Provider(), ctx.Err())
+			}
+		}
+
+		resp, err := c.LLMClient.Generate(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s 调用超时或取消: %w", c.Provider(), ctx.Err())
+		}
+		if !IsRetryable(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("%s 调用失败（已重试%d次）: %w", c.Provider(), c.config.MaxRetries, lastErr)
+}
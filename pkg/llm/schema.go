@@ -0,0 +1,124 @@
+package llm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema document
+type Schema map[string]any
+
+// SchemaFor derives a JSON Schema from the Go type of v, following its json
+// tags. Fields without omitempty or omitzero are required. A jsonschema tag
+// adds constraints, e.g. `jsonschema:"minimum=1,maximum=10"` or
+// `jsonschema:"enum=low|medium|high"`.
+func SchemaFor(v any) Schema {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaForType(t reflect.Type) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return schemaForStruct(t)
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	}
+	return Schema{}
+}
+
+func schemaForStruct(t reflect.Type) Schema {
+	properties := Schema{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := schemaForType(field.Type)
+		applyConstraints(prop, field.Tag.Get("jsonschema"))
+		properties[name] = prop
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// applyConstraints adds the constraints of a jsonschema tag to prop
+func applyConstraints(prop Schema, tag string) {
+	if tag == "" {
+		return
+	}
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "minimum", "maximum":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				prop[key] = n
+			}
+		case "enum":
+			prop[key] = strings.Split(value, "|")
+		case "description":
+			prop[key] = value
+		}
+	}
+}
+
+// String renders the schema as indented JSON, for prompts
+func (s Schema) String() string {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// systemWithSchema returns the system prompt with an instruction to follow
+// the schema, for providers without a structured output mode
+func (r *Request) systemWithSchema() string {
+	if r.Schema == nil {
+		return r.System
+	}
+	instruction := "Respond with a single JSON object, without markdown fences, that conforms to this JSON Schema:\n" + r.Schema.String()
+	if r.System == "" {
+		return instruction
+	}
+	return r.System + "\n\n" + instruction
+}
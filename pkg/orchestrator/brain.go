@@ -5,52 +5,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
+	"reflect"
 	"strings"
 	"time"
 
-	"google.golang.org/genai"
-
 	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+	"github.com/yourusername/claude-swarm/pkg/llm"
+	"github.com/yourusername/claude-swarm/pkg/prompts"
 	"github.com/yourusername/claude-swarm/pkg/state"
 )
 
-// OrchestratorBrain AI主脑 - 使用Gemini进行智能决策
+// OrchestratorBrain AI主脑 - 通过可配置的 LLM 进行智能决策
 type OrchestratorBrain struct {
-	client      *genai.Client
-	taskQueue   *state.TaskQueue
-	context     *ConversationContext
-	modelName   string
-}
+	client    llm.LLMClient
+	taskQueue *state.TaskQueue
+	context   *ConversationContext
+	auditLog  *audit.Log
+	prompts   *prompts.Store
 
-// NewOrchestratorBrain 创建AI主脑
-// apiKey如果为空，会从环境变量GEMINI_API_KEY读取
-func NewOrchestratorBrain(apiKey string, taskQueue *state.TaskQueue) (*OrchestratorBrain, error) {
-	ctx := context.Background()
-
-	// 如果没有传入 apiKey，尝试从环境变量读取
-	if apiKey == "" {
-		apiKey = os.Getenv("GEMINI_API_KEY")
-		if apiKey == "" {
-			return nil, fmt.Errorf("gemini API key is required (pass as parameter or set GEMINI_API_KEY env var)")
-		}
-	}
+	// repoContext 仓库摘要（文件树、包、关键符号等），分析需求时放进提示词
+	repoContext string
 
-	// 初始化Gemini客户端（使用 apiKey）
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey: apiKey,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
-	}
+	// notRetryable 诊断认为不值得重试的失败任务及替代方案，触发重新规划
+	notRetryable map[string]string
 
-	// 使用Gemini 3 Flash Preview模型（最新）
-	modelName := "gemini-3-flash-preview"
+	// runsDir 保存项目运行上下文的目录，为空时上下文只在内存中
+	runsDir string
+}
 
+// NewOrchestratorBrain 创建AI主脑
+// client 由 llm.New 按配置创建，超时和重试已包含在内；为 nil 时只能使用不调用 LLM 的方法
+// （校验依赖、创建任务），供 swarm plan apply 使用
+func NewOrchestratorBrain(client llm.LLMClient, taskQueue *state.TaskQueue) *OrchestratorBrain {
 	brain := &OrchestratorBrain{
 		client:    client,
 		taskQueue: taskQueue,
-		modelName: modelName,
+		prompts:   prompts.Default(),
 		context: &ConversationContext{
 			Conversations: make([]Message, 0),
 			TaskHistory:   make([]*models.Task, 0),
@@ -58,88 +49,80 @@ func NewOrchestratorBrain(apiKey string, taskQueue *state.TaskQueue) (*Orchestra
 		},
 	}
 
-	log.Printf("✓ AI主脑初始化成功 (模型: %s)", modelName)
-	return brain, nil
+	if client != nil {
+		log.Printf("✓ AI主脑初始化成功 (%s, 模型: %s)", client.Provider(), client.Model())
+	}
+	return brain
 }
 
-// Close 关闭客户端（新版SDK不需要显式关闭）
-func (b *OrchestratorBrain) Close() error {
-	// 新版Gemini SDK不需要显式关闭
-	return nil
+// SetPrompts 设置提示词模板（语言和项目覆盖），默认使用内置的中文模板
+func (b *OrchestratorBrain) SetPrompts(store *prompts.Store) {
+	b.prompts = store
 }
 
-// AnalyzeRequirement AI分析用户需求
-func (b *OrchestratorBrain) AnalyzeRequirement(ctx context.Context, requirement string) (*AnalysisResult, error) {
-	log.Printf("🧠 AI主脑开始分析需求...")
-
-	prompt := b.buildAnalysisPrompt(requirement)
-
-	// 添加超时控制（2分钟）
-	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
-	defer cancel()
-
-	// 调用Gemini API with重试机制
-	var result *genai.GenerateContentResponse
-	var err error
-
-	// 指数退避重试策略：1s, 3s, 10s
-	retryDelays := []time.Duration{1 * time.Second, 3 * time.Second, 10 * time.Second}
-	maxAttempts := len(retryDelays) + 1 // 1次初始 + 3次重试
-
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		if attempt > 0 {
-			log.Printf("⚠️  API调用失败，第 %d/%d 次重试...", attempt, maxAttempts-1)
+// SetRepoContext 设置分析需求时使用的仓库摘要（见 pkg/repocontext），为空时只根据需求文本分析
+func (b *OrchestratorBrain) SetRepoContext(text string) {
+	b.repoContext = text
+}
 
-			// 等待重试延迟
-			select {
-			case <-time.After(retryDelays[attempt-1]):
-				// 继续重试
-			case <-ctx.Done():
-				return nil, fmt.Errorf("API调用取消: %w", ctx.Err())
-			}
-		}
+// SetAuditLog 设置决策审计日志
+func (b *OrchestratorBrain) SetAuditLog(auditLog *audit.Log) {
+	b.auditLog = auditLog
+}
 
-		result, err = b.client.Models.GenerateContent(
-			ctx,
-			b.modelName,
-			genai.Text(prompt),
-			nil,
-		)
+// recordDecision 将一次决策写入审计日志
+func (b *OrchestratorBrain) recordDecision(entry audit.Entry) {
+	entry.Actor = "brain"
+	if entry.Rule == "" {
+		entry.Rule = b.client.Model()
+	}
+	if err := b.auditLog.Record(entry); err != nil {
+		log.Printf("⚠️  写入审计日志失败: %v", err)
+	}
+}
 
-		if err == nil {
-			// 成功，退出重试循环
-			break
-		}
+// digestOf 计算决策输入的摘要
+func digestOf(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return audit.Digest(string(data))
+}
 
-		// 检查是否是不可重试的错误
-		if ctx.Err() != nil {
-			// Context 取消或超时，不重试
-			return nil, fmt.Errorf("API调用超时或取消: %w", ctx.Err())
-		}
+// Close 关闭客户端（各提供方都不需要显式关闭）
+func (b *OrchestratorBrain) Close() error {
+	return nil
+}
 
-		// 记录错误，准备重试
-		log.Printf("⚠️  API调用失败 (尝试 %d/%d): %v", attempt+1, maxAttempts, err)
-	}
+// AnalyzeRequirement AI分析用户需求
+func (b *OrchestratorBrain) AnalyzeRequirement(ctx context.Context, requirement string) (*AnalysisResult, error) {
+	log.Printf("🧠 AI主脑开始分析需求...")
 
+	prompt, err := b.analyzePrompt(requirement)
 	if err != nil {
-		return nil, fmt.Errorf("Gemini API调用失败（已重试%d次）: %w", maxAttempts-1, err)
+		return nil, err
 	}
 
-	// 获取响应文本
-	responseText := result.Text()
-
-	// 解析JSON响应
-	analysisResult, err := b.parseAnalysisResponse(responseText)
+	var analysisResult AnalysisResult
+	responseText, err := b.generateJSON(ctx, prompt, &analysisResult, func() error {
+		assignTaskIDs(&analysisResult)
+		if err := analysisResult.Validate(); err != nil {
+			return err
+		}
+		return b.detectCyclicDependencies(analysisResult.Tasks)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("解析AI响应失败: %w\n原始响应: %s", err, responseText)
+		return nil, responseError("AI响应", err, responseText)
 	}
 
 	// 保存到上下文（限制大小）
 	b.context.Requirement = requirement
-	b.context.AnalysisResult = analysisResult
+	b.context.AnalysisResult = &analysisResult
 	b.context.Conversations = append(b.context.Conversations, Message{
 		Role:      "user",
 		Content:   requirement,
+		Prompt:    prompt.Version,
 		Timestamp: time.Now(),
 	})
 	b.context.Conversations = append(b.context.Conversations, Message{
@@ -156,75 +139,10 @@ func (b *OrchestratorBrain) AnalyzeRequirement(ctx context.Context, requirement
 		log.Printf("⚠️  对话历史已满，清理旧对话（保留最近%d条）", maxConversations)
 	}
 
-	log.Printf("✓ AI分析完成: %d个模块, %d个任务", len(analysisResult.Modules), len(analysisResult.Tasks))
-	return analysisResult, nil
-}
-
-// buildAnalysisPrompt 构建分析提示词
-func (b *OrchestratorBrain) buildAnalysisPrompt(requirement string) string {
-	return fmt.Sprintf(`你是一个资深软件架构师和项目经理，负责分析用户需求并拆分成可并行开发的任务。
-
-用户需求：
-%s
-
-请按以下步骤分析：
-
-1. **理解需求**：总结需求的核心功能和目标
-
-2. **模块拆分**：将需求拆分成独立的功能模块（3-8个模块）
-   - 每个模块应该是独立的功能单元
-   - 模块之间的耦合度要低
-   - 考虑可并行开发
-
-3. **任务生成**：为每个模块生成具体的开发任务
-   - 任务要具体、可执行
-   - 每个任务预计30分钟到2小时完成
-   - 明确任务描述，让AI agent能理解
-   - 任务描述要包含具体要实现的功能，而不仅仅是"设计"或"规划"
-
-4. **依赖分析**：识别任务之间的依赖关系
-   - 哪些任务必须先完成
-   - 哪些任务可以并行
-
-5. **文件预测**：预测每个任务可能涉及的文件路径
-
-请以JSON格式返回，格式如下：
-{
-  "summary": "需求概要（一句话）",
-  "complexity": "low|medium|high",
-  "estimated_time": "预计总时间",
-  "modules": [
-    {
-      "name": "模块名",
-      "description": "模块描述",
-      "files": ["涉及的文件路径"],
-      "priority": 1-10
-    }
-  ],
-  "tasks": [
-    {
-      "id": "task-001",
-      "description": "具体任务描述（给AI agent执行），例如：'创建一个todo.go文件，实现AddTask函数用于添加新任务到数组'",
-      "module": "所属模块名",
-      "files": ["涉及的文件"],
-      "dependencies": ["依赖的任务ID"],
-      "priority": 1-10,
-      "estimated": "30m|1h|2h"
-    }
-  ],
-  "dependencies": {
-    "task-002": ["task-001"],
-    "task-003": ["task-001"]
-  }
-}
+	b.saveContext()
 
-重要要求：
-- 只返回JSON，不要额外的解释文字
-- 不要用markdown代码块包裹JSON
-- task ID格式：task-001, task-002...
-- 任务描述要清晰具体，让Claude Code agent能直接执行
-- 任务描述要包含要创建的文件名和具体要实现的功能
-- 考虑Git分支隔离，每个task在独立分支开发`, requirement)
+	log.Printf("✓ AI分析完成: %d个模块, %d个任务", len(analysisResult.Modules), len(analysisResult.Tasks))
+	return &analysisResult, nil
 }
 
 // parseAnalysisResponse 解析AI响应
@@ -237,14 +155,17 @@ func (b *OrchestratorBrain) parseAnalysisResponse(response string) (*AnalysisRes
 		return nil, fmt.Errorf("JSON解析失败: %w\n原始响应: %s", err, response)
 	}
 
-	// 生成任务ID（如果没有）
+	assignTaskIDs(&result)
+	return &result, nil
+}
+
+// assignTaskIDs 为没有ID的任务生成ID
+func assignTaskIDs(result *AnalysisResult) {
 	for i, task := range result.Tasks {
 		if task.ID == "" {
 			task.ID = fmt.Sprintf("task-%03d", i+1)
 		}
 	}
-
-	return &result, nil
 }
 
 // CreateTasksFromAnalysis 将AI分析结果转换为任务队列
@@ -264,6 +185,12 @@ func (b *OrchestratorBrain) CreateTasksFromAnalysis(ctx context.Context, result
 			Description: taskSpec.Description,
 			Status:      models.TaskStatusPending,
 			Priority:    taskSpec.Priority,    // ✅ 添加优先级
+			AcceptanceCriteria: taskSpec.AcceptanceCriteria,
+			RequiredFiles:      taskSpec.RequiredFiles,
+			Checks:             taskSpec.Checks,
+			ForbiddenPaths:     taskSpec.ForbiddenPaths,
+			ReadPaths:          taskSpec.ReadFiles,
+			WritePaths:         taskSpec.Files,
 			MaxRetries:  3,                    // ✅ 设置重试次数
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
@@ -417,6 +344,8 @@ func (b *OrchestratorBrain) MonitorProgress(ctx context.Context, agents []*model
 		}
 	}
 
+	report.Reviews = models.SummarizeReviews(tasks)
+
 	// 计算进度
 	if report.TotalTasks > 0 {
 		report.OverallProgress = float64(report.CompletedTasks) / float64(report.TotalTasks) * 100
@@ -442,8 +371,42 @@ func (b *OrchestratorBrain) MonitorProgress(ctx context.Context, agents []*model
 	return report, nil
 }
 
-// DecideNextAction AI决策下一步行动（增强版）
+// DecideNextAction AI决策下一步行动（增强版），决策结果记录到审计日志和决策历史
 func (b *OrchestratorBrain) DecideNextAction(ctx context.Context, progress *ProgressReport) (*Action, error) {
+	action, err := b.decideNextAction(ctx, progress)
+
+	entry := audit.Entry{
+		Decision:    audit.DecisionNextAction,
+		InputDigest: digestOf(progress),
+		Rule:        "heuristics+" + b.client.Model(),
+	}
+	if err != nil {
+		entry.Action = "error"
+		entry.Outcome = err.Error()
+	} else {
+		entry.TaskID = action.TaskID
+		entry.AgentID = action.TargetAgent
+		entry.Action = string(action.Type)
+		entry.Outcome = action.Reason
+		if action.Command != "" {
+			entry.Details = map[string]string{"command": action.Command}
+		}
+	}
+	// 等待是最常见的结果，不记录以免淹没审计日志和决策历史
+	if err != nil || action.Type != ActionWait {
+		b.recordDecision(entry)
+	}
+	if err == nil && action.Type != ActionWait {
+		// 执行结果由调用方通过 RecordActionResult 补充
+		b.addDecision(Decision{Timestamp: time.Now(), Action: action, Reasoning: action.Reason})
+		b.saveContext()
+	}
+
+	return action, err
+}
+
+// decideNextAction 按优先级决定下一步行动
+func (b *OrchestratorBrain) decideNextAction(ctx context.Context, progress *ProgressReport) (*Action, error) {
 	// 优先级：失败任务 > 卡住Agent > 空闲Agent > 等待
 
 	// 1. 检查失败任务，决定是否重试
@@ -468,7 +431,8 @@ func (b *OrchestratorBrain) DecideNextAction(ctx context.Context, progress *Prog
 					}, nil
 				} else {
 					log.Printf("⚠️  任务 %s 不建议重试: %s", task.ID, diagnosis.AlternativeAction)
-					// 可以记录到决策历史，但不采取行动
+					// 不在这里采取行动，由重新规划处理（见 DetectReplanTriggers）
+					b.MarkNotRetryable(task.ID, diagnosis.AlternativeAction)
 				}
 			}
 		}
@@ -534,91 +498,77 @@ func (b *OrchestratorBrain) DecideNextAction(ctx context.Context, progress *Prog
 	}, nil
 }
 
-// callGemini 通用的 Gemini API 调用方法
-func (b *OrchestratorBrain) callGemini(ctx context.Context, prompt string) (string, error) {
-	// 添加超时控制
-	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
-	defer cancel()
-
-	// 重试策略
-	retryDelays := []time.Duration{1 * time.Second, 3 * time.Second, 10 * time.Second}
-	maxAttempts := len(retryDelays) + 1
-
-	var result *genai.GenerateContentResponse
-	var err error
-
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		if attempt > 0 {
-			log.Printf("⚠️  API调用失败，第 %d/%d 次重试...", attempt, maxAttempts-1)
-			select {
-			case <-time.After(retryDelays[attempt-1]):
-			case <-ctx.Done():
-				return "", fmt.Errorf("API调用取消: %w", ctx.Err())
-			}
+// maxRepairAttempts 响应无法解析或未通过校验时，把错误发回模型修复的最大次数
+const maxRepairAttempts = 2
+
+// generateJSON 用渲染好的 prompt 调用 LLM，要求响应符合由 out 的类型推导出的
+// JSON Schema，解析到 out 后用 check 做语义校验（可为 nil）。响应无效时把错误作为新一轮
+// 对话发回模型修复，最多 maxRepairAttempts 次。超时和重试由客户端处理。
+// 返回最后一次的响应文本；调用本身失败时为空
+func (b *OrchestratorBrain) generateJSON(ctx context.Context, prompt *prompts.Prompt, out any, check func() error) (string, error) {
+	req := llm.NewTemplateRequest(prompt.Name, prompt.Text)
+	req.Schema = llm.SchemaFor(out)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := b.client.Generate(ctx, req)
+		if err != nil {
+			return "", err
 		}
 
-		result, err = b.client.Models.GenerateContent(
-			ctx,
-			b.modelName,
-			genai.Text(prompt),
-			nil,
-		)
-
+		err = decodeResponse(resp.Text, out, check)
 		if err == nil {
-			break
+			return resp.Text, nil
 		}
-
-		if ctx.Err() != nil {
-			return "", fmt.Errorf("API调用超时或取消: %w", ctx.Err())
+		if attempt == maxRepairAttempts {
+			return resp.Text, fmt.Errorf("修复%d次后响应仍无效: %w", maxRepairAttempts, err)
 		}
 
-		log.Printf("⚠️  API调用失败 (尝试 %d/%d): %v", attempt+1, maxAttempts, err)
+		log.Printf("⚠️  %s 响应无效，请求模型修复 (%d/%d): %v", prompt.Name, attempt+1, maxRepairAttempts, err)
+		repair, renderErr := b.prompts.Render(TemplateRepair, repairPromptData{Error: err.Error()})
+		if renderErr != nil {
+			return resp.Text, renderErr
+		}
+		req.Messages = append(req.Messages,
+			llm.Message{Role: llm.RoleAssistant, Content: resp.Text},
+			llm.Message{Role: llm.RoleUser, Content: repair.Text},
+		)
 	}
+}
 
-	if err != nil {
-		return "", fmt.Errorf("Gemini API调用失败（已重试%d次）: %w", maxAttempts-1, err)
+// decodeResponse 清理并解析响应到 out，再执行语义校验
+func decodeResponse(text string, out any, check func() error) error {
+	// 上一轮的解析结果不能残留到这一轮
+	reflect.ValueOf(out).Elem().SetZero()
+	if err := json.Unmarshal([]byte(cleanJSONResponse(text)), out); err != nil {
+		return fmt.Errorf("JSON解析失败: %w", err)
+	}
+	if check == nil {
+		return nil
 	}
+	return check()
+}
 
-	return result.Text(), nil
+// responseError 为无效响应的错误加上说明和原始响应，调用本身失败的错误原样返回
+func responseError(what string, err error, response string) error {
+	if response == "" {
+		return err
+	}
+	return fmt.Errorf("解析%s失败: %w\n原始响应: %s", what, err, response)
 }
 
-// DiagnoseFailure 使用 Gemini 分析任务失败原因
+// DiagnoseFailure 使用 AI 分析任务失败原因
 func (b *OrchestratorBrain) DiagnoseFailure(ctx context.Context, task *models.Task) (*FailureDiagnosis, error) {
 	log.Printf("🔍 AI诊断失败任务: %s", task.ID)
 
-	prompt := fmt.Sprintf(`你是一个专业的调试专家。某个开发任务失败了，请分析原因并给出解决建议。
-
-任务信息：
-- 任务ID: %s
-- 任务描述: %s
-- 失败次数: %d/%d
-- 错误信息: %s
-
-请分析：
-1. 失败的可能原因（技术原因、描述不清、依赖问题等）
-2. 是否值得重试（true/false）
-3. 如果重试，需要修改什么
-4. 如果不值得重试，建议怎么处理
-
-返回JSON格式（不要用markdown代码块包裹）：
-{
-  "root_cause": "根本原因分析",
-  "should_retry": true,
-  "retry_suggestion": "如何修改任务描述以提高成功率",
-  "alternative_action": "如果不重试，建议的替代方案",
-  "estimated_success_rate": 75
-}`, task.ID, task.Description, task.RetryCount, task.MaxRetries, task.LastError)
-
-	responseText, err := b.callGemini(ctx, prompt)
+	prompt, err := b.diagnosePrompt(task)
 	if err != nil {
 		return nil, err
 	}
 
-	responseText = cleanJSONResponse(responseText)
-
 	var diagnosis FailureDiagnosis
-	if err := json.Unmarshal([]byte(responseText), &diagnosis); err != nil {
-		return nil, fmt.Errorf("解析诊断结果失败: %w\n原始响应: %s", err, responseText)
+	responseText, err := b.generateJSON(ctx, prompt, &diagnosis, diagnosis.Validate)
+	if err != nil {
+		return nil, responseError("诊断结果", err, responseText)
 	}
 
 	log.Printf("✅ 诊断完成: 成功率预估 %d%%, 建议%s",
@@ -628,46 +578,66 @@ func (b *OrchestratorBrain) DiagnoseFailure(ctx context.Context, task *models.Ta
 	return &diagnosis, nil
 }
 
-// HelpStuckAgent 帮助卡住的 Agent
-func (b *OrchestratorBrain) HelpStuckAgent(ctx context.Context, agentID string, task *models.Task, lastOutput string) (*AgentHelp, error) {
-	log.Printf("🆘 AI帮助卡住的Agent: %s", agentID)
-
-	// 限制输出长度，避免 prompt 过长
-	if len(lastOutput) > 1000 {
-		lastOutput = lastOutput[:1000] + "...(已截断)"
+// formatAttemptHistory 将任务的尝试记录整理为诊断用的文本，每次尝试的输出只保留末尾几行
+func formatAttemptHistory(task *models.Task) string {
+	if len(task.Attempts) == 0 {
+		return "（无记录）"
 	}
 
-	prompt := fmt.Sprintf(`你是一个资深导师，帮助卡住的AI开发Agent。
+	var sb strings.Builder
+	for _, attempt := range task.Attempts {
+		fmt.Fprintf(&sb, "第%d次 (Agent %s, 耗时 %s, 退出码 %d): ",
+			attempt.Number, attempt.AgentID, attempt.Duration().Round(time.Second), attempt.ExitCode)
+		if attempt.Failure == nil {
+			sb.WriteString("成功\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "[%s] %s\n", attempt.Failure.Category, attempt.Failure.Message)
+		if loc := attempt.Failure.Location(); loc != "" {
+			fmt.Fprintf(&sb, "  位置: %s\n", loc)
+		}
+		for _, check := range attempt.Verification {
+			if !check.Passed {
+				fmt.Fprintf(&sb, "  校验未通过: %s\n", check.Name)
+			}
+		}
+		if attempt.DiffStat != "" {
+			fmt.Fprintf(&sb, "  改动:\n%s\n", indentLines(attempt.DiffStat, "    "))
+		}
+		if attempt.Output != "" {
+			fmt.Fprintf(&sb, "  输出末尾:\n%s\n", indentLines(lastLines(attempt.Output, 15), "    "))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
 
-Agent信息：
-- Agent ID: %s
-- 当前任务: %s
-- 最后输出: %s
-- 卡住时长: 超过3分钟
+// lastLines 返回 s 的最后 n 行
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
 
-请分析：
-1. Agent可能在哪里卡住了
-2. 给出具体的提示或建议
-3. 是否需要重新分配任务
+// indentLines 为 s 的每一行加上前缀
+func indentLines(s, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+prefix)
+}
 
-返回JSON格式（不要用markdown代码块包裹）：
-{
-  "stuck_point": "卡住的具体位置/问题",
-  "hint": "给Agent的提示（一两句话，简洁明确）",
-  "should_reassign": false,
-  "reassign_reason": "如果需要重新分配，说明原因"
-}`, agentID, task.Description, lastOutput)
+// HelpStuckAgent 帮助卡住的 Agent
+func (b *OrchestratorBrain) HelpStuckAgent(ctx context.Context, agentID string, task *models.Task, lastOutput string) (*AgentHelp, error) {
+	log.Printf("🆘 AI帮助卡住的Agent: %s", agentID)
 
-	responseText, err := b.callGemini(ctx, prompt)
+	prompt, err := b.helpAgentPrompt(agentID, task, lastOutput)
 	if err != nil {
 		return nil, err
 	}
 
-	responseText = cleanJSONResponse(responseText)
-
 	var help AgentHelp
-	if err := json.Unmarshal([]byte(responseText), &help); err != nil {
-		return nil, fmt.Errorf("解析帮助信息失败: %w\n原始响应: %s", err, responseText)
+	responseText, err := b.generateJSON(ctx, prompt, &help, help.Validate)
+	if err != nil {
+		return nil, responseError("帮助信息", err, responseText)
 	}
 
 	log.Printf("✅ 帮助生成: %s", help.Hint)
@@ -675,48 +645,20 @@ Agent信息：
 	return &help, nil
 }
 
-// ValidateTaskCompletion 验证任务完成质量
-func (b *OrchestratorBrain) ValidateTaskCompletion(ctx context.Context, task *models.Task, output string) (*QualityReport, error) {
+// ValidateTaskCompletion 评审任务的完成质量：把任务描述、验收标准、代码改动（diff）和 Agent 输出的
+// 最后部分发给模型，返回质量报告。不修改对话上下文，可以在 Agent 的工作协程中调用
+func (b *OrchestratorBrain) ValidateTaskCompletion(ctx context.Context, task *models.Task, diff, output string) (*QualityReport, error) {
 	log.Printf("🔍 AI检查任务质量: %s", task.ID)
 
-	// 限制输出长度
-	if len(output) > 2000 {
-		output = output[:2000] + "...(已截断)"
-	}
-
-	prompt := fmt.Sprintf(`你是一个代码审查专家。检查这个任务是否真正完成。
-
-任务要求：
-%s
-
-Agent的输出：
-%s
-
-请检查：
-1. 是否完成了任务描述中的所有要求
-2. 代码质量如何
-3. 是否有明显的bug或问题
-4. 是否需要返工
-
-返回JSON格式（不要用markdown代码块包裹）：
-{
-  "is_complete": true,
-  "quality_score": 85,
-  "issues": ["发现的问题1", "发现的问题2"],
-  "needs_rework": false,
-  "rework_instructions": "如果需要返工，具体要改什么"
-}`, task.Description, output)
-
-	responseText, err := b.callGemini(ctx, prompt)
+	prompt, err := b.validateTaskPrompt(task, diff, output)
 	if err != nil {
 		return nil, err
 	}
 
-	responseText = cleanJSONResponse(responseText)
-
 	var report QualityReport
-	if err := json.Unmarshal([]byte(responseText), &report); err != nil {
-		return nil, fmt.Errorf("解析质量报告失败: %w\n原始响应: %s", err, responseText)
+	responseText, err := b.generateJSON(ctx, prompt, &report, report.Validate)
+	if err != nil {
+		return nil, responseError("质量报告", err, responseText)
 	}
 
 	log.Printf("✅ 质量检查完成: 评分 %d/100, 完成度: %v",
@@ -726,6 +668,23 @@ Agent的输出：
 	return &report, nil
 }
 
+// ReviewTask 评审任务的代码改动，结果转换为保存在任务尝试中的评审记录（实现 controller.Reviewer）
+func (b *OrchestratorBrain) ReviewTask(ctx context.Context, task *models.Task, diff, output string) (*models.Review, error) {
+	report, err := b.ValidateTaskCompletion(ctx, task, diff, output)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Review{
+		Score:        report.QualityScore,
+		Complete:     report.IsComplete,
+		Issues:       report.Issues,
+		NeedsRework:  report.NeedsRework,
+		Instructions: report.ReworkInstructions,
+		Reviewer:     b.client.Model(),
+		ReviewedAt:   time.Now(),
+	}, nil
+}
+
 // cleanJSONResponse 清理响应中的markdown标记和多余空白
 func cleanJSONResponse(response string) string {
 	// 去除 ```json 和 ``` 标记
@@ -748,43 +707,38 @@ func (b *OrchestratorBrain) DecideMergeStrategy(ctx context.Context, mergeStatus
 
 	log.Printf("🧠 AI分析合并策略: %d个分支待处理", len(mergeStatuses))
 
-	// 构建分支信息
-	var branchInfo strings.Builder
-	for _, status := range mergeStatuses {
-		branchInfo.WriteString(fmt.Sprintf("- 分支: %s (Agent: %s)\n", status.Branch, status.AgentID))
-		branchInfo.WriteString(fmt.Sprintf("  提交数: %d, 文件: %v\n", status.CommitCount, status.Files))
-		branchInfo.WriteString(fmt.Sprintf("  可合并: %v\n", status.ReadyToMerge))
-	}
-
-	prompt := fmt.Sprintf(`你是一个Git合并策略专家。分析以下待合并的分支，决定最佳合并顺序。
-
-待合并分支：
-%s
-
-请分析：
-1. 这些分支是否有潜在冲突（基于修改的文件）
-2. 最佳合并顺序（考虑依赖关系和冲突风险）
-3. 是否应该现在合并，还是等待更多任务完成
-
-返回JSON格式（不要用markdown代码块包裹）：
-{
-  "should_merge": true,
-  "merge_order": ["agent-0-branch", "agent-1-branch"],
-  "reason": "决策理由",
-  "potential_issues": ["可能的问题1", "可能的问题2"]
-}`, branchInfo.String())
-
-	responseText, err := b.callGemini(ctx, prompt)
+	prompt, err := b.mergeStrategyPrompt(mergeStatuses)
 	if err != nil {
 		return nil, err
 	}
 
-	responseText = cleanJSONResponse(responseText)
+	entry := audit.Entry{
+		Decision:    audit.DecisionMergeStrategy,
+		InputDigest: audit.Digest(prompt.Text),
+		Prompt:      prompt.Version,
+	}
 
 	var decision MergeDecision
-	if err := json.Unmarshal([]byte(responseText), &decision); err != nil {
-		return nil, fmt.Errorf("解析合并决策失败: %w\n原始响应: %s", err, responseText)
+	responseText, err := b.generateJSON(ctx, prompt, &decision, func() error {
+		return decision.validateOrder(mergeStatuses)
+	})
+	if err != nil {
+		entry.Action = "error"
+		entry.Outcome = err.Error()
+		if responseText != "" {
+			entry.Outcome = "invalid response"
+		}
+		b.recordDecision(entry)
+		return nil, responseError("合并决策", err, responseText)
+	}
+
+	entry.Action = "defer"
+	if decision.ShouldMerge {
+		entry.Action = "merge"
 	}
+	entry.Outcome = strings.Join(decision.MergeOrder, ",")
+	entry.Details = map[string]string{"reason": decision.Reason}
+	b.recordDecision(entry)
 
 	log.Printf("✅ 合并决策: 合并=%v, 顺序=%v", decision.ShouldMerge, decision.MergeOrder)
 	return &decision, nil
@@ -794,47 +748,42 @@ func (b *OrchestratorBrain) DecideMergeStrategy(ctx context.Context, mergeStatus
 func (b *OrchestratorBrain) ResolveConflict(ctx context.Context, branch string, conflictFiles []string, conflictContent string) (*ConflictResolution, error) {
 	log.Printf("🧠 AI分析合并冲突: %s, 冲突文件: %v", branch, conflictFiles)
 
-	// 限制内容长度
-	if len(conflictContent) > 3000 {
-		conflictContent = conflictContent[:3000] + "...(已截断)"
-	}
-
-	prompt := fmt.Sprintf(`你是一个代码合并专家。分析以下合并冲突并提供解决方案。
-
-分支: %s
-冲突文件: %v
-
-冲突内容:
-%s
-
-请分析：
-1. 冲突的原因
-2. 是否可以自动解决（保留两边改动/选择一边）
-3. 具体的解决建议
-
-返回JSON格式（不要用markdown代码块包裹）：
-{
-  "can_auto_resolve": false,
-  "resolution": "解决方案描述",
-  "file_resolutions": {
-    "file1.go": "保留双方改动，手动合并",
-    "file2.go": "使用当前分支版本"
-  },
-  "needs_human_review": true,
-  "reason": "为什么需要/不需要人工审核"
-}`, branch, conflictFiles, conflictContent)
-
-	responseText, err := b.callGemini(ctx, prompt)
+	prompt, err := b.resolveConflictPrompt(branch, conflictFiles, conflictContent)
 	if err != nil {
 		return nil, err
 	}
 
-	responseText = cleanJSONResponse(responseText)
+	entry := audit.Entry{
+		Decision:    audit.DecisionResolveConflict,
+		InputDigest: audit.Digest(prompt.Text),
+		Prompt:      prompt.Version,
+		Details:     map[string]string{"branch": branch},
+	}
 
 	var resolution ConflictResolution
-	if err := json.Unmarshal([]byte(responseText), &resolution); err != nil {
-		return nil, fmt.Errorf("解析冲突解决方案失败: %w\n原始响应: %s", err, responseText)
+	responseText, err := b.generateJSON(ctx, prompt, &resolution, func() error {
+		return resolution.validateFiles(conflictFiles)
+	})
+	if err != nil {
+		entry.Action = "error"
+		entry.Outcome = err.Error()
+		if responseText != "" {
+			entry.Outcome = "invalid response"
+		}
+		b.recordDecision(entry)
+		return nil, responseError("冲突解决方案", err, responseText)
+	}
+
+	switch {
+	case resolution.NeedsHumanReview:
+		entry.Action = "needs_human_review"
+	case resolution.CanAutoResolve:
+		entry.Action = "auto_resolve"
+	default:
+		entry.Action = "manual_resolve"
 	}
+	entry.Outcome = resolution.Resolution
+	b.recordDecision(entry)
 
 	log.Printf("✅ 冲突分析完成: 可自动解决=%v, 需人工=%v", resolution.CanAutoResolve, resolution.NeedsHumanReview)
 	return &resolution, nil
@@ -844,35 +793,15 @@ func (b *OrchestratorBrain) ResolveConflict(ctx context.Context, branch string,
 func (b *OrchestratorBrain) ValidateMergeResult(ctx context.Context, branch string, mergedFiles []string) (*QualityReport, error) {
 	log.Printf("🧠 AI验证合并结果: %s", branch)
 
-	prompt := fmt.Sprintf(`你是一个代码审查专家。验证以下分支合并后的代码质量。
-
-合并的分支: %s
-涉及的文件: %v
-
-请检查：
-1. 合并是否完整
-2. 是否有潜在的集成问题
-3. 是否需要额外的测试
-
-返回JSON格式（不要用markdown代码块包裹）：
-{
-  "is_complete": true,
-  "quality_score": 85,
-  "issues": ["可能的问题"],
-  "needs_rework": false,
-  "rework_instructions": ""
-}`, branch, mergedFiles)
-
-	responseText, err := b.callGemini(ctx, prompt)
+	prompt, err := b.validateMergePrompt(branch, mergedFiles)
 	if err != nil {
 		return nil, err
 	}
 
-	responseText = cleanJSONResponse(responseText)
-
 	var report QualityReport
-	if err := json.Unmarshal([]byte(responseText), &report); err != nil {
-		return nil, fmt.Errorf("解析验证结果失败: %w\n原始响应: %s", err, responseText)
+	responseText, err := b.generateJSON(ctx, prompt, &report, report.Validate)
+	if err != nil {
+		return nil, responseError("验证结果", err, responseText)
 	}
 
 	log.Printf("✅ 合并验证完成: 评分=%d, 完整=%v", report.QualityScore, report.IsComplete)
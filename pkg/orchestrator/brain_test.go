@@ -1,8 +1,17 @@
 package orchestrator
 
 import (
+	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/llm"
+	"github.com/yourusername/claude-swarm/pkg/prompts"
+	"github.com/yourusername/claude-swarm/pkg/state"
 )
 
 func TestValidateDependencies(t *testing.T) {
@@ -520,3 +529,323 @@ func TestParseConflictResolutionJSON(t *testing.T) {
 		})
 	}
 }
+
+// scriptedBrain 返回由 fake 客户端驱动的主脑，任务队列在临时目录中
+func scriptedBrain(t *testing.T, client llm.LLMClient) *OrchestratorBrain {
+	t.Helper()
+	queue, err := state.NewTaskQueue(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewOrchestratorBrain(client, queue)
+}
+
+func newFlowFake() *llm.FakeClient {
+	return llm.NewFakeClient().
+		On(TemplateAnalyze, "```json\n"+`{
+			"summary": "login",
+			"tasks": [
+				{"id": "task-001", "description": "Add user model", "priority": 8, "files": ["user.go"], "read_files": ["db/*.go"]},
+				{"id": "task-002", "description": "Add login API", "dependencies": ["task-001"], "priority": 5}
+			]
+		}`+"\n```").
+		On(TemplateDiagnose, `{"root_cause": "missing import", "should_retry": true, "retry_suggestion": "import bcrypt", "estimated_success_rate": 80}`).
+		On(TemplateMergeStrategy, `{"should_merge": true, "merge_order": ["agent-1-branch", "agent-0-branch"], "reason": "model first"}`).
+		On(TemplateResolveConflict, `{"can_auto_resolve": true, "resolution": "keep both", "needs_human_review": false}`)
+}
+
+// runFlow 依次执行 orchestrate → 诊断失败 → 合并 → 冲突解决 中调用 LLM 的步骤
+func runFlow(t *testing.T, brain *OrchestratorBrain) {
+	t.Helper()
+	ctx := context.Background()
+
+	analysis, err := brain.AnalyzeRequirement(ctx, "add login")
+	if err != nil {
+		t.Fatalf("AnalyzeRequirement() error = %v", err)
+	}
+	if err := brain.ValidateDependencies(analysis); err != nil {
+		t.Fatalf("ValidateDependencies() error = %v", err)
+	}
+	if err := brain.CreateTasksFromAnalysis(ctx, analysis); err != nil {
+		t.Fatalf("CreateTasksFromAnalysis() error = %v", err)
+	}
+	tasks := brain.taskQueue.ListTasks()
+	if len(tasks) != 2 {
+		t.Fatalf("queue has %d tasks, want 2", len(tasks))
+	}
+	for _, task := range tasks {
+		if task.Description == "Add user model" && (len(task.WritePaths) != 1 || task.WritePaths[0] != "user.go" || len(task.ReadPaths) != 1) {
+			t.Errorf("task scope = writes %v, reads %v; want the predicted files", task.WritePaths, task.ReadPaths)
+		}
+	}
+
+	diagnosis, err := brain.DiagnoseFailure(ctx, &models.Task{ID: tasks[0].ID, Description: tasks[0].Description, LastError: "undefined: bcrypt"})
+	if err != nil || !diagnosis.ShouldRetry || diagnosis.EstimatedSuccessRate != 80 {
+		t.Fatalf("DiagnoseFailure() = %+v, %v", diagnosis, err)
+	}
+
+	decision, err := brain.DecideMergeStrategy(ctx, []*MergeStatus{
+		{Branch: "agent-0-branch", AgentID: "agent-0", CommitCount: 1, ReadyToMerge: true},
+		{Branch: "agent-1-branch", AgentID: "agent-1", CommitCount: 2, ReadyToMerge: true},
+	})
+	if err != nil || !decision.ShouldMerge || len(decision.MergeOrder) != 2 || decision.MergeOrder[0] != "agent-1-branch" {
+		t.Fatalf("DecideMergeStrategy() = %+v, %v", decision, err)
+	}
+
+	resolution, err := brain.ResolveConflict(ctx, "agent-0-branch", []string{"user.go"}, "<<<<<<< HEAD")
+	if err != nil || !resolution.CanAutoResolve {
+		t.Fatalf("ResolveConflict() = %+v, %v", resolution, err)
+	}
+}
+
+func TestBrainFlowWithFakeClient(t *testing.T) {
+	fake := newFlowFake()
+	runFlow(t, scriptedBrain(t, fake))
+
+	for _, template := range []string{TemplateAnalyze, TemplateDiagnose, TemplateMergeStrategy, TemplateResolveConflict} {
+		if n := fake.CallCount(template); n != 1 {
+			t.Errorf("%s called %d times, want 1", template, n)
+		}
+	}
+}
+
+func TestBrainFlowReplaysCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flow.json")
+	runFlow(t, scriptedBrain(t, llm.NewRecorder(newFlowFake(), path)))
+
+	// 回放时不访问任何提供方；任务 ID 含时间戳，提示词与录制时不同，按模板顺序匹配
+	replayer, err := llm.NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runFlow(t, scriptedBrain(t, replayer))
+	if n := replayer.Remaining(); n != 0 {
+		t.Errorf("%d recorded interactions not replayed", n)
+	}
+}
+
+func TestAnalyzeRequirementError(t *testing.T) {
+	fake := llm.NewFakeClient().On(TemplateAnalyze, "not json")
+	if _, err := scriptedBrain(t, fake).AnalyzeRequirement(context.Background(), "add login"); err == nil {
+		t.Error("unparseable analysis should fail")
+	}
+}
+
+func TestGenerateJSONRepair(t *testing.T) {
+	duplicate := `{"tasks": [{"id": "task-001", "description": "a", "priority": 5}, {"id": "task-001", "description": "b", "priority": 11}]}`
+	valid := `{"tasks": [{"id": "task-001", "description": "a", "priority": 5}, {"id": "task-002", "description": "b", "priority": 10}]}`
+
+	t.Run("repairs invalid response", func(t *testing.T) {
+		fake := llm.NewFakeClient().On(TemplateAnalyze, duplicate, valid)
+		result, err := scriptedBrain(t, fake).AnalyzeRequirement(context.Background(), "add login")
+		if err != nil {
+			t.Fatalf("AnalyzeRequirement() error = %v", err)
+		}
+		if len(result.Tasks) != 2 || result.Tasks[1].ID != "task-002" {
+			t.Errorf("tasks = %+v, want the repaired response", result.Tasks)
+		}
+
+		calls := fake.Calls()
+		if len(calls) != 2 {
+			t.Fatalf("got %d calls, want 2", len(calls))
+		}
+		repair := calls[1]
+		if len(repair.Messages) != 3 || repair.Messages[1].Content != duplicate {
+			t.Fatalf("repair request messages = %+v", repair.Messages)
+		}
+		feedback := repair.Messages[2].Content
+		if !strings.Contains(feedback, "task-001 重复") || !strings.Contains(feedback, "priority 为 11") {
+			t.Errorf("repair feedback = %q, want every validation error", feedback)
+		}
+		if calls[0].Schema == nil {
+			t.Error("request should carry the response schema")
+		}
+	})
+
+	t.Run("gives up after bounded repairs", func(t *testing.T) {
+		fake := llm.NewFakeClient().On(TemplateAnalyze, "not json")
+		_, err := scriptedBrain(t, fake).AnalyzeRequirement(context.Background(), "add login")
+		if err == nil {
+			t.Fatal("AnalyzeRequirement() should fail")
+		}
+		if n := fake.CallCount(TemplateAnalyze); n != maxRepairAttempts+1 {
+			t.Errorf("got %d calls, want %d", n, maxRepairAttempts+1)
+		}
+	})
+
+	t.Run("rejects unknown merge branches", func(t *testing.T) {
+		fake := llm.NewFakeClient().On(TemplateMergeStrategy,
+			`{"should_merge": true, "merge_order": ["agent-9-branch"]}`,
+			`{"should_merge": true, "merge_order": ["agent-0-branch"]}`)
+		decision, err := scriptedBrain(t, fake).DecideMergeStrategy(context.Background(),
+			[]*MergeStatus{{Branch: "agent-0-branch", ReadyToMerge: true}})
+		if err != nil || decision.MergeOrder[0] != "agent-0-branch" {
+			t.Errorf("DecideMergeStrategy() = %+v, %v", decision, err)
+		}
+	})
+	t.Run("rejects unresolved conflict files", func(t *testing.T) {
+		fake := llm.NewFakeClient().On(TemplateResolveConflict,
+			`{"can_auto_resolve": true, "resolution": "keep both", "file_resolutions": {"user.go": "<<<<<<< HEAD\nmain\n=======\nbranch\n>>>>>>> agent-0-branch\n", "other.go": "x"}}`,
+			`{"can_auto_resolve": true, "resolution": "keep both", "file_resolutions": {"user.go": "main\nbranch\n"}}`)
+		resolution, err := scriptedBrain(t, fake).ResolveConflict(context.Background(), "agent-0-branch", []string{"user.go"}, "<<<<<<< HEAD")
+		if err != nil || resolution.FileResolutions["user.go"] != "main\nbranch\n" {
+			t.Fatalf("ResolveConflict() = %+v, %v", resolution, err)
+		}
+		feedback := fake.Calls()[1].Messages[2].Content
+		if !strings.Contains(feedback, "仍包含冲突标记") || !strings.Contains(feedback, "other.go 不是冲突文件") {
+			t.Errorf("repair feedback = %q, want both errors", feedback)
+		}
+	})
+}
+
+func TestAnalysisResultValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  AnalysisResult
+		wantErr bool
+	}{
+		{"valid", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 1}, {ID: "b", Priority: 10, Dependencies: []string{"a"}}}}, false},
+		{"no tasks", AnalysisResult{}, true},
+		{"duplicate id", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 1}, {ID: "a", Priority: 1}}}, true},
+		{"missing dependency", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 1, Dependencies: []string{"z"}}}}, true},
+		{"priority out of range", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 0}}}, true},
+		{"module priority out of range", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 1}}, Modules: []Module{{Name: "m", Priority: 42}}}, true},
+		{"definition of done", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 1, RequiredFiles: []string{"cmd/**/*.go"}, Checks: []string{"go test ./..."}, ForbiddenPaths: []string{"vendor/"}}}}, false},
+		{"required file outside the repository", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 1, RequiredFiles: []string{"../secrets"}}}}, true},
+		{"bad forbidden glob", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 1, ForbiddenPaths: []string{"docs/[a"}}}}, true},
+		{"empty check", AnalysisResult{Tasks: []*TaskSpec{{ID: "a", Priority: 1, Checks: []string{" "}}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.result.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderPromptAllTemplates(t *testing.T) {
+	task := &models.Task{
+		ID:          "task-1",
+		Description: "Add login API",
+		Status:      models.TaskStatusFailed,
+		LastError:   "undefined: bcrypt",
+		Attempts: []models.Attempt{
+			{Number: 1, AgentID: "agent-0", Branch: "agent-0-task-1", Output: "go build failed", Commits: []string{"abc123"}},
+		},
+	}
+
+	for _, language := range prompts.Languages() {
+		store, err := prompts.New("", language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range prompts.Names() {
+			prompt, err := RenderPrompt(store, name, task, "", "")
+			if err != nil {
+				t.Errorf("%s/%s: %v", name, language, err)
+				continue
+			}
+			if strings.Contains(prompt.Text, "<no value>") || prompt.Version == "" {
+				t.Errorf("%s/%s rendered badly: %s", name, language, prompt.Text)
+			}
+		}
+	}
+
+	zh, err := RenderPrompt(prompts.Default(), TemplateMergeStrategy, task, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(zh.Text, "- 分支: agent-0-task-1 (Agent: agent-0)\n  提交数: 1, 文件: []\n  可合并: false\n\n请分析") {
+		t.Errorf("merge_strategy prompt = %s", zh.Text)
+	}
+
+	repo := "Commit: abc123\n\n## Packages\npkg/auth/ (go, 3 files)"
+	for _, language := range prompts.Languages() {
+		store, _ := prompts.New("", language)
+		with, err := RenderPrompt(store, TemplateAnalyze, nil, "Add login", repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		without, _ := RenderPrompt(store, TemplateAnalyze, nil, "Add login", "")
+		if !strings.Contains(with.Text, "Add login\n\n") || !strings.Contains(with.Text, repo+"\n\n") {
+			t.Errorf("%s analyze prompt missing repository context:\n%s", language, with.Text)
+		}
+		if strings.Contains(without.Text, "HEAD") {
+			t.Errorf("%s analyze prompt without repository context:\n%s", language, without.Text)
+		}
+	}
+}
+
+func TestPlanRoundTrip(t *testing.T) {
+	result := &AnalysisResult{
+		Summary: "login",
+		Modules: []Module{{Name: "auth", Description: "accounts", Priority: 5}},
+		Tasks: []*TaskSpec{
+			{ID: "task-001", Description: "Add user model", Module: "auth", Files: []string{"user.go"}, Priority: 8,
+				RequiredFiles: []string{"user.go"}, Checks: []string{"go test ./..."}, ForbiddenPaths: []string{"go.mod"}},
+			{ID: "task-002", Description: "Add login API", Module: "auth", Dependencies: []string{"task-001"}, Priority: 5},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "plans", "login.yaml")
+	if err := SavePlan(path, NewPlan("add login", result)); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := LoadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	brain := &OrchestratorBrain{}
+	loaded, err := brain.ValidatePlan(plan)
+	if err != nil {
+		t.Fatalf("ValidatePlan() error = %v", err)
+	}
+	if plan.Requirement != "add login" || len(loaded.Tasks) != 2 || loaded.Tasks[0].Files[0] != "user.go" ||
+		loaded.Tasks[0].Checks[0] != "go test ./..." || loaded.Tasks[0].ForbiddenPaths[0] != "go.mod" {
+		t.Errorf("loaded plan = %+v", plan)
+	}
+	if deps := loaded.Dependencies["task-002"]; len(deps) != 1 || deps[0] != "task-001" {
+		t.Errorf("dependency graph = %v, want it rebuilt from the tasks", loaded.Dependencies)
+	}
+
+	// An edit that introduces a cycle is rejected before anything is created
+	plan.Tasks[0].Dependencies = []string{"task-002"}
+	if _, err := brain.ValidatePlan(plan); err == nil {
+		t.Error("cyclic plan should be rejected")
+	}
+
+	// Typos in field names are errors rather than silently ignored
+	if err := os.WriteFile(path, []byte("tasks:\n  - id: task-001\n    priorty: 5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPlan(path); err == nil {
+		t.Error("unknown field should be rejected")
+	}
+}
+
+func TestReviewTask(t *testing.T) {
+	fake := llm.NewFakeClient().On(TemplateValidateTask,
+		`{"is_complete": false, "quality_score": 55, "issues": ["no tests"], "needs_rework": true, "rework_instructions": "add tests for /logout"}`)
+	task := &models.Task{ID: "task-1", Description: "Add logout", AcceptanceCriteria: []string{"POST /logout clears the session"},
+		WritePaths: []string{"auth/"}, Attempts: []models.Attempt{{Number: 1, OutOfScope: []string{"go.mod"}}}}
+	diff := "+func Logout() {}\n"
+
+	review, err := scriptedBrain(t, fake).ReviewTask(context.Background(), task, diff, "")
+	if err != nil {
+		t.Fatalf("ReviewTask() error = %v", err)
+	}
+	if review.Score != 55 || !review.NeedsRework || review.Instructions != "add tests for /logout" || review.Complete {
+		t.Errorf("review = %+v", review)
+	}
+	if review.ReviewedAt.IsZero() {
+		t.Error("review should record when it was made")
+	}
+
+	prompt := fake.Calls()[0].Messages[0].Content
+	for _, want := range []string{"POST /logout clears the session", "修改范围：\n- auth/", "超出修改范围的改动：\n- go.mod", diff} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("review prompt does not contain %q", want)
+		}
+	}
+}
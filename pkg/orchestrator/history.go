@@ -0,0 +1,115 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/yourusername/claude-swarm/pkg/state"
+)
+
+// ErrNoRun 没有保存的项目运行
+var ErrNoRun = errors.New("没有保存的项目运行")
+
+// maxDecisions 上下文中保留的决策数量
+const maxDecisions = 500
+
+// StartRun 开始一次项目运行：记录需求和（可能经过人工修改的）计划并保存到 dir，
+// 之后上下文的每次变化都保存到同一个运行中。requirement 为空时保留分析需求时记录的需求
+func (b *OrchestratorBrain) StartRun(dir, requirement string, result *AnalysisResult) error {
+	b.runsDir = dir
+	b.context.RunID = state.NewBrainRunID(dir)
+	b.context.StartedAt = time.Now()
+	if requirement != "" {
+		b.context.Requirement = requirement
+	}
+	if result != nil {
+		b.context.AnalysisResult = result
+	}
+	return b.saveContextErr()
+}
+
+// ResumeRun 加载 dir 中保存的项目运行（id 为空时加载最近一次），之后的变化继续保存到该运行。
+// 没有保存的运行时返回 ErrNoRun
+func (b *OrchestratorBrain) ResumeRun(dir, id string) error {
+	loaded, err := LoadRun(dir, id)
+	if err != nil {
+		return err
+	}
+	b.runsDir = dir
+	b.context = loaded
+	return nil
+}
+
+// LoadRun 读取保存的项目运行，id 为空时读取最近一次
+func LoadRun(dir, id string) (*ConversationContext, error) {
+	if id == "" {
+		ids, err := state.ListBrainRuns(dir)
+		if err != nil {
+			return nil, fmt.Errorf("读取项目运行失败: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil, ErrNoRun
+		}
+		id = ids[len(ids)-1]
+	}
+
+	var loaded ConversationContext
+	if err := state.LoadBrainRun(dir, id, &loaded); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("项目运行 %s 不存在", id)
+		}
+		return nil, err
+	}
+	if loaded.RunID == "" {
+		loaded.RunID = id
+	}
+	return &loaded, nil
+}
+
+// Context 返回当前的对话上下文
+func (b *OrchestratorBrain) Context() *ConversationContext {
+	return b.context
+}
+
+// RecordDecision 记录一次决策及其结果，例如合并分支的结果
+func (b *OrchestratorBrain) RecordDecision(action *Action, reasoning, result string) {
+	b.addDecision(Decision{Timestamp: time.Now(), Action: action, Reasoning: reasoning, Result: result})
+	b.saveContext()
+}
+
+// RecordActionResult 记录 DecideNextAction 返回的行动的执行结果
+func (b *OrchestratorBrain) RecordActionResult(action *Action, result string) {
+	for i := len(b.context.Decisions) - 1; i >= 0; i-- {
+		if b.context.Decisions[i].Action == action {
+			b.context.Decisions[i].Result = result
+			b.saveContext()
+			return
+		}
+	}
+}
+
+// addDecision 添加决策记录（限制数量）
+func (b *OrchestratorBrain) addDecision(decision Decision) {
+	b.context.Decisions = append(b.context.Decisions, decision)
+	if len(b.context.Decisions) > maxDecisions {
+		b.context.Decisions = b.context.Decisions[len(b.context.Decisions)-maxDecisions:]
+	}
+}
+
+// saveContext 保存上下文到当前项目运行，失败只打印警告
+func (b *OrchestratorBrain) saveContext() {
+	if err := b.saveContextErr(); err != nil {
+		log.Printf("⚠️  保存主脑上下文失败: %v", err)
+	}
+}
+
+func (b *OrchestratorBrain) saveContextErr() error {
+	if b.runsDir == "" || b.context.RunID == "" {
+		return nil
+	}
+	b.context.UpdatedAt = time.Now()
+	return state.SaveBrainRun(b.runsDir, b.context.RunID, b.context)
+}
@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/llm"
+)
+
+func TestRunPersistence(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "runs")
+	ctx := context.Background()
+
+	// orchestrate: 分析需求、创建任务并开始运行
+	brain := scriptedBrain(t, newFlowFake())
+	result, err := brain.AnalyzeRequirement(ctx, "add login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := brain.StartRun(dir, "add login (edited)", result); err != nil {
+		t.Fatalf("StartRun() error = %v", err)
+	}
+	runID := brain.Context().RunID
+
+	// start --with-brain: 新的主脑加载同一个运行
+	fake := llm.NewFakeClient().
+		On(TemplateDiagnose, `{"root_cause": "flaky test", "should_retry": true, "retry_suggestion": "rerun", "estimated_success_rate": 90}`)
+	resumed := scriptedBrain(t, fake)
+	if err := resumed.ResumeRun(dir, ""); err != nil {
+		t.Fatalf("ResumeRun() error = %v", err)
+	}
+	if resumed.Context().RunID != runID || resumed.Context().Requirement != "add login (edited)" {
+		t.Errorf("resumed context = %+v", resumed.Context())
+	}
+	if resumed.Context().AnalysisResult == nil || len(resumed.Context().AnalysisResult.Tasks) != 2 {
+		t.Errorf("resumed analysis = %+v", resumed.Context().AnalysisResult)
+	}
+	if len(resumed.Context().Conversations) != 2 {
+		t.Errorf("resumed conversations = %d, want 2", len(resumed.Context().Conversations))
+	}
+
+	// 决策和执行结果都保存到运行中
+	_ = resumed.taskQueue.AddTask(&models.Task{ID: "task-1", Status: models.TaskStatusFailed, RetryCount: 1})
+	action, err := resumed.DecideNextAction(ctx, &ProgressReport{FailedTasks: 1})
+	if err != nil || action.Type != ActionReassignTask {
+		t.Fatalf("DecideNextAction() = %+v, %v", action, err)
+	}
+	resumed.RecordActionResult(action, "任务已重置为待执行")
+	resumed.RecordDecision(&Action{Type: ActionMergeBranch, Command: "agent-0-branch"}, "model first", "已合并")
+
+	loaded, err := LoadRun(dir, runID)
+	if err != nil {
+		t.Fatalf("LoadRun() error = %v", err)
+	}
+	if len(loaded.Decisions) != 2 {
+		t.Fatalf("saved decisions = %+v", loaded.Decisions)
+	}
+	first := loaded.Decisions[0]
+	if first.Action.Type != ActionReassignTask || first.Action.TaskID != "task-1" || first.Result != "任务已重置为待执行" {
+		t.Errorf("first decision = %+v %+v", first, first.Action)
+	}
+	if loaded.Decisions[1].Result != "已合并" || loaded.UpdatedAt.IsZero() {
+		t.Errorf("second decision = %+v", loaded.Decisions[1])
+	}
+
+	// 新的运行成为最近一次
+	if err := scriptedBrain(t, nil).StartRun(dir, "add logout", nil); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := LoadRun(dir, "")
+	if err != nil || latest.Requirement != "add logout" || latest.RunID == runID {
+		t.Errorf("LoadRun(latest) = %+v, %v", latest, err)
+	}
+}
+
+func TestResumeRunWithoutRuns(t *testing.T) {
+	err := scriptedBrain(t, nil).ResumeRun(filepath.Join(t.TempDir(), "runs"), "")
+	if !errors.Is(err, ErrNoRun) {
+		t.Errorf("ResumeRun() error = %v, want ErrNoRun", err)
+	}
+	if _, err := LoadRun(t.TempDir(), "run-missing"); err == nil {
+		t.Error("LoadRun() of a missing run should fail")
+	}
+}
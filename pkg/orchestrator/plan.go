@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Plan 可编辑的任务计划：需求和 AI 的分析结果。审批时写成 YAML 供用户编辑，
+// 也可以保存下来稍后用 swarm plan apply 创建任务
+type Plan struct {
+	Requirement    string    `yaml:"requirement"`
+	CreatedAt      time.Time `yaml:"created_at"`
+	AnalysisResult `yaml:",inline"`
+}
+
+// planHeader 写在计划文件开头的编辑说明
+const planHeader = `# swarm 任务计划：编辑后保存，创建任务前会重新校验
+#
+#   tasks[].id            计划内唯一，dependencies 引用这些ID（创建时换成真实任务ID）
+#   tasks[].priority      1-10，越大越先执行
+#   tasks[].dependencies  删除任务时记得同时删除其他任务对它的依赖，且不能形成环
+#   tasks[].files         任务会修改的文件（支持目录和 * **），修改范围重叠的任务不会同时执行；
+#                         read_files 是只需阅读的文件
+#   tasks[].required_files / checks / forbidden_paths
+#                         可选的完成条件：必须存在的文件、必须通过的命令、不允许修改的路径，
+#                         每次执行后在 agent 的 worktree 中自动校验（路径相对仓库根目录，支持 * 和 **）
+#   modules               仅用于说明，不会创建任务
+#
+# 应用: swarm plan apply <本文件>
+
+`
+
+// NewPlan 由分析结果创建计划
+func NewPlan(requirement string, result *AnalysisResult) *Plan {
+	return &Plan{
+		Requirement:    requirement,
+		CreatedAt:      time.Now(),
+		AnalysisResult: *result,
+	}
+}
+
+// Result 返回计划中的分析结果，依赖关系图按任务的 dependencies 重建
+func (p *Plan) Result() *AnalysisResult {
+	result := p.AnalysisResult
+	result.Dependencies = make(map[string][]string)
+	for _, task := range result.Tasks {
+		if len(task.Dependencies) > 0 {
+			result.Dependencies[task.ID] = task.Dependencies
+		}
+	}
+	return &result
+}
+
+// SavePlan 把计划写成带编辑说明的 YAML 文件
+func SavePlan(path string, plan *Plan) error {
+	var buf bytes.Buffer
+	buf.WriteString(planHeader)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(plan); err != nil {
+		return fmt.Errorf("序列化计划失败: %w", err)
+	}
+	encoder.Close()
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建计划目录失败: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入计划失败: %w", err)
+	}
+	return nil
+}
+
+// LoadPlan 读取计划文件，未知字段视为错误以便发现拼写错误
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取计划失败: %w", err)
+	}
+
+	var plan Plan
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&plan); err != nil {
+		return nil, fmt.Errorf("解析计划 %s 失败: %w", path, err)
+	}
+	return &plan, nil
+}
+
+// ValidatePlan 校验计划：任务ID唯一、依赖存在、优先级合法（AnalysisResult.Validate），
+// 再用 ValidateDependencies 检查循环依赖。返回校验后的分析结果
+func (b *OrchestratorBrain) ValidatePlan(plan *Plan) (*AnalysisResult, error) {
+	result := plan.Result()
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+	if err := b.ValidateDependencies(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package orchestrator
+
+import (
+	"fmt"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/prompts"
+)
+
+// 提示词模板 ID，即 pkg/prompts 中的模板名；随请求发给 LLM 客户端，fake 客户端和 cassette 按它匹配响应
+const (
+	TemplateAnalyze         = "analyze"
+	TemplateDiagnose        = "diagnose"
+	TemplateHelpAgent       = "help_agent"
+	TemplateValidateTask    = "validate_task"
+	TemplateMergeStrategy   = "merge_strategy"
+	TemplateResolveConflict = "resolve_conflict"
+	TemplateValidateMerge   = "validate_merge"
+	TemplateReplan          = "replan"
+	TemplateRepair          = "repair" // 响应无效时发回模型的修复请求
+)
+
+// 各模板的数据，字段即模板中可用的变量（覆盖模板时参考）
+
+type analyzePromptData struct {
+	Requirement string
+	RepoContext string // 仓库摘要，可能为空
+}
+
+type diagnosePromptData struct {
+	Task           *models.Task
+	AttemptHistory string // 历次尝试的摘要，见 formatAttemptHistory
+}
+
+type helpAgentPromptData struct {
+	AgentID    string
+	Task       *models.Task
+	LastOutput string // 最多1000字节
+}
+
+type validateTaskPromptData struct {
+	Task   *models.Task // 含验收标准 AcceptanceCriteria
+	Diff   string       // 相对 main 的代码改动，最多12000字节
+	Output string       // Agent 输出的最后部分，最多2000字节，可能为空
+}
+
+type mergeStrategyPromptData struct {
+	Statuses []*MergeStatus
+}
+
+type resolveConflictPromptData struct {
+	Branch  string
+	Files   []string
+	Content string // 带冲突标记的 git diff 和冲突文件，最多20000字节
+}
+
+type validateMergePromptData struct {
+	Branch string
+	Files  []string
+}
+
+type replanPromptData struct {
+	Requirement string
+	Trigger     ReplanTrigger
+	TaskGraph   string // 当前任务图，见 formatTaskGraph
+	RepoContext string // 仓库摘要，可能为空
+}
+
+type repairPromptData struct {
+	Error string
+}
+
+// truncate 截断过长的内容，避免 prompt 过长
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "...(已截断)"
+	}
+	return s
+}
+
+func (b *OrchestratorBrain) analyzePrompt(requirement string) (*prompts.Prompt, error) {
+	return b.prompts.Render(TemplateAnalyze, analyzePromptData{Requirement: requirement, RepoContext: b.repoContext})
+}
+
+func (b *OrchestratorBrain) diagnosePrompt(task *models.Task) (*prompts.Prompt, error) {
+	return b.prompts.Render(TemplateDiagnose, diagnosePromptData{Task: task, AttemptHistory: formatAttemptHistory(task)})
+}
+
+func (b *OrchestratorBrain) helpAgentPrompt(agentID string, task *models.Task, lastOutput string) (*prompts.Prompt, error) {
+	return b.prompts.Render(TemplateHelpAgent, helpAgentPromptData{AgentID: agentID, Task: task, LastOutput: truncate(lastOutput, 1000)})
+}
+
+func (b *OrchestratorBrain) validateTaskPrompt(task *models.Task, diff, output string) (*prompts.Prompt, error) {
+	return b.prompts.Render(TemplateValidateTask, validateTaskPromptData{
+		Task:   task,
+		Diff:   truncate(diff, 12000),
+		Output: truncate(output, 2000),
+	})
+}
+
+func (b *OrchestratorBrain) mergeStrategyPrompt(statuses []*MergeStatus) (*prompts.Prompt, error) {
+	return b.prompts.Render(TemplateMergeStrategy, mergeStrategyPromptData{Statuses: statuses})
+}
+
+func (b *OrchestratorBrain) resolveConflictPrompt(branch string, files []string, content string) (*prompts.Prompt, error) {
+	return b.prompts.Render(TemplateResolveConflict, resolveConflictPromptData{Branch: branch, Files: files, Content: truncate(content, 20000)})
+}
+
+func (b *OrchestratorBrain) validateMergePrompt(branch string, files []string) (*prompts.Prompt, error) {
+	return b.prompts.Render(TemplateValidateMerge, validateMergePromptData{Branch: branch, Files: files})
+}
+
+func (b *OrchestratorBrain) replanPrompt(trigger ReplanTrigger, tasks []*models.Task) (*prompts.Prompt, error) {
+	sortTasks(tasks)
+	return b.prompts.Render(TemplateReplan, replanPromptData{
+		Requirement: b.context.Requirement,
+		Trigger:     trigger,
+		TaskGraph:   formatTaskGraph(tasks),
+		RepoContext: b.repoContext,
+	})
+}
+
+// RenderPrompt 渲染主脑会发给 LLM 的提示词而不调用 LLM，供 swarm prompts render 使用。
+// analyze 使用 requirement（为空时使用任务描述）和仓库摘要 repoContext；其余模板以任务及其最近一次尝试为输入，
+// 合并相关的模板使用该尝试的分支，冲突内容在真实合并时才会产生；replan 以该任务永久失败为触发、只含该任务的任务图为输入
+func RenderPrompt(store *prompts.Store, name string, task *models.Task, requirement, repoContext string) (*prompts.Prompt, error) {
+	b := &OrchestratorBrain{prompts: store, repoContext: repoContext, context: &ConversationContext{Requirement: requirement}}
+	if name == TemplateAnalyze {
+		if requirement == "" && task != nil {
+			requirement = task.Description
+		}
+		if requirement == "" {
+			return nil, fmt.Errorf("%s 需要 --requirement 或 --task", name)
+		}
+		return b.analyzePrompt(requirement)
+	}
+	if name == TemplateRepair {
+		return store.Render(name, repairPromptData{Error: "任务ID task-001 重复"})
+	}
+
+	if task == nil {
+		return nil, fmt.Errorf("%s 需要 --task", name)
+	}
+	attempt := task.LastAttempt()
+	if attempt == nil {
+		attempt = &models.Attempt{AgentID: task.AssigneeID}
+	}
+
+	switch name {
+	case TemplateDiagnose:
+		return b.diagnosePrompt(task)
+	case TemplateHelpAgent:
+		return b.helpAgentPrompt(attempt.AgentID, task, attempt.Output)
+	case TemplateValidateTask:
+		return b.validateTaskPrompt(task, "（代码改动在评审时从 worktree 生成）", attempt.Output)
+	case TemplateMergeStrategy:
+		return b.mergeStrategyPrompt([]*MergeStatus{{
+			Branch:       attempt.Branch,
+			AgentID:      attempt.AgentID,
+			HasChanges:   len(attempt.Commits) > 0,
+			CommitCount:  len(attempt.Commits),
+			ReadyToMerge: task.Status == models.TaskStatusCompleted,
+		}})
+	case TemplateResolveConflict:
+		return b.resolveConflictPrompt(attempt.Branch, nil, "（冲突内容在合并时产生）")
+	case TemplateValidateMerge:
+		return b.validateMergePrompt(attempt.Branch, nil)
+	case TemplateReplan:
+		trigger := ReplanTrigger{Kind: TriggerTaskFailed, TaskID: task.ID, Detail: task.LastError}
+		return b.replanPrompt(trigger, []*models.Task{task})
+	}
+	return store.Render(name, nil)
+}
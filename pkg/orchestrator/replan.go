@@ -0,0 +1,696 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/audit"
+)
+
+// ReplanTriggerKind 重新规划的触发原因
+type ReplanTriggerKind string
+
+const (
+	TriggerTaskFailed ReplanTriggerKind = "task_failed" // 任务永久失败：重试次数用尽，或诊断认为不值得重试
+	TriggerConflicts  ReplanTriggerKind = "conflicts"   // 任务的工作反复合并冲突
+	TriggerFollowUp   ReplanTriggerKind = "follow_up"   // Agent 在输出中报告了后续工作
+)
+
+// DefaultFollowUpMarker Agent 在输出中报告后续工作的标记，如 "TODO for follow-up: 补充迁移脚本"
+const DefaultFollowUpMarker = "TODO for follow-up"
+
+// ReplanOptions 触发条件
+type ReplanOptions struct {
+	ConflictThreshold int    // 任务合并冲突达到该次数时触发，默认 2
+	FollowUpMarker    string // 默认 DefaultFollowUpMarker
+	MaxPerTask        int    // 每个任务最多重新规划的次数，用完后该任务不再触发，0 表示不限制
+}
+
+// ReplanTrigger 触发重新规划的事件
+type ReplanTrigger struct {
+	Kind   ReplanTriggerKind `json:"kind"`
+	TaskID string            `json:"task_id"`
+	Detail string            `json:"detail"` // 失败原因、冲突次数或 Agent 报告的后续工作
+	Key    string            `json:"key"`    // 同一事件只触发一次
+}
+
+// 重新规划的操作
+const (
+	ReplanAdd     = "add"     // 添加新任务
+	ReplanSplit   = "split"   // 把一个任务拆成多个新任务，依赖原任务的任务改为依赖新任务
+	ReplanRemove  = "remove"  // 删除任务，其他任务对它的依赖一并删除
+	ReplanReorder = "reorder" // 修改任务的依赖和优先级；失败的任务重新排队
+)
+
+// ReplanChange 对当前任务图的一项修改
+type ReplanChange struct {
+	Op           string      `json:"op" jsonschema:"enum=add|split|remove|reorder"`
+	TaskID       string      `json:"task_id,omitempty"`                                    // split/remove/reorder 的目标（现有任务ID）
+	Tasks        []*TaskSpec `json:"tasks,omitempty"`                                      // add/split 的新任务，ID 为临时ID（如 new-1）
+	Dependencies []string    `json:"dependencies"`                                         // reorder: 目标任务完整的新依赖列表
+	Priority     int         `json:"priority,omitempty" jsonschema:"minimum=0,maximum=10"` // reorder: 新优先级，0 表示不变
+	Reason       string      `json:"reason"`
+}
+
+// ReplanProposal AI提出的重新规划
+type ReplanProposal struct {
+	Summary string         `json:"summary"`
+	Changes []ReplanChange `json:"changes"` // 为空表示不需要修改
+}
+
+// Checks 返回提议为新任务添加的检查命令。检查命令会在 Agent 的工作区中执行，
+// 包含检查命令的提议即使开启 auto_apply 也需要人工审批
+func (p *ReplanProposal) Checks() []string {
+	var checks []string
+	for _, change := range p.Changes {
+		for _, spec := range change.Tasks {
+			checks = append(checks, spec.Checks...)
+		}
+	}
+	return checks
+}
+
+// 重新规划记录的状态
+const (
+	ReplanProposed = "proposed" // 等待人工审批
+	ReplanApplied  = "applied"
+	ReplanRejected = "rejected"
+	ReplanFailed   = "failed" // 提议无效或应用失败
+)
+
+// ReplanRecord 一次重新规划：触发事件、提议、对任务图的修改和处理结果
+type ReplanRecord struct {
+	Timestamp  time.Time       `json:"timestamp"`
+	Trigger    ReplanTrigger   `json:"trigger"`
+	Proposal   *ReplanProposal `json:"proposal,omitempty"`
+	Diff       string          `json:"diff,omitempty"`
+	Status     string          `json:"status"`
+	ApprovalID string          `json:"approval_id,omitempty"`
+	Result     string          `json:"result,omitempty"`
+}
+
+// MarkNotRetryable 记录诊断认为不值得重试的失败任务，DetectReplanTriggers 把它视为永久失败
+func (b *OrchestratorBrain) MarkNotRetryable(taskID, reason string) {
+	if b.notRetryable == nil {
+		b.notRetryable = make(map[string]string)
+	}
+	b.notRetryable[taskID] = reason
+}
+
+// DetectReplanTriggers 检查任务队列中需要重新规划的事件，已经处理过的事件不再返回
+func (b *OrchestratorBrain) DetectReplanTriggers(opts ReplanOptions) []ReplanTrigger {
+	if opts.ConflictThreshold <= 0 {
+		opts.ConflictThreshold = 2
+	}
+	if opts.FollowUpMarker == "" {
+		opts.FollowUpMarker = DefaultFollowUpMarker
+	}
+
+	tasks := b.taskQueue.ListTasks()
+	sortTasks(tasks)
+
+	var triggers []ReplanTrigger
+	pending := make(map[string]int)
+	add := func(trigger ReplanTrigger) {
+		if b.replanHandled(trigger.Key) {
+			return
+		}
+		// 重新排队会清零重试次数，没有上限时同一任务可能被反复重新规划
+		if opts.MaxPerTask > 0 && b.replanCount(trigger.TaskID)+pending[trigger.TaskID] >= opts.MaxPerTask {
+			return
+		}
+		pending[trigger.TaskID]++
+		triggers = append(triggers, trigger)
+	}
+
+	for _, task := range tasks {
+		if task.Status == models.TaskStatusFailed {
+			reason, diagnosed := b.notRetryable[task.ID]
+			if diagnosed || task.RetryCount >= task.MaxRetries {
+				detail := task.LastError
+				if diagnosed && reason != "" {
+					detail += "\n诊断建议: " + reason
+				}
+				add(ReplanTrigger{
+					Kind:   TriggerTaskFailed,
+					TaskID: task.ID,
+					Detail: detail,
+					Key:    fmt.Sprintf("%s:%s:%d", TriggerTaskFailed, task.ID, len(task.Attempts)),
+				})
+			}
+		}
+
+		if task.MergeConflicts >= opts.ConflictThreshold {
+			add(ReplanTrigger{
+				Kind:   TriggerConflicts,
+				TaskID: task.ID,
+				Detail: fmt.Sprintf("合并该任务的工作已冲突 %d 次", task.MergeConflicts),
+				Key:    fmt.Sprintf("%s:%s", TriggerConflicts, task.ID),
+			})
+		}
+
+		if last := task.LastAttempt(); last != nil && !last.FinishedAt.IsZero() {
+			if followUps := findFollowUps(last, opts.FollowUpMarker); len(followUps) > 0 {
+				add(ReplanTrigger{
+					Kind:   TriggerFollowUp,
+					TaskID: task.ID,
+					Detail: strings.Join(followUps, "\n"),
+					Key:    fmt.Sprintf("%s:%s:%d", TriggerFollowUp, task.ID, last.Number),
+				})
+			}
+		}
+	}
+	return triggers
+}
+
+// replanHandled 报告事件是否已经触发过重新规划
+func (b *OrchestratorBrain) replanHandled(key string) bool {
+	for _, record := range b.context.Replans {
+		if record.Trigger.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// replanCount 返回任务已触发的重新规划次数，不论提议是否被应用
+func (b *OrchestratorBrain) replanCount(taskID string) int {
+	count := 0
+	for _, record := range b.context.Replans {
+		if record.Trigger.TaskID == taskID {
+			count++
+		}
+	}
+	return count
+}
+
+// maxFollowUps 每次尝试最多读取的后续工作条数
+const maxFollowUps = 10
+
+// findFollowUps 在尝试的完整输出（没有时用保存的最后几行）中查找带标记的行
+func findFollowUps(attempt *models.Attempt, marker string) []string {
+	var lines []string
+	collect := func(line string) bool {
+		if i := strings.Index(strings.ToLower(line), strings.ToLower(marker)); i >= 0 {
+			lines = append(lines, truncate(strings.TrimSpace(line[i:]), 300))
+		}
+		return len(lines) < maxFollowUps
+	}
+
+	if attempt.TranscriptPath != "" {
+		if file, err := os.Open(attempt.TranscriptPath); err == nil {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() && collect(scanner.Text()) {
+			}
+			return lines
+		}
+	}
+	for _, line := range strings.Split(attempt.Output, "\n") {
+		if !collect(line) {
+			break
+		}
+	}
+	return lines
+}
+
+// Replan 让AI针对触发事件提出对当前任务图的修改。提议经过校验（引用的任务存在、
+// 只修改尚未开始或失败的任务、没有循环依赖），记录到对话上下文和审计日志，但不应用
+func (b *OrchestratorBrain) Replan(ctx context.Context, trigger ReplanTrigger) (*ReplanProposal, *ReplanRecord, error) {
+	log.Printf("🧠 AI主脑重新规划: %s (%s)", trigger.TaskID, trigger.Kind)
+
+	tasks := b.taskQueue.ListTasks()
+	prompt, err := b.replanPrompt(trigger, tasks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var proposal ReplanProposal
+	var edit *replanEdit
+	responseText, err := b.generateJSON(ctx, prompt, &proposal, func() error {
+		var err error
+		edit, err = planReplan(tasks, &proposal)
+		return err
+	})
+
+	record := &ReplanRecord{Timestamp: time.Now(), Trigger: trigger}
+	entry := audit.Entry{
+		Decision:    audit.DecisionReplan,
+		TaskID:      trigger.TaskID,
+		InputDigest: audit.Digest(prompt.Text),
+		Prompt:      prompt.Version,
+		Details:     map[string]string{"trigger": string(trigger.Kind)},
+	}
+	if err != nil {
+		record.Status = ReplanFailed
+		record.Result = err.Error()
+		b.addReplanRecord(record)
+		b.saveContext()
+		entry.Action = "error"
+		entry.Outcome = err.Error()
+		b.recordDecision(entry)
+		return nil, record, responseError("重新规划", err, responseText)
+	}
+
+	record.Proposal = &proposal
+	record.Diff = edit.diff(&proposal)
+	entry.Action = "propose"
+	entry.Outcome = proposal.Summary
+	entry.Details["diff"] = record.Diff
+	b.recordDecision(entry)
+
+	b.context.Conversations = append(b.context.Conversations,
+		Message{Role: "user", Content: fmt.Sprintf("[%s] %s: %s", trigger.Kind, trigger.TaskID, trigger.Detail), Prompt: prompt.Version, Timestamp: time.Now()},
+		Message{Role: "assistant", Content: responseText, Timestamp: time.Now()},
+	)
+	b.saveContext()
+	return &proposal, record, nil
+}
+
+// addReplanRecord 把重新规划记录加入对话上下文，用于触发去重和历史查询
+func (b *OrchestratorBrain) addReplanRecord(record *ReplanRecord) {
+	b.context.Replans = append(b.context.Replans, record)
+}
+
+// ProposeReplan 记录一个等待人工审批的提议
+func (b *OrchestratorBrain) ProposeReplan(record *ReplanRecord, approvalID string) {
+	record.Status = ReplanProposed
+	record.ApprovalID = approvalID
+	b.addReplanRecord(record)
+	b.saveContext()
+}
+
+// ApplyReplan 按当前任务图重新校验并应用提议，返回实际的修改。by 为批准者（自动应用时为 "brain"，人工为 "human:<name>"）。
+// record 为 nil 时新建记录（例如审批通过的提议来自之前的进程）
+func (b *OrchestratorBrain) ApplyReplan(proposal *ReplanProposal, record *ReplanRecord, by string) (string, error) {
+	if record == nil {
+		record = &ReplanRecord{Timestamp: time.Now(), Proposal: proposal}
+	}
+	if !slices.Contains(b.context.Replans, record) {
+		b.addReplanRecord(record)
+	}
+
+	diff, err := b.applyReplan(proposal)
+	record.Diff = diff
+	entry := audit.Entry{
+		Actor:    by,
+		Decision: audit.DecisionReplan,
+		TaskID:   record.Trigger.TaskID,
+		Action:   "apply",
+		Outcome:  proposal.Summary,
+		Details:  map[string]string{"diff": diff},
+	}
+	if err != nil {
+		record.Status = ReplanFailed
+		record.Result = err.Error()
+		entry.Action = "error"
+		entry.Outcome = err.Error()
+	} else {
+		record.Status = ReplanApplied
+		record.Result = "applied by " + by
+	}
+	if err := b.auditLog.Record(entry); err != nil {
+		log.Printf("⚠️  写入审计日志失败: %v", err)
+	}
+
+	b.addDecision(Decision{
+		Timestamp: time.Now(),
+		Action: &Action{
+			Type:   ActionReplan,
+			TaskID: record.Trigger.TaskID,
+			Reason: proposal.Summary,
+		},
+		Reasoning: string(record.Trigger.Kind) + ": " + record.Trigger.Detail,
+		Result:    record.Status + "\n" + diff,
+	})
+	b.saveContext()
+	return diff, err
+}
+
+// RejectReplan 记录被拒绝的提议
+func (b *OrchestratorBrain) RejectReplan(record *ReplanRecord, by, note string) {
+	if record == nil {
+		return
+	}
+	record.Status = ReplanRejected
+	record.Result = "rejected by " + by
+	if note != "" {
+		record.Result += ": " + note
+	}
+	if !slices.Contains(b.context.Replans, record) {
+		b.addReplanRecord(record)
+	}
+	b.saveContext()
+}
+
+// ReplanRecordFor 返回审批项对应的重新规划记录，没有时返回 nil
+func (b *OrchestratorBrain) ReplanRecordFor(approvalID string) *ReplanRecord {
+	for _, record := range b.context.Replans {
+		if record.ApprovalID == approvalID {
+			return record
+		}
+	}
+	return nil
+}
+
+// Replans 返回本次运行的重新规划记录
+func (b *OrchestratorBrain) Replans() []*ReplanRecord {
+	return b.context.Replans
+}
+
+// applyReplan 把提议应用到任务队列：先添加新任务，再修改现有任务，最后删除任务
+func (b *OrchestratorBrain) applyReplan(proposal *ReplanProposal) (string, error) {
+	edit, err := planReplan(b.taskQueue.ListTasks(), proposal)
+	if err != nil {
+		return "", fmt.Errorf("提议已不适用于当前任务图: %w", err)
+	}
+	diff := edit.diff(proposal)
+
+	idMap := make(map[string]string)
+	for _, spec := range edit.add {
+		idMap[spec.ID] = fmt.Sprintf("task-%d", time.Now().UnixNano())
+		time.Sleep(1 * time.Millisecond) // 确保ID唯一
+	}
+	realID := func(id string) string {
+		if actual, ok := idMap[id]; ok {
+			return actual
+		}
+		return id
+	}
+	realIDs := func(ids []string) []string {
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = realID(id)
+		}
+		return out
+	}
+
+	for _, spec := range edit.add {
+		task := &models.Task{
+			ID:           idMap[spec.ID],
+			Description:  spec.Description,
+			Status:       models.TaskStatusPending,
+			Priority:     spec.Priority,
+			MaxRetries:   3,
+			Dependencies: realIDs(edit.graph[spec.ID]),
+
+			AcceptanceCriteria: spec.AcceptanceCriteria,
+			RequiredFiles:      spec.RequiredFiles,
+			Checks:             spec.Checks,
+			ForbiddenPaths:     spec.ForbiddenPaths,
+			ReadPaths:          spec.ReadFiles,
+			WritePaths:         spec.Files,
+		}
+		if err := b.taskQueue.AddTask(task); err != nil {
+			return diff, fmt.Errorf("添加任务失败: %w", err)
+		}
+		log.Printf("  ✓ %s (%s): %s", task.ID, spec.ID, task.Description)
+	}
+
+	for _, id := range edit.changed {
+		task, err := b.taskQueue.GetTask(id)
+		if err != nil {
+			return diff, err
+		}
+		task.Dependencies = realIDs(edit.graph[id])
+		if priority, ok := edit.priority[id]; ok {
+			task.Priority = priority
+		}
+		if slices.Contains(edit.requeue, id) {
+			task.Status = models.TaskStatusPending
+			task.AssigneeID = ""
+			task.RetryCount = 0
+			task.NotBefore = time.Time{}
+		}
+		if err := b.taskQueue.UpdateTask(task); err != nil {
+			return diff, fmt.Errorf("更新任务 %s 失败: %w", id, err)
+		}
+	}
+
+	for _, id := range edit.remove {
+		if err := b.taskQueue.RemoveTask(id); err != nil {
+			return diff, fmt.Errorf("删除任务 %s 失败: %w", id, err)
+		}
+	}
+
+	// 差异中的临时ID换成真实ID，便于之后查询
+	for tempID, actual := range idMap {
+		diff = strings.ReplaceAll(diff, tempID+" ", actual+" ")
+	}
+	log.Printf("✅ 重新规划已应用: %s", proposal.Summary)
+	return diff, nil
+}
+
+// replanEdit 提议应用到当前任务图后的结果
+type replanEdit struct {
+	tasks    map[string]*models.Task // 当前任务
+	graph    map[string][]string     // 应用后每个任务（含新任务）的依赖
+	add      []*TaskSpec             // 新任务，ID 为临时ID
+	remove   []string                // 删除的现有任务
+	changed  []string                // 依赖、优先级或状态改变的现有任务
+	priority map[string]int          // 现有任务的新优先级
+	requeue  []string                // 重新排队的失败任务
+}
+
+// planReplan 在当前任务图上模拟提议，校验后返回修改结果
+func planReplan(tasks []*models.Task, proposal *ReplanProposal) (*replanEdit, error) {
+	edit := &replanEdit{
+		tasks:    make(map[string]*models.Task),
+		graph:    make(map[string][]string),
+		priority: make(map[string]int),
+	}
+	for _, task := range tasks {
+		edit.tasks[task.ID] = task
+		edit.graph[task.ID] = slices.Clone(task.Dependencies)
+	}
+	removed := make(map[string]bool)
+
+	var errs []error
+	fail := func(i int, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("changes[%d]: "+format, append([]any{i}, args...)...))
+	}
+	// target 检查 split/remove/reorder 的目标任务，返回是否可以修改
+	target := func(i int, change ReplanChange, allowed ...models.TaskStatus) bool {
+		task, ok := edit.tasks[change.TaskID]
+		switch {
+		case change.TaskID == "":
+			fail(i, "%s 需要 task_id", change.Op)
+		case !ok || removed[change.TaskID]:
+			fail(i, "任务 %s 不存在", change.TaskID)
+		case !slices.Contains(allowed, task.Status):
+			fail(i, "任务 %s 状态为 %s，不能 %s", change.TaskID, task.Status, change.Op)
+		default:
+			return true
+		}
+		return false
+	}
+	addTasks := func(i int, change ReplanChange) bool {
+		if len(change.Tasks) == 0 {
+			fail(i, "%s 需要至少一个新任务", change.Op)
+			return false
+		}
+		for _, spec := range change.Tasks {
+			if _, exists := edit.graph[spec.ID]; exists || spec.ID == "" {
+				fail(i, "新任务ID %q 为空或已存在", spec.ID)
+				return false
+			}
+			if strings.TrimSpace(spec.Description) == "" {
+				fail(i, "新任务 %s 缺少描述", spec.ID)
+			}
+			if spec.Priority < 1 || spec.Priority > 10 {
+				fail(i, "新任务 %s 的优先级 %d 不在1-10之间", spec.ID, spec.Priority)
+			}
+			for _, err := range spec.validateDone() {
+				fail(i, "%v", err)
+			}
+			edit.graph[spec.ID] = slices.Clone(spec.Dependencies)
+			edit.add = append(edit.add, spec)
+		}
+		return true
+	}
+	// drop 删除任务，依赖它的任务改为依赖 replacements
+	drop := func(id string, replacements []string) {
+		removed[id] = true
+		delete(edit.graph, id)
+		edit.remove = append(edit.remove, id)
+		for other, deps := range edit.graph {
+			if i := slices.Index(deps, id); i >= 0 {
+				deps = slices.Delete(deps, i, i+1)
+				for _, r := range replacements {
+					if r != other && !slices.Contains(deps, r) {
+						deps = append(deps, r)
+					}
+				}
+				edit.graph[other] = deps
+			}
+		}
+	}
+
+	changeable := []models.TaskStatus{models.TaskStatusPending, models.TaskStatusFailed}
+	for i, change := range proposal.Changes {
+		switch change.Op {
+		case ReplanAdd:
+			addTasks(i, change)
+
+		case ReplanSplit:
+			if !target(i, change, changeable...) {
+				continue
+			}
+			if len(change.Tasks) < 2 {
+				fail(i, "split 需要至少两个新任务")
+				continue
+			}
+			if !addTasks(i, change) {
+				continue
+			}
+			// 依赖原任务的任务改为依赖拆分出的最后一批任务（没有被其他新任务依赖的）
+			var sinks []string
+			for _, spec := range change.Tasks {
+				dependedOn := false
+				for _, other := range change.Tasks {
+					dependedOn = dependedOn || slices.Contains(other.Dependencies, spec.ID)
+				}
+				if !dependedOn {
+					sinks = append(sinks, spec.ID)
+				}
+			}
+			drop(change.TaskID, sinks)
+
+		case ReplanRemove:
+			if target(i, change, changeable...) {
+				drop(change.TaskID, nil)
+			}
+
+		case ReplanReorder:
+			if !target(i, change, append(changeable, models.TaskStatusAwaitingApproval)...) {
+				continue
+			}
+			if change.Priority < 0 || change.Priority > 10 {
+				fail(i, "优先级 %d 不在0-10之间", change.Priority)
+				continue
+			}
+			edit.graph[change.TaskID] = slices.Clone(change.Dependencies)
+			if change.Priority > 0 {
+				edit.priority[change.TaskID] = change.Priority
+			}
+			if edit.tasks[change.TaskID].Status == models.TaskStatusFailed && !slices.Contains(edit.requeue, change.TaskID) {
+				edit.requeue = append(edit.requeue, change.TaskID)
+			}
+
+		default:
+			fail(i, "未知操作 %q", change.Op)
+		}
+	}
+
+	// 依赖必须指向应用后仍存在的任务，且不能形成环
+	var specs []*TaskSpec
+	for id, deps := range edit.graph {
+		for _, dep := range deps {
+			if _, ok := edit.graph[dep]; !ok {
+				errs = append(errs, fmt.Errorf("任务 %s 依赖的任务 %s 不存在", id, dep))
+			}
+		}
+		specs = append(specs, &TaskSpec{ID: id, Dependencies: deps})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].ID < specs[j].ID })
+	if err := (&OrchestratorBrain{}).detectCyclicDependencies(specs); err != nil {
+		return nil, err
+	}
+
+	for _, task := range tasks {
+		id := task.ID
+		if removed[id] {
+			continue
+		}
+		_, reprioritized := edit.priority[id]
+		if !slices.Equal(edit.graph[id], task.Dependencies) || reprioritized || slices.Contains(edit.requeue, id) {
+			edit.changed = append(edit.changed, id)
+		}
+	}
+	return edit, nil
+}
+
+// diff 以对当前任务图的差异显示修改：+ 新任务，- 删除的任务，~ 修改的任务
+func (e *replanEdit) diff(proposal *ReplanProposal) string {
+	var b strings.Builder
+	b.WriteString(proposal.Summary + "\n")
+	if len(e.add)+len(e.remove)+len(e.changed) == 0 {
+		b.WriteString("（任务图不变）\n")
+	}
+	for _, spec := range e.add {
+		fmt.Fprintf(&b, "+ %s %s (优先级 %d", spec.ID, truncate(spec.Description, 100), spec.Priority)
+		if deps := e.graph[spec.ID]; len(deps) > 0 {
+			fmt.Fprintf(&b, ", 依赖 %s", strings.Join(deps, ", "))
+		}
+		b.WriteString(")\n")
+		for _, check := range spec.Checks {
+			fmt.Fprintf(&b, "  检查: %s\n", check)
+		}
+	}
+	for _, id := range e.remove {
+		fmt.Fprintf(&b, "- %s %s\n", id, truncate(e.tasks[id].Description, 100))
+	}
+	for _, id := range e.changed {
+		task := e.tasks[id]
+		if deps := e.graph[id]; !slices.Equal(deps, task.Dependencies) {
+			fmt.Fprintf(&b, "~ %s 依赖 [%s] → [%s]\n", id, strings.Join(task.Dependencies, ", "), strings.Join(deps, ", "))
+		}
+		if priority, ok := e.priority[id]; ok && priority != task.Priority {
+			fmt.Fprintf(&b, "~ %s 优先级 %d → %d\n", id, task.Priority, priority)
+		}
+		if slices.Contains(e.requeue, id) {
+			fmt.Fprintf(&b, "~ %s 重新排队（%s → %s）\n", id, task.Status, models.TaskStatusPending)
+		}
+	}
+	for _, change := range proposal.Changes {
+		target := change.TaskID
+		if target == "" {
+			var ids []string
+			for _, spec := range change.Tasks {
+				ids = append(ids, spec.ID)
+			}
+			target = strings.Join(ids, ", ")
+		}
+		fmt.Fprintf(&b, "  · %s %s: %s\n", change.Op, target, change.Reason)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatTaskGraph 列出当前任务图，供重新规划的提示词使用
+func formatTaskGraph(tasks []*models.Task) string {
+	var b strings.Builder
+	for _, task := range tasks {
+		fmt.Fprintf(&b, "- %s [%s] 优先级 %d", task.ID, task.Status, task.Priority)
+		if len(task.Dependencies) > 0 {
+			fmt.Fprintf(&b, " 依赖 %s", strings.Join(task.Dependencies, ", "))
+		}
+		fmt.Fprintf(&b, "\n  %s\n", truncate(task.Description, 300))
+		if task.Status == models.TaskStatusFailed && task.LastError != "" {
+			fmt.Fprintf(&b, "  错误: %s\n", truncate(task.LastError, 300))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// sortTasks 按创建时间排序任务
+func sortTasks(tasks []*models.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
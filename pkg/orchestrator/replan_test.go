@@ -0,0 +1,299 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/llm"
+)
+
+// replanTasks 返回一个任务图：a 已完成，b 失败，c 和 d 依赖 b，e 运行中
+func replanTasks() []*models.Task {
+	return []*models.Task{
+		{ID: "a", Description: "Add user model", Status: models.TaskStatusCompleted, Priority: 8},
+		{ID: "b", Description: "Add login API", Status: models.TaskStatusFailed, Priority: 5, Dependencies: []string{"a"}},
+		{ID: "c", Description: "Add login page", Status: models.TaskStatusPending, Priority: 5, Dependencies: []string{"b"}},
+		{ID: "d", Description: "Add logout", Status: models.TaskStatusPending, Priority: 3, Dependencies: []string{"a", "b"}},
+		{ID: "e", Description: "Add docs", Status: models.TaskStatusInProgress, Priority: 1},
+	}
+}
+
+func TestPlanReplan(t *testing.T) {
+	split := ReplanChange{Op: ReplanSplit, TaskID: "b", Tasks: []*TaskSpec{
+		{ID: "new-1", Description: "Add session store", Priority: 6, Dependencies: []string{"a"}},
+		{ID: "new-2", Description: "Add login handler", Priority: 5, Dependencies: []string{"new-1"}},
+	}}
+
+	tests := []struct {
+		name    string
+		changes []ReplanChange
+		wantErr string
+		check   func(t *testing.T, edit *replanEdit)
+	}{
+		{
+			name:    "split rewires dependents to the last new task",
+			changes: []ReplanChange{split},
+			check: func(t *testing.T, edit *replanEdit) {
+				if !slices.Equal(edit.remove, []string{"b"}) || len(edit.add) != 2 {
+					t.Errorf("remove = %v, add = %d", edit.remove, len(edit.add))
+				}
+				if !slices.Equal(edit.graph["c"], []string{"new-2"}) || !slices.Equal(edit.graph["d"], []string{"a", "new-2"}) {
+					t.Errorf("graph = %v", edit.graph)
+				}
+				if !slices.Equal(edit.changed, []string{"c", "d"}) {
+					t.Errorf("changed = %v", edit.changed)
+				}
+			},
+		},
+		{
+			name:    "remove drops the dependency",
+			changes: []ReplanChange{{Op: ReplanRemove, TaskID: "b"}},
+			check: func(t *testing.T, edit *replanEdit) {
+				if len(edit.graph["c"]) != 0 || !slices.Equal(edit.graph["d"], []string{"a"}) {
+					t.Errorf("graph = %v", edit.graph)
+				}
+			},
+		},
+		{
+			name: "add a prerequisite and reorder the failed task after it",
+			changes: []ReplanChange{
+				{Op: ReplanAdd, Tasks: []*TaskSpec{{ID: "new-1", Description: "Add bcrypt dependency", Priority: 9}}},
+				{Op: ReplanReorder, TaskID: "b", Dependencies: []string{"a", "new-1"}, Priority: 7},
+			},
+			check: func(t *testing.T, edit *replanEdit) {
+				if !slices.Equal(edit.requeue, []string{"b"}) || edit.priority["b"] != 7 {
+					t.Errorf("requeue = %v, priority = %v", edit.requeue, edit.priority)
+				}
+			},
+		},
+		{name: "unknown task", changes: []ReplanChange{{Op: ReplanRemove, TaskID: "z"}}, wantErr: "任务 z 不存在"},
+		{name: "running task", changes: []ReplanChange{{Op: ReplanRemove, TaskID: "e"}}, wantErr: "状态为 in_progress"},
+		{name: "completed task", changes: []ReplanChange{{Op: ReplanReorder, TaskID: "a"}}, wantErr: "状态为 completed"},
+		{name: "split into one task", changes: []ReplanChange{{Op: ReplanSplit, TaskID: "b", Tasks: split.Tasks[:1]}}, wantErr: "至少两个"},
+		{name: "clashing new ID", changes: []ReplanChange{{Op: ReplanAdd, Tasks: []*TaskSpec{{ID: "c", Description: "x", Priority: 1}}}}, wantErr: `"c" 为空或已存在`},
+		{name: "missing dependency", changes: []ReplanChange{{Op: ReplanReorder, TaskID: "c", Dependencies: []string{"new-9"}}}, wantErr: "new-9 不存在"},
+		{name: "cycle", changes: []ReplanChange{{Op: ReplanReorder, TaskID: "b", Dependencies: []string{"c"}}}, wantErr: "循环依赖"},
+		{name: "unknown op", changes: []ReplanChange{{Op: "merge"}}, wantErr: "未知操作"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit, err := planReplan(replanTasks(), &ReplanProposal{Summary: "s", Changes: tt.changes})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("planReplan() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("planReplan() error = %v", err)
+			}
+			tt.check(t, edit)
+		})
+	}
+}
+
+func TestReplanDiff(t *testing.T) {
+	proposal := &ReplanProposal{Summary: "Split the login API", Changes: []ReplanChange{
+		{Op: ReplanSplit, TaskID: "b", Reason: "too large", Tasks: []*TaskSpec{
+			{ID: "new-1", Description: "Add session store", Priority: 6, Dependencies: []string{"a"}},
+			{ID: "new-2", Description: "Add login handler", Priority: 5, Dependencies: []string{"new-1"}},
+		}},
+	}}
+	edit, err := planReplan(replanTasks(), proposal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `Split the login API
++ new-1 Add session store (优先级 6, 依赖 a)
++ new-2 Add login handler (优先级 5, 依赖 new-1)
+- b Add login API
+~ c 依赖 [b] → [new-2]
+~ d 依赖 [a, b] → [a, new-2]
+  · split b: too large`
+	if got := edit.diff(proposal); got != want {
+		t.Errorf("diff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReplanAndApply(t *testing.T) {
+	fake := llm.NewFakeClient().On(TemplateReplan, `{
+		"summary": "Add the missing dependency first",
+		"changes": [
+			{"op": "add", "tasks": [{"id": "new-1", "description": "Add bcrypt to go.mod", "priority": 9, "dependencies": [], "checks": ["go build ./..."]}], "dependencies": [], "reason": "missing prerequisite"},
+			{"op": "reorder", "task_id": "b", "dependencies": ["a", "new-1"], "reason": "needs bcrypt"}
+		]
+	}`)
+	brain := scriptedBrain(t, fake)
+	for _, task := range replanTasks() {
+		if err := brain.taskQueue.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// b 的重试次数已用尽
+	b, _ := brain.taskQueue.GetTask("b")
+	b.RetryCount, b.LastError = b.MaxRetries, "undefined: bcrypt"
+	b.Attempts = []models.Attempt{{Number: 3, StartedAt: time.Now()}}
+	_ = brain.taskQueue.UpdateTask(b)
+
+	triggers := brain.DetectReplanTriggers(ReplanOptions{})
+	if len(triggers) != 1 || triggers[0].Kind != TriggerTaskFailed || triggers[0].TaskID != "b" {
+		t.Fatalf("triggers = %+v", triggers)
+	}
+
+	proposal, record, err := brain.Replan(context.Background(), triggers[0])
+	if err != nil {
+		t.Fatalf("Replan() error = %v", err)
+	}
+	prompt := fake.Calls()[0].Messages[0].Content
+	if !strings.Contains(prompt, "- b [failed] 优先级 5 依赖 a") || !strings.Contains(prompt, "undefined: bcrypt") {
+		t.Errorf("replan prompt missing the task graph:\n%s", prompt)
+	}
+	if !strings.Contains(record.Diff, "+ new-1 Add bcrypt to go.mod") || !strings.Contains(record.Diff, "  检查: go build ./...") {
+		t.Errorf("diff = %s", record.Diff)
+	}
+	// 检查命令需要人工审批
+	if checks := proposal.Checks(); len(checks) != 1 || checks[0] != "go build ./..." {
+		t.Errorf("Checks() = %v", checks)
+	}
+
+	diff, err := brain.ApplyReplan(proposal, record, "brain")
+	if err != nil {
+		t.Fatalf("ApplyReplan() error = %v", err)
+	}
+	tasks := brain.taskQueue.ListTasks()
+	if len(tasks) != 6 {
+		t.Fatalf("queue has %d tasks, want 6", len(tasks))
+	}
+	b, _ = brain.taskQueue.GetTask("b")
+	if b.Status != models.TaskStatusPending || b.RetryCount != 0 || len(b.Dependencies) != 2 {
+		t.Errorf("b = %+v, want requeued after the new task", b)
+	}
+	added, err := brain.taskQueue.GetTask(b.Dependencies[1])
+	if err != nil || added.Description != "Add bcrypt to go.mod" {
+		t.Fatalf("b depends on %v: %v", b.Dependencies, err)
+	}
+	if !strings.Contains(diff, "+ "+added.ID+" ") {
+		t.Errorf("applied diff should use the real task ID:\n%s", diff)
+	}
+
+	// 记录在对话上下文中，同一事件不再触发
+	if record.Status != ReplanApplied || len(brain.Replans()) != 1 {
+		t.Errorf("record = %+v, replans = %d", record, len(brain.Replans()))
+	}
+	last := brain.context.Decisions[len(brain.context.Decisions)-1]
+	if last.Action.Type != ActionReplan {
+		t.Errorf("last decision = %+v", last.Action)
+	}
+	if triggers := brain.DetectReplanTriggers(ReplanOptions{}); len(triggers) != 0 {
+		t.Errorf("triggers after replan = %+v", triggers)
+	}
+
+	// 任务已被领取，过时的提议不能再应用
+	b.Status = models.TaskStatusInProgress
+	_ = brain.taskQueue.UpdateTask(b)
+	if _, err := brain.ApplyReplan(proposal, nil, "brain"); err == nil {
+		t.Error("stale proposal should be rejected")
+	}
+	if n := len(brain.taskQueue.ListTasks()); n != 6 {
+		t.Errorf("stale proposal changed the queue: %d tasks", n)
+	}
+}
+
+func TestDetectReplanTriggers(t *testing.T) {
+	brain := scriptedBrain(t, nil)
+	transcript := filepath.Join(t.TempDir(), "transcript.log")
+	if err := os.WriteFile(transcript, []byte("done\n- TODO for follow-up: add rate limiting\nbye\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	tasks := []*models.Task{
+		{ID: "conflicted", Status: models.TaskStatusCompleted, MergeConflicts: 2},
+		{ID: "reported", Status: models.TaskStatusCompleted, Attempts: []models.Attempt{
+			{Number: 1, StartedAt: now, FinishedAt: now, TranscriptPath: transcript},
+		}},
+		{ID: "retrying", Status: models.TaskStatusFailed, RetryCount: 1},
+		{ID: "diagnosed", Status: models.TaskStatusFailed, RetryCount: 1, LastError: "wrong approach"},
+		{ID: "once", Status: models.TaskStatusCompleted, MergeConflicts: 1},
+	}
+	for _, task := range tasks {
+		if err := brain.taskQueue.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	brain.MarkNotRetryable("diagnosed", "split the task")
+
+	got := make(map[string]ReplanTrigger)
+	for _, trigger := range brain.DetectReplanTriggers(ReplanOptions{}) {
+		got[trigger.TaskID] = trigger
+	}
+	if len(got) != 3 {
+		t.Fatalf("triggers = %+v", got)
+	}
+	if got["conflicted"].Kind != TriggerConflicts {
+		t.Errorf("conflicted = %+v", got["conflicted"])
+	}
+	if got["reported"].Kind != TriggerFollowUp || got["reported"].Detail != "TODO for follow-up: add rate limiting" {
+		t.Errorf("reported = %+v", got["reported"])
+	}
+	if got["diagnosed"].Kind != TriggerTaskFailed || !strings.Contains(got["diagnosed"].Detail, "split the task") {
+		t.Errorf("diagnosed = %+v", got["diagnosed"])
+	}
+
+	// 阈值可配置
+	if triggers := brain.DetectReplanTriggers(ReplanOptions{ConflictThreshold: 1}); len(triggers) != 4 {
+		t.Errorf("with threshold 1 got %d triggers, want 4", len(triggers))
+	}
+}
+
+func TestDetectReplanTriggersBudget(t *testing.T) {
+	brain := scriptedBrain(t, nil)
+	tasks := []*models.Task{
+		{ID: "looping", Status: models.TaskStatusFailed, RetryCount: 3, MaxRetries: 3, Attempts: make([]models.Attempt, 3)},
+		{ID: "conflicted", Status: models.TaskStatusFailed, RetryCount: 3, MaxRetries: 3, MergeConflicts: 2},
+	}
+	for _, task := range tasks {
+		if err := brain.taskQueue.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 之前两次重新排队后又失败
+	for i := 1; i <= 2; i++ {
+		brain.context.Replans = append(brain.context.Replans, &ReplanRecord{
+			Trigger: ReplanTrigger{Kind: TriggerTaskFailed, TaskID: "looping", Key: fmt.Sprintf("%s:looping:%d", TriggerTaskFailed, i)},
+			Status:  ReplanApplied,
+		})
+	}
+
+	count := func(opts ReplanOptions) map[string]int {
+		got := make(map[string]int)
+		for _, trigger := range brain.DetectReplanTriggers(opts) {
+			got[trigger.TaskID]++
+		}
+		return got
+	}
+
+	if got := count(ReplanOptions{}); got["looping"] != 1 || got["conflicted"] != 2 {
+		t.Errorf("without a budget got %v", got)
+	}
+	if got := count(ReplanOptions{MaxPerTask: 3}); got["looping"] != 1 {
+		t.Errorf("budget 3 should allow a third re-plan: %v", got)
+	}
+	got := count(ReplanOptions{MaxPerTask: 2})
+	if got["looping"] != 0 {
+		t.Errorf("spent budget should stop triggers: %v", got)
+	}
+	if got["conflicted"] != 2 {
+		t.Errorf("budget is per task: %v", got)
+	}
+	if got := count(ReplanOptions{MaxPerTask: 1}); got["conflicted"] != 1 {
+		t.Errorf("triggers of one check count against the budget: %v", got)
+	}
+}
@@ -8,31 +8,36 @@ import (
 
 // AnalysisResult AI分析用户需求的结果
 type AnalysisResult struct {
-	Summary     string           `json:"summary"`      // 需求概要
-	Modules     []Module         `json:"modules"`      // 拆分的模块
-	Tasks       []*TaskSpec      `json:"tasks"`        // 生成的任务列表
-	Dependencies map[string][]string `json:"dependencies"` // 任务依赖关系 taskID -> [依赖的taskIDs]
-	EstimatedTime string          `json:"estimated_time"` // 预计完成时间
-	Complexity   string           `json:"complexity"`   // 复杂度 low/medium/high
+	Summary     string           `json:"summary" yaml:"summary"`      // 需求概要
+	Modules     []Module         `json:"modules" yaml:"modules"`      // 拆分的模块
+	Tasks       []*TaskSpec      `json:"tasks" yaml:"tasks"`        // 生成的任务列表
+	Dependencies map[string][]string `json:"dependencies" yaml:"-"` // 任务依赖关系 taskID -> [依赖的taskIDs]，与任务的 dependencies 重复，计划文件中省略
+	EstimatedTime string          `json:"estimated_time" yaml:"estimated_time"` // 预计完成时间
+	Complexity   string           `json:"complexity" yaml:"complexity" jsonschema:"enum=low|medium|high"`   // 复杂度 low/medium/high
 }
 
 // Module 需求模块
 type Module struct {
-	Name        string   `json:"name"`        // 模块名称
-	Description string   `json:"description"` // 模块描述
-	Files       []string `json:"files"`       // 涉及的文件
-	Priority    int      `json:"priority"`    // 优先级 1-10
+	Name        string   `json:"name" yaml:"name"`        // 模块名称
+	Description string   `json:"description" yaml:"description"` // 模块描述
+	Files       []string `json:"files" yaml:"files,omitempty"`       // 涉及的文件
+	Priority    int      `json:"priority" yaml:"priority" jsonschema:"minimum=1,maximum=10"`    // 优先级 1-10
 }
 
 // TaskSpec AI生成的任务规格
 type TaskSpec struct {
-	ID          string   `json:"id"`          // 任务ID
-	Description string   `json:"description"` // 任务描述
-	Module      string   `json:"module"`      // 所属模块
-	Files       []string `json:"files"`       // 涉及的文件
-	Dependencies []string `json:"dependencies"` // 依赖的任务ID
-	Priority    int      `json:"priority"`    // 优先级
-	Estimated   string   `json:"estimated"`   // 预计耗时
+	ID          string   `json:"id" yaml:"id"`          // 任务ID
+	Description string   `json:"description" yaml:"description"` // 任务描述
+	Module      string   `json:"module" yaml:"module,omitempty"`      // 所属模块
+	Files       []string `json:"files" yaml:"files,omitempty"`       // 会创建或修改的文件（可用通配符），即任务的写范围
+	ReadFiles   []string `json:"read_files,omitempty" yaml:"read_files,omitempty"` // 需要阅读的文件（可用通配符）
+	Dependencies []string `json:"dependencies" yaml:"dependencies,omitempty"` // 依赖的任务ID
+	Priority    int      `json:"priority" yaml:"priority" jsonschema:"minimum=1,maximum=10"`    // 优先级 1-10
+	Estimated   string   `json:"estimated" yaml:"estimated,omitempty"`   // 预计耗时
+	AcceptanceCriteria []string `json:"acceptance_criteria,omitempty" yaml:"acceptance_criteria,omitempty"` // 验收标准，评审时检查
+	RequiredFiles  []string `json:"required_files,omitempty" yaml:"required_files,omitempty"`   // 完成后必须存在的文件（可用通配符）
+	Checks         []string `json:"checks,omitempty" yaml:"checks,omitempty"`                   // 完成后必须通过的命令
+	ForbiddenPaths []string `json:"forbidden_paths,omitempty" yaml:"forbidden_paths,omitempty"` // 不允许修改的路径（可用通配符）
 }
 
 // ProgressReport 进展报告
@@ -46,6 +51,7 @@ type ProgressReport struct {
 	OverallProgress float64               `json:"overall_progress"` // 0-100
 	EstimatedTimeLeft string              `json:"estimated_time_left"`
 	Blockers       []string               `json:"blockers"` // 阻塞问题
+	Reviews        models.ReviewSummary   `json:"reviews"`  // 已评审任务的评分汇总
 }
 
 // AgentProgress Agent进展
@@ -79,22 +85,28 @@ const (
 	ActionMergeBranch   ActionType = "merge_branch"    // 合并分支
 	ActionWait          ActionType = "wait"            // 等待
 	ActionAskUser       ActionType = "ask_user"        // 询问用户
+	ActionReplan        ActionType = "replan"          // 修改任务图
 )
 
-// ConversationContext AI对话上下文
+// ConversationContext AI对话上下文，按项目运行保存（见 StartRun）
 type ConversationContext struct {
+	RunID           string                 `json:"run_id,omitempty"` // 项目运行ID，未保存时为空
+	StartedAt       time.Time              `json:"started_at,omitzero"`
+	UpdatedAt       time.Time              `json:"updated_at,omitzero"`
 	Requirement     string                 `json:"requirement"`      // 原始需求
 	AnalysisResult  *AnalysisResult        `json:"analysis_result"`  // 分析结果
 	TaskHistory     []*models.Task         `json:"task_history"`     // 任务历史
 	Conversations   []Message              `json:"conversations"`    // 对话历史
 	CurrentPhase    string                 `json:"current_phase"`    // 当前阶段
 	Decisions       []Decision             `json:"decisions"`        // 决策记录
+	Replans         []*ReplanRecord        `json:"replans,omitempty"` // 重新规划记录
 }
 
 // Message 对话消息
 type Message struct {
 	Role      string    `json:"role"`    // user/assistant
 	Content   string    `json:"content"`
+	Prompt    string    `json:"prompt,omitempty"` // 提示词模板版本，如 analyze/zh@3f2a9c1e
 	Timestamp time.Time `json:"timestamp"`
 }
 
@@ -112,7 +124,7 @@ type FailureDiagnosis struct {
 	ShouldRetry          bool    `json:"should_retry"`           // 是否值得重试
 	RetrySuggestion      string  `json:"retry_suggestion"`       // 重试建议
 	AlternativeAction    string  `json:"alternative_action"`     // 替代方案
-	EstimatedSuccessRate int     `json:"estimated_success_rate"` // 预估成功率 0-100
+	EstimatedSuccessRate int     `json:"estimated_success_rate" jsonschema:"minimum=0,maximum=100"` // 预估成功率 0-100
 }
 
 // AgentHelp Agent 帮助信息
@@ -126,7 +138,7 @@ type AgentHelp struct {
 // QualityReport 任务质量报告
 type QualityReport struct {
 	IsComplete         bool     `json:"is_complete"`         // 是否完成
-	QualityScore       int      `json:"quality_score"`       // 质量评分 0-100
+	QualityScore       int      `json:"quality_score" jsonschema:"minimum=0,maximum=100"`       // 质量评分 0-100
 	Issues             []string `json:"issues"`              // 发现的问题
 	NeedsRework        bool     `json:"needs_rework"`        // 是否需要返工
 	ReworkInstructions string   `json:"rework_instructions"` // 返工指示
@@ -144,7 +156,7 @@ type MergeDecision struct {
 type ConflictResolution struct {
 	CanAutoResolve   bool              `json:"can_auto_resolve"`   // 是否可以自动解决
 	Resolution       string            `json:"resolution"`         // 解决方案
-	FileResolutions  map[string]string `json:"file_resolutions"`   // 每个文件的解决方案
+	FileResolutions  map[string]string `json:"file_resolutions"`   // 每个冲突文件解决后的完整内容
 	NeedsHumanReview bool              `json:"needs_human_review"` // 是否需要人工审核
 	Reason           string            `json:"reason"`             // 理由
 }
@@ -0,0 +1,188 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// 响应的语义校验，结构之外的约束。错误会发回模型修复，所以一次列出全部问题
+
+// Checks 返回计划中所有任务的检查命令。检查命令会在 Agent 的工作区中执行，
+// 包含检查命令的计划必须经过人工审批
+func (r *AnalysisResult) Checks() []string {
+	var checks []string
+	for _, spec := range r.Tasks {
+		checks = append(checks, spec.Checks...)
+	}
+	return checks
+}
+
+// Validate 检查分析结果：至少一个任务，任务ID非空且唯一，依赖的任务存在，优先级在1-10之间，
+// 文件范围和完成条件中的路径是仓库内的合法通配符、命令非空
+func (r *AnalysisResult) Validate() error {
+	var errs []error
+	if len(r.Tasks) == 0 {
+		errs = append(errs, errors.New("tasks 为空，至少需要一个任务"))
+	}
+
+	ids := make(map[string]bool, len(r.Tasks))
+	for i, task := range r.Tasks {
+		switch {
+		case task.ID == "":
+			errs = append(errs, fmt.Errorf("tasks[%d] 缺少 id", i))
+		case ids[task.ID]:
+			errs = append(errs, fmt.Errorf("任务ID %s 重复", task.ID))
+		}
+		ids[task.ID] = true
+		if task.Priority < 1 || task.Priority > 10 {
+			errs = append(errs, fmt.Errorf("任务 %s 的 priority 为 %d，应在1-10之间", task.ID, task.Priority))
+		}
+		errs = append(errs, task.validateDone()...)
+	}
+
+	for _, task := range r.Tasks {
+		for _, depID := range task.Dependencies {
+			if !ids[depID] {
+				errs = append(errs, fmt.Errorf("任务 %s 依赖的任务 %s 不存在", task.ID, depID))
+			}
+		}
+	}
+
+	for _, module := range r.Modules {
+		if module.Priority < 1 || module.Priority > 10 {
+			errs = append(errs, fmt.Errorf("模块 %s 的 priority 为 %d，应在1-10之间", module.Name, module.Priority))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 检查诊断结果：预估成功率在0-100之间，建议重试时给出修改建议
+func (d *FailureDiagnosis) Validate() error {
+	var errs []error
+	if d.EstimatedSuccessRate < 0 || d.EstimatedSuccessRate > 100 {
+		errs = append(errs, fmt.Errorf("estimated_success_rate 为 %d，应在0-100之间", d.EstimatedSuccessRate))
+	}
+	if d.ShouldRetry && d.RetrySuggestion == "" {
+		errs = append(errs, errors.New("should_retry 为 true 时 retry_suggestion 不能为空"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 检查帮助信息：必须给出提示
+func (h *AgentHelp) Validate() error {
+	if h.Hint == "" && !h.ShouldReassign {
+		return errors.New("hint 不能为空（除非 should_reassign 为 true）")
+	}
+	return nil
+}
+
+// Validate 检查质量报告：评分在0-100之间，需要返工时给出返工指示
+func (q *QualityReport) Validate() error {
+	var errs []error
+	if q.QualityScore < 0 || q.QualityScore > 100 {
+		errs = append(errs, fmt.Errorf("quality_score 为 %d，应在0-100之间", q.QualityScore))
+	}
+	if q.NeedsRework && q.ReworkInstructions == "" {
+		errs = append(errs, errors.New("needs_rework 为 true 时 rework_instructions 不能为空"))
+	}
+	return errors.Join(errs...)
+}
+
+// validateOrder 检查合并顺序只包含待合并的分支且不重复
+func (d *MergeDecision) validateOrder(statuses []*MergeStatus) error {
+	var errs []error
+	seen := make(map[string]bool, len(d.MergeOrder))
+	for _, branch := range d.MergeOrder {
+		known := slices.ContainsFunc(statuses, func(s *MergeStatus) bool { return s.Branch == branch })
+		switch {
+		case !known:
+			errs = append(errs, fmt.Errorf("merge_order 中的分支 %s 不在待合并分支中", branch))
+		case seen[branch]:
+			errs = append(errs, fmt.Errorf("merge_order 中的分支 %s 重复", branch))
+		}
+		seen[branch] = true
+	}
+	if d.ShouldMerge && len(d.MergeOrder) == 0 {
+		errs = append(errs, errors.New("should_merge 为 true 时 merge_order 不能为空"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 检查冲突解决方案：可自动解决时必须给出方案
+func (c *ConflictResolution) Validate() error {
+	if c.CanAutoResolve && c.Resolution == "" {
+		return errors.New("can_auto_resolve 为 true 时 resolution 不能为空")
+	}
+	return nil
+}
+
+// validateFiles 在 Validate 之外检查 file_resolutions 只包含冲突文件，且内容中没有冲突标记
+func (c *ConflictResolution) validateFiles(conflictFiles []string) error {
+	errs := []error{c.Validate()}
+	for file, content := range c.FileResolutions {
+		if !slices.Contains(conflictFiles, file) {
+			errs = append(errs, fmt.Errorf("file_resolutions 中的文件 %s 不是冲突文件", file))
+			continue
+		}
+		if hasConflictMarkers(content) {
+			errs = append(errs, fmt.Errorf("file_resolutions 中 %s 的内容仍包含冲突标记，应给出解决后的完整文件", file))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateDone 检查任务的文件范围和完成条件：files、read_files、required_files 和
+// forbidden_paths 是仓库内的相对路径且通配符合法，checks 中没有空命令
+func (t *TaskSpec) validateDone() []error {
+	var errs []error
+	for _, field := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"files", t.Files}, {"read_files", t.ReadFiles},
+		{"required_files", t.RequiredFiles}, {"forbidden_paths", t.ForbiddenPaths},
+	} {
+		for _, pattern := range field.patterns {
+			if err := validatePathPattern(pattern); err != nil {
+				errs = append(errs, fmt.Errorf("任务 %s 的 %s 中 %q %v", t.ID, field.name, pattern, err))
+			}
+		}
+	}
+	for _, check := range t.Checks {
+		if strings.TrimSpace(check) == "" {
+			errs = append(errs, fmt.Errorf("任务 %s 的 checks 中有空命令", t.ID))
+		}
+	}
+	return errs
+}
+
+// validatePathPattern 检查路径通配符：非空、相对仓库根目录、不含 ..、语法合法
+func validatePathPattern(pattern string) error {
+	switch {
+	case strings.TrimSpace(pattern) == "":
+		return errors.New("为空")
+	case strings.HasPrefix(pattern, "/"):
+		return errors.New("应为相对仓库根目录的路径")
+	case slices.Contains(strings.Split(pattern, "/"), ".."):
+		return errors.New("不能包含 ..")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.New("不是合法的通配符")
+	}
+	return nil
+}
+
+// hasConflictMarkers 判断文件内容中是否有 git 的冲突标记行
+func hasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		for _, marker := range []string{"<<<<<<< ", ">>>>>>> ", "||||||| "} {
+			if strings.HasPrefix(line, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
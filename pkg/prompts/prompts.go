@@ -0,0 +1,193 @@
+// Package prompts renders the orchestrator brain's prompts from
+// text/template files. The defaults are embedded for each language; a
+// project overrides any of them with a file of the same name under
+// .swarm/prompts/ (or .swarm/prompts/<language>/).
+package prompts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+const (
+	LanguageChinese = "zh"
+	LanguageEnglish = "en"
+
+	// DefaultLanguage is used when no language is configured
+	DefaultLanguage = LanguageChinese
+	// DefaultDir is the project directory searched for overrides
+	DefaultDir = ".swarm/prompts"
+
+	// SourceEmbedded is the Source of a built-in template
+	SourceEmbedded = "embedded"
+
+	ext = ".tmpl"
+)
+
+// Template is the source of a prompt template
+type Template struct {
+	Name    string // e.g. "analyze"
+	Version string // Name, language and a digest of the source, e.g. "analyze/zh@3f2a9c1e"
+	Source  string // SourceEmbedded or the override file
+	Text    string
+}
+
+// Prompt is a rendered template
+type Prompt struct {
+	Name    string
+	Version string
+	Text    string
+}
+
+// Store looks up the templates of one language
+type Store struct {
+	dir      string
+	language string
+}
+
+// New creates a store for language that prefers overrides in dir. An empty
+// dir uses only the embedded templates; an empty language the default.
+func New(dir, language string) (*Store, error) {
+	if language == "" {
+		language = DefaultLanguage
+	}
+	if !slices.Contains(Languages(), language) {
+		return nil, fmt.Errorf("unknown prompt language %q (available: %s)", language, strings.Join(Languages(), ", "))
+	}
+	return &Store{dir: dir, language: language}, nil
+}
+
+// Default returns the embedded templates in the default language
+func Default() *Store {
+	return &Store{language: DefaultLanguage}
+}
+
+// Language returns the store's language
+func (s *Store) Language() string {
+	return s.language
+}
+
+// Languages lists the languages with embedded templates
+func Languages() []string {
+	entries, _ := fs.ReadDir(embedded, "templates")
+	var languages []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			languages = append(languages, entry.Name())
+		}
+	}
+	return languages
+}
+
+// Names lists the templates of the default language
+func Names() []string {
+	entries, _ := fs.ReadDir(embedded, path.Join("templates", DefaultLanguage))
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ext); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Lookup returns the template name: the language-specific override, the
+// shared override, or the embedded default, in that order
+func (s *Store) Lookup(name string) (*Template, error) {
+	if s.dir != "" {
+		for _, file := range []string{
+			filepath.Join(s.dir, s.language, name+ext),
+			filepath.Join(s.dir, name+ext),
+		} {
+			data, err := os.ReadFile(file)
+			if err == nil {
+				return s.newTemplate(name, file, data), nil
+			}
+			if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read prompt override: %w", err)
+			}
+		}
+	}
+
+	data, err := embedded.ReadFile(path.Join("templates", s.language, name+ext))
+	if err != nil {
+		return nil, fmt.Errorf("unknown prompt template %q", name)
+	}
+	return s.newTemplate(name, SourceEmbedded, data), nil
+}
+
+func (s *Store) newTemplate(name, source string, data []byte) *Template {
+	sum := sha256.Sum256(data)
+	return &Template{
+		Name:    name,
+		Version: fmt.Sprintf("%s/%s@%s", name, s.language, hex.EncodeToString(sum[:4])),
+		Source:  source,
+		Text:    string(data),
+	}
+}
+
+// Render executes template name with data. Fields missing from data are
+// errors rather than "<no value>", so a broken override fails loudly.
+func (s *Store) Render(name string, data any) (*Prompt, error) {
+	tmpl, err := s.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := template.New(name).Option("missingkey=error").Parse(tmpl.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s (%s): %w", name, tmpl.Source, err)
+	}
+	var buf bytes.Buffer
+	if err := parsed.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render prompt %s (%s): %w", name, tmpl.Source, err)
+	}
+
+	return &Prompt{
+		Name:    name,
+		Version: tmpl.Version,
+		Text:    strings.TrimRight(buf.String(), "\n"),
+	}, nil
+}
+
+// Export writes the embedded templates of language into dir as a starting
+// point for overrides. Existing files are kept; the written paths are returned.
+func Export(dir, language string) ([]string, error) {
+	store, err := New("", language)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	var written []string
+	for _, name := range Names() {
+		file := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(file); err == nil {
+			continue
+		}
+		tmpl, err := store.Lookup(name)
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(file, []byte(tmpl.Text), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		written = append(written, file)
+	}
+	return written, nil
+}
@@ -0,0 +1,93 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEveryLanguageHasEveryTemplate(t *testing.T) {
+	for _, language := range Languages() {
+		store, err := New("", language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range Names() {
+			if _, err := store.Lookup(name); err != nil {
+				t.Errorf("%s: %v", language, err)
+			}
+		}
+	}
+	if _, err := New("", "fr"); err == nil {
+		t.Error("unknown language should be rejected")
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	dir := t.TempDir()
+	write := func(file, text string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := New(dir, LanguageEnglish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtin, err := store.Render("repair", struct{ Error string }{"bad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A shared override applies to every language; a language-specific one wins
+	write(filepath.Join(dir, "repair.tmpl"), "shared: {{.Error}}\n")
+	shared, err := store.Render("repair", struct{ Error string }{"bad"})
+	if err != nil || shared.Text != "shared: bad" {
+		t.Errorf("shared override = %+v, %v", shared, err)
+	}
+	write(filepath.Join(dir, "en", "repair.tmpl"), "english: {{.Error}}")
+	english, err := store.Render("repair", struct{ Error string }{"bad"})
+	if err != nil || english.Text != "english: bad" {
+		t.Errorf("language override = %+v, %v", english, err)
+	}
+
+	if builtin.Version == shared.Version || shared.Version == english.Version {
+		t.Errorf("versions should follow the template source: %s, %s, %s", builtin.Version, shared.Version, english.Version)
+	}
+	if !strings.HasPrefix(english.Version, "repair/en@") {
+		t.Errorf("Version = %s, want repair/en@<digest>", english.Version)
+	}
+
+	// A broken override fails instead of rendering "<no value>"
+	write(filepath.Join(dir, "en", "repair.tmpl"), "{{.Missing}}")
+	if _, err := store.Render("repair", map[string]string{"Error": "bad"}); err == nil {
+		t.Error("missing template field should fail")
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "prompts")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "analyze.tmpl"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := Export(dir, LanguageChinese)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != len(Names())-1 {
+		t.Errorf("wrote %d files, want all but the existing one", len(written))
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "analyze.tmpl")); string(data) != "mine" {
+		t.Error("existing override was overwritten")
+	}
+}
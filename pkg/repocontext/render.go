@@ -0,0 +1,176 @@
+package repocontext
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// section is one part of the rendered context and its share of the budget
+type section struct {
+	title string
+	share int // percent
+	lines func(rc *Context) []string
+	// compact is a shorter form used when lines do not fit, or nil
+	compact func(rc *Context) []string
+}
+
+// sections in the order they are rendered. A section that needs less than
+// its share passes the rest on to the sections after it.
+var sections = []section{
+	{title: "Packages", share: 15, lines: packageLines},
+	{title: "README", share: 15, lines: readmeLines},
+	{title: "Files", share: 25, lines: fileLines, compact: dirLines},
+	{title: "Key symbols", share: 25, lines: symbolLines},
+	{title: "Recent commits", share: 10, lines: func(rc *Context) []string { return rc.Commits }},
+	{title: "Open TODOs", share: 10, lines: todoLines},
+}
+
+// EstimateTokens approximates the tokens in s at four bytes per token
+func EstimateTokens(s string) int {
+	return tokensFor(len(s))
+}
+
+func tokensFor(bytes int) int {
+	return (bytes + 3) / 4
+}
+
+// Render formats the summary as Markdown within budget estimated tokens.
+// Sections are cut at a line boundary, with a note of what was left out.
+func (rc *Context) Render(budget int) string {
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Commit: %s\n", shortSHA(rc.Commit))
+	remaining := budget - EstimateTokens(b.String())
+	carry := 0
+	for i, s := range sections {
+		allowed := budget*s.share/100 + carry
+		if i == len(sections)-1 {
+			allowed = remaining
+		}
+		allowed = min(allowed, remaining)
+
+		text := renderSection(s, rc, allowed)
+		used := EstimateTokens(text)
+		b.WriteString(text)
+		remaining -= used
+		carry = max(allowed-used, 0)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderSection(s section, rc *Context, budget int) string {
+	lines := s.lines(rc)
+	if len(lines) == 0 {
+		return ""
+	}
+	header := "\n## " + s.title + "\n"
+	if budget <= EstimateTokens(header) {
+		return ""
+	}
+
+	if s.compact != nil && EstimateTokens(strings.Join(lines, "\n")) > budget-EstimateTokens(header) {
+		lines = s.compact(rc)
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	for i, line := range lines {
+		omitted := fmt.Sprintf("... (%d more)\n", len(lines)-i)
+		if tokensFor(b.Len()+len(line)+1+len(omitted)) > budget {
+			if i == 0 {
+				return ""
+			}
+			b.WriteString(omitted)
+			break
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func packageLines(rc *Context) []string {
+	lines := append([]string(nil), rc.Manifests...)
+	for _, p := range rc.Packages {
+		lines = append(lines, fmt.Sprintf("%s/ (%s, %d files)", p.Dir, p.Language, p.Files))
+	}
+	return lines
+}
+
+func readmeLines(rc *Context) []string {
+	var lines []string
+	for _, line := range strings.Split(rc.Readme, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		// blank lines, HTML and badges cost budget without telling the model anything
+		if line != "" && !strings.HasPrefix(line, "<") && !strings.HasPrefix(line, "[![") {
+			lines = append(lines, clip(line))
+		}
+	}
+	return lines
+}
+
+// fileLines lists files grouped by directory: "pkg/git/: merge.go repository.go"
+func fileLines(rc *Context) []string {
+	var lines []string
+	dir, names := "", []string(nil)
+	flush := func() {
+		if len(names) > 0 {
+			lines = append(lines, dir+"/: "+strings.Join(names, " "))
+		}
+	}
+	for _, file := range rc.Files {
+		d := path.Dir(file)
+		if d != dir || names == nil {
+			flush()
+			dir, names = d, nil
+		}
+		names = append(names, path.Base(file))
+	}
+	flush()
+	return lines
+}
+
+// dirLines lists directories with their file counts
+func dirLines(rc *Context) []string {
+	var lines []string
+	dir, count := "", 0
+	for _, file := range rc.Files {
+		d := path.Dir(file)
+		if d != dir && count > 0 {
+			lines = append(lines, fmt.Sprintf("%s/ (%d files)", dir, count))
+			count = 0
+		}
+		dir = d
+		count++
+	}
+	if count > 0 {
+		lines = append(lines, fmt.Sprintf("%s/ (%d files)", dir, count))
+	}
+	return lines
+}
+
+func symbolLines(rc *Context) []string {
+	var lines []string
+	for _, s := range rc.Symbols {
+		lines = append(lines, s.File+": "+s.Text)
+	}
+	return lines
+}
+
+func todoLines(rc *Context) []string {
+	var lines []string
+	for _, s := range rc.TODOs {
+		lines = append(lines, fmt.Sprintf("%s:%d: %s", s.File, s.Line, s.Text))
+	}
+	return lines
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
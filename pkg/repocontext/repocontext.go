@@ -0,0 +1,377 @@
+// Package repocontext summarizes a git repository for the orchestrator
+// brain: file tree, packages, key symbols, README, recent commits and open
+// TODOs. Everything is read from HEAD, so a summary is cached by commit SHA
+// and rendered into a token budget when a prompt is built.
+package repocontext
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultBudget is the rendered size, in estimated tokens
+	DefaultBudget = 4000
+	// DefaultCacheDir is the cache directory, relative to the repository
+	DefaultCacheDir = ".swarm/cache/repocontext"
+
+	maxFiles       = 5000 // files kept in the tree
+	maxSymbols     = 2000
+	maxTODOs       = 200
+	maxCommits     = 15
+	maxReadmeBytes = 16 * 1024
+	maxLineBytes   = 160
+)
+
+// Context is the summary of a repository at one commit
+type Context struct {
+	Commit    string    `json:"commit"`
+	Manifests []string  `json:"manifests,omitempty"` // e.g. "go.mod: module example.com/app"
+	Packages  []Package `json:"packages,omitempty"`
+	Readme    string    `json:"readme,omitempty"`
+	Files     []string  `json:"files"`
+	Symbols   []Symbol  `json:"symbols,omitempty"`
+	Commits   []string  `json:"commits,omitempty"` // "<short sha> <subject>", newest first
+	TODOs     []Symbol  `json:"todos,omitempty"`
+}
+
+// Package is a directory of source files
+type Package struct {
+	Dir      string `json:"dir"`
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+}
+
+// Symbol is a matching source line, such as an exported declaration or a TODO
+type Symbol struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Options controls Build
+type Options struct {
+	// CacheDir holds one summary per commit; relative paths are resolved
+	// against the repository. Empty disables caching.
+	CacheDir string
+}
+
+// languages maps source file extensions to a language name
+var languages = map[string]string{
+	".go": "go", ".py": "python", ".rs": "rust", ".java": "java", ".kt": "kotlin",
+	".ts": "typescript", ".tsx": "typescript", ".js": "javascript", ".jsx": "javascript", ".mjs": "javascript",
+	".rb": "ruby", ".php": "php", ".cs": "csharp", ".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp",
+	".swift": "swift", ".scala": "scala", ".sh": "shell",
+}
+
+// symbolPatterns are the declarations worth showing per language: exported
+// or top-level names only, which is what a task description refers to
+var symbolPatterns = []struct {
+	globs   []string
+	pattern string
+}{
+	{[]string{"*.go"}, `^(func (\([^)]*\) )?[A-Z]|type [A-Z])`},
+	{[]string{"*.py"}, `^(async def|def|class) [A-Za-z]`},
+	{[]string{"*.ts", "*.tsx", "*.js", "*.jsx", "*.mjs"}, `^export (default )?(async )?(function|class|interface|type|const|enum) `},
+	{[]string{"*.rs"}, `^pub (async )?(fn|struct|enum|trait|type|mod) `},
+	{[]string{"*.java", "*.kt"}, `^public (abstract |final )?(class|interface|enum|record) `},
+}
+
+// excluded are pathspecs left out of every listing
+var excluded = []string{
+	":(exclude)vendor/*", ":(exclude)*/vendor/*",
+	":(exclude)node_modules/*", ":(exclude)*/node_modules/*",
+	":(exclude)*_test.go", ":(exclude)*.test.ts", ":(exclude)*.spec.ts", ":(exclude)*.min.js",
+}
+
+// vendored reports whether file is third-party code copied into the tree
+func vendored(file string) bool {
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if dir == "vendor" || dir == "node_modules" {
+			return true
+		}
+	}
+	return false
+}
+
+// Build summarizes the repository at path as of HEAD, reusing the cached
+// summary for the same commit when there is one
+func Build(ctx context.Context, repoPath string, opts Options) (*Context, error) {
+	g := gitRunner{ctx: ctx, dir: repoPath}
+	commit, err := g.run("rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	commit = strings.TrimSpace(commit)
+
+	cacheFile := ""
+	if opts.CacheDir != "" {
+		dir := opts.CacheDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(repoPath, dir)
+		}
+		cacheFile = filepath.Join(dir, commit+".json")
+		if cached, err := load(cacheFile); err == nil && cached.Commit == commit {
+			return cached, nil
+		}
+	}
+
+	rc, err := g.collect(commit)
+	if err != nil {
+		return nil, err
+	}
+	if cacheFile != "" {
+		if err := rc.save(cacheFile); err != nil {
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+type gitRunner struct {
+	ctx context.Context
+	dir string
+}
+
+func (g gitRunner) run(args ...string) (string, error) {
+	cmd := exec.CommandContext(g.ctx, "git", append([]string{"-C", g.dir}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s: %w", args[0], strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(output), nil
+}
+
+// grep runs git grep over HEAD; no match is not an error
+func (g gitRunner) grep(commit string, args []string, globs []string, limit int) ([]Symbol, error) {
+	argv := append([]string{"grep", "-n", "-I", "-E"}, args...)
+	argv = append(argv, commit, "--")
+	argv = append(argv, globs...)
+	argv = append(argv, excluded...)
+
+	output, err := g.run(argv...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		// <commit>:<file>:<line>:<text>
+		rest := strings.TrimPrefix(line, commit+":")
+		file, rest, ok := strings.Cut(rest, ":")
+		if !ok {
+			continue
+		}
+		lineNo, text, ok := strings.Cut(rest, ":")
+		if !ok {
+			continue
+		}
+		var n int
+		fmt.Sscanf(lineNo, "%d", &n)
+		symbols = append(symbols, Symbol{File: file, Line: n, Text: clip(declaration(text))})
+		if len(symbols) >= limit {
+			break
+		}
+	}
+	return symbols, nil
+}
+
+func (g gitRunner) collect(commit string) (*Context, error) {
+	rc := &Context{Commit: commit}
+
+	output, err := g.run("ls-tree", "-r", "--name-only", commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	var files []string
+	for _, file := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if file != "" && !vendored(file) && len(files) < maxFiles {
+			files = append(files, file)
+		}
+	}
+	// group by directory so the tree renders one line per directory
+	sort.SliceStable(files, func(i, j int) bool { return path.Dir(files[i]) < path.Dir(files[j]) })
+	rc.Files = files
+	rc.Packages = packagesOf(files)
+
+	for _, file := range files {
+		if rc.Readme == "" && !strings.Contains(file, "/") && strings.HasPrefix(strings.ToLower(file), "readme") {
+			if readme, err := g.run("show", commit+":"+file); err == nil {
+				rc.Readme = truncateBytes(readme, maxReadmeBytes)
+			}
+		}
+		if manifest := g.manifest(commit, file); manifest != "" {
+			rc.Manifests = append(rc.Manifests, manifest)
+		}
+	}
+
+	for _, p := range symbolPatterns {
+		symbols, err := g.grep(commit, []string{"-e", p.pattern}, p.globs, maxSymbols-len(rc.Symbols))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find symbols: %w", err)
+		}
+		rc.Symbols = append(rc.Symbols, symbols...)
+		if len(rc.Symbols) >= maxSymbols {
+			break
+		}
+	}
+
+	rc.TODOs, err = g.grep(commit, []string{"-w", "-e", "TODO|FIXME|XXX|HACK"}, []string{"."}, maxTODOs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find TODOs: %w", err)
+	}
+
+	if log, err := g.run("log", "-n", fmt.Sprint(maxCommits), "--format=%h %s", commit); err == nil {
+		for _, line := range strings.Split(strings.TrimRight(log, "\n"), "\n") {
+			if line != "" {
+				rc.Commits = append(rc.Commits, clip(line))
+			}
+		}
+	}
+	return rc, nil
+}
+
+// manifest describes a build manifest in the top two directory levels,
+// or returns "" for any other file
+func (g gitRunner) manifest(commit, file string) string {
+	if strings.Count(file, "/") > 1 {
+		return ""
+	}
+	switch path.Base(file) {
+	case "go.mod":
+		data, err := g.run("show", commit+":"+file)
+		if err != nil {
+			return file
+		}
+		for _, line := range strings.Split(data, "\n") {
+			if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+				return file + ": module " + strings.TrimSpace(module)
+			}
+		}
+		return file
+	case "package.json":
+		data, err := g.run("show", commit+":"+file)
+		if err != nil {
+			return file
+		}
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal([]byte(data), &pkg) == nil && pkg.Name != "" {
+			return file + ": " + pkg.Name
+		}
+		return file
+	case "Cargo.toml", "pyproject.toml", "setup.py", "pom.xml", "build.gradle", "build.gradle.kts", "Gemfile", "composer.json", "Makefile":
+		return file
+	}
+	return ""
+}
+
+// packagesOf groups source files by directory
+func packagesOf(files []string) []Package {
+	counts := make(map[string]map[string]int) // dir -> language -> files
+	for _, file := range files {
+		language, ok := languages[path.Ext(file)]
+		if !ok {
+			continue
+		}
+		dir := path.Dir(file)
+		if counts[dir] == nil {
+			counts[dir] = make(map[string]int)
+		}
+		counts[dir][language]++
+	}
+
+	var packages []Package
+	for dir, byLanguage := range counts {
+		language, most := "", 0
+		total := 0
+		for l, n := range byLanguage {
+			total += n
+			if n > most || (n == most && l < language) {
+				language, most = l, n
+			}
+		}
+		packages = append(packages, Package{Dir: dir, Language: language, Files: total})
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Dir < packages[j].Dir })
+	return packages
+}
+
+func load(file string) (*Context, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rc Context
+	if err := json.Unmarshal(data, &rc); err != nil {
+		return nil, err
+	}
+	return &rc, nil
+}
+
+func (rc *Context) save(file string) error {
+	data, err := json.Marshal(rc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repository context: %w", err)
+	}
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	// the cache lives inside the repository; keep it out of commits
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		os.WriteFile(ignore, []byte("*\n"), 0644)
+	}
+
+	tmpFile := file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmpFile, file); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
+
+// declaration trims the opening brace, or an empty body, off a source line
+func declaration(line string) string {
+	line = strings.TrimSpace(line)
+	for _, suffix := range []string{"{}", "{"} {
+		line = strings.TrimSpace(strings.TrimSuffix(line, suffix))
+	}
+	return line
+}
+
+func clip(s string) string {
+	return truncateBytes(s, maxLineBytes)
+}
+
+// truncateBytes cuts s to at most n bytes without splitting a UTF-8 sequence
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n] + "…"
+}
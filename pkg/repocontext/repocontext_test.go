@@ -0,0 +1,193 @@
+package repocontext
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                   "module example.com/shop\n\ngo 1.22\n",
+		"README.md":                "# Shop\n\n[![CI](https://ci/badge.svg)](https://ci)\n\nAn online shop.\n",
+		"cmd/shop/main.go":         "package main\n\nfunc main() {}\n",
+		"pkg/cart/cart.go":         "package cart\n\n// TODO: support coupons\ntype Cart struct{}\n\nfunc (c *Cart) Add(sku string) error {\n\treturn nil\n}\n\nfunc helper() {}\n",
+		"pkg/cart/cart_test.go":    "package cart\n\nfunc TestAdd() {}\n",
+		"vendor/lib/lib.go":        "package lib\n\nfunc Vendored() {}\n",
+		"web/src/api.ts":           "export function fetchCart() {}\nfunction local() {}\n",
+		"docs/notes.txt":           "FIXME write docs\n",
+		"pkg/payment/stripe.go":    "package payment\n\nfunc Charge() {}\n",
+		"pkg/payment/provider.go":  "package payment\n\ntype Provider interface{}\n",
+		"pkg/payment/internal.go":  "package payment\n\nfunc retry() {}\n",
+		"pkg/payment/TODO.md":      "nothing to see\n",
+		"web/package.json":         `{"name": "shop-web"}`,
+		"scripts/deploy/deploy.sh": "#!/bin/sh\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+		{"add", "."},
+		{"commit", "-q", "-m", "Add cart"},
+	} {
+		if output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	return dir
+}
+
+func TestBuild(t *testing.T) {
+	dir := setupRepo(t)
+
+	rc, err := Build(context.Background(), dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rc.Commit) != 40 {
+		t.Errorf("Commit = %q", rc.Commit)
+	}
+	for _, file := range rc.Files {
+		if strings.HasPrefix(file, "vendor/") {
+			t.Errorf("vendored file listed: %s", file)
+		}
+	}
+	if !strings.Contains(rc.Readme, "An online shop.") {
+		t.Errorf("Readme = %q", rc.Readme)
+	}
+	if strings.Join(rc.Manifests, "\n") != "go.mod: module example.com/shop\nweb/package.json: shop-web" {
+		t.Errorf("Manifests = %q", rc.Manifests)
+	}
+	if len(rc.Commits) != 1 || !strings.HasSuffix(rc.Commits[0], " Add cart") {
+		t.Errorf("Commits = %q", rc.Commits)
+	}
+
+	var symbols []string
+	for _, s := range rc.Symbols {
+		symbols = append(symbols, s.File+": "+s.Text)
+	}
+	got := strings.Join(symbols, "\n")
+	for _, want := range []string{
+		"pkg/cart/cart.go: type Cart struct",
+		"pkg/cart/cart.go: func (c *Cart) Add(sku string) error",
+		"pkg/payment/stripe.go: func Charge()",
+		"web/src/api.ts: export function fetchCart()",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("symbols missing %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"helper", "retry", "TestAdd", "Vendored", "local"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("symbols should not include %s:\n%s", unwanted, got)
+		}
+	}
+
+	if len(rc.TODOs) != 2 {
+		t.Fatalf("TODOs = %+v", rc.TODOs)
+	}
+	for _, todo := range rc.TODOs {
+		if todo.File == "pkg/cart/cart.go" && todo.Line != 3 {
+			t.Errorf("TODO line = %d, want 3", todo.Line)
+		}
+	}
+}
+
+func TestBuildCachesByCommit(t *testing.T) {
+	dir := setupRepo(t)
+	opts := Options{CacheDir: DefaultCacheDir}
+
+	rc, err := Build(context.Background(), dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cacheFile := filepath.Join(dir, DefaultCacheDir, rc.Commit+".json")
+	if _, err := os.Stat(cacheFile); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+	if output, _ := exec.Command("git", "-C", dir, "status", "--porcelain").Output(); len(output) != 0 {
+		t.Errorf("cache should be ignored by git, status:\n%s", output)
+	}
+
+	// a cached summary is used as is for the same commit
+	rc.Readme = "from cache"
+	if err := rc.save(cacheFile); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := Build(context.Background(), dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Readme != "from cache" {
+		t.Errorf("Readme = %q, want cached summary", cached.Readme)
+	}
+
+	// a new commit is summarized afresh
+	exec.Command("git", "-C", dir, "commit", "-q", "--allow-empty", "-m", "Empty").Run()
+	fresh, err := Build(context.Background(), dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.Commit == rc.Commit || fresh.Readme == "from cache" {
+		t.Errorf("expected a fresh summary, got commit %s readme %q", fresh.Commit, fresh.Readme)
+	}
+}
+
+func TestBuildNotARepository(t *testing.T) {
+	if _, err := Build(context.Background(), t.TempDir(), Options{}); err == nil {
+		t.Error("expected an error outside a git repository")
+	}
+}
+
+func TestRender(t *testing.T) {
+	rc, err := Build(context.Background(), setupRepo(t), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full := rc.Render(DefaultBudget)
+	for _, want := range []string{
+		"## Packages\ngo.mod: module example.com/shop",
+		"pkg/payment/ (go, 3 files)",
+		"## README\n# Shop\nAn online shop.",
+		"pkg/cart/: cart.go cart_test.go\n",
+		"## Key symbols",
+		"## Recent commits",
+		"## Open TODOs\ndocs/notes.txt:1: FIXME write docs\npkg/cart/cart.go:3: // TODO: support coupons",
+	} {
+		if !strings.Contains(full, want) {
+			t.Errorf("render missing %q:\n%s", want, full)
+		}
+	}
+
+	// many files: the tree falls back to directories and sections are cut
+	for i := range 400 {
+		rc.Files = append(rc.Files, filepath.Join("gen", string(rune('a'+i%26)), "file"+strings.Repeat("x", i%7)+".go"))
+	}
+	for _, budget := range []int{60, 200, 400} {
+		out := rc.Render(budget)
+		if got := EstimateTokens(out); got > budget {
+			t.Errorf("Render(%d) = %d tokens:\n%s", budget, got, out)
+		}
+	}
+	small := rc.Render(400)
+	if !strings.Contains(small, "more)") {
+		t.Errorf("expected a truncation note:\n%s", small)
+	}
+}
@@ -0,0 +1,196 @@
+package retry
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// BreakerConfig configures the coordinator's circuit breaker
+type BreakerConfig struct {
+	Threshold   int                    // Consecutive provider failures that trip the breaker, default 3
+	Cooldown    time.Duration          // Pause after tripping, default 1m
+	MaxCooldown time.Duration          // Cap when failed probes double the cooldown, default 10m
+	Categories  []models.ErrorCategory // Failures that count, default rate_limit and network
+}
+
+// DefaultBreakerConfig returns the default breaker configuration
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Threshold:   3,
+		Cooldown:    time.Minute,
+		MaxCooldown: 10 * time.Minute,
+		Categories:  []models.ErrorCategory{models.ErrorRateLimit, models.ErrorNetwork},
+	}
+}
+
+// CircuitBreaker pauses task starts for every agent after consecutive
+// provider-side failures, so the agents stop hammering an API that is
+// rate limiting them. After the cooldown it half-opens and lets a single
+// probe task through: success closes it, another provider failure opens
+// it again for twice as long.
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	config      BreakerConfig
+	state       models.BreakerState
+	consecutive int
+	category    models.ErrorCategory
+	openUntil   time.Time
+	cooldown    time.Duration // Current cooldown, grows with failed probes
+	trips       int
+	probing     bool // A half-open probe is running
+	onChange    func(from, to models.BreakerState)
+	now         func() time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker; zero config fields use the defaults
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	defaults := DefaultBreakerConfig()
+	if config.Threshold <= 0 {
+		config.Threshold = defaults.Threshold
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = defaults.Cooldown
+	}
+	if config.MaxCooldown < config.Cooldown {
+		config.MaxCooldown = max(defaults.MaxCooldown, config.Cooldown)
+	}
+	if len(config.Categories) == 0 {
+		config.Categories = defaults.Categories
+	}
+
+	return &CircuitBreaker{
+		config:   config,
+		state:    models.BreakerClosed,
+		cooldown: config.Cooldown,
+		now:      time.Now,
+	}
+}
+
+// OnStateChange registers fn to be called after every state transition.
+// fn runs with the breaker unlocked.
+func (b *CircuitBreaker) OnStateChange(fn func(from, to models.BreakerState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = fn
+}
+
+// Allow reports whether a task may start now. In the half-open state it
+// grants a single probe; call Release if the probe ends up not running.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	from := b.state
+
+	allowed := false
+	switch b.state {
+	case models.BreakerClosed:
+		allowed = true
+	case models.BreakerOpen:
+		if !b.now().Before(b.openUntil) {
+			b.state = models.BreakerHalfOpen
+			b.probing = true
+			allowed = true
+		}
+	case models.BreakerHalfOpen:
+		if !b.probing {
+			b.probing = true
+			allowed = true
+		}
+	}
+
+	b.unlockAndNotify(from)
+	return allowed
+}
+
+// Release gives back a half-open probe granted by Allow that never ran
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == models.BreakerHalfOpen {
+		b.probing = false
+	}
+}
+
+// Record reports the outcome of a task run, nil for success
+func (b *CircuitBreaker) Record(failure *models.Failure) {
+	b.mu.Lock()
+	from := b.state
+
+	if failure == nil || !slices.Contains(b.config.Categories, failure.Category) {
+		// The provider answered; whatever went wrong was the agent's work
+		b.consecutive = 0
+		if b.state == models.BreakerHalfOpen {
+			b.state = models.BreakerClosed
+			b.probing = false
+			b.cooldown = b.config.Cooldown
+		}
+		b.unlockAndNotify(from)
+		return
+	}
+
+	b.consecutive++
+	b.category = failure.Category
+	retryAfter := time.Duration(failure.RetryAfterSeconds) * time.Second
+
+	switch b.state {
+	case models.BreakerClosed:
+		if b.consecutive >= b.config.Threshold {
+			b.trip(retryAfter)
+		}
+	case models.BreakerHalfOpen:
+		// The probe (or a task started before the trip) failed again
+		b.cooldown = min(2*b.cooldown, b.config.MaxCooldown)
+		b.trip(retryAfter)
+	case models.BreakerOpen:
+		// A task started before the trip; honour a longer retry-after
+		if until := b.now().Add(retryAfter); until.After(b.openUntil) {
+			b.openUntil = until
+		}
+	}
+
+	b.unlockAndNotify(from)
+}
+
+// trip opens the breaker for the current cooldown or retry-after,
+// whichever is longer; callers hold mu
+func (b *CircuitBreaker) trip(retryAfter time.Duration) {
+	b.state = models.BreakerOpen
+	b.probing = false
+	b.openUntil = b.now().Add(max(b.cooldown, retryAfter))
+	b.trips++
+}
+
+// unlockAndNotify releases mu and reports a transition away from from
+func (b *CircuitBreaker) unlockAndNotify(from models.BreakerState) {
+	to, onChange := b.state, b.onChange
+	b.mu.Unlock()
+
+	if to != from && onChange != nil {
+		onChange(from, to)
+	}
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() models.BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Snapshot fills the breaker fields of status
+func (b *CircuitBreaker) Snapshot(status *models.ThrottleStatus) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status.Breaker = b.state
+	status.ConsecutiveFailures = b.consecutive
+	status.LastCategory = b.category
+	status.Trips = b.trips
+	status.OpenUntil = time.Time{}
+	if b.state == models.BreakerOpen {
+		status.OpenUntil = b.openUntil
+	}
+}
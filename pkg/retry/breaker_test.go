@@ -0,0 +1,147 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// fakeClock is a settable time source for the breaker and limiter
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(clock *fakeClock) *CircuitBreaker {
+	b := NewCircuitBreaker(BreakerConfig{Threshold: 2, Cooldown: time.Minute, MaxCooldown: 3 * time.Minute})
+	b.now = clock.now
+	return b
+}
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	b := newTestBreaker(clock)
+	rateLimited := &models.Failure{Category: models.ErrorRateLimit}
+
+	var transitions []models.BreakerState
+	b.OnStateChange(func(from, to models.BreakerState) { transitions = append(transitions, to) })
+
+	// Agent-side failures and successes don't count
+	b.Record(rateLimited)
+	b.Record(&models.Failure{Category: models.ErrorCompile})
+	b.Record(rateLimited)
+	if b.State() != models.BreakerClosed {
+		t.Fatalf("state = %s, want closed after non-consecutive failures", b.State())
+	}
+
+	b.Record(rateLimited)
+	if b.State() != models.BreakerOpen || b.Allow() {
+		t.Fatalf("state = %s, want open and no starts", b.State())
+	}
+
+	// Half-open after the cooldown: exactly one probe
+	clock.advance(time.Minute)
+	if !b.Allow() {
+		t.Fatal("Allow() after cooldown = false, want a probe")
+	}
+	if b.Allow() {
+		t.Error("second Allow() while probing = true, want false")
+	}
+
+	// A released probe can be granted again
+	b.Release()
+	if !b.Allow() {
+		t.Error("Allow() after Release = false, want a new probe")
+	}
+
+	// A failed probe reopens for twice as long
+	b.Record(rateLimited)
+	clock.advance(time.Minute)
+	if b.Allow() {
+		t.Error("Allow() one cooldown after a failed probe = true, want still open")
+	}
+	clock.advance(time.Minute)
+	if !b.Allow() {
+		t.Fatal("Allow() after doubled cooldown = false, want a probe")
+	}
+
+	// A successful probe closes it
+	b.Record(nil)
+	if b.State() != models.BreakerClosed || !b.Allow() || !b.Allow() {
+		t.Errorf("state = %s, want closed and unlimited starts", b.State())
+	}
+
+	want := []models.BreakerState{
+		models.BreakerOpen, models.BreakerHalfOpen, models.BreakerOpen, models.BreakerHalfOpen, models.BreakerClosed,
+	}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transition %d = %s, want %s", i, transitions[i], want[i])
+		}
+	}
+}
+
+func TestCircuitBreakerRetryAfter(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	b := newTestBreaker(clock)
+
+	b.Record(&models.Failure{Category: models.ErrorNetwork})
+	b.Record(&models.Failure{Category: models.ErrorRateLimit, RetryAfterSeconds: 300})
+
+	var status models.ThrottleStatus
+	b.Snapshot(&status)
+	if status.Breaker != models.BreakerOpen || status.Trips != 1 || status.LastCategory != models.ErrorRateLimit {
+		t.Fatalf("snapshot = %+v, want open after one rate_limit trip", status)
+	}
+	if got := status.OpenUntil.Sub(clock.t); got != 5*time.Minute {
+		t.Errorf("open for %s, want the 5m retry-after", got)
+	}
+
+	clock.advance(4 * time.Minute)
+	if b.Allow() {
+		t.Error("Allow() before retry-after elapsed = true, want false")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	l := NewLimiter(LimiterConfig{StartsPerMinute: 2, Burst: 2, MaxConcurrent: 3})
+	l.now = clock.now
+	l.last = clock.t
+
+	if !l.Acquire() || !l.Acquire() {
+		t.Fatal("burst of 2 should allow two starts")
+	}
+	if l.Acquire() {
+		t.Error("empty bucket allowed a start")
+	}
+
+	// Half a minute earns one token
+	clock.advance(30 * time.Second)
+	if !l.Acquire() {
+		t.Fatal("refilled token not available")
+	}
+
+	// Concurrency cap: 3 running even with tokens
+	clock.advance(time.Minute)
+	if l.Acquire() {
+		t.Error("start allowed beyond MaxConcurrent")
+	}
+	l.Done()
+	if !l.Acquire() {
+		t.Error("start refused after a task finished")
+	}
+
+	// Refund gives back the token and the slot
+	l.Done()
+	running, tokens := l.Snapshot()
+	l.Acquire()
+	l.Refund()
+	if r, tk := l.Snapshot(); r != running || tk != tokens {
+		t.Errorf("after Refund running=%d tokens=%g, want %d and %g", r, tk, running, tokens)
+	}
+}
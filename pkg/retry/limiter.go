@@ -0,0 +1,105 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// LimiterConfig bounds how fast and how many tasks run across all agents
+type LimiterConfig struct {
+	StartsPerMinute float64 // Token refill rate; 0 = unlimited
+	Burst           int     // Bucket size, default 1 when rate limited
+	MaxConcurrent   int     // Tasks running at once; 0 = no cap beyond the agent count
+}
+
+// Limiter is a token bucket on task starts plus a concurrency cap, shared
+// by every agent of a coordinator so they back off together
+type Limiter struct {
+	mu      sync.Mutex
+	config  LimiterConfig
+	tokens  float64
+	last    time.Time
+	running int
+	now     func() time.Time
+}
+
+// NewLimiter creates a limiter with a full bucket
+func NewLimiter(config LimiterConfig) *Limiter {
+	if config.StartsPerMinute < 0 {
+		config.StartsPerMinute = 0
+	}
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+	l := &Limiter{config: config, now: time.Now}
+	l.tokens = float64(config.Burst)
+	l.last = l.now()
+	return l
+}
+
+// Acquire takes a token and a concurrency slot for one task start.
+// It returns false, taking nothing, if either is unavailable.
+func (l *Limiter) Acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if l.config.MaxConcurrent > 0 && l.running >= l.config.MaxConcurrent {
+		return false
+	}
+	if l.config.StartsPerMinute > 0 {
+		if l.tokens < 1 {
+			return false
+		}
+		l.tokens--
+	}
+	l.running++
+	return true
+}
+
+// Refund returns the token and slot of an Acquire that started nothing
+func (l *Limiter) Refund() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running > 0 {
+		l.running--
+	}
+	if l.config.StartsPerMinute > 0 {
+		l.tokens = min(l.tokens+1, float64(l.config.Burst))
+	}
+}
+
+// Done releases the concurrency slot of a finished task
+func (l *Limiter) Done() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running > 0 {
+		l.running--
+	}
+}
+
+// Snapshot returns the running tasks and the tokens available now
+func (l *Limiter) Snapshot() (running int, tokens float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	return l.running, l.tokens
+}
+
+// Config returns the limiter's configuration
+func (l *Limiter) Config() LimiterConfig {
+	return l.config
+}
+
+// refill adds the tokens earned since the last call; callers hold mu
+func (l *Limiter) refill() {
+	now := l.now()
+	if l.config.StartsPerMinute > 0 {
+		earned := now.Sub(l.last).Minutes() * l.config.StartsPerMinute
+		l.tokens = min(l.tokens+earned, float64(l.config.Burst))
+	}
+	l.last = now
+}
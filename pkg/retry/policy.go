@@ -0,0 +1,154 @@
+package retry
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+	"github.com/yourusername/claude-swarm/pkg/analyzer"
+)
+
+// Backoff is the shape of the delay curve between attempts
+type Backoff string
+
+const (
+	BackoffConstant    Backoff = "constant"    // InitialDelay every time
+	BackoffLinear      Backoff = "linear"      // InitialDelay * attempt
+	BackoffExponential Backoff = "exponential" // InitialDelay * Factor^(attempt-1)
+)
+
+// RetryPolicy decides how a task is retried after one category of failure
+type RetryPolicy struct {
+	MaxAttempts  int           `yaml:"max_attempts"`            // Retries allowed after failures of this category; 0 never retries
+	Backoff      Backoff       `yaml:"backoff,omitempty"`       // Default exponential
+	InitialDelay time.Duration `yaml:"initial_delay,omitempty"` // e.g. 5s
+	MaxDelay     time.Duration `yaml:"max_delay,omitempty"`     // Cap before jitter
+	Factor       float64       `yaml:"factor,omitempty"`        // Exponential base, default 2
+	Jitter       float64       `yaml:"jitter,omitempty"`        // Fraction of the delay to randomize, 0-1
+	SwitchAgent  bool          `yaml:"switch_agent,omitempty"`  // Prefer another agent for the retry
+}
+
+// DefaultPolicies are used for categories the configuration doesn't list.
+// Provider-side failures back off and retry; failures in the agent's own
+// work get one fresh attempt on another agent, and work that misses its
+// definition of done gets two more tries with the failed checks; credentials, policy and
+// resource failures need a human.
+func DefaultPolicies() map[models.ErrorCategory]RetryPolicy {
+	return map[models.ErrorCategory]RetryPolicy{
+		models.ErrorRateLimit: {MaxAttempts: 5, Backoff: BackoffExponential, InitialDelay: 30 * time.Second,
+			MaxDelay: 10 * time.Minute, Jitter: 0.3},
+		models.ErrorNetwork: {MaxAttempts: 3, Backoff: BackoffExponential, InitialDelay: 5 * time.Second,
+			MaxDelay: 2 * time.Minute, Jitter: 0.2},
+		models.ErrorTimeout:       {MaxAttempts: 1, Backoff: BackoffConstant, InitialDelay: 10 * time.Second},
+		models.ErrorToolFailure:   {MaxAttempts: 1, Backoff: BackoffConstant, InitialDelay: 10 * time.Second, SwitchAgent: true},
+		models.ErrorAgentGaveUp:   {MaxAttempts: 1, Backoff: BackoffConstant, InitialDelay: 5 * time.Second, SwitchAgent: true},
+		models.ErrorUnmetDone:     {MaxAttempts: 2, Backoff: BackoffConstant, InitialDelay: 5 * time.Second},
+		models.ErrorCompile:       {MaxAttempts: 0},
+		models.ErrorTestFailure:   {MaxAttempts: 0},
+		models.ErrorAuth:          {MaxAttempts: 0},
+		models.ErrorPolicyBlock:   {MaxAttempts: 0},
+		models.ErrorResource:      {MaxAttempts: 0},
+		models.ErrorUncategorized: {MaxAttempts: 2, Backoff: BackoffExponential, InitialDelay: 5 * time.Second, Jitter: 0.2},
+	}
+}
+
+// providerCategories are failures on the provider's side. They say nothing
+// about the task's work, so they don't count against the task's MaxRetries.
+var providerCategories = map[models.ErrorCategory]bool{
+	models.ErrorRateLimit: true,
+	models.ErrorNetwork:   true,
+}
+
+// policyFile is the layout of .swarm/retry.yaml
+type policyFile struct {
+	Categories map[models.ErrorCategory]RetryPolicy `yaml:"categories"`
+}
+
+// LoadPolicies reads per-category policies from path on top of
+// DefaultPolicies. A missing file yields the defaults.
+func LoadPolicies(path string) (map[models.ErrorCategory]RetryPolicy, error) {
+	policies := DefaultPolicies()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return policies, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retry policy: %w", err)
+	}
+
+	var file policyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse retry policy %s: %w", path, err)
+	}
+	for category, policy := range file.Categories {
+		if !analyzer.IsKnownCategory(category) {
+			return nil, fmt.Errorf("retry policy %s: unknown category %q", path, category)
+		}
+		if err := policy.validate(); err != nil {
+			return nil, fmt.Errorf("retry policy %s: %s: %w", path, category, err)
+		}
+		policies[category] = policy
+	}
+	return policies, nil
+}
+
+func (p RetryPolicy) validate() error {
+	switch p.Backoff {
+	case "", BackoffConstant, BackoffLinear, BackoffExponential:
+	default:
+		return fmt.Errorf("unknown backoff %q (constant, linear or exponential)", p.Backoff)
+	}
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts must not be negative")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// delay returns the backoff before retry number attempt (1-based), before jitter
+func (p RetryPolicy) delay(attempt int, fallback RetryConfig) time.Duration {
+	initial := p.InitialDelay
+	if initial <= 0 {
+		initial = fallback.InitialDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = fallback.MaxDelay
+	}
+	factor := p.Factor
+	if factor <= 1 {
+		factor = fallback.BackoffFactor
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	var d float64
+	switch p.Backoff {
+	case BackoffConstant:
+		d = float64(initial)
+	case BackoffLinear:
+		d = float64(initial) * float64(attempt)
+	default:
+		d = float64(initial) * math.Pow(factor, float64(attempt-1))
+	}
+	if d > float64(maxDelay) {
+		d = float64(maxDelay)
+	}
+	return time.Duration(d)
+}
+
+// Decision is what to do with a task after a failed attempt
+type Decision struct {
+	Retry       bool
+	Delay       time.Duration
+	SwitchAgent bool
+	Reason      string
+}
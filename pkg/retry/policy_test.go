@@ -0,0 +1,163 @@
+package retry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func newTestManager(policies map[models.ErrorCategory]RetryPolicy) *RetryManager {
+	rm := NewRetryManager(RetryConfig{Policies: policies})
+	rm.random = func() float64 { return 0.5 } // No jitter
+	return rm
+}
+
+// failedTask returns a task whose attempts failed with earlier, followed by
+// the attempt being decided on
+func failedTask(maxRetries int, earlier ...models.ErrorCategory) *models.Task {
+	task := &models.Task{ID: "t", MaxRetries: maxRetries, RetryCount: len(earlier)}
+	for _, category := range earlier {
+		task.Attempts = append(task.Attempts, models.Attempt{Failure: &models.Failure{Category: category}})
+	}
+	task.Attempts = append(task.Attempts, models.Attempt{})
+	return task
+}
+
+func TestDecidePerCategory(t *testing.T) {
+	rm := newTestManager(nil)
+
+	rateLimited := []models.ErrorCategory{models.ErrorRateLimit, models.ErrorRateLimit, models.ErrorRateLimit}
+	tests := []struct {
+		name     string
+		category models.ErrorCategory
+		earlier  []models.ErrorCategory
+		retry    bool
+		switchTo bool
+	}{
+		{"rate limit retries", models.ErrorRateLimit, nil, true, false},
+		{"rate limit beyond task max retries", models.ErrorRateLimit, rateLimited, true, false},
+		{"rate limit exhausted", models.ErrorRateLimit, append(rateLimited, models.ErrorRateLimit, models.ErrorRateLimit), false, false},
+		{"auth never retries", models.ErrorAuth, nil, false, false},
+		{"compile error not retried", models.ErrorCompile, nil, false, false},
+		{"tool failure switches agent", models.ErrorToolFailure, nil, true, true},
+		{"tool failure after rate limits", models.ErrorToolFailure, rateLimited, true, true},
+		{"tool failure once", models.ErrorToolFailure, []models.ErrorCategory{models.ErrorToolFailure}, false, false},
+		{"unknown retries twice", models.ErrorUncategorized, []models.ErrorCategory{models.ErrorUncategorized}, true, false},
+		{"own failures capped by task", models.ErrorUncategorized,
+			[]models.ErrorCategory{models.ErrorToolFailure, models.ErrorAgentGaveUp, models.ErrorTimeout}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := rm.Decide(failedTask(3, tt.earlier...), &models.Failure{Category: tt.category})
+			if d.Retry != tt.retry || d.SwitchAgent != tt.switchTo {
+				t.Errorf("Decide() = %+v, want retry=%v switch=%v", d, tt.retry, tt.switchTo)
+			}
+		})
+	}
+
+	// Without attempt records every retry counts against the task
+	if d := rm.Decide(&models.Task{MaxRetries: 3, RetryCount: 3}, &models.Failure{Category: models.ErrorUncategorized}); d.Retry {
+		t.Errorf("Decide() without attempts = %+v, want no retry", d)
+	}
+
+	// A nil failure is treated as unknown
+	if d := rm.Decide(&models.Task{MaxRetries: 3}, nil); !d.Retry {
+		t.Errorf("Decide(nil) = %+v, want a retry", d)
+	}
+}
+
+func TestDecideBackoff(t *testing.T) {
+	rm := newTestManager(map[models.ErrorCategory]RetryPolicy{
+		models.ErrorNetwork: {MaxAttempts: 5, Backoff: BackoffExponential, InitialDelay: time.Second, MaxDelay: 5 * time.Second},
+		models.ErrorTimeout: {MaxAttempts: 5, Backoff: BackoffLinear, InitialDelay: 2 * time.Second},
+	})
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	var earlier []models.ErrorCategory
+	for retries, expected := range want {
+		task := failedTask(10, earlier...)
+		if d := rm.Decide(task, &models.Failure{Category: models.ErrorNetwork}); d.Delay != expected {
+			t.Errorf("exponential retry %d delay = %v, want %v", retries+1, d.Delay, expected)
+		}
+		earlier = append(earlier, models.ErrorNetwork)
+	}
+
+	// Only retries after timeouts move the timeout backoff along
+	task := failedTask(10, models.ErrorTimeout, models.ErrorNetwork, models.ErrorTimeout)
+	if d := rm.Decide(task, &models.Failure{Category: models.ErrorTimeout}); d.Delay != 6*time.Second {
+		t.Errorf("linear retry 3 delay = %v, want 6s", d.Delay)
+	}
+
+	// Retry-After from the provider is a floor
+	failure := &models.Failure{Category: models.ErrorNetwork, RetryAfterSeconds: 90}
+	if d := rm.Decide(&models.Task{MaxRetries: 10}, failure); d.Delay != 90*time.Second {
+		t.Errorf("retry-after delay = %v, want 90s", d.Delay)
+	}
+}
+
+func TestDecideJitter(t *testing.T) {
+	rm := newTestManager(map[models.ErrorCategory]RetryPolicy{
+		models.ErrorNetwork: {MaxAttempts: 1, Backoff: BackoffConstant, InitialDelay: 10 * time.Second, Jitter: 0.5},
+	})
+	task := &models.Task{MaxRetries: 3}
+
+	rm.random = func() float64 { return 0 }
+	if d := rm.Decide(task, &models.Failure{Category: models.ErrorNetwork}); d.Delay != 5*time.Second {
+		t.Errorf("min jitter delay = %v, want 5s", d.Delay)
+	}
+	rm.random = func() float64 { return 0.999999 }
+	if d := rm.Decide(task, &models.Failure{Category: models.ErrorNetwork}); d.Delay < 14*time.Second || d.Delay > 15*time.Second {
+		t.Errorf("max jitter delay = %v, want ~15s", d.Delay)
+	}
+}
+
+func TestLoadPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "retry.yaml")
+
+	policies, err := LoadPolicies(path)
+	if err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+	if policies[models.ErrorRateLimit].MaxAttempts != DefaultPolicies()[models.ErrorRateLimit].MaxAttempts {
+		t.Error("missing file should yield the defaults")
+	}
+
+	config := `categories:
+  compile_error:
+    max_attempts: 2
+    backoff: linear
+    initial_delay: 30s
+    switch_agent: true
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policies, err = LoadPolicies(path)
+	if err != nil {
+		t.Fatalf("LoadPolicies() error = %v", err)
+	}
+	got := policies[models.ErrorCompile]
+	if got.MaxAttempts != 2 || got.Backoff != BackoffLinear || got.InitialDelay != 30*time.Second || !got.SwitchAgent {
+		t.Errorf("compile_error policy = %+v", got)
+	}
+	if policies[models.ErrorAuth].MaxAttempts != 0 {
+		t.Error("unlisted categories should keep their defaults")
+	}
+
+	for _, bad := range []string{
+		"categories:\n  flaky: {max_attempts: 1}\n",
+		"categories:\n  network: {backoff: fibonacci}\n",
+		"categories:\n  network: {jitter: 2}\n",
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPolicies(path); err == nil {
+			t.Errorf("LoadPolicies(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package retry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+const (
+	// PromptOutputLines is how many lines of the previous attempt's output a
+	// retry prompt includes
+	PromptOutputLines = 40
+
+	// promptOutputBytes caps the output excerpt
+	promptOutputBytes = 4000
+)
+
+// BuildPrompt returns the prompt for the next attempt of task. The first
+// attempt gets the description and the task's definition of done; a retry
+// also gets what went wrong last time so a deterministic failure isn't
+// simply repeated. The orchestrator's hint is included in any attempt.
+func BuildPrompt(task *models.Task) string {
+	last := task.LastAttempt()
+	if last == nil || last.Failure == nil {
+		return hintPrompt(task, last)
+	}
+
+	var b strings.Builder
+	b.WriteString(describeTask(task))
+	fmt.Fprintf(&b, "\n\n---\nThis is attempt %d of this task. Attempt %d failed.\n", len(task.Attempts)+1, last.Number)
+
+	fmt.Fprintf(&b, "\nFailure category: %s\n", last.Failure.Category)
+	if last.Failure.Message != "" {
+		fmt.Fprintf(&b, "Error: %s\n", last.Failure.Message)
+	}
+	if loc := last.Failure.Location(); loc != "" {
+		fmt.Fprintf(&b, "Failing location: %s\n", loc)
+	}
+
+	for _, check := range last.Verification {
+		if check.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "Failed check: %s\n", check.Name)
+		if output := tailLines(check.Output, 10, 1000); output != "" {
+			fmt.Fprintf(&b, "```\n%s\n```\n", output)
+		}
+	}
+
+	if task.RetryHint != "" {
+		fmt.Fprintf(&b, "\nSuggestion for this attempt: %s\n", task.RetryHint)
+	}
+
+	if last.DiffStat != "" {
+		fmt.Fprintf(&b, "\nChanges the previous attempt left (git diff --stat against main):\n%s\n", last.DiffStat)
+	}
+
+	if output := tailLines(last.Output, PromptOutputLines, promptOutputBytes); output != "" {
+		fmt.Fprintf(&b, "\nLast lines of output from attempt %d:\n```\n%s\n```\n", last.Number, output)
+	}
+
+	b.WriteString("\nFind and fix the cause of this failure first instead of repeating the same approach.\n")
+	return b.String()
+}
+
+// hintPrompt returns the prompt for an attempt that follows no failure:
+// the description plus the review that sent the last attempt back or why
+// the orchestrator stopped it, and the orchestrator's hint, if any
+func hintPrompt(task *models.Task, last *models.Attempt) string {
+	interrupted := last != nil && last.Interrupted != ""
+	rework := last != nil && last.Review != nil && last.Review.SentBack
+	if !interrupted && !rework && task.RetryHint == "" {
+		return describeTask(task)
+	}
+
+	var b strings.Builder
+	b.WriteString(describeTask(task))
+	b.WriteString("\n\n---\n")
+	if interrupted {
+		fmt.Fprintf(&b, "This is attempt %d of this task. The orchestrator stopped attempt %d: %s\n",
+			len(task.Attempts)+1, last.Number, last.Interrupted)
+	}
+	if rework {
+		writeRework(&b, task, last)
+	}
+	if task.RetryHint != "" {
+		fmt.Fprintf(&b, "Suggestion from the orchestrator: %s\n", task.RetryHint)
+	}
+	return b.String()
+}
+
+// writeRework describes the review that sent the last attempt back
+func writeRework(b *strings.Builder, task *models.Task, last *models.Attempt) {
+	review := last.Review
+	fmt.Fprintf(b, "This is attempt %d of this task. A review of attempt %d scored it %d/100 and sent it back for rework.\n",
+		len(task.Attempts)+1, last.Number, review.Score)
+	fmt.Fprintln(b, "Your changes from that attempt are still in the worktree; build on them instead of starting over.")
+	if len(review.Issues) > 0 {
+		fmt.Fprintln(b, "\nIssues found by the review:")
+		for _, issue := range review.Issues {
+			fmt.Fprintf(b, "- %s\n", issue)
+		}
+	}
+	if review.Instructions != "" {
+		fmt.Fprintf(b, "\nRework instructions: %s\n", review.Instructions)
+	}
+}
+
+// describeTask returns the description followed by the task's file scope,
+// acceptance criteria and definition of done, if any
+func describeTask(task *models.Task) string {
+	var b strings.Builder
+	b.WriteString(task.Description)
+
+	if len(task.WritePaths) > 0 || len(task.ReadPaths) > 0 {
+		b.WriteString("\n\nScope:")
+		if len(task.WritePaths) > 0 {
+			fmt.Fprintf(&b, "\n- Change only files matching %s; other agents may be working on the rest of the repository",
+				strings.Join(task.WritePaths, ", "))
+		}
+		if len(task.ReadPaths) > 0 {
+			fmt.Fprintf(&b, "\n- Files to read first: %s", strings.Join(task.ReadPaths, ", "))
+		}
+	}
+
+	if len(task.AcceptanceCriteria) == 0 && !task.HasDefinitionOfDone() {
+		return b.String()
+	}
+	b.WriteString("\n\nDefinition of done:")
+	for _, criterion := range task.AcceptanceCriteria {
+		fmt.Fprintf(&b, "\n- %s", criterion)
+	}
+	for _, file := range task.RequiredFiles {
+		fmt.Fprintf(&b, "\n- %s exists", file)
+	}
+	for _, check := range task.Checks {
+		fmt.Fprintf(&b, "\n- `%s` passes in the repository root", check)
+	}
+	if len(task.ForbiddenPaths) > 0 {
+		fmt.Fprintf(&b, "\n- No changes to files matching %s", strings.Join(task.ForbiddenPaths, ", "))
+	}
+	if task.HasDefinitionOfDone() {
+		b.WriteString("\nFiles, commands and paths are checked automatically after you finish; the task fails if any of them does not pass.")
+	}
+	return b.String()
+}
+
+// tailLines returns at most n trailing lines of s, trimmed to maxBytes
+func tailLines(s string, n, maxBytes int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	tail := strings.Join(lines, "\n")
+	if len(tail) > maxBytes {
+		tail = tail[len(tail)-maxBytes:]
+		if i := strings.IndexByte(tail, '\n'); i >= 0 {
+			tail = tail[i+1:]
+		}
+	}
+	return strings.TrimSpace(tail)
+}
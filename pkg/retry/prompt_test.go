@@ -0,0 +1,182 @@
+package retry
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func TestBuildPromptFirstAttempt(t *testing.T) {
+	task := &models.Task{Description: "Add a /health endpoint"}
+	if got := BuildPrompt(task); got != task.Description {
+		t.Errorf("BuildPrompt() = %q, want the description", got)
+	}
+}
+
+func TestBuildPromptRetry(t *testing.T) {
+	var output strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&output, "line %d\n", i)
+	}
+
+	task := &models.Task{
+		Description: "Add a /health endpoint",
+		RetryHint:   "Register the route in router.go",
+		Attempts: []models.Attempt{{
+			Number:  1,
+			AgentID: "agent-0",
+			Failure: &models.Failure{
+				Category: models.ErrorCompile,
+				Message:  "Code does not compile: ./server.go:12:5: undefined: healthHandler",
+				File:     "./server.go",
+				Line:     12,
+			},
+			Output:   output.String(),
+			DiffStat: " server.go | 4 ++++\n 1 file changed, 4 insertions(+)",
+		}},
+	}
+
+	prompt := BuildPrompt(task)
+	for _, want := range []string{
+		"Add a /health endpoint",
+		"This is attempt 2",
+		"Failure category: compile_error",
+		"Failing location: ./server.go:12",
+		"Register the route in router.go",
+		"server.go | 4 ++++",
+		"line 100",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("BuildPrompt() is missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "line 60\n") {
+		t.Errorf("BuildPrompt() should only include the last %d output lines", PromptOutputLines)
+	}
+}
+
+func TestBuildPromptAfterSuccess(t *testing.T) {
+	task := &models.Task{
+		Description: "Bump the version",
+		Attempts:    []models.Attempt{{Number: 1, AgentID: "agent-0"}},
+	}
+	if got := BuildPrompt(task); got != task.Description {
+		t.Errorf("BuildPrompt() = %q, want the description", got)
+	}
+}
+
+func TestBuildPromptHint(t *testing.T) {
+	task := &models.Task{Description: "Add a /health endpoint", RetryHint: "Reuse the router in server.go"}
+	prompt := BuildPrompt(task)
+	if !strings.HasPrefix(prompt, task.Description) || !strings.Contains(prompt, "Suggestion from the orchestrator: Reuse the router in server.go") {
+		t.Errorf("BuildPrompt() of a first attempt with a hint = %q", prompt)
+	}
+
+	// An attempt stopped by the orchestrator is not a failure
+	task.Attempts = []models.Attempt{{Number: 1, AgentID: "agent-0", Interrupted: "agent restarted: no output for 10 minutes"}}
+	prompt = BuildPrompt(task)
+	for _, want := range []string{"This is attempt 2", "stopped attempt 1: agent restarted", "Reuse the router"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("BuildPrompt() is missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Find and fix the cause") {
+		t.Errorf("BuildPrompt() treats an interrupted attempt as a failure:\n%s", prompt)
+	}
+}
+
+func TestBuildPromptRework(t *testing.T) {
+	task := &models.Task{
+		Description:        "Add a /health endpoint",
+		AcceptanceCriteria: []string{"GET /health returns 200"},
+		Attempts: []models.Attempt{{
+			Number:  1,
+			AgentID: "agent-0",
+			Review: &models.Review{
+				Score:        55,
+				Issues:       []string{"No test for the handler"},
+				NeedsRework:  true,
+				Instructions: "Add a handler test",
+				SentBack:     true,
+			},
+		}},
+	}
+
+	prompt := BuildPrompt(task)
+	for _, want := range []string{
+		"This is attempt 2",
+		"scored it 55/100",
+		"still in the worktree",
+		"- GET /health returns 200",
+		"- No test for the handler",
+		"Rework instructions: Add a handler test",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("BuildPrompt() is missing %q:\n%s", want, prompt)
+		}
+	}
+
+	// An accepted review adds nothing to the first attempt's prompt
+	task.Attempts[0].Review.SentBack = false
+	first := BuildPrompt(&models.Task{Description: task.Description, AcceptanceCriteria: task.AcceptanceCriteria})
+	if got := BuildPrompt(task); got != first {
+		t.Errorf("BuildPrompt() after an accepted review = %q, want %q", got, first)
+	}
+}
+
+func TestBuildPromptDefinitionOfDone(t *testing.T) {
+	task := &models.Task{
+		Description:        "Add a /health endpoint",
+		AcceptanceCriteria: []string{"GET /health returns 200"},
+		RequiredFiles:      []string{"health.go"},
+		Checks:             []string{"go test ./..."},
+		ForbiddenPaths:     []string{"go.mod", "vendor/"},
+	}
+
+	prompt := BuildPrompt(task)
+	for _, want := range []string{
+		"Add a /health endpoint\n\nDefinition of done:\n",
+		"- GET /health returns 200\n",
+		"- health.go exists\n",
+		"- `go test ./...` passes",
+		"- No changes to files matching go.mod, vendor/\n",
+		"checked automatically",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("BuildPrompt() is missing %q:\n%s", want, prompt)
+		}
+	}
+
+	// Retries keep the definition of done ahead of the failure
+	task.Attempts = []models.Attempt{{
+		Number:       1,
+		Failure:      &models.Failure{Category: models.ErrorTestFailure, Message: "definition of done not met: go test ./..."},
+		Verification: []models.CheckResult{{Name: "go test ./...", Output: "--- FAIL: TestHealth"}},
+	}}
+	retry := BuildPrompt(task)
+	if !strings.HasPrefix(retry, prompt) || !strings.Contains(retry, "Failed check: go test ./...\n```\n--- FAIL: TestHealth") {
+		t.Errorf("retry prompt = %s", retry)
+	}
+}
+
+func TestBuildPromptScope(t *testing.T) {
+	task := &models.Task{
+		Description: "Add a /health endpoint",
+		WritePaths:  []string{"api/health.go", "api/health_test.go"},
+		ReadPaths:   []string{"api/router.go"},
+	}
+
+	want := "Add a /health endpoint\n\nScope:\n" +
+		"- Change only files matching api/health.go, api/health_test.go; other agents may be working on the rest of the repository\n" +
+		"- Files to read first: api/router.go"
+	if got := BuildPrompt(task); got != want {
+		t.Errorf("BuildPrompt() = %q, want %q", got, want)
+	}
+
+	task.Checks = []string{"go test ./api/..."}
+	if got := BuildPrompt(task); !strings.HasPrefix(got, want+"\n\nDefinition of done:\n") {
+		t.Errorf("BuildPrompt() = %q, want the scope before the definition of done", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"math/rand/v2"
 	"time"
 
 	"github.com/yourusername/claude-swarm/internal/models"
@@ -13,6 +14,7 @@ import (
 // RetryManager manages task retry logic
 type RetryManager struct {
 	config RetryConfig
+	random func() float64 // Source of jitter in [0, 1)
 }
 
 // RetryConfig contains retry configuration
@@ -21,6 +23,9 @@ type RetryConfig struct {
 	InitialDelay  time.Duration // Initial delay before first retry
 	MaxDelay      time.Duration // Maximum delay between retries
 	BackoffFactor float64       // Exponential backoff multiplier
+
+	// Per-category policies; missing categories use DefaultPolicies
+	Policies map[models.ErrorCategory]RetryPolicy
 }
 
 // DefaultRetryConfig returns default retry configuration
@@ -49,11 +54,99 @@ func NewRetryManager(config RetryConfig) *RetryManager {
 		config.BackoffFactor = 2.0
 	}
 
+	policies := DefaultPolicies()
+	for category, policy := range config.Policies {
+		policies[category] = policy
+	}
+	config.Policies = policies
+
 	return &RetryManager{
 		config: config,
+		random: rand.Float64,
+	}
+}
+
+// Policy returns the retry policy for a category
+func (rm *RetryManager) Policy(category models.ErrorCategory) RetryPolicy {
+	if policy, ok := rm.config.Policies[category]; ok {
+		return policy
+	}
+	return rm.config.Policies[models.ErrorUncategorized]
+}
+
+// Decide applies the policy of the failure's category to a task whose
+// attempt just failed. The failed attempt is the last of task.Attempts and
+// RetryCount must not yet include this failure.
+//
+// A category's MaxAttempts counts only the retries after failures of that
+// category. The task's MaxRetries caps the retries after failures of the
+// task's own work; rate-limit and network failures are the provider's and
+// are bounded by their policy alone, so an outage doesn't use up the task's
+// retries. A server-requested retry-after is used as the minimum delay.
+func (rm *RetryManager) Decide(task *models.Task, failure *models.Failure) Decision {
+	category := models.ErrorUncategorized
+	if failure != nil && failure.Category != "" {
+		category = failure.Category
+	}
+	policy := rm.Policy(category)
+	inCategory, own := retriesSoFar(task, category)
+
+	if task.MaxRetries > 0 && !providerCategories[category] && own >= task.MaxRetries {
+		return Decision{Reason: fmt.Sprintf("exceeded max retries (%d/%d)", own, task.MaxRetries)}
+	}
+	if inCategory >= policy.MaxAttempts {
+		if policy.MaxAttempts == 0 {
+			return Decision{Reason: fmt.Sprintf("%s failures are not retried", category)}
+		}
+		return Decision{Reason: fmt.Sprintf("%s policy allows %d attempt(s)", category, policy.MaxAttempts)}
+	}
+
+	attempt := inCategory + 1
+	delay := policy.delay(attempt, rm.config)
+	if policy.Jitter > 0 {
+		// Spread retries over delay * (1 ± jitter)
+		delay = time.Duration(float64(delay) * (1 + policy.Jitter*(2*rm.random()-1)))
+	}
+	if failure != nil && failure.RetryAfterSeconds > 0 {
+		if floor := time.Duration(failure.RetryAfterSeconds) * time.Second; delay < floor {
+			delay = floor
+		}
+	}
+
+	return Decision{
+		Retry:       true,
+		Delay:       delay,
+		SwitchAgent: policy.SwitchAgent,
+		Reason:      fmt.Sprintf("%s retry %d/%d", category, attempt, policy.MaxAttempts),
 	}
 }
 
+// retriesSoFar counts the task's retries after failures of category and
+// after failures of its own work. Only attempts since RetryCount was last
+// reset count; tasks without attempt records count every retry as their own.
+func retriesSoFar(task *models.Task, category models.ErrorCategory) (inCategory, own int) {
+	own = task.RetryCount
+	end := len(task.Attempts) - 1 // The attempt that just failed
+	start := max(end-task.RetryCount, 0)
+	for i := start; i < end; i++ {
+		failure := task.Attempts[i].Failure
+		if failure == nil {
+			continue
+		}
+		failed := failure.Category
+		if failed == "" {
+			failed = models.ErrorUncategorized
+		}
+		if failed == category {
+			inCategory++
+		}
+		if providerCategories[failed] {
+			own--
+		}
+	}
+	return inCategory, own
+}
+
 // ShouldRetry determines if a task should be retried based on error type and retry count
 func (rm *RetryManager) ShouldRetry(task *models.Task, errorDetails *analyzer.ErrorDetails) bool {
 	// Check if we've exceeded max retries
@@ -62,6 +155,15 @@ func (rm *RetryManager) ShouldRetry(task *models.Task, errorDetails *analyzer.Er
 		return false
 	}
 
+	if errorDetails == nil {
+		errorDetails = &analyzer.ErrorDetails{Type: analyzer.ErrorTypeUnknown, Message: "no error details"}
+	}
+	if errorDetails.Category != "" {
+		decision := rm.Decide(task, errorDetails.Failure())
+		log.Printf("[RETRY] Task %s: %s", task.ID, decision.Reason)
+		return decision.Retry
+	}
+
 	// Check error type
 	switch errorDetails.Type {
 	case analyzer.ErrorTypeRetryable:
@@ -111,6 +213,9 @@ func (rm *RetryManager) CalculateDelay(retryCount int) time.Duration {
 // RecordRetry records a retry attempt on a task
 func (rm *RetryManager) RecordRetry(task *models.Task, errorDetails *analyzer.ErrorDetails) {
 	task.RetryCount++
+	if errorDetails.Category != "" {
+		task.Failure = errorDetails.Failure()
+	}
 	task.LastError = fmt.Sprintf("[%s] %s (Context: %.200s)",
 		rm.errorTypeString(errorDetails.Type),
 		errorDetails.Message,
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/yourusername/claude-swarm/internal/models"
 )
@@ -83,11 +84,15 @@ func (ds *DAGScheduler) UpdateTask(task *models.Task) {
 // 1. Have status = pending
 // 2. Have no assignee
 // 3. All dependencies are completed
+// 4. Are not waiting for a scheduled retry
+// 5. May not change files a running task may change (declared write scopes overlap)
 func (ds *DAGScheduler) GetReadyTasks() []*models.Task {
 	ds.mu.RLock()
 	defer ds.mu.RUnlock()
 
 	var readyTasks []*models.Task
+	now := time.Now()
+	running := ds.runningWriteScopesUnlocked()
 
 	for _, task := range ds.tasks {
 		// Check if task is pending and unassigned
@@ -95,6 +100,16 @@ func (ds *DAGScheduler) GetReadyTasks() []*models.Task {
 			continue
 		}
 
+		// Check if a scheduled retry is due
+		if now.Before(task.NotBefore) {
+			continue
+		}
+
+		// Check if a running task may be editing the same files
+		if overlapsAny(task.WritePaths, running) {
+			continue
+		}
+
 		// Check if all dependencies are satisfied
 		if ds.areDependenciesSatisfiedUnlocked(task) {
 			readyTasks = append(readyTasks, task)
@@ -113,6 +128,27 @@ func (ds *DAGScheduler) GetReadyTasks() []*models.Task {
 	return readyTasks
 }
 
+// runningWriteScopesUnlocked returns the declared write scopes of in-progress tasks
+func (ds *DAGScheduler) runningWriteScopesUnlocked() [][]string {
+	var scopes [][]string
+	for _, task := range ds.tasks {
+		if task.Status == models.TaskStatusInProgress && len(task.WritePaths) > 0 {
+			scopes = append(scopes, task.WritePaths)
+		}
+	}
+	return scopes
+}
+
+// overlapsAny reports whether scope may overlap any of scopes; an undeclared scope overlaps nothing
+func overlapsAny(scope []string, scopes [][]string) bool {
+	for _, other := range scopes {
+		if models.PathsOverlap(scope, other) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetBlockedTasks returns tasks that are blocked by dependencies
 func (ds *DAGScheduler) GetBlockedTasks() []*models.Task {
 	ds.mu.RLock()
@@ -0,0 +1,304 @@
+// Package secrets detects credentials in agent output and diffs.
+//
+// The scanner combines known token formats (AWS, GitHub, Google/Gemini,
+// Anthropic, private keys, ...) with a Shannon-entropy check on values that
+// are assigned to secret-looking names. It is used both to redact log lines
+// and to block merges that would commit credentials into the main branch.
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedPlaceholder replaces every detected secret in redacted text
+const RedactedPlaceholder = "[REDACTED]"
+
+// Rule describes a known secret format
+type Rule struct {
+	ID      string
+	Pattern *regexp.Regexp
+	// Group selects the capture group holding the secret (0 = whole match)
+	Group int
+}
+
+// Finding is a single detected secret
+type Finding struct {
+	RuleID  string  `json:"rule_id"`
+	File    string  `json:"file,omitempty"`
+	Line    int     `json:"line,omitempty"`
+	Match   string  `json:"match"`             // Masked preview, never the raw secret
+	Entropy float64 `json:"entropy,omitempty"` // Only set for entropy findings
+}
+
+// DefaultRules returns the built-in secret formats
+func DefaultRules() []Rule {
+	return []Rule{
+		{ID: "aws-access-key-id", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA)[0-9A-Z]{16}\b`)},
+		{ID: "aws-secret-access-key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`), Group: 1},
+		{ID: "github-token", Pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,255}\b`)},
+		{ID: "github-fine-grained-pat", Pattern: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{82}\b`)},
+		{ID: "google-api-key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z\-_]{35}\b`)},
+		{ID: "anthropic-api-key", Pattern: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9\-_]{20,}`)},
+		{ID: "openai-api-key", Pattern: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9]{32,}\b`)},
+		{ID: "slack-token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+		// The whole BEGIN...END block, or everything after BEGIN when END is missing
+		{ID: "private-key", Pattern: regexp.MustCompile(`(?s)-----BEGIN (?:[A-Z]+ )*PRIVATE KEY(?: BLOCK)?-----(?:.*?-----END (?:[A-Z]+ )*PRIVATE KEY(?: BLOCK)?-----|.*)`)},
+	}
+}
+
+// assignmentPattern matches `name = value` pairs where name looks like a credential
+var assignmentPattern = regexp.MustCompile(
+	`(?i)(?:secret|token|passw(?:or)?d|api[_-]?key|access[_-]?key|auth|credential)[A-Za-z0-9_\-]*["']?\s*[:=]\s*["']?([A-Za-z0-9/+=_\-\.]{16,})`)
+
+// Scanner finds secrets in text and unified diffs
+type Scanner struct {
+	rules            []Rule
+	entropyThreshold float64
+	minEntropyLength int
+
+	mu       sync.RWMutex
+	literals map[string]string // Exact values to redact -> rule ID
+}
+
+// NewScanner creates a scanner with the default rules
+func NewScanner() *Scanner {
+	return &Scanner{
+		rules:            DefaultRules(),
+		entropyThreshold: 4.0,
+		minEntropyLength: 20,
+		literals:         make(map[string]string),
+	}
+}
+
+// AddLiteral registers an exact value (e.g. a resolved secret) that must always be redacted
+func (s *Scanner) AddLiteral(ruleID, value string) {
+	// Very short values would redact ordinary words
+	if len(value) < 6 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.literals[value] = ruleID
+}
+
+// ScanText returns all secrets found in text. Line numbers are 1-based.
+func (s *Scanner) ScanText(text string) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(text, "\n") {
+		for _, f := range s.scanLine(line) {
+			f.Line = i + 1
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// ScanDiff scans only the added lines of a unified diff (git diff output)
+func (s *Scanner) ScanDiff(diff string) []Finding {
+	var findings []Finding
+	currentFile := ""
+	newLine := 0
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			currentFile = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			continue
+		case strings.HasPrefix(line, "--- "):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			newLine = parseHunkStart(line)
+			continue
+		case strings.HasPrefix(line, "+"):
+			for _, f := range s.scanLine(line[1:]) {
+				f.File = currentFile
+				f.Line = newLine
+				findings = append(findings, f)
+			}
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			// Removed lines don't advance the new-file counter
+		default:
+			newLine++
+		}
+	}
+
+	return findings
+}
+
+// Redact replaces every detected secret in text with RedactedPlaceholder
+func (s *Scanner) Redact(text string) string {
+	for _, rule := range s.rules {
+		if rule.Group == 0 {
+			text = rule.Pattern.ReplaceAllString(text, RedactedPlaceholder)
+			continue
+		}
+		text = replaceGroup(rule.Pattern, text, rule.Group)
+	}
+
+	// High-entropy assignments
+	text = assignmentPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := assignmentPattern.FindStringSubmatch(m)
+		if len(sub) < 2 || !s.isHighEntropy(sub[1]) {
+			return m
+		}
+		return strings.Replace(m, sub[1], RedactedPlaceholder, 1)
+	})
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for value := range s.literals {
+		text = strings.ReplaceAll(text, value, RedactedPlaceholder)
+	}
+
+	return text
+}
+
+// scanLine returns the findings in a single line
+func (s *Scanner) scanLine(line string) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool)
+
+	for _, rule := range s.rules {
+		for _, m := range rule.Pattern.FindAllStringSubmatch(line, -1) {
+			value := m[0]
+			if rule.Group > 0 && rule.Group < len(m) {
+				value = m[rule.Group]
+			}
+			if seen[value] {
+				continue
+			}
+			seen[value] = true
+			findings = append(findings, Finding{RuleID: rule.ID, Match: mask(value)})
+		}
+	}
+
+	for _, m := range assignmentPattern.FindAllStringSubmatch(line, -1) {
+		value := m[1]
+		if seen[value] || !s.isHighEntropy(value) {
+			continue
+		}
+		seen[value] = true
+		findings = append(findings, Finding{
+			RuleID:  "high-entropy-assignment",
+			Match:   mask(value),
+			Entropy: shannonEntropy(value),
+		})
+	}
+
+	s.mu.RLock()
+	for value, ruleID := range s.literals {
+		if !seen[value] && strings.Contains(line, value) {
+			seen[value] = true
+			findings = append(findings, Finding{RuleID: ruleID, Match: mask(value)})
+		}
+	}
+	s.mu.RUnlock()
+
+	return findings
+}
+
+// isHighEntropy reports whether value looks like a random credential
+func (s *Scanner) isHighEntropy(value string) bool {
+	if len(value) < s.minEntropyLength {
+		return false
+	}
+	return shannonEntropy(value) >= s.entropyThreshold
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	length := float64(len([]rune(s)))
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// mask keeps a short prefix so findings can be identified without leaking the value
+func mask(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", 8) + fmt.Sprintf("(%d chars)", len(value))
+}
+
+// replaceGroup redacts only the given capture group of each match
+func replaceGroup(re *regexp.Regexp, text string, group int) string {
+	return re.ReplaceAllStringFunc(text, func(m string) string {
+		sub := re.FindStringSubmatch(m)
+		if len(sub) <= group || sub[group] == "" {
+			return m
+		}
+		return strings.Replace(m, sub[group], RedactedPlaceholder, 1)
+	})
+}
+
+// parseHunkStart extracts the new-file start line from "@@ -a,b +c,d @@"
+func parseHunkStart(header string) int {
+	plus := strings.Index(header, "+")
+	if plus < 0 {
+		return 0
+	}
+	start := 0
+	for _, r := range header[plus+1:] {
+		if r < '0' || r > '9' {
+			break
+		}
+		start = start*10 + int(r-'0')
+	}
+	return start
+}
+
+// FormatReport renders findings as a human-readable report
+func FormatReport(findings []Finding) string {
+	if len(findings) == 0 {
+		return "no secrets detected"
+	}
+
+	sorted := make([]Finding, len(findings))
+	copy(sorted, findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d potential secret(s) detected:\n", len(sorted))
+	for _, f := range sorted {
+		location := f.File
+		if location == "" {
+			location = "<output>"
+		}
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, f.Line)
+		}
+		fmt.Fprintf(&b, "  - %s [%s] %s\n", location, f.RuleID, f.Match)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// LeakError is returned when a diff would commit secrets
+type LeakError struct {
+	Findings []Finding
+}
+
+func (e *LeakError) Error() string {
+	return "secret scan blocked merge: " + FormatReport(e.Findings)
+}
@@ -0,0 +1,141 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RefPrefix marks an environment value as a reference to a named secret,
+// e.g. NPM_TOKEN=secret:npm
+const RefPrefix = "secret:"
+
+// KeyringService is the service name secrets are stored under in the OS keyring
+const KeyringService = "claude-swarm"
+
+// DefaultStorePath returns the secrets file used when SWARM_SECRETS_FILE is unset
+func DefaultStorePath() string {
+	if path := os.Getenv("SWARM_SECRETS_FILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude-swarm", "secrets.yaml")
+}
+
+// Store resolves named secrets from a local YAML file (name: value) and
+// falls back to the OS keyring. Every resolved value is registered with
+// the scanner so it is redacted from captured output.
+type Store struct {
+	path    string
+	scanner *Scanner
+	keyring func(name string) (string, error)
+
+	mu     sync.Mutex
+	loaded bool
+	file   map[string]string
+}
+
+// NewStore creates a store backed by the secrets file at path. The file is
+// optional; a missing file leaves only the keyring.
+func NewStore(path string, scanner *Scanner) *Store {
+	if scanner == nil {
+		scanner = Default()
+	}
+	return &Store{path: path, scanner: scanner, keyring: keyringLookup}
+}
+
+// Resolve returns the value of the named secret
+func (s *Store) Resolve(name string) (string, error) {
+	if err := s.load(); err != nil {
+		return "", err
+	}
+
+	value, ok := s.file[name]
+	if !ok {
+		var err error
+		value, err = s.keyring(name)
+		if err != nil {
+			return "", fmt.Errorf("secret %q not found in %s or the OS keyring: %w", name, s.path, err)
+		}
+	}
+
+	s.scanner.AddLiteral("secret-ref", value)
+	return value, nil
+}
+
+// ResolveValue resolves value if it is a secret reference and returns it
+// unchanged otherwise
+func (s *Store) ResolveValue(value string) (string, error) {
+	if !strings.HasPrefix(value, RefPrefix) {
+		return value, nil
+	}
+	return s.Resolve(strings.TrimPrefix(value, RefPrefix))
+}
+
+// load reads the secrets file once. Files readable by others are refused.
+func (s *Store) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loaded {
+		return nil
+	}
+
+	s.file = make(map[string]string)
+	if s.path != "" {
+		info, err := os.Stat(s.path)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return fmt.Errorf("failed to stat secrets file: %w", err)
+		case runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0:
+			return fmt.Errorf("secrets file %s is accessible by other users (mode %o), run: chmod 600 %s",
+				s.path, info.Mode().Perm(), s.path)
+		default:
+			data, err := os.ReadFile(s.path)
+			if err != nil {
+				return fmt.Errorf("failed to read secrets file: %w", err)
+			}
+			if err := yaml.Unmarshal(data, &s.file); err != nil {
+				return fmt.Errorf("failed to parse secrets file %s: %w", s.path, err)
+			}
+		}
+	}
+
+	s.loaded = true
+	return nil
+}
+
+// keyringLookup reads a secret from the OS keyring: libsecret's secret-tool
+// on Linux, the login keychain on macOS
+func keyringLookup(name string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", KeyringService, "account", name)
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", KeyringService, "-a", name, "-w")
+	default:
+		return "", fmt.Errorf("no keyring support on %s", runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("keyring lookup failed: %w", err)
+	}
+	value := string(bytes.TrimRight(out, "\r\n"))
+	if value == "" {
+		return "", fmt.Errorf("keyring has no entry")
+	}
+	return value, nil
+}
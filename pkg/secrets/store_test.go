@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoreResolvesFileAndKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	os.WriteFile(path, []byte("db: hunter2-staging\n"), 0600)
+
+	scanner := NewScanner()
+	store := NewStore(path, scanner)
+	store.keyring = func(name string) (string, error) {
+		if name == "npm" {
+			return "npm_keyring_value", nil
+		}
+		return "", errors.New("no entry")
+	}
+
+	if v, err := store.Resolve("db"); err != nil || v != "hunter2-staging" {
+		t.Errorf("Resolve(db) = %q, %v", v, err)
+	}
+	if v, err := store.ResolveValue("secret:npm"); err != nil || v != "npm_keyring_value" {
+		t.Errorf("ResolveValue(secret:npm) = %q, %v", v, err)
+	}
+	if v, _ := store.ResolveValue("plain"); v != "plain" {
+		t.Errorf("plain values should pass through, got %q", v)
+	}
+	if _, err := store.Resolve("missing"); err == nil {
+		t.Error("Resolve() of an unknown secret should fail")
+	}
+
+	// Resolved values are redacted from captured output
+	out := scanner.Redact("connecting with hunter2-staging and npm_keyring_value")
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "npm_keyring") {
+		t.Errorf("resolved secrets not redacted: %s", out)
+	}
+}
+
+func TestStoreRefusesWorldReadableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	os.WriteFile(path, []byte("db: value\n"), 0644)
+
+	store := NewStore(path, NewScanner())
+	if _, err := store.Resolve("db"); err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("expected a permission error, got %v", err)
+	}
+}
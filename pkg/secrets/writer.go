@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"io"
+	"sync"
+)
+
+var (
+	defaultScanner     *Scanner
+	defaultScannerOnce sync.Once
+)
+
+// Default returns the process-wide scanner shared by log redaction and merge checks
+func Default() *Scanner {
+	defaultScannerOnce.Do(func() {
+		defaultScanner = NewScanner()
+	})
+	return defaultScanner
+}
+
+// RedactingWriter redacts secrets from everything written through it.
+// It is meant to wrap log output, where each Write is one complete line.
+type RedactingWriter struct {
+	out     io.Writer
+	scanner *Scanner
+	mu      sync.Mutex
+}
+
+// NewRedactingWriter wraps out so that secrets never reach it
+func NewRedactingWriter(out io.Writer, scanner *Scanner) *RedactingWriter {
+	if scanner == nil {
+		scanner = Default()
+	}
+	return &RedactingWriter{out: out, scanner: scanner}
+}
+
+// Write redacts p and forwards it. It reports len(p) on success so callers
+// such as the log package don't treat the changed length as a short write.
+func (w *RedactingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	redacted := w.scanner.Redact(string(p))
+	if _, err := io.WriteString(w.out, redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
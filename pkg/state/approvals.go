@@ -0,0 +1,304 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// ApprovalQueue stores decisions escalated to a human in a JSON file.
+// It lives next to the task queue so every command that knows the queue
+// path (start, monitor, approvals) finds the same file.
+type ApprovalQueue struct {
+	filePath  string
+	mu        sync.Mutex
+	lockFile  *os.File
+	approvals map[string]*models.Approval
+}
+
+type approvalFile struct {
+	Approvals []*models.Approval `json:"approvals"`
+}
+
+// ApprovalsPathFor returns the approval queue path belonging to a task queue file
+func ApprovalsPathFor(taskQueuePath string) string {
+	return filepath.Join(filepath.Dir(taskQueuePath), "approvals.json")
+}
+
+// NewApprovalQueue opens or creates the approval queue at filePath
+func NewApprovalQueue(filePath string) (*ApprovalQueue, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("filePath cannot be empty")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	lockFile, err := os.OpenFile(filePath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+
+	aq := &ApprovalQueue{
+		filePath:  filePath,
+		lockFile:  lockFile,
+		approvals: make(map[string]*models.Approval),
+	}
+
+	if err := aq.load(); err != nil {
+		if os.IsNotExist(err) {
+			if err := aq.save(); err != nil {
+				lockFile.Close()
+				return nil, err
+			}
+		} else {
+			lockFile.Close()
+			return nil, err
+		}
+	}
+
+	return aq, nil
+}
+
+// Close releases the file lock
+func (aq *ApprovalQueue) Close() error {
+	if aq.lockFile != nil {
+		return aq.lockFile.Close()
+	}
+	return nil
+}
+
+// Request adds a pending approval. If an identical request (same kind,
+// task and branch) is already pending, the existing one is returned instead.
+func (aq *ApprovalQueue) Request(approval *models.Approval) (*models.Approval, error) {
+	result := approval
+	err := aq.update(func(approvals map[string]*models.Approval) error {
+		for _, existing := range approvals {
+			if existing.Status == models.ApprovalStatusPending &&
+				existing.Kind == approval.Kind &&
+				existing.TaskID == approval.TaskID &&
+				existing.Branch == approval.Branch {
+				result = existing
+				return nil
+			}
+		}
+
+		if approval.ID == "" {
+			approval.ID = fmt.Sprintf("apr-%d", time.Now().UnixNano())
+		}
+		if approval.CreatedAt.IsZero() {
+			approval.CreatedAt = time.Now()
+		}
+		approval.Status = models.ApprovalStatusPending
+		approval.Applied = false
+
+		approvals[approval.ID] = approval
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Resolve approves or rejects a pending approval
+func (aq *ApprovalQueue) Resolve(id string, approve bool, note, resolvedBy string) (*models.Approval, error) {
+	var approval *models.Approval
+	err := aq.update(func(approvals map[string]*models.Approval) error {
+		var exists bool
+		approval, exists = approvals[id]
+		if !exists {
+			return fmt.Errorf("approval not found: %s", id)
+		}
+		if approval.Status != models.ApprovalStatusPending {
+			return fmt.Errorf("approval %s is already %s", id, approval.Status)
+		}
+
+		now := time.Now()
+		if approve {
+			approval.Status = models.ApprovalStatusApproved
+		} else {
+			approval.Status = models.ApprovalStatusRejected
+		}
+		approval.Note = note
+		approval.ResolvedBy = resolvedBy
+		approval.ResolvedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// MarkApplied records that the swarm has acted on a resolved approval
+func (aq *ApprovalQueue) MarkApplied(id string) error {
+	return aq.update(func(approvals map[string]*models.Approval) error {
+		approval, exists := approvals[id]
+		if !exists {
+			return fmt.Errorf("approval not found: %s", id)
+		}
+		approval.Applied = true
+		return nil
+	})
+}
+
+// Get returns an approval by ID
+func (aq *ApprovalQueue) Get(id string) (*models.Approval, error) {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+
+	_ = aq.load()
+
+	approval, exists := aq.approvals[id]
+	if !exists {
+		return nil, fmt.Errorf("approval not found: %s", id)
+	}
+	return approval, nil
+}
+
+// List returns approvals with the given status (all if status is empty), oldest first
+func (aq *ApprovalQueue) List(status models.ApprovalStatus) []*models.Approval {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+
+	_ = aq.load()
+
+	result := make([]*models.Approval, 0, len(aq.approvals))
+	for _, approval := range aq.approvals {
+		if status == "" || approval.Status == status {
+			result = append(result, approval)
+		}
+	}
+	sortApprovals(result)
+	return result
+}
+
+// ListUnapplied returns resolved approvals of the given kind that have not been acted on yet
+func (aq *ApprovalQueue) ListUnapplied(kind models.ApprovalKind) []*models.Approval {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+
+	_ = aq.load()
+
+	var result []*models.Approval
+	for _, approval := range aq.approvals {
+		if approval.Kind == kind && approval.Status != models.ApprovalStatusPending && !approval.Applied {
+			result = append(result, approval)
+		}
+	}
+	sortApprovals(result)
+	return result
+}
+
+// PendingForTask returns the pending approval for a task, if any
+func (aq *ApprovalQueue) PendingForTask(taskID string) *models.Approval {
+	for _, approval := range aq.List(models.ApprovalStatusPending) {
+		if approval.TaskID == taskID {
+			return approval
+		}
+	}
+	return nil
+}
+
+// sortApprovals sorts approvals by creation time (oldest first)
+func sortApprovals(approvals []*models.Approval) {
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.Before(approvals[j].CreatedAt)
+	})
+}
+
+// update applies fn to the approvals read from the file and writes them
+// back. The exclusive lock is held from the read to the write, so a change
+// made by another process in between can't be lost.
+func (aq *ApprovalQueue) update(fn func(approvals map[string]*models.Approval) error) error {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+
+	if err := syscall.Flock(int(aq.lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to acquire write lock: %w", err)
+	}
+	defer syscall.Flock(int(aq.lockFile.Fd()), syscall.LOCK_UN)
+
+	if err := aq.read(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := fn(aq.approvals); err != nil {
+		return err
+	}
+	return aq.write()
+}
+
+// load loads approvals from the JSON file
+func (aq *ApprovalQueue) load() error {
+	if err := syscall.Flock(int(aq.lockFile.Fd()), syscall.LOCK_SH); err != nil {
+		return fmt.Errorf("failed to acquire read lock: %w", err)
+	}
+	defer syscall.Flock(int(aq.lockFile.Fd()), syscall.LOCK_UN)
+
+	return aq.read()
+}
+
+// read parses the JSON file; the caller holds the file lock
+func (aq *ApprovalQueue) read() error {
+	data, err := os.ReadFile(aq.filePath)
+	if err != nil {
+		return err
+	}
+
+	var af approvalFile
+	if err := json.Unmarshal(data, &af); err != nil {
+		return fmt.Errorf("failed to unmarshal approvals: %w", err)
+	}
+
+	aq.approvals = make(map[string]*models.Approval)
+	for _, approval := range af.Approvals {
+		aq.approvals[approval.ID] = approval
+	}
+
+	return nil
+}
+
+// save saves approvals to the JSON file using atomic write
+func (aq *ApprovalQueue) save() error {
+	if err := syscall.Flock(int(aq.lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to acquire write lock: %w", err)
+	}
+	defer syscall.Flock(int(aq.lockFile.Fd()), syscall.LOCK_UN)
+
+	return aq.write()
+}
+
+// write atomically replaces the JSON file; the caller holds the exclusive lock
+func (aq *ApprovalQueue) write() error {
+	approvals := make([]*models.Approval, 0, len(aq.approvals))
+	for _, approval := range aq.approvals {
+		approvals = append(approvals, approval)
+	}
+	sortApprovals(approvals)
+
+	data, err := json.MarshalIndent(approvalFile{Approvals: approvals}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal approvals: %w", err)
+	}
+
+	tmpFile := aq.filePath + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, aq.filePath); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+func TestApprovalQueue_RequestAndResolve(t *testing.T) {
+	tmpDir := t.TempDir()
+	aq, err := NewApprovalQueue(filepath.Join(tmpDir, "approvals.json"))
+	if err != nil {
+		t.Fatalf("Failed to create approval queue: %v", err)
+	}
+	defer aq.Close()
+
+	approval, err := aq.Request(&models.Approval{
+		Kind:        models.ApprovalKindTask,
+		TaskID:      "task-1",
+		Title:       "CRITICAL risk",
+		RequestedBy: "test",
+	})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if approval.Status != models.ApprovalStatusPending {
+		t.Errorf("Expected pending, got %s", approval.Status)
+	}
+
+	// Same request again should be de-duplicated
+	dup, err := aq.Request(&models.Approval{Kind: models.ApprovalKindTask, TaskID: "task-1"})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if dup.ID != approval.ID {
+		t.Errorf("Expected duplicate request to return %s, got %s", approval.ID, dup.ID)
+	}
+
+	if got := aq.PendingForTask("task-1"); got == nil || got.ID != approval.ID {
+		t.Errorf("PendingForTask() = %v, want %s", got, approval.ID)
+	}
+
+	resolved, err := aq.Resolve(approval.ID, true, "looks fine", "alice")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if resolved.Status != models.ApprovalStatusApproved || resolved.Note != "looks fine" {
+		t.Errorf("Unexpected resolution: %+v", resolved)
+	}
+
+	if _, err := aq.Resolve(approval.ID, false, "", "bob"); err == nil {
+		t.Error("Resolving twice should fail")
+	}
+
+	unapplied := aq.ListUnapplied(models.ApprovalKindTask)
+	if len(unapplied) != 1 {
+		t.Fatalf("Expected 1 unapplied approval, got %d", len(unapplied))
+	}
+
+	if err := aq.MarkApplied(approval.ID); err != nil {
+		t.Fatalf("MarkApplied() error = %v", err)
+	}
+	if len(aq.ListUnapplied(models.ApprovalKindTask)) != 0 {
+		t.Error("Expected no unapplied approvals after MarkApplied")
+	}
+}
+
+func TestApprovalQueue_SharedAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.json")
+
+	writer, err := NewApprovalQueue(path)
+	if err != nil {
+		t.Fatalf("Failed to create approval queue: %v", err)
+	}
+	defer writer.Close()
+
+	reader, err := NewApprovalQueue(path)
+	if err != nil {
+		t.Fatalf("Failed to create approval queue: %v", err)
+	}
+	defer reader.Close()
+
+	approval, err := writer.Request(&models.Approval{Kind: models.ApprovalKindMerge, Branch: "agent-0-branch"})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	// A separate process (e.g. `swarm approvals approve`) resolves it
+	if _, err := reader.Resolve(approval.ID, false, "contains a key", "cli"); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	got, err := writer.Get(approval.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != models.ApprovalStatusRejected {
+		t.Errorf("Expected rejected, got %s", got.Status)
+	}
+}
+
+func TestApprovalQueue_ConcurrentWritersKeepEveryRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.json")
+
+	// Two instances stand in for the coordinator and `swarm approvals`
+	var queues []*ApprovalQueue
+	for i := 0; i < 2; i++ {
+		aq, err := NewApprovalQueue(path)
+		if err != nil {
+			t.Fatalf("Failed to create approval queue: %v", err)
+		}
+		defer aq.Close()
+		queues = append(queues, aq)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("apr-%d", i)
+			if _, err := queues[i%2].Request(&models.Approval{ID: id, Kind: models.ApprovalKindTask, TaskID: id}); err != nil {
+				t.Errorf("Request() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(queues[0].List(models.ApprovalStatusPending)); got != 40 {
+		t.Errorf("Expected 40 pending approvals, got %d", got)
+	}
+}
+
+func TestApprovalsPathFor(t *testing.T) {
+	got := ApprovalsPathFor("/home/u/.claude-swarm/tasks.json")
+	if got != "/home/u/.claude-swarm/approvals.json" {
+		t.Errorf("ApprovalsPathFor() = %s", got)
+	}
+}
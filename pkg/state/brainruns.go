@@ -0,0 +1,84 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BrainRunsDirFor returns the directory of saved brain runs belonging to a task queue file
+func BrainRunsDirFor(taskQueuePath string) string {
+	return filepath.Join(filepath.Dir(taskQueuePath), "runs")
+}
+
+// NewBrainRunID returns an unused run ID in dir. IDs embed the start time,
+// so sorting them by name sorts runs by age.
+func NewBrainRunID(dir string) string {
+	base := time.Now().Format("run-20060102-150405")
+	id := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, id+".json")); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// SaveBrainRun atomically writes the brain context v of run id to dir
+func SaveBrainRun(dir, id string, v any) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create runs directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal brain run: %w", err)
+	}
+
+	path := filepath.Join(dir, id+".json")
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
+
+// LoadBrainRun reads the saved brain context of run id into v
+func LoadBrainRun(dir, id string, v any) error {
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal brain run %s: %w", id, err)
+	}
+	return nil
+}
+
+// ListBrainRuns returns the IDs of the runs saved in dir, oldest first
+func ListBrainRuns(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if id, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,49 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBrainRuns(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "runs")
+
+	if ids, err := ListBrainRuns(dir); err != nil || len(ids) != 0 {
+		t.Fatalf("ListBrainRuns() on a missing directory = %v, %v", ids, err)
+	}
+
+	first := NewBrainRunID(dir)
+	if err := SaveBrainRun(dir, first, map[string]string{"requirement": "add login"}); err != nil {
+		t.Fatalf("SaveBrainRun() error = %v", err)
+	}
+	// A run started within the same second gets its own ID
+	second := NewBrainRunID(dir)
+	if second == first {
+		t.Fatalf("NewBrainRunID() reused %s", first)
+	}
+	if err := SaveBrainRun(dir, second, map[string]string{"requirement": "add logout"}); err != nil {
+		t.Fatalf("SaveBrainRun() error = %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644)
+
+	ids, err := ListBrainRuns(dir)
+	if err != nil || !slices.Equal(ids, []string{first, second}) {
+		t.Fatalf("ListBrainRuns() = %v, %v, want [%s %s]", ids, err, first, second)
+	}
+
+	var run map[string]string
+	if err := LoadBrainRun(dir, first, &run); err != nil || run["requirement"] != "add login" {
+		t.Errorf("LoadBrainRun() = %v, %v", run, err)
+	}
+	if err := LoadBrainRun(dir, "run-missing", &run); !os.IsNotExist(err) {
+		t.Errorf("LoadBrainRun() of a missing run error = %v", err)
+	}
+}
+
+func TestBrainRunsDirFor(t *testing.T) {
+	if got := BrainRunsDirFor("/home/u/.claude-swarm/tasks.json"); got != "/home/u/.claude-swarm/runs" {
+		t.Errorf("BrainRunsDirFor() = %s", got)
+	}
+}
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ControlTokenPathFor returns the control API token path belonging to a task queue file
+func ControlTokenPathFor(taskQueuePath string) string {
+	return filepath.Join(filepath.Dir(taskQueuePath), "control.token")
+}
+
+// NewControlToken generates a fresh bearer token for the control API and
+// writes it to path, readable by the current user only. Each coordinator
+// run gets its own token, so a token leaked from an earlier run is useless.
+func NewControlToken(path string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate control token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write a new file instead of truncating an existing one, whose mode
+	// (or owner) could have been loosened
+	tmpFile := path + ".tmp"
+	os.Remove(tmpFile)
+	f, err := os.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := f.WriteString(token + "\n"); err != nil {
+		f.Close()
+		os.Remove(tmpFile)
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpFile)
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return "", fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return token, nil
+}
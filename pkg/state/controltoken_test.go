@@ -0,0 +1,43 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewControlToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swarm", "control.token")
+
+	first, err := NewControlToken(path)
+	if err != nil {
+		t.Fatalf("NewControlToken() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.TrimSpace(string(data)) != first || len(first) != 64 {
+		t.Errorf("token file = %q, token = %q", data, first)
+	}
+
+	// A loosened file is replaced, not reused
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewControlToken(path)
+	if err != nil {
+		t.Fatalf("NewControlToken() error = %v", err)
+	}
+	if second == first {
+		t.Error("every run should get a new token")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
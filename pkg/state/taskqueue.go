@@ -13,6 +13,11 @@ import (
 	"github.com/yourusername/claude-swarm/pkg/scheduler"
 )
 
+// AvoidAgentGrace is how long a retry waits for another agent before the
+// agent it avoids may claim it again, and how long a task assigned to an
+// agent waits for it before any agent may claim it
+const AvoidAgentGrace = 2 * time.Minute
+
 // TaskQueue manages tasks using a JSON file
 type TaskQueue struct {
 	filePath  string
@@ -148,12 +153,33 @@ func (tq *TaskQueue) ClaimTask(agentID string) (*models.Task, error) {
 		return nil, nil // No ready tasks
 	}
 
-	// Select the first ready task (highest priority)
-	selectedTask := readyTasks[0]
+	// Select a task assigned to this agent, else the first ready task
+	// (highest priority) that isn't waiting for another agent
+	var selectedTask *models.Task
+	for _, task := range readyTasks {
+		if task.PreferAgent == agentID {
+			selectedTask = task
+			break
+		}
+	}
+	if selectedTask == nil {
+		for _, task := range readyTasks {
+			if !waitsForOtherAgent(task, agentID) {
+				selectedTask = task
+				break
+			}
+		}
+	}
+	if selectedTask == nil {
+		return nil, nil // Only tasks waiting for another agent
+	}
 
 	// Claim the task
 	selectedTask.Status = models.TaskStatusInProgress
 	selectedTask.AssigneeID = agentID
+	selectedTask.NotBefore = time.Time{}
+	selectedTask.AvoidAgent = ""
+	selectedTask.PreferAgent = ""
 	selectedTask.UpdatedAt = time.Now()
 
 	// Update in scheduler
@@ -166,6 +192,15 @@ func (tq *TaskQueue) ClaimTask(agentID string) (*models.Task, error) {
 	return selectedTask, nil
 }
 
+// waitsForOtherAgent reports whether task is still within its grace period
+// for an agent other than agentID: it avoids agentID or prefers another agent
+func waitsForOtherAgent(task *models.Task, agentID string) bool {
+	if time.Since(task.NotBefore) > AvoidAgentGrace {
+		return false
+	}
+	return task.AvoidAgent == agentID || (task.PreferAgent != "" && task.PreferAgent != agentID)
+}
+
 // UpdateTaskStatus updates the status of a task
 func (tq *TaskQueue) UpdateTaskStatus(taskID string, status models.TaskStatus) error {
 	tq.mu.Lock()
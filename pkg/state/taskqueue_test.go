@@ -1,6 +1,7 @@
 package state
 
 import (
+	"fmt"
 	"path/filepath"
 	"testing"
 	"time"
@@ -295,3 +296,142 @@ func TestTaskQueue_RemoveTaskNotFound(t *testing.T) {
 		t.Error("Expected error when removing nonexistent task, got nil")
 	}
 }
+
+func TestTaskQueue_ScheduledRetry(t *testing.T) {
+	tmpDir := t.TempDir()
+	taskFile := filepath.Join(tmpDir, "tasks.json")
+
+	tq, err := NewTaskQueue(taskFile)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+
+	// 失败后安排重试：一小时内不可领取
+	task := &models.Task{ID: "retry-1", Description: "Flaky task", Status: models.TaskStatusPending}
+	if err := tq.AddTask(task); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+	task.RetryCount = 1
+	task.NotBefore = time.Now().Add(time.Hour)
+	if err := tq.UpdateTask(task); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+	tq.Close()
+
+	// 重启后重试时间仍然有效
+	tq, err = NewTaskQueue(taskFile)
+	if err != nil {
+		t.Fatalf("Failed to reopen task queue: %v", err)
+	}
+	defer tq.Close()
+
+	claimed, err := tq.ClaimTask("agent-0")
+	if err != nil {
+		t.Fatalf("ClaimTask failed: %v", err)
+	}
+	if claimed != nil {
+		t.Fatalf("Task claimed before its retry time")
+	}
+
+	// 到期后，避开的 agent 不能领取，其他 agent 可以
+	reloaded, _ := tq.GetTask("retry-1")
+	reloaded.NotBefore = time.Now().Add(-time.Second)
+	reloaded.AvoidAgent = "agent-0"
+	if err := tq.UpdateTask(reloaded); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	if claimed, _ := tq.ClaimTask("agent-0"); claimed != nil {
+		t.Errorf("Avoided agent claimed the retry")
+	}
+	claimed, err = tq.ClaimTask("agent-1")
+	if err != nil || claimed == nil {
+		t.Fatalf("ClaimTask(agent-1) = %v, %v; want the task", claimed, err)
+	}
+	if !claimed.NotBefore.IsZero() || claimed.AvoidAgent != "" {
+		t.Errorf("Claim should clear the retry schedule, got %v / %q", claimed.NotBefore, claimed.AvoidAgent)
+	}
+}
+
+func TestTaskQueue_PreferAgent(t *testing.T) {
+	tq, err := NewTaskQueue(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer tq.Close()
+
+	// 主脑把低优先级任务指定给 agent-1
+	for _, task := range []*models.Task{
+		{ID: "urgent", Description: "Urgent", Status: models.TaskStatusPending, Priority: 9},
+		{ID: "assigned", Description: "Assigned", Status: models.TaskStatusPending, Priority: 1},
+	} {
+		if err := tq.AddTask(task); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+	assigned, _ := tq.GetTask("assigned")
+	assigned.PreferAgent = "agent-1"
+	assigned.NotBefore = time.Now()
+	if err := tq.UpdateTask(assigned); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	// 宽限期内其他 agent 不领取该任务，指定的 agent 优先领取它
+	if claimed, _ := tq.ClaimTask("agent-0"); claimed == nil || claimed.ID != "urgent" {
+		t.Fatalf("ClaimTask(agent-0) = %v, want urgent", claimed)
+	}
+	if claimed, _ := tq.ClaimTask("agent-2"); claimed != nil {
+		t.Errorf("ClaimTask(agent-2) claimed %s reserved for agent-1", claimed.ID)
+	}
+	claimed, err := tq.ClaimTask("agent-1")
+	if err != nil || claimed == nil || claimed.ID != "assigned" {
+		t.Fatalf("ClaimTask(agent-1) = %v, %v; want assigned", claimed, err)
+	}
+	if claimed.PreferAgent != "" {
+		t.Errorf("Claim should clear the preferred agent, got %q", claimed.PreferAgent)
+	}
+}
+
+func TestTaskQueue_WriteScope(t *testing.T) {
+	tq, err := NewTaskQueue(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer tq.Close()
+
+	for _, task := range []*models.Task{
+		{ID: "auth", Description: "Auth", Status: models.TaskStatusPending, Priority: 9, WritePaths: []string{"pkg/auth/"}},
+		{ID: "login", Description: "Login", Status: models.TaskStatusPending, Priority: 8, WritePaths: []string{"pkg/auth/login.go"}},
+		{ID: "docs", Description: "Docs", Status: models.TaskStatusPending, Priority: 7, WritePaths: []string{"docs/**/*.md"}},
+		{ID: "unscoped", Description: "Unscoped", Status: models.TaskStatusPending, Priority: 6},
+	} {
+		if err := tq.AddTask(task); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+
+	// login 的修改范围与运行中的 auth 重叠，要等 auth 结束；未声明范围的任务不受限制
+	for agent, want := range []string{"auth", "docs", "unscoped", ""} {
+		claimed, err := tq.ClaimTask(fmt.Sprintf("agent-%d", agent))
+		if err != nil {
+			t.Fatalf("ClaimTask() error = %v", err)
+		}
+		if got := claimedID(claimed); got != want {
+			t.Errorf("agent-%d claimed %q, want %q", agent, got, want)
+		}
+	}
+
+	if err := tq.UpdateTaskStatus("auth", models.TaskStatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, _ := tq.ClaimTask("agent-3"); claimedID(claimed) != "login" {
+		t.Errorf("agent-3 claimed %q after auth finished, want login", claimedID(claimed))
+	}
+}
+
+func claimedID(task *models.Task) string {
+	if task == nil {
+		return ""
+	}
+	return task.ID
+}
@@ -0,0 +1,51 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/claude-swarm/internal/models"
+)
+
+// ThrottlePathFor returns the throttle status path belonging to a task queue file
+func ThrottlePathFor(taskQueuePath string) string {
+	return filepath.Join(filepath.Dir(taskQueuePath), "throttle.json")
+}
+
+// SaveThrottleStatus atomically writes the coordinator's limiter and
+// breaker snapshot so that other processes (the monitor) can show it
+func SaveThrottleStatus(path string, status *models.ThrottleStatus) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal throttle status: %w", err)
+	}
+
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
+
+// LoadThrottleStatus reads the last saved snapshot, nil if none was saved
+func LoadThrottleStatus(path string) (*models.ThrottleStatus, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var status models.ThrottleStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal throttle status: %w", err)
+	}
+	return &status, nil
+}
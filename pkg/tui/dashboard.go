@@ -10,7 +10,7 @@
 //	getAgents := func() []*models.AgentStatus {
 //	    return state.LoadAgentStates("~/.claude-swarm/agents.json")
 //	}
-//	if err := tui.Run(taskQueue, getAgents); err != nil {
+//	if err := tui.Run(taskQueue, nil, "", getAgents, nil); err != nil {
 //	    log.Fatal(err)
 //	}
 package tui
@@ -51,6 +51,8 @@ type tickMsg time.Time
 //   - View(): Renders the current state as a string
 type Dashboard struct {
 	taskQueue    *state.TaskQueue
+	approvals    *state.ApprovalQueue // Optional, enables y/n on tasks awaiting approval
+	operator     string               // Recorded as the resolver of approvals
 	getAgentsFn  func() []*models.AgentStatus
 	taskList     *TaskListView
 	agentGrid    *AgentGridView
@@ -63,6 +65,11 @@ type Dashboard struct {
 	quitting     bool
 	updateTicker *time.Ticker
 	lastUpdate   time.Time // 最后更新时间
+	pending      []*models.Approval
+	message      string // 最近一次审批操作的结果
+
+	getThrottleFn func() *models.ThrottleStatus // Optional, shows the circuit breaker
+	throttle      *models.ThrottleStatus
 }
 
 // NewDashboard creates a new Dashboard instance.
@@ -92,6 +99,19 @@ func NewDashboard(taskQueue *state.TaskQueue, getAgentsFn func() []*models.Agent
 	}
 }
 
+// SetApprovalQueue enables approving and rejecting tasks from the dashboard.
+// Decisions are recorded as made by "tui:<operator>".
+func (m *Dashboard) SetApprovalQueue(approvals *state.ApprovalQueue, operator string) {
+	m.approvals = approvals
+	m.operator = operator
+}
+
+// SetThrottleSource shows the coordinator's circuit breaker and start
+// limiter in the status bar
+func (m *Dashboard) SetThrottleSource(fn func() *models.ThrottleStatus) {
+	m.getThrottleFn = fn
+}
+
 // Init initializes the dashboard and returns initial commands.
 // This is part of the Bubble Tea Model interface.
 //
@@ -214,6 +234,12 @@ func (m *Dashboard) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Select current item and update log viewer
 		m.updateLogViewer()
 
+	case "y", "n":
+		// Approve or reject the selected task awaiting approval
+		if m.activePane == PaneTasks {
+			m.resolveSelected(msg.String() == "y")
+		}
+
 	case "home":
 		// Jump to first item
 		switch m.activePane {
@@ -342,8 +368,11 @@ func (m *Dashboard) View() string {
 		helpText = "Tab: 切换面板 | ↑↓←→/hjkl: 导航 | Home/End: 首个/末个 | Enter: 选择 | q: 退出 | r: 刷新"
 		tipText = "💡 提示: 按 Enter 查看选中 Agent 的详细日志"
 	case PaneTasks:
-		helpText = "Tab: 切换面板 | ↑↓/jk: 导航 | Home/End: 首个/末个 | Enter: 选择 | q: 退出 | r: 刷新"
+		helpText = "Tab: 切换面板 | ↑↓/jk: 导航 | Home/End: 首个/末个 | Enter: 选择 | y/n: 批准/拒绝 | q: 退出 | r: 刷新"
 		tipText = "💡 提示: 按 Enter 查看任务对应 Agent 的执行日志"
+		if m.message != "" {
+			tipText = m.message
+		}
 	default:
 		helpText = "Tab: 切换面板 | ↑↓/jk: 导航 | Enter: 选择 | q: 退出 | r: 刷新"
 	}
@@ -435,6 +464,10 @@ func (m *Dashboard) renderStatusBar() string {
 	if failedTasks > 0 {
 		warnings += " • " + metricErrorStyle.Render(fmt.Sprintf("✗ %d 失败", failedTasks))
 	}
+	if len(m.pending) > 0 {
+		warnings += " • " + metricWarningStyle.Render(fmt.Sprintf("⏸ %d 待审批", len(m.pending)))
+	}
+	warnings += m.renderThrottle()
 
 	statusContent := fmt.Sprintf("%s  |  %s  |  %s%s",
 		agentMetric, taskMetric, completionMetric, warnings)
@@ -519,6 +552,33 @@ func (m *Dashboard) initializeViews() {
 	m.refreshData()
 }
 
+// throttleStaleAfter hides a throttle status the coordinator stopped updating
+const throttleStaleAfter = 30 * time.Second
+
+// renderThrottle renders the circuit breaker and limiter warnings of the status bar
+func (m *Dashboard) renderThrottle() string {
+	t := m.throttle
+	if t == nil || time.Since(t.UpdatedAt) > throttleStaleAfter {
+		return ""
+	}
+
+	switch t.Breaker {
+	case models.BreakerOpen:
+		remaining := time.Until(t.OpenUntil).Round(time.Second)
+		if remaining < 0 {
+			remaining = 0
+		}
+		return " • " + metricErrorStyle.Render(fmt.Sprintf("⛔ 熔断 %s (%s 后半开)", t.LastCategory, remaining))
+	case models.BreakerHalfOpen:
+		return " • " + metricWarningStyle.Render("◐ 熔断半开 (试探中)")
+	}
+
+	if t.StartsPerMin > 0 && t.Tokens < 1 {
+		return " • " + metricWarningStyle.Render(fmt.Sprintf("⏳ 限流 %g/分钟", t.StartsPerMin))
+	}
+	return ""
+}
+
 // refreshData refreshes task and agent data
 func (m *Dashboard) refreshData() {
 	// Get tasks
@@ -537,6 +597,16 @@ func (m *Dashboard) refreshData() {
 		}
 	}
 
+	// Get pending approvals
+	if m.approvals != nil {
+		m.pending = m.approvals.List(models.ApprovalStatusPending)
+	}
+
+	// Get circuit breaker state
+	if m.getThrottleFn != nil {
+		m.throttle = m.getThrottleFn()
+	}
+
 	// Update log viewer with current selection
 	m.updateLogViewer()
 
@@ -585,6 +655,40 @@ func (m *Dashboard) updateLogViewer() {
 	}
 }
 
+// resolveSelected approves or rejects the pending approval of the selected task
+func (m *Dashboard) resolveSelected(approve bool) {
+	if m.approvals == nil || m.taskList == nil {
+		return
+	}
+
+	task := m.taskList.GetSelectedTask()
+	if task == nil || task.Status != models.TaskStatusAwaitingApproval {
+		return
+	}
+
+	approval := m.approvals.PendingForTask(task.ID)
+	if approval == nil {
+		m.message = fmt.Sprintf("⚠️  任务 %s 没有待处理的审批", task.ID)
+		return
+	}
+
+	resolvedBy := "tui"
+	if m.operator != "" {
+		resolvedBy = "tui:" + m.operator
+	}
+	if _, err := m.approvals.Resolve(approval.ID, approve, "", resolvedBy); err != nil {
+		m.message = fmt.Sprintf("⚠️  审批失败: %v", err)
+		return
+	}
+
+	if approve {
+		m.message = fmt.Sprintf("👍 已批准 %s (%s)", approval.ID, approval.Title)
+	} else {
+		m.message = fmt.Sprintf("👎 已拒绝 %s (%s)", approval.ID, approval.Title)
+	}
+	m.refreshData()
+}
+
 // tickCmd returns a command that sends a tick message after 2 seconds
 func tickCmd() tea.Cmd {
 	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
@@ -592,10 +696,16 @@ func tickCmd() tea.Cmd {
 	})
 }
 
-// Run starts the dashboard TUI
-func Run(taskQueue *state.TaskQueue, getAgentsFn func() []*models.AgentStatus) error {
+// Run starts the dashboard TUI. approvals and getThrottleFn may be nil;
+// operator names who resolves approvals from the dashboard.
+func Run(taskQueue *state.TaskQueue, approvals *state.ApprovalQueue, operator string, getAgentsFn func() []*models.AgentStatus,
+	getThrottleFn func() *models.ThrottleStatus) error {
+	dashboard := NewDashboard(taskQueue, getAgentsFn)
+	dashboard.SetApprovalQueue(approvals, operator)
+	dashboard.SetThrottleSource(getThrottleFn)
+
 	p := tea.NewProgram(
-		NewDashboard(taskQueue, getAgentsFn),
+		dashboard,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
@@ -138,6 +138,9 @@ func (v *TaskListView) renderTask(task *models.Task, selected bool) string {
 	case models.TaskStatusFailed:
 		statusIcon = "❌"
 		statusStyle = statusErrorStyle
+	case models.TaskStatusAwaitingApproval:
+		statusIcon = "⏸"
+		statusStyle = statusWaitingStyle
 	default:
 		statusIcon = "❓"
 		statusStyle = statusIdleStyle